@@ -21,6 +21,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"log"
+	"net"
 	"net/http"
 	"os"
 	"path"
@@ -33,27 +34,38 @@ import (
 	"github.com/spf13/cobra"
 
 	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/k0sproject/k0s/internal/pkg/templatewriter"
 	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/certificate"
 	"github.com/k0sproject/k0s/pkg/config"
 	"github.com/k0sproject/k0s/pkg/etcd"
 	"github.com/k0sproject/k0s/pkg/kubernetes"
+	"github.com/k0sproject/k0s/pkg/token"
 )
 
 type CmdOpts config.CLIOptions
 
 const (
 	workerRole     = "worker"
+	workerJoinRole = "worker-join"
 	controllerRole = "controller"
 )
 
 var allowedUsageByRole = map[string]string{
 	workerRole:     "usage-bootstrap-api-worker-calls",
+	workerJoinRole: "usage-worker-join",
 	controllerRole: "usage-controller-join",
 }
 
+// kubeletCertificateTimeout bounds the wait for the kubelet client certificate of a joining worker, within the write timeout of the API
+const kubeletCertificateTimeout = 10 * time.Second
+
+// tokenIDKey is the request context key of the ID of the token the request is authenticated with
+type tokenIDKey struct{}
+
 func NewAPICmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "api",
@@ -101,6 +113,9 @@ func (c *CmdOpts) startAPI() error {
 	router.Path(prefix + "/calico/kubeconfig").Methods("GET").Handler(
 		c.workerHandler(c.kubeConfigHandler()),
 	)
+	router.Path(prefix + "/join/worker").Methods("POST").Handler(
+		c.authMiddleware(c.workerJoinHandler(), workerJoinRole),
+	)
 
 	srv := &http.Server{
 		Handler:      router,
@@ -150,7 +165,8 @@ func (c *CmdOpts) etcdHandler() http.Handler {
 		}
 
 		etcdCaCertPath, etcdCaCertKey := filepath.Join(c.K0sVars.EtcdCertDir, "ca.crt"), filepath.Join(c.K0sVars.EtcdCertDir, "ca.key")
-		etcdCACert, err := os.ReadFile(etcdCaCertPath)
+		// joining controllers get the whole chain, see certificate.CABundleFile
+		etcdCACert, err := os.ReadFile(certificate.CABundleFile(etcdCaCertPath))
 		if err != nil {
 			sendError(err, resp)
 			return
@@ -238,6 +254,13 @@ users:
 
 func (c *CmdOpts) caHandler() http.Handler {
 	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		// syncing the CA is the first step of a controller join
+		nodeName := req.Header.Get(token.NodeNameHeader)
+		if _, err := c.checkToken(req, nodeName); err != nil {
+			sendError(err, resp, http.StatusForbidden)
+			return
+		}
+
 		caResp := v1beta1.CaResponse{}
 		key, err := os.ReadFile(path.Join(c.K0sVars.CertRootDir, "ca.key"))
 		if err != nil {
@@ -245,7 +268,7 @@ func (c *CmdOpts) caHandler() http.Handler {
 			return
 		}
 		caResp.Key = key
-		crt, err := os.ReadFile(path.Join(c.K0sVars.CertRootDir, "ca.crt"))
+		crt, err := os.ReadFile(certificate.CABundleFile(path.Join(c.K0sVars.CertRootDir, "ca.crt")))
 		if err != nil {
 			sendError(err, resp)
 			return
@@ -266,6 +289,10 @@ func (c *CmdOpts) caHandler() http.Handler {
 		}
 		caResp.SAPub = saPub
 
+		if err := c.recordJoin(req, nodeName); err != nil {
+			sendError(err, resp, http.StatusForbidden)
+			return
+		}
 		resp.Header().Set("content-type", "application/json")
 		if err := json.NewEncoder(resp).Encode(caResp); err != nil {
 			sendError(err, resp)
@@ -274,6 +301,84 @@ func (c *CmdOpts) caHandler() http.Handler {
 	})
 }
 
+func (c *CmdOpts) workerJoinHandler() http.Handler {
+	return http.HandlerFunc(func(resp http.ResponseWriter, req *http.Request) {
+		var joinReq v1beta1.WorkerJoinRequest
+		if err := json.NewDecoder(req.Body).Decode(&joinReq); err != nil {
+			sendError(err, resp, http.StatusBadRequest)
+			return
+		}
+		if joinReq.Node == "" {
+			sendError(fmt.Errorf("node cannot be empty"), resp, http.StatusBadRequest)
+			return
+		}
+		scope, err := c.checkToken(req, joinReq.Node)
+		if err != nil {
+			sendError(err, resp, http.StatusForbidden)
+			return
+		}
+		// only a token bound to the node may take over the identity of a registered node
+		if scope.NodeName != joinReq.Node {
+			_, err := c.KubeClient.CoreV1().Nodes().Get(req.Context(), joinReq.Node, v1.GetOptions{})
+			if err == nil {
+				sendError(fmt.Errorf("node %s already exists", joinReq.Node), resp, http.StatusConflict)
+				return
+			}
+			if !apierrors.IsNotFound(err) {
+				sendError(err, resp)
+				return
+			}
+		}
+		logrus.Infof("worker join API, node %s joining", joinReq.Node)
+
+		// the kubelet gets a client certificate for the joining node only, instead of a reusable bootstrap token
+		cert, err := token.NewManagerForClient(c.KubeClient).IssueKubeletCertificate(req.Context(), joinReq.Node, joinReq.CSR, kubeletCertificateTimeout)
+		if err != nil {
+			sendError(err, resp)
+			return
+		}
+		// the token is only used up by the joins which get their certificate
+		if err := c.recordJoin(req, joinReq.Node); err != nil {
+			sendError(err, resp, http.StatusForbidden)
+			return
+		}
+		joinResp := v1beta1.WorkerJoinResponse{
+			Certificate: cert,
+			APIServer:   c.NodeConfig.Spec.API.APIAddressURL(),
+			Profile:     scope.Profile,
+			Labels:      scope.Labels,
+			Taints:      scope.Taints,
+		}
+		resp.Header().Set("content-type", "application/json")
+		if err := json.NewEncoder(resp).Encode(joinResp); err != nil {
+			sendError(err, resp)
+			return
+		}
+	})
+}
+
+// checkToken checks the scope of the token the request is authenticated with for the join of the given node
+func (c *CmdOpts) checkToken(req *http.Request, nodeName string) (*token.Scope, error) {
+	tokenID, addr := joinOrigin(req)
+	return token.NewManagerForClient(c.KubeClient).Allows(req.Context(), tokenID, nodeName, addr)
+}
+
+// recordJoin records the join of the given node on the token the request is authenticated with, using it up
+func (c *CmdOpts) recordJoin(req *http.Request, nodeName string) error {
+	tokenID, addr := joinOrigin(req)
+	return token.NewManagerForClient(c.KubeClient).RecordJoin(req.Context(), tokenID, nodeName, addr)
+}
+
+// joinOrigin returns the ID of the token the request is authenticated with and the address of the joining node
+func joinOrigin(req *http.Request) (string, net.IP) {
+	tokenID, _ := req.Context().Value(tokenIDKey{}).(string)
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		host = req.RemoteAddr
+	}
+	return tokenID, net.ParseIP(host)
+}
+
 /** The token is in form of xyz.foobar where:
 - xyz: the token "ID" in kube api
 - foobar: the token itself
@@ -322,6 +427,7 @@ func (c *CmdOpts) authMiddleware(next http.Handler, role string) http.Handler {
 				sendError(fmt.Errorf("go away"), w, http.StatusUnauthorized)
 				return
 			}
+			r = r.WithContext(context.WithValue(r.Context(), tokenIDKey{}, strings.Split(token, ".")[0]))
 		} else {
 			sendError(fmt.Errorf("go away"), w, http.StatusUnauthorized)
 			return
@@ -20,22 +20,31 @@ limitations under the License.
 package backup
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"strings"
 
 	"github.com/sirupsen/logrus"
 	"github.com/spf13/cobra"
+	"k8s.io/client-go/kubernetes"
 
 	"github.com/k0sproject/k0s/internal/pkg/dir"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 	"github.com/k0sproject/k0s/pkg/backup"
 	"github.com/k0sproject/k0s/pkg/config"
 	"github.com/k0sproject/k0s/pkg/install"
+	kubeutil "github.com/k0sproject/k0s/pkg/kubernetes"
 )
 
 type CmdOpts config.CLIOptions
 
-var savePath string
+var (
+	savePath       string
+	s3             v1beta1.BackupS3Destination
+	encryption     v1beta1.BackupEncryption
+	signingKeyFile string
+)
 
 func NewBackupCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -43,9 +52,6 @@ func NewBackupCmd() *cobra.Command {
 		Short: "Back-Up k0s configuration. Must be run as root (or with sudo)",
 		RunE: func(cmd *cobra.Command, args []string) error {
 			c := CmdOpts(config.GetCmdOpts())
-			if c.NodeConfig.Spec.Storage.Etcd.IsExternalClusterUsed() {
-				return fmt.Errorf("command 'k0s backup' does not support external etcd cluster")
-			}
 			return c.backup()
 		},
 		PreRunE: func(c *cobra.Command, args []string) error {
@@ -54,8 +60,13 @@ func NewBackupCmd() *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVar(&savePath, "save-path", "", "destination directory path for backup assets, use '-' for stdout")
+	cmd.Flags().AddFlagSet(backup.GetS3FlagSet(&s3))
+	cmd.Flags().StringVar(&encryption.PassphraseFile, "encryption-passphrase-file", "", "encrypt the backup archive with the passphrase read from the given file")
+	cmd.Flags().StringSliceVar(&encryption.Recipients, "encryption-recipient", nil, "encrypt the backup archive to the given age X25519 public key (age1...), can be repeated")
+	cmd.Flags().StringVar(&signingKeyFile, "signing-key", "", "sign the backup archive manifest with the PEM encoded Ed25519 private key read from the given file")
 	cmd.SilenceUsage = true
 	cmd.PersistentFlags().AddFlagSet(config.GetPersistentFlagSet())
+	cmd.AddCommand(newInspectCmd())
 	return cmd
 }
 
@@ -64,10 +75,21 @@ func (c *CmdOpts) backup() error {
 		logrus.Fatal("this command must be run as root!")
 	}
 
-	if savePath != "-" && !dir.IsDirectory(savePath) {
+	if s3.Bucket != "" {
+		if savePath != "" {
+			return fmt.Errorf("only one of --save-path and --s3-bucket may be given")
+		}
+		if errs := s3.Validate(); len(errs) > 0 {
+			return errs[0]
+		}
+	} else if savePath != "-" && !dir.IsDirectory(savePath) {
 		return fmt.Errorf("the save-path directory (%v) does not exist", savePath)
 	}
 
+	if encryption.PassphraseFile != "" && len(encryption.Recipients) > 0 {
+		return fmt.Errorf("only one of --encryption-passphrase-file and --encryption-recipient may be given")
+	}
+
 	if !dir.IsDirectory(c.K0sVars.DataDir) {
 		return fmt.Errorf("cannot find data-dir (%v). check your environment and/or command input and try again", c.K0sVars.DataDir)
 	}
@@ -79,11 +101,45 @@ func (c *CmdOpts) backup() error {
 	logrus.Debugf("detected role for backup operations: %v", status.Role)
 
 	if strings.Contains(status.Role, "controller") {
+		ctx := context.Background()
+		dest, err := c.destination(ctx)
+		if err != nil {
+			return err
+		}
 		mgr, err := backup.NewBackupManager()
 		if err != nil {
 			return err
 		}
-		return mgr.RunBackup(c.NodeConfig.Spec, c.K0sVars, savePath)
+		if encryption.PassphraseFile != "" || len(encryption.Recipients) > 0 {
+			if mgr.Encryption, err = backup.NewEncryption(&encryption); err != nil {
+				return err
+			}
+		}
+		if signingKeyFile != "" {
+			if mgr.SigningKey, err = backup.LoadSigningKey(signingKeyFile); err != nil {
+				return err
+			}
+		}
+		return mgr.RunBackup(ctx, c.NodeConfig.Spec, c.K0sVars, dest)
 	}
 	return fmt.Errorf("backup command must be run on the controller node, have `%s`", status.Role)
 }
+
+func (c *CmdOpts) destination(ctx context.Context) (backup.Destination, error) {
+	switch {
+	case s3.Bucket != "":
+		var kubeClient kubernetes.Interface
+		if s3.CredentialsSecret != "" {
+			client, err := kubeutil.NewAdminClientFactory(c.K0sVars).GetClient()
+			if err != nil {
+				return nil, err
+			}
+			kubeClient = client
+		}
+		return backup.NewS3Destination(ctx, &s3, kubeClient)
+	case savePath == "-":
+		return backup.NewStdioDestination(), nil
+	default:
+		return backup.NewLocalDestination(savePath), nil
+	}
+}
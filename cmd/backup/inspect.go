@@ -0,0 +1,123 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/backup"
+	"github.com/k0sproject/k0s/pkg/config"
+)
+
+func newInspectCmd() *cobra.Command {
+	var (
+		output     string
+		s3         v1beta1.BackupS3Destination
+		decryption backup.DecryptionOptions
+	)
+
+	cmd := &cobra.Command{
+		Use:   "inspect filename",
+		Short: "Show the content of a backup archive. Use '-' as filename to read from stdin",
+		Long: `Show the steps, files, k0s version, storage type and configuration contained in a backup archive.
+Use '-' as filename to read from stdin.
+If --s3-bucket is given, the filename is the name of the archive object in the bucket, relative to --s3-prefix.`,
+		Args: cobra.ExactArgs(1),
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := CmdOpts(config.GetCmdOpts())
+
+			ctx := context.Background()
+			src, archiveName, err := backup.NewSource(ctx, args[0], &s3)
+			if err != nil {
+				return err
+			}
+			mgr, err := backup.NewBackupManager()
+			if err != nil {
+				return err
+			}
+			if err := decryption.Apply(mgr); err != nil {
+				return err
+			}
+			inspection, err := mgr.Inspect(ctx, src, archiveName, c.K0sVars)
+			if err != nil {
+				return err
+			}
+			return printInspection(inspection, output)
+		},
+	}
+	cmd.SilenceUsage = true
+	cmd.Flags().StringVarP(&output, "output", "o", "", "Output format. Must be one of yaml|json")
+	cmd.Flags().AddFlagSet(backup.GetS3FlagSet(&s3))
+	cmd.Flags().AddFlagSet(backup.GetDecryptionFlagSet(&decryption))
+	return cmd
+}
+
+func printInspection(inspection *backup.Inspection, output string) error {
+	switch output {
+	case "json":
+		jsn, err := json.MarshalIndent(inspection, "", "   ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(jsn))
+	case "yaml":
+		ym, err := yaml.Marshal(inspection)
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(ym))
+	case "":
+		fmt.Println("Archive:", inspection.Archive)
+		if m := inspection.Metadata; m != nil {
+			fmt.Println("k0s version:", m.K0sVersion)
+			fmt.Println("Created at:", m.CreatedAt)
+			fmt.Println("Hostname:", m.Hostname)
+			fmt.Println("Format version:", m.FormatVersion)
+		} else {
+			fmt.Println("k0s version: unknown, the archive has no metadata")
+		}
+		fmt.Println("Storage type:", inspection.StorageType)
+		fmt.Println("Steps:")
+		for _, step := range inspection.Steps {
+			fmt.Println("  -", step)
+		}
+		fmt.Println("Files:")
+		for _, f := range inspection.Files {
+			fmt.Printf("  - %s (%d bytes)\n", f.Name, f.Size)
+		}
+		cfg, err := yaml.Marshal(inspection.Config)
+		if err != nil {
+			return err
+		}
+		fmt.Println("Config:")
+		_, err = os.Stdout.Write(cfg)
+		return err
+	default:
+		return fmt.Errorf("unsupported output format %q, must be one of yaml|json", output)
+	}
+	return nil
+}
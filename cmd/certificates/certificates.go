@@ -0,0 +1,123 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package certificates
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"time"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+
+	"github.com/k0sproject/k0s/pkg/certificate"
+	"github.com/k0sproject/k0s/pkg/config"
+	"github.com/k0sproject/k0s/pkg/install"
+)
+
+func NewCertificatesCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "certificates",
+		Aliases: []string{"certs"},
+		Short:   "Manage the certificates of the controller",
+	}
+
+	cmd.SilenceUsage = true
+	cmd.AddCommand(expiryCmd())
+	cmd.AddCommand(rotateCmd())
+	return cmd
+}
+
+func expiryCmd() *cobra.Command {
+	var output string
+	cmd := &cobra.Command{
+		Use:   "expiry",
+		Short: "Show the expiry of the certificates in the k0s cert dirs",
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := config.GetCmdOpts()
+			expiries, err := certificate.ScanExpiry(c.K0sVars.CertRootDir, c.K0sVars.EtcdCertDir)
+			if err != nil {
+				return err
+			}
+
+			if output == "json" {
+				jsn, _ := json.MarshalIndent(expiries, "", "   ")
+				fmt.Println(string(jsn))
+				return nil
+			}
+			if len(expiries) == 0 {
+				fmt.Println("No certificates found")
+				return nil
+			}
+
+			now := time.Now()
+			table := tablewriter.NewWriter(os.Stdout)
+			table.SetHeader([]string{"Certificate", "Expires at", "Days left", "CA", "Managed by k0s"})
+			table.SetAutoWrapText(false)
+			table.SetAutoFormatHeaders(true)
+			table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+			table.SetAlignment(tablewriter.ALIGN_LEFT)
+			table.SetCenterSeparator("")
+			table.SetColumnSeparator("")
+			table.SetRowSeparator("")
+			table.SetHeaderLine(false)
+			table.SetBorder(false)
+			table.SetTablePadding("\t") // pad with tabs
+			table.SetNoWhiteSpace(true)
+			for _, e := range expiries {
+				table.Append([]string{
+					e.Name,
+					e.NotAfter.Local().Format(time.RFC3339),
+					strconv.Itoa(e.DaysRemaining(now)),
+					strconv.FormatBool(e.IsCA),
+					strconv.FormatBool(e.Managed),
+				})
+			}
+			table.Render()
+			return nil
+		},
+	}
+	cmd.Flags().StringVarP(&output, "out", "o", "", "sets type of output to json")
+	cmd.PersistentFlags().AddFlagSet(config.GetPersistentFlagSet())
+	return cmd
+}
+
+func rotateCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rotate",
+		Short: "Rotate the k0s managed certificates of the running controller",
+		Long: `Regenerates the leaf certificates issued by the k0s CAs and restarts the processes using them.
+The CAs themselves and certificates issued by other CAs are left untouched.`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			if runtime.GOOS == "windows" {
+				return fmt.Errorf("currently not supported on windows")
+			}
+
+			if err := install.RotateCertificates(config.StatusSocket); err != nil {
+				return err
+			}
+			fmt.Println("k0s certificates rotated")
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&config.StatusSocket, "status-socket", filepath.Join(config.K0sVars.RunDir, "status.sock"), "Full file path to the socket file.")
+	return cmd
+}
@@ -66,6 +66,7 @@ type Certificates struct {
 
 // Init initializes the certificate component
 func (c *Certificates) Init(ctx context.Context) error {
+	c.CertManager.Config = c.ClusterSpec.Certificates
 	eg, _ := errgroup.WithContext(ctx)
 	// Common CA
 	caCertPath := filepath.Join(c.K0sVars.CertRootDir, "ca.crt")
@@ -77,7 +78,7 @@ func (c *Certificates) Init(ctx context.Context) error {
 
 	// We need CA cert loaded to generate client configs
 	logrus.Debugf("CA key and cert exists, loading")
-	cert, err := os.ReadFile(caCertPath)
+	cert, err := os.ReadFile(certificate.CABundleFile(caCertPath))
 	if err != nil {
 		return fmt.Errorf("failed to read ca cert: %w", err)
 	}
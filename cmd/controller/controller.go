@@ -50,6 +50,7 @@ import (
 	"github.com/k0sproject/k0s/pkg/constant"
 	"github.com/k0sproject/k0s/pkg/kubernetes"
 	"github.com/k0sproject/k0s/pkg/performance"
+	"github.com/k0sproject/k0s/pkg/supervisor"
 	"github.com/k0sproject/k0s/pkg/token"
 )
 
@@ -149,7 +150,7 @@ func (c *CmdOpts) startController(ctx context.Context) error {
 	// from now on, we only refer to the runtime config
 	c.CfgFile = loadingRules.RuntimeConfigPath
 
-	certificateManager := certificate.Manager{K0sVars: c.K0sVars}
+	certificateManager := certificate.Manager{K0sVars: c.K0sVars, Config: c.NodeConfig.Spec.Certificates}
 
 	var joinClient *token.JoinClient
 	var err error
@@ -169,21 +170,29 @@ func (c *CmdOpts) startController(ctx context.Context) error {
 		return err
 	}
 	logrus.Infof("DNS address: %s", dnsAddress)
+	for name, resources := range c.NodeConfig.Spec.ComponentResources {
+		resources := resources
+		supervisor.SetResources(name, &resources)
+	}
 	var storageBackend component.Component
 
 	switch c.NodeConfig.Spec.Storage.Type {
 	case v1beta1.KineStorageType:
 		storageBackend = &controller.Kine{
-			Config:  c.NodeConfig.Spec.Storage.Kine,
-			K0sVars: c.K0sVars,
+			Config:        c.NodeConfig.Spec.Storage.Kine,
+			K0sVars:       c.K0sVars,
+			RestartPolicy: &c.RestartPolicy,
+			LogConfig:     &c.ComponentLogs,
 		}
 	case v1beta1.EtcdStorageType:
 		storageBackend = &controller.Etcd{
-			CertManager: certificateManager,
-			Config:      c.NodeConfig.Spec.Storage.Etcd,
-			JoinClient:  joinClient,
-			K0sVars:     c.K0sVars,
-			LogLevel:    c.Logging["etcd"],
+			CertManager:   certificateManager,
+			Config:        c.NodeConfig.Spec.Storage.Etcd,
+			JoinClient:    joinClient,
+			K0sVars:       c.K0sVars,
+			LogLevel:      c.Logging["etcd"],
+			RestartPolicy: &c.RestartPolicy,
+			LogConfig:     &c.ComponentLogs,
 		}
 	default:
 		return fmt.Errorf("invalid storage type: %s", c.NodeConfig.Spec.Storage.Type)
@@ -194,13 +203,16 @@ func (c *CmdOpts) startController(ctx context.Context) error {
 	// common factory to get the admin kube client that's needed in many components
 	adminClientFactory := kubernetes.NewAdminClientFactory(c.K0sVars)
 	enableKonnectivity := !c.SingleNode && !stringslice.Contains(c.DisableComponents, constant.KonnectivityServerComponentName)
-	c.NodeComponents.Add(ctx, &controller.APIServer{
+	apiServer := &controller.APIServer{
 		ClusterConfig:      c.NodeConfig,
 		K0sVars:            c.K0sVars,
 		LogLevel:           c.Logging["kube-apiserver"],
 		Storage:            storageBackend,
 		EnableKonnectivity: enableKonnectivity,
-	})
+		RestartPolicy:      &c.RestartPolicy,
+		LogConfig:          &c.ComponentLogs,
+	}
+	c.NodeComponents.Add(ctx, apiServer)
 
 	if !c.SingleNode {
 		c.NodeComponents.Add(ctx, &controller.K0sControllersLeaseCounter{
@@ -222,16 +234,20 @@ func (c *CmdOpts) startController(ctx context.Context) error {
 	}
 	c.NodeComponents.Add(ctx, leaderElector)
 
-	c.NodeComponents.Add(ctx, &applier.Manager{
+	applierManager := &applier.Manager{
 		K0sVars:           c.K0sVars,
 		KubeClientFactory: adminClientFactory,
+		NodeConfig:        c.NodeConfig,
 		LeaderElector:     leaderElector,
-	})
+	}
+	c.NodeComponents.Add(ctx, applierManager)
 
 	if !c.SingleNode && !stringslice.Contains(c.DisableComponents, constant.ControlAPIComponentName) {
 		c.NodeComponents.Add(ctx, &controller.K0SControlAPI{
-			ConfigPath: c.CfgFile,
-			K0sVars:    c.K0sVars,
+			ConfigPath:    c.CfgFile,
+			K0sVars:       c.K0sVars,
+			RestartPolicy: &c.RestartPolicy,
+			LogConfig:     &c.ComponentLogs,
 		})
 	}
 
@@ -241,6 +257,14 @@ func (c *CmdOpts) startController(ctx context.Context) error {
 			adminClientFactory))
 	}
 
+	if c.NodeConfig.Spec.Backup != nil {
+		c.NodeComponents.Add(ctx, controller.NewBackup(c.NodeConfig, c.K0sVars, leaderElector, adminClientFactory))
+	}
+
+	if etcdConfig := c.NodeConfig.Spec.Storage.Etcd; c.NodeConfig.Spec.Storage.Type == v1beta1.EtcdStorageType && etcdConfig.Maintenance != nil && !etcdConfig.IsExternalClusterUsed() {
+		c.NodeComponents.Add(ctx, controller.NewEtcdMaintenance(etcdConfig, c.K0sVars, leaderElector))
+	}
+
 	if c.EnableK0sCloudProvider {
 		c.NodeComponents.Add(
 			ctx,
@@ -252,6 +276,13 @@ func (c *CmdOpts) startController(ctx context.Context) error {
 		)
 	}
 
+	reloader := &nodeConfigReloader{opts: c, storage: storageBackend, apiServer: apiServer}
+	certRotation := &controller.CertificateRotation{
+		K0sVars: c.K0sVars,
+		Renew:   reloader.renewCertificates,
+	}
+	c.NodeComponents.Add(ctx, certRotation)
+
 	c.NodeComponents.Add(ctx, &status.Status{
 		StatusInformation: install.K0sStatus{
 			Pid:           os.Getpid(),
@@ -263,7 +294,11 @@ func (c *CmdOpts) startController(ctx context.Context) error {
 			K0sVars:       c.K0sVars,
 			ClusterConfig: c.NodeConfig,
 		},
-		Socket: config.StatusSocket,
+		Socket:             config.StatusSocket,
+		Reload:             reloader.reloadRequest,
+		RotateCertificates: certRotation.Rotate,
+		Stacks:             applierManager.Statuses,
+		MigrateStorage:     reloader.migrateStorage,
 	})
 
 	perfTimer.Checkpoint("starting-certificates-init")
@@ -299,6 +334,7 @@ func (c *CmdOpts) startController(ctx context.Context) error {
 			logrus.Info("All node components stopped")
 		}
 	}()
+	go reloader.watchSignals(ctx)
 
 	var configSource clusterconfig.ConfigSource
 	// For backwards compatibility, use file as config source by default
@@ -330,6 +366,10 @@ func (c *CmdOpts) startController(ctx context.Context) error {
 			return fmt.Errorf("failed to initialize cluster-config reconciler: %w", err)
 		}
 		c.ClusterComponents.Add(ctx, cfgReconciler)
+		c.ClusterComponents.Add(ctx, &applier.ConfigReconciler{Manager: applierManager})
+	} else {
+		// Nothing reconciles the cluster-wide config, render the manifest templates from the node config
+		applierManager.SetClusterConfig(c.NodeConfig)
 	}
 
 	if !stringslice.Contains(c.DisableComponents, constant.HelmComponentName) {
@@ -432,22 +472,28 @@ func (c *CmdOpts) startController(ctx context.Context) error {
 			K0sVars:           c.K0sVars,
 			KubeClientFactory: adminClientFactory,
 			NodeConfig:        c.NodeConfig,
+			RestartPolicy:     &c.RestartPolicy,
+			LogConfig:         &c.ComponentLogs,
 		})
 	}
 
 	if !stringslice.Contains(c.DisableComponents, constant.KubeSchedulerComponentName) {
 		c.ClusterComponents.Add(ctx, &controller.Scheduler{
-			LogLevel:   c.Logging[constant.KubeSchedulerComponentName],
-			K0sVars:    c.K0sVars,
-			SingleNode: c.SingleNode,
+			LogLevel:      c.Logging[constant.KubeSchedulerComponentName],
+			K0sVars:       c.K0sVars,
+			SingleNode:    c.SingleNode,
+			RestartPolicy: &c.RestartPolicy,
+			LogConfig:     &c.ComponentLogs,
 		})
 	}
 
 	if !stringslice.Contains(c.DisableComponents, constant.KubeControllerManagerComponentName) {
 		c.ClusterComponents.Add(ctx, &controller.Manager{
-			LogLevel:   c.Logging[constant.KubeControllerManagerComponentName],
-			K0sVars:    c.K0sVars,
-			SingleNode: c.SingleNode,
+			LogLevel:      c.Logging[constant.KubeControllerManagerComponentName],
+			K0sVars:       c.K0sVars,
+			SingleNode:    c.SingleNode,
+			RestartPolicy: &c.RestartPolicy,
+			LogConfig:     &c.ComponentLogs,
 		})
 	}
 
@@ -0,0 +1,160 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"reflect"
+	"sync"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/certificate"
+	"github.com/k0sproject/k0s/pkg/component"
+	"github.com/k0sproject/k0s/pkg/component/controller"
+	"github.com/k0sproject/k0s/pkg/config"
+	"github.com/k0sproject/k0s/pkg/install"
+	"github.com/k0sproject/k0s/pkg/supervisor"
+)
+
+// nodeConfigReloader reloads the node config of a running controller, on SIGHUP or through the status socket
+type nodeConfigReloader struct {
+	opts      *CmdOpts
+	storage   component.Component
+	apiServer *controller.APIServer
+	mutex     sync.Mutex
+}
+
+// watchSignals reloads the node config whenever k0s receives SIGHUP, until the context is done
+func (r *nodeConfigReloader) watchSignals(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if err := r.reload(ctx); err != nil {
+				logrus.WithError(err).Error("Failed to reload the node config")
+			}
+		}
+	}
+}
+
+// reloadRequest reloads the node config on a request through the status socket
+func (r *nodeConfigReloader) reloadRequest(ctx context.Context, reload install.Reload) error {
+	if len(reload.WorkerFlags) > 0 {
+		return fmt.Errorf("the worker flags of controllers can't be reloaded, restart k0s to apply them")
+	}
+	return r.reload(ctx)
+}
+
+// reload re-reads the config file and reconciles the node components with the changed node config.
+// The runtime config is only replaced once the components have been reconciled.
+func (r *nodeConfigReloader) reload(ctx context.Context) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c := r.opts
+	logrus.Info("Reloading the node config")
+	loadingRules := config.ClientConfigLoadingRules{RuntimeConfigPath: c.CfgFile, K0sVars: c.K0sVars}
+	reloaded, err := loadingRules.LoadConfigFile()
+	if err != nil {
+		return fmt.Errorf("failed to reload %s: %w", config.CfgFile, err)
+	}
+	nodeConfig := reloaded.DeepCopy()
+	nodeConfig = nodeConfig.GetBootstrappingConfig(nodeConfig.Spec.Storage)
+	if err := checkNodeConfigChange(c.NodeConfig, nodeConfig); err != nil {
+		return err
+	}
+
+	// Regenerate the server certificates if the SANs changed, kube-apiserver picks them up without a restart
+	certs := &Certificates{
+		ClusterSpec: nodeConfig.Spec,
+		CertManager: certificate.Manager{K0sVars: c.K0sVars},
+		K0sVars:     c.K0sVars,
+	}
+	if err := certs.Init(ctx); err != nil {
+		return err
+	}
+
+	// The resource limits apply to the processes restarted from now on
+	for name := range c.NodeConfig.Spec.ComponentResources {
+		supervisor.SetResources(name, nil)
+	}
+	for name, resources := range nodeConfig.Spec.ComponentResources {
+		resources := resources
+		supervisor.SetResources(name, &resources)
+	}
+
+	if err := c.NodeComponents.ReconcileNodeConfig(ctx, nodeConfig); err != nil {
+		return err
+	}
+	if err := loadingRules.WriteRuntimeConfig(reloaded); err != nil {
+		return err
+	}
+	c.NodeConfig = nodeConfig
+	logrus.Info("Reloaded the node config")
+	return nil
+}
+
+// renewCertificates regenerates the k0s managed certificates, serialized with the reloads as both write them
+func (r *nodeConfigReloader) renewCertificates(ctx context.Context) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c := r.opts
+	certs := &Certificates{
+		ClusterSpec: c.NodeConfig.Spec,
+		CertManager: certificate.Manager{K0sVars: c.K0sVars},
+		K0sVars:     c.K0sVars,
+	}
+	if err := certs.Init(ctx); err != nil {
+		return err
+	}
+	if etcd, ok := r.storage.(*controller.Etcd); ok {
+		etcd.CertManager.Config = c.NodeConfig.Spec.Certificates
+		return etcd.RenewCertificates(ctx)
+	}
+	return nil
+}
+
+// checkNodeConfigChange returns an error if the changes of the node config can't be applied without a restart
+func checkNodeConfigChange(current, reloaded *v1beta1.ClusterConfig) error {
+	if current.Spec.Storage.Type != reloaded.Spec.Storage.Type {
+		return fmt.Errorf("changing the storage type from %s to %s requires a restart", current.Spec.Storage.Type, reloaded.Spec.Storage.Type)
+	}
+	// The tuning fields of etcd are applied by restarting it, the others affect the cluster membership or other components
+	if from, to := current.Spec.Storage.Etcd, reloaded.Spec.Storage.Etcd; from != nil && to != nil {
+		if !reflect.DeepEqual(from.ExternalCluster, to.ExternalCluster) {
+			return fmt.Errorf("changing spec.storage.etcd.externalCluster requires a restart")
+		}
+		if from.PeerAddress != to.PeerAddress {
+			return fmt.Errorf("changing spec.storage.etcd.peerAddress requires a restart")
+		}
+		if !reflect.DeepEqual(from.Maintenance, to.Maintenance) {
+			return fmt.Errorf("changing spec.storage.etcd.maintenance requires a restart")
+		}
+	}
+	return nil
+}
@@ -0,0 +1,262 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controller
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/k0sproject/k0s/internal/pkg/file"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/certificate"
+	"github.com/k0sproject/k0s/pkg/component"
+	"github.com/k0sproject/k0s/pkg/component/controller"
+	"github.com/k0sproject/k0s/pkg/config"
+	"github.com/k0sproject/k0s/pkg/etcd"
+	"github.com/k0sproject/k0s/pkg/install"
+)
+
+// storageStartTimeout is the time given to the storage and kube-apiserver to become healthy during a migration
+const storageStartTimeout = 2 * time.Minute
+
+// migrateStorage migrates the controller between kine and the etcd managed by k0s: it starts the target storage
+// next to the current one, stops kube-apiserver, copies all the kubernetes resources, records the new storage
+// and restarts kube-apiserver on it. The current storage is left untouched, so it can be switched back to.
+func (r *nodeConfigReloader) migrateStorage(ctx context.Context, migration install.StorageMigration) (err error) {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	c := r.opts
+	current := c.NodeConfig.Spec.Storage
+	target, err := storageMigrationTarget(current, migration, c.K0sVars.DataDir)
+	if err != nil {
+		return err
+	}
+	log := logrus.WithFields(logrus.Fields{"component": "storage-migration", "from": current.Type, "to": target.Type})
+
+	source, err := r.storageClient(current)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the %s storage: %w", current.Type, err)
+	}
+	defer source.Close()
+	if current.Type == v1beta1.EtcdStorageType {
+		members, err := source.ListMembers(ctx)
+		if err != nil {
+			return fmt.Errorf("failed to list the etcd members: %w", err)
+		}
+		if len(members) > 1 {
+			return fmt.Errorf("the etcd cluster has %d members, remove the other controllers first", len(members))
+		}
+	}
+
+	cleanup, err := storageCleanup(target, c.K0sVars.EtcdDataDir)
+	if err != nil {
+		return err
+	}
+
+	log.Info("Starting the target storage")
+	storage := r.newStorageComponent(target)
+	if err := storage.Init(ctx); err != nil {
+		return fmt.Errorf("failed to initialize the %s storage: %w", target.Type, err)
+	}
+	if err := storage.Run(ctx); err != nil {
+		return fmt.Errorf("failed to start the %s storage: %w", target.Type, err)
+	}
+	apiServerStopped := false
+	defer func() {
+		if err == nil {
+			return
+		}
+		if apiServerStopped {
+			log.Info("Restarting kube-apiserver on the current storage")
+			// kube-apiserver may have been started on the target storage already
+			if stopErr := r.apiServer.Stop(); stopErr != nil {
+				log.WithError(stopErr).Debug("Failed to stop kube-apiserver")
+			}
+			r.apiServer.ClusterConfig = c.NodeConfig
+			if runErr := r.apiServer.Run(ctx); runErr != nil {
+				log.WithError(runErr).Error("Failed to restart kube-apiserver")
+			}
+		}
+		if stopErr := storage.Stop(); stopErr != nil {
+			log.WithError(stopErr).Warn("Failed to stop the target storage")
+		}
+		if cleanupErr := cleanup(); cleanupErr != nil {
+			log.WithError(cleanupErr).Warn("Failed to clean up the target storage, it has to be emptied before retrying")
+		}
+	}()
+	if err := waitForHealthy(ctx, storage); err != nil {
+		return fmt.Errorf("the %s storage didn't become healthy: %w", target.Type, err)
+	}
+
+	destination, err := r.storageClient(target)
+	if err != nil {
+		return fmt.Errorf("failed to connect to the %s storage: %w", target.Type, err)
+	}
+	defer destination.Close()
+	count, err := destination.CountKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to check the %s storage: %w", target.Type, err)
+	}
+	if count > 0 {
+		return fmt.Errorf("the %s storage already contains %d kubernetes resources", target.Type, count)
+	}
+
+	log.Info("Stopping kube-apiserver to copy the kubernetes resources")
+	if err := r.apiServer.Stop(); err != nil {
+		return fmt.Errorf("failed to stop kube-apiserver: %w", err)
+	}
+	apiServerStopped = true
+	result, err := source.CopyTo(ctx, destination)
+	if err != nil {
+		return fmt.Errorf("failed to copy the kubernetes resources after %d of them: %w", result.Copied, err)
+	}
+	log.Infof("Copied %d kubernetes resources, skipped %d resources attached to a lease", result.Copied, result.Skipped)
+
+	nodeConfig := c.NodeConfig.DeepCopy()
+	nodeConfig.Spec.Storage = target
+	log.Info("Starting kube-apiserver on the target storage")
+	r.apiServer.ClusterConfig = nodeConfig
+	if err := r.apiServer.Run(ctx); err != nil {
+		return fmt.Errorf("failed to start kube-apiserver on the %s storage: %w", target.Type, err)
+	}
+	if err := waitForHealthy(ctx, r.apiServer); err != nil {
+		return fmt.Errorf("kube-apiserver didn't become healthy on the %s storage: %w", target.Type, err)
+	}
+
+	// the storage is only recorded once kube-apiserver works on it, so that a restart never boots on a half migrated storage
+	loadingRules := config.ClientConfigLoadingRules{RuntimeConfigPath: c.CfgFile, K0sVars: c.K0sVars}
+	if err := loadingRules.RecordStorage(nodeConfig); err != nil {
+		if restoreErr := loadingRules.RecordStorage(c.NodeConfig); restoreErr != nil {
+			log.WithError(restoreErr).Error("Failed to restore the recorded storage")
+		}
+		return err
+	}
+
+	if err := c.NodeComponents.Replace(r.storage, storage); err != nil {
+		log.WithError(err).Warn("Failed to stop the previous storage")
+	}
+	r.storage = storage
+	c.NodeConfig = nodeConfig
+	log.Info("Migrated the storage")
+	return nil
+}
+
+// storageMigrationTarget returns the storage to migrate to
+func storageMigrationTarget(current *v1beta1.StorageSpec, migration install.StorageMigration, dataDir string) (*v1beta1.StorageSpec, error) {
+	if migration.To == current.Type {
+		return nil, fmt.Errorf("the storage is already %s", current.Type)
+	}
+	if current.Type == v1beta1.EtcdStorageType && current.Etcd.IsExternalClusterUsed() {
+		return nil, fmt.Errorf("migrating from an external etcd cluster is not supported")
+	}
+
+	target := current.DeepCopy()
+	target.Type = migration.To
+	switch migration.To {
+	case v1beta1.EtcdStorageType:
+		if migration.KineDataSource != "" {
+			return nil, fmt.Errorf("a kine data source can't be given when migrating to etcd")
+		}
+		if target.Etcd == nil {
+			target.Etcd = v1beta1.DefaultEtcdConfig()
+		}
+		if target.Etcd.IsExternalClusterUsed() {
+			return nil, fmt.Errorf("migrating to an external etcd cluster is not supported")
+		}
+	case v1beta1.KineStorageType:
+		target.Kine = v1beta1.DefaultKineConfig(dataDir)
+		if migration.KineDataSource != "" {
+			target.Kine.DataSource = migration.KineDataSource
+		}
+	default:
+		return nil, fmt.Errorf("unsupported storage type %q, it must be %s or %s", migration.To, v1beta1.EtcdStorageType, v1beta1.KineStorageType)
+	}
+	return target, nil
+}
+
+// storageCleanup returns a function removing the data written to the target storage by a failed migration, if the
+// data can be told apart from existing data. The etcd data dir and the kine sqlite database must not exist yet.
+func storageCleanup(target *v1beta1.StorageSpec, etcdDataDir string) (func() error, error) {
+	var paths []string
+	switch target.Type {
+	case v1beta1.EtcdStorageType:
+		paths = []string{filepath.Join(etcdDataDir, "member")}
+	case v1beta1.KineStorageType:
+		dsURL, err := url.Parse(target.Kine.DataSource)
+		if err != nil {
+			return nil, fmt.Errorf("invalid kine data source: %w", err)
+		}
+		if dsURL.Scheme != "sqlite" {
+			// the emptiness of other databases is checked once kine runs
+			return func() error { return nil }, nil
+		}
+		paths = []string{dsURL.Path, dsURL.Path + "-wal", dsURL.Path + "-shm"}
+	}
+
+	if file.Exists(paths[0]) {
+		return nil, fmt.Errorf("%s already exists, remove it to migrate to %s", paths[0], target.Type)
+	}
+	return func() error {
+		for _, path := range paths {
+			if err := os.RemoveAll(path); err != nil {
+				return err
+			}
+		}
+		return nil
+	}, nil
+}
+
+func (r *nodeConfigReloader) newStorageComponent(storage *v1beta1.StorageSpec) component.Component {
+	c := r.opts
+	if storage.Type == v1beta1.KineStorageType {
+		return &controller.Kine{
+			Config:        storage.Kine,
+			K0sVars:       c.K0sVars,
+			RestartPolicy: &c.RestartPolicy,
+			LogConfig:     &c.ComponentLogs,
+		}
+	}
+	return &controller.Etcd{
+		CertManager:   certificate.Manager{K0sVars: c.K0sVars, Config: c.NodeConfig.Spec.Certificates},
+		Config:        storage.Etcd,
+		K0sVars:       c.K0sVars,
+		LogLevel:      c.Logging["etcd"],
+		RestartPolicy: &c.RestartPolicy,
+		LogConfig:     &c.ComponentLogs,
+	}
+}
+
+func (r *nodeConfigReloader) storageClient(storage *v1beta1.StorageSpec) (*etcd.Client, error) {
+	c := r.opts
+	if storage.Type == v1beta1.KineStorageType {
+		return etcd.NewKineClient(c.K0sVars.KineSocketPath)
+	}
+	return etcd.NewClient(c.K0sVars.CertRootDir, c.K0sVars.EtcdCertDir, storage.Etcd)
+}
+
+func waitForHealthy(ctx context.Context, comp component.Component) error {
+	return wait.PollImmediateWithContext(ctx, time.Second, storageStartTimeout, func(context.Context) (bool, error) {
+		return comp.Healthy() == nil, nil
+	})
+}
@@ -0,0 +1,74 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controller
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/install"
+)
+
+func TestStorageMigrationTarget(t *testing.T) {
+	kine := &v1beta1.StorageSpec{Type: v1beta1.KineStorageType, Kine: v1beta1.DefaultKineConfig("/var/lib/k0s")}
+
+	target, err := storageMigrationTarget(kine, install.StorageMigration{To: "etcd"}, "/var/lib/k0s")
+	require.NoError(t, err)
+	assert.Equal(t, v1beta1.EtcdStorageType, target.Type)
+	assert.NotNil(t, target.Etcd)
+	assert.Equal(t, v1beta1.KineStorageType, kine.Type, "the current storage must be left untouched")
+
+	target, err = storageMigrationTarget(v1beta1.DefaultStorageSpec(), install.StorageMigration{To: "kine", KineDataSource: "mysql://k0s@tcp(db)/k0s"}, "/var/lib/k0s")
+	require.NoError(t, err)
+	assert.Equal(t, v1beta1.KineStorageType, target.Type)
+	assert.Equal(t, "mysql://k0s@tcp(db)/k0s", target.Kine.DataSource)
+
+	_, err = storageMigrationTarget(kine, install.StorageMigration{To: "kine"}, "/var/lib/k0s")
+	assert.EqualError(t, err, "the storage is already kine")
+	_, err = storageMigrationTarget(kine, install.StorageMigration{To: "etcd", KineDataSource: "sqlite:///tmp/db"}, "/var/lib/k0s")
+	assert.Error(t, err)
+	_, err = storageMigrationTarget(kine, install.StorageMigration{To: "dqlite"}, "/var/lib/k0s")
+	assert.Error(t, err)
+
+	external := v1beta1.DefaultStorageSpec()
+	external.Etcd.ExternalCluster = &v1beta1.ExternalCluster{Endpoints: []string{"https://etcd:2379"}, EtcdPrefix: "k0s"}
+	_, err = storageMigrationTarget(external, install.StorageMigration{To: "kine"}, "/var/lib/k0s")
+	assert.EqualError(t, err, "migrating from an external etcd cluster is not supported")
+}
+
+func TestStorageCleanup(t *testing.T) {
+	dataDir := t.TempDir()
+	kine := &v1beta1.StorageSpec{Type: v1beta1.KineStorageType, Kine: v1beta1.DefaultKineConfig(dataDir)}
+	db := filepath.Join(dataDir, "db", "state.db")
+
+	cleanup, err := storageCleanup(kine, filepath.Join(dataDir, "etcd"))
+	require.NoError(t, err)
+	require.NoError(t, os.MkdirAll(filepath.Dir(db), 0700))
+	require.NoError(t, os.WriteFile(db, nil, 0600))
+	require.NoError(t, os.WriteFile(db+"-wal", nil, 0600))
+
+	_, err = storageCleanup(kine, filepath.Join(dataDir, "etcd"))
+	assert.ErrorContains(t, err, "already exists")
+
+	require.NoError(t, cleanup())
+	assert.NoFileExists(t, db)
+	assert.NoFileExists(t, db+"-wal")
+}
@@ -0,0 +1,108 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package etcd
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/k0sproject/k0s/pkg/config"
+	"github.com/k0sproject/k0s/pkg/etcd"
+)
+
+func etcdAlarmCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "alarm",
+		Short: "Manage the alarms raised by the etcd cluster members",
+	}
+	cmd.AddCommand(etcdAlarmListCmd())
+	cmd.AddCommand(etcdAlarmDisarmCmd())
+	return cmd
+}
+
+func etcdAlarmListCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:     "list",
+		Short:   "Lists the alarms raised by the etcd cluster members",
+		PreRunE: checkOutput,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := CmdOpts(config.GetCmdOpts())
+			etcdClient, err := etcd.NewClient(c.K0sVars.CertRootDir, c.K0sVars.EtcdCertDir, c.NodeConfig.Spec.Storage.Etcd)
+			if err != nil {
+				return fmt.Errorf("can't connect to the etcd: %v", err)
+			}
+			defer etcdClient.Close()
+
+			alarms, err := etcdClient.ListAlarms(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return printOutput(map[string]interface{}{"alarms": alarms}, func() {
+				if len(alarms) == 0 {
+					fmt.Println("No etcd alarms raised")
+					return
+				}
+				printAlarms(alarms)
+			})
+		},
+	}
+	addOutputFlag(cmd)
+	cmd.PersistentFlags().AddFlagSet(config.GetPersistentFlagSet())
+	return cmd
+}
+
+func etcdAlarmDisarmCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "disarm",
+		Short: "Disarms all the alarms raised by the etcd cluster members",
+		Long: `Disarms all the alarms raised by the etcd cluster members.
+A NOSPACE alarm is raised again if the database still exceeds its quota, compact and defragment it first.`,
+		PreRunE: checkOutput,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := CmdOpts(config.GetCmdOpts())
+			etcdClient, err := etcd.NewClient(c.K0sVars.CertRootDir, c.K0sVars.EtcdCertDir, c.NodeConfig.Spec.Storage.Etcd)
+			if err != nil {
+				return fmt.Errorf("can't connect to the etcd: %v", err)
+			}
+			defer etcdClient.Close()
+
+			alarms, err := etcdClient.DisarmAlarms(cmd.Context())
+			if err != nil {
+				return err
+			}
+			return printOutput(map[string]interface{}{"disarmed": alarms}, func() {
+				if len(alarms) == 0 {
+					fmt.Println("No etcd alarms raised")
+					return
+				}
+				fmt.Println("Disarmed:")
+				printAlarms(alarms)
+			})
+		},
+	}
+	addOutputFlag(cmd)
+	cmd.PersistentFlags().AddFlagSet(config.GetPersistentFlagSet())
+	return cmd
+}
+
+func printAlarms(alarms []etcd.Alarm) {
+	table := newTable("Member ID", "Member", "Alarm")
+	for _, a := range alarms {
+		table.Append([]string{a.MemberID, a.Member, a.Alarm})
+	}
+	table.Render()
+}
@@ -48,6 +48,10 @@ func NewEtcdCmd() *cobra.Command {
 	cmd.SilenceUsage = true
 	cmd.AddCommand(etcdLeaveCmd())
 	cmd.AddCommand(etcdListCmd())
+	cmd.AddCommand(etcdStatusCmd())
+	cmd.AddCommand(etcdRemoveCmd())
+	cmd.AddCommand(etcdMoveLeaderCmd())
+	cmd.AddCommand(etcdAlarmCmd())
 	cmd.PersistentFlags().AddFlagSet(config.GetPersistentFlagSet())
 	return cmd
 }
@@ -0,0 +1,112 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package etcd
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/k0sproject/k0s/pkg/config"
+	"github.com/k0sproject/k0s/pkg/etcd"
+)
+
+func etcdMoveLeaderCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "move-leader [member]",
+		Short: "Transfers the etcd leadership to another member",
+		Long: `Transfers the etcd leadership to another member, given by its name, ID or peer address.
+Without a member, the leadership is transferred to the first healthy voting member.
+The command must be run on the controller of the current leader, e.g. before taking it down for maintenance.`,
+		Example: `k0s etcd move-leader
+k0s etcd move-leader controller-2 -o json`,
+		Args:    cobra.MaximumNArgs(1),
+		PreRunE: checkOutput,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := CmdOpts(config.GetCmdOpts())
+			ctx := cmd.Context()
+			etcdClient, err := etcd.NewClient(c.K0sVars.CertRootDir, c.K0sVars.EtcdCertDir, c.NodeConfig.Spec.Storage.Etcd)
+			if err != nil {
+				return fmt.Errorf("can't connect to the etcd: %v", err)
+			}
+			defer etcdClient.Close()
+
+			status, err := etcdClient.ClusterStatus(ctx)
+			if err != nil {
+				return fmt.Errorf("can't get the etcd cluster status: %v", err)
+			}
+			var from, to *etcd.Member
+			for i, m := range status.Members {
+				if m.Local {
+					from = &status.Members[i]
+				}
+			}
+			if from == nil || !from.Leader {
+				return fmt.Errorf("the local etcd member is not the leader, run the command on the controller of the leader %q", status.Leader)
+			}
+
+			if len(args) > 0 {
+				member, err := etcdClient.FindMember(ctx, args[0])
+				if err != nil {
+					return err
+				}
+				for i, m := range status.Members {
+					if m.ID == etcd.FormatID(member.ID) {
+						to = &status.Members[i]
+					}
+				}
+			} else {
+				for i, m := range status.Members {
+					if !m.Local && !m.Learner && m.Healthy {
+						to = &status.Members[i]
+						break
+					}
+				}
+			}
+			switch {
+			case to == nil && len(args) > 0:
+				return fmt.Errorf("member %q is not part of the cluster status", args[0])
+			case to == nil:
+				return fmt.Errorf("there is no healthy voting member to transfer the leadership to")
+			case to.Local:
+				return fmt.Errorf("the local etcd member is already the leader")
+			case to.Learner:
+				return fmt.Errorf("can't transfer the leadership to learner %q", to.Name)
+			}
+
+			toID, err := strconv.ParseUint(to.ID, 16, 64)
+			if err != nil {
+				return err
+			}
+			if err := etcdClient.MoveLeader(ctx, toID); err != nil {
+				return fmt.Errorf("can't transfer the etcd leadership to %q: %v", to.Name, err)
+			}
+
+			result := map[string]string{"from": from.Name, "to": to.Name}
+			return printOutput(result, func() {
+				logrus.
+					WithField("from", from.Name).
+					WithField("to", to.Name).
+					Info("Successfully transferred the leadership")
+			})
+		},
+	}
+	addOutputFlag(cmd)
+	cmd.PersistentFlags().AddFlagSet(config.GetPersistentFlagSet())
+	return cmd
+}
@@ -0,0 +1,77 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package etcd
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	"github.com/olekukonko/tablewriter"
+	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
+)
+
+var output string
+
+func addOutputFlag(cmd *cobra.Command) {
+	cmd.Flags().StringVarP(&output, "out", "o", "", "sets type of output to json or yaml")
+}
+
+func checkOutput(cmd *cobra.Command, args []string) error {
+	if output != "" && output != "json" && output != "yaml" {
+		return fmt.Errorf("unsupported output %q; supported outputs are json and yaml", output)
+	}
+	return nil
+}
+
+// printOutput prints v in the requested output format, or calls printText if none was requested
+func printOutput(v interface{}, printText func()) error {
+	switch output {
+	case "json":
+		jsn, err := json.MarshalIndent(v, "", "   ")
+		if err != nil {
+			return err
+		}
+		fmt.Println(string(jsn))
+	case "yaml":
+		ym, err := yaml.Marshal(v)
+		if err != nil {
+			return err
+		}
+		fmt.Print(string(ym))
+	default:
+		printText()
+	}
+	return nil
+}
+
+func newTable(header ...string) *tablewriter.Table {
+	table := tablewriter.NewWriter(os.Stdout)
+	table.SetHeader(header)
+	table.SetAutoWrapText(false)
+	table.SetAutoFormatHeaders(true)
+	table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
+	table.SetAlignment(tablewriter.ALIGN_LEFT)
+	table.SetCenterSeparator("")
+	table.SetColumnSeparator("")
+	table.SetRowSeparator("")
+	table.SetHeaderLine(false)
+	table.SetBorder(false)
+	table.SetTablePadding("\t") // pad with tabs
+	table.SetNoWhiteSpace(true)
+	return table
+}
@@ -0,0 +1,79 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package etcd
+
+import (
+	"fmt"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/cobra"
+
+	"github.com/k0sproject/k0s/pkg/config"
+	"github.com/k0sproject/k0s/pkg/etcd"
+)
+
+func etcdRemoveCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "remove <member>",
+		Short: "Removes a member from the etcd cluster",
+		Long: `Removes a member, given by its name, ID or peer address, from the etcd cluster.
+This is meant to evict a dead controller and must be run on a healthy one. Use 'k0s etcd leave' on a running controller instead.`,
+		Example: `k0s etcd remove controller-2
+k0s etcd remove 10.0.0.2
+k0s etcd remove 91bc3c398fb3c146 -o json`,
+		Args:    cobra.ExactArgs(1),
+		PreRunE: checkOutput,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := CmdOpts(config.GetCmdOpts())
+			ctx := cmd.Context()
+			etcdClient, err := etcd.NewClient(c.K0sVars.CertRootDir, c.K0sVars.EtcdCertDir, c.NodeConfig.Spec.Storage.Etcd)
+			if err != nil {
+				return fmt.Errorf("can't connect to the etcd: %v", err)
+			}
+			defer etcdClient.Close()
+
+			if err := etcdClient.Health(ctx); err != nil {
+				return fmt.Errorf("the local etcd member is not healthy, run the command on a healthy controller: %v", err)
+			}
+			member, err := etcdClient.FindMember(ctx, args[0])
+			if err != nil {
+				return err
+			}
+			localID, _, err := etcdClient.LocalMember(ctx)
+			if err != nil {
+				return fmt.Errorf("can't get the local etcd member: %v", err)
+			}
+			if member.ID == localID {
+				return fmt.Errorf("can't remove the local etcd member, use 'k0s etcd leave' instead")
+			}
+
+			if err := etcdClient.DeleteMember(ctx, member.ID); err != nil {
+				return fmt.Errorf("can't remove etcd member %s: %v", etcd.FormatID(member.ID), err)
+			}
+
+			removed := etcd.Member{ID: etcd.FormatID(member.ID), Name: member.Name, PeerURLs: member.PeerURLs, Learner: member.IsLearner}
+			return printOutput(map[string]interface{}{"removed": removed}, func() {
+				logrus.
+					WithField("peerID", removed.ID).
+					WithField("name", removed.Name).
+					Info("Successfully removed")
+			})
+		},
+	}
+	addOutputFlag(cmd)
+	cmd.PersistentFlags().AddFlagSet(config.GetPersistentFlagSet())
+	return cmd
+}
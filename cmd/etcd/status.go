@@ -0,0 +1,87 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package etcd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/k0sproject/k0s/pkg/config"
+	"github.com/k0sproject/k0s/pkg/etcd"
+)
+
+func etcdStatusCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "status",
+		Short: "Returns the status of the etcd cluster members",
+		Long: `Returns the health, role and version of each etcd cluster member, along with the raft term of the cluster.
+The database size is only known for the member of the controller the command is run on.`,
+		Example: `k0s etcd status
+k0s etcd status -o json`,
+		PreRunE: checkOutput,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := CmdOpts(config.GetCmdOpts())
+			etcdClient, err := etcd.NewClient(c.K0sVars.CertRootDir, c.K0sVars.EtcdCertDir, c.NodeConfig.Spec.Storage.Etcd)
+			if err != nil {
+				return fmt.Errorf("can't connect to the etcd: %v", err)
+			}
+			defer etcdClient.Close()
+
+			status, err := etcdClient.ClusterStatus(cmd.Context())
+			if err != nil {
+				return fmt.Errorf("can't get the etcd cluster status: %v", err)
+			}
+			return printOutput(status, func() { printStatus(status) })
+		},
+	}
+	addOutputFlag(cmd)
+	cmd.PersistentFlags().AddFlagSet(config.GetPersistentFlagSet())
+	return cmd
+}
+
+func printStatus(status *etcd.ClusterStatus) {
+	fmt.Printf("Cluster ID: %s\nLeader: %s\nRaft term: %d\nRevision: %d\n\n", status.ClusterID, status.Leader, status.RaftTerm, status.Revision)
+
+	table := newTable("ID", "Name", "Peer URLs", "Role", "Healthy", "Version", "DB size", "Error")
+	for _, m := range status.Members {
+		role := "follower"
+		switch {
+		case m.Leader:
+			role = "leader"
+		case m.Learner:
+			role = "learner"
+		}
+		name := m.Name
+		dbSize := ""
+		if m.Local {
+			name += " (local)"
+			dbSize = fmt.Sprintf("%s (%s in use)", formatBytes(m.DBSize), formatBytes(m.DBSizeInUse))
+		}
+		table.Append([]string{m.ID, name, strings.Join(m.PeerURLs, ","), role, fmt.Sprint(m.Healthy), m.Version, dbSize, m.Error})
+	}
+	table.Render()
+
+	if len(status.Alarms) > 0 {
+		fmt.Println()
+		printAlarms(status.Alarms)
+	}
+}
+
+func formatBytes(b int64) string {
+	return fmt.Sprintf("%.1f MiB", float64(b)/(1<<20))
+}
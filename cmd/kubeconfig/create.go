@@ -79,7 +79,7 @@ Note: A certificate once signed cannot be revoked for a particular user`,
 			c := CmdOpts(config.GetCmdOpts())
 			clusterAPIURL := c.NodeConfig.Spec.API.APIAddressURL()
 
-			caCert, err := os.ReadFile(path.Join(c.K0sVars.CertRootDir, "ca.crt"))
+			caCert, err := os.ReadFile(certificate.CABundleFile(path.Join(c.K0sVars.CertRootDir, "ca.crt")))
 			if err != nil {
 				return fmt.Errorf("failed to read cluster ca certificate: %w, check if the control plane is initialized on this node", err)
 			}
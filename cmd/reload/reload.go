@@ -0,0 +1,58 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package reload
+
+import (
+	"fmt"
+	"path/filepath"
+	"runtime"
+
+	"github.com/spf13/cobra"
+
+	"github.com/k0sproject/k0s/pkg/config"
+	"github.com/k0sproject/k0s/pkg/install"
+)
+
+func NewReloadCmd() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "reload [-- worker flags]",
+		Short: "Reload the node config of the running k0s",
+		Long: `Re-reads the config file of the running k0s controller and restarts only the components affected by
+changes of the node config, e.g. spec.api.extraArgs or spec.storage.kine.dataSource. Sending SIGHUP to the
+k0s process has the same effect.
+
+A running k0s worker applies the changed worker flags given after "--", e.g. --labels or --kubelet-extra-args,
+and fetches the kubelet config of its worker profile again. It restarts kubelet and containerd only if they
+are affected by the changes. Sending SIGHUP to the k0s worker process reloads it with its current flags.`,
+		Example: `	$ k0s reload
+	$ k0s reload -- --kubelet-extra-args="--max-pods=200" --labels=disktype=ssd`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			cmd.SilenceUsage = true
+			if runtime.GOOS == "windows" {
+				return fmt.Errorf("currently not supported on windows")
+			}
+
+			if err := install.ReloadConfig(config.StatusSocket, install.Reload{WorkerFlags: args}); err != nil {
+				return err
+			}
+			fmt.Println("k0s node config reloaded")
+			return nil
+		},
+	}
+
+	cmd.PersistentFlags().StringVar(&config.StatusSocket, "status-socket", filepath.Join(config.K0sVars.RunDir, "status.sock"), "Full file path to the socket file.")
+	return cmd
+}
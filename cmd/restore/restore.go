@@ -20,6 +20,7 @@ limitations under the License.
 package restore
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"path"
@@ -27,7 +28,7 @@ import (
 	"strings"
 
 	"github.com/k0sproject/k0s/internal/pkg/dir"
-	"github.com/k0sproject/k0s/internal/pkg/file"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 	"github.com/k0sproject/k0s/pkg/backup"
 	"github.com/k0sproject/k0s/pkg/config"
 	"github.com/k0sproject/k0s/pkg/constant"
@@ -39,12 +40,22 @@ import (
 
 type CmdOpts config.CLIOptions
 
-var restoredConfigPath string
+var (
+	restoredConfigPath string
+	s3                 v1beta1.BackupS3Destination
+	decryption         backup.DecryptionOptions
+	dryRun             bool
+)
 
 func NewRestoreCmd() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "restore filename",
 		Short: "restore k0s state from given backup archive. Use '-' as filename to read from stdin. Must be run as root (or with sudo)",
+		Long: `Restore k0s state from given backup archive. Use '-' as filename to read from stdin.
+If --s3-bucket is given, the filename is the name of the archive object in the bucket, relative to --s3-prefix.
+The integrity manifest of the archive is verified before the data directory is touched.
+With --dry-run, the archive is checked and every restore step is validated, but nothing is written.
+Must be run as root (or with sudo)`,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			c := CmdOpts(config.GetCmdOpts())
 			if len(args) != 1 {
@@ -63,6 +74,9 @@ func NewRestoreCmd() *cobra.Command {
 
 	restoredConfigPathDescription := fmt.Sprintf("Specify desired name and full path for the restored k0s.yaml file (default: %s/k0s_<archive timestamp>.yaml", cwd)
 	cmd.Flags().StringVar(&restoredConfigPath, "config-out", "", restoredConfigPathDescription)
+	cmd.Flags().AddFlagSet(backup.GetS3FlagSet(&s3))
+	cmd.Flags().AddFlagSet(backup.GetDecryptionFlagSet(&decryption))
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only check that the backup archive can be restored, without writing anything")
 	cmd.PersistentFlags().AddFlagSet(config.GetPersistentFlagSet())
 	return cmd
 }
@@ -72,16 +86,20 @@ func (c *CmdOpts) restore(path string) error {
 		return fmt.Errorf("this command must be run as root")
 	}
 
-	k0sStatus, _ := install.GetStatusInfo(config.StatusSocket)
-	if k0sStatus != nil && k0sStatus.Pid != 0 {
-		logrus.Fatal("k0s seems to be running! k0s must be down during the restore operation.")
+	if !dryRun {
+		k0sStatus, _ := install.GetStatusInfo(config.StatusSocket)
+		if k0sStatus != nil && k0sStatus.Pid != 0 {
+			logrus.Fatal("k0s seems to be running! k0s must be down during the restore operation.")
+		}
 	}
 
-	if path != "-" && !file.Exists(path) {
-		return fmt.Errorf("given file %s does not exist", path)
+	ctx := context.Background()
+	src, archiveName, err := backup.NewSource(ctx, path, &s3)
+	if err != nil {
+		return err
 	}
 
-	if !dir.IsDirectory(c.K0sVars.DataDir) {
+	if !dryRun && !dir.IsDirectory(c.K0sVars.DataDir) {
 		if err := dir.Init(c.K0sVars.DataDir, constant.DataDirMode); err != nil {
 			return err
 		}
@@ -91,10 +109,14 @@ func (c *CmdOpts) restore(path string) error {
 	if err != nil {
 		return err
 	}
+	if err := decryption.Apply(mgr); err != nil {
+		return err
+	}
+	mgr.DryRun = dryRun
 	if restoredConfigPath == "" {
 		restoredConfigPath = defaultConfigFileOutputPath(path)
 	}
-	return mgr.RunRestore(path, c.K0sVars, restoredConfigPath)
+	return mgr.RunRestore(ctx, src, archiveName, c.K0sVars, restoredConfigPath)
 }
 
 // set output config file name and path according to input archive Timestamps
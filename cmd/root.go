@@ -27,6 +27,7 @@ import (
 	"github.com/k0sproject/k0s/cmd/airgap"
 	"github.com/k0sproject/k0s/cmd/api"
 	"github.com/k0sproject/k0s/cmd/backup"
+	"github.com/k0sproject/k0s/cmd/certificates"
 	cfg "github.com/k0sproject/k0s/cmd/config"
 	"github.com/k0sproject/k0s/cmd/controller"
 	"github.com/k0sproject/k0s/cmd/ctr"
@@ -34,11 +35,13 @@ import (
 	"github.com/k0sproject/k0s/cmd/install"
 	"github.com/k0sproject/k0s/cmd/kubeconfig"
 	"github.com/k0sproject/k0s/cmd/kubectl"
+	"github.com/k0sproject/k0s/cmd/reload"
 	"github.com/k0sproject/k0s/cmd/reset"
 	"github.com/k0sproject/k0s/cmd/restore"
 	"github.com/k0sproject/k0s/cmd/start"
 	"github.com/k0sproject/k0s/cmd/status"
 	"github.com/k0sproject/k0s/cmd/stop"
+	"github.com/k0sproject/k0s/cmd/storage"
 	"github.com/k0sproject/k0s/cmd/sysinfo"
 	"github.com/k0sproject/k0s/cmd/token"
 	"github.com/k0sproject/k0s/cmd/validate"
@@ -46,6 +49,7 @@ import (
 	"github.com/k0sproject/k0s/cmd/worker"
 	"github.com/k0sproject/k0s/pkg/build"
 	"github.com/k0sproject/k0s/pkg/config"
+	"github.com/k0sproject/k0s/pkg/supervisor"
 )
 
 var longDesc string
@@ -81,6 +85,7 @@ func NewRootCmd() *cobra.Command {
 	cmd.AddCommand(airgap.NewAirgapCmd())
 	cmd.AddCommand(api.NewAPICmd())
 	cmd.AddCommand(backup.NewBackupCmd())
+	cmd.AddCommand(certificates.NewCertificatesCmd())
 	cmd.AddCommand(controller.NewControllerCmd())
 	cmd.AddCommand(ctr.NewCtrCommand())
 	cmd.AddCommand(cfg.NewConfigCmd())
@@ -88,11 +93,13 @@ func NewRootCmd() *cobra.Command {
 	cmd.AddCommand(install.NewInstallCmd())
 	cmd.AddCommand(kubeconfig.NewKubeConfigCmd())
 	cmd.AddCommand(kubectl.NewK0sKubectlCmd())
+	cmd.AddCommand(reload.NewReloadCmd())
 	cmd.AddCommand(reset.NewResetCmd())
 	cmd.AddCommand(restore.NewRestoreCmd())
 	cmd.AddCommand(start.NewStartCmd())
 	cmd.AddCommand(status.NewStatusCmd())
 	cmd.AddCommand(stop.NewStopCmd())
+	cmd.AddCommand(storage.NewStorageCmd())
 	cmd.AddCommand(sysinfo.NewSysinfoCmd())
 	cmd.AddCommand(token.NewTokenCmd())
 	cmd.AddCommand(validate.NewValidateCmd()) // hidden+deprecated
@@ -102,6 +109,7 @@ func NewRootCmd() *cobra.Command {
 	cmd.AddCommand(newCompletionCmd())
 	cmd.AddCommand(newDefaultConfigCmd()) // hidden+deprecated
 	cmd.AddCommand(newDocsCmd())
+	cmd.AddCommand(newCgroupExecCmd()) // hidden, run by the supervisor
 
 	cmd.DisableAutoGenTag = true
 	longDesc = "k0s - The zero friction Kubernetes - https://k0sproject.io"
@@ -138,6 +146,20 @@ func newDefaultConfigCmd() *cobra.Command {
 	return cmd
 }
 
+// newCgroupExecCmd places the process in the cgroup of a supervised component before executing the component binary
+func newCgroupExecCmd() *cobra.Command {
+	return &cobra.Command{
+		Use:                supervisor.CgroupExecCommand + " <cgroup> <uid> <gid> <binary> [args...]",
+		Hidden:             true,
+		DisableFlagParsing: true,
+		// skip the config loading of the root command
+		PersistentPreRun: func(*cobra.Command, []string) {},
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return supervisor.ExecInCgroup(args)
+		},
+	}
+}
+
 func newCompletionCmd() *cobra.Command {
 	return &cobra.Command{
 		Use:   "completion <bash|zsh|fish|powershell>",
@@ -20,6 +20,8 @@ import (
 	"fmt"
 	"path/filepath"
 	"runtime"
+	"strings"
+	"time"
 
 	"github.com/spf13/cobra"
 	"sigs.k8s.io/yaml"
@@ -31,7 +33,9 @@ import (
 type CmdOpts config.CLIOptions
 
 var (
-	output string
+	output         string
+	showComponents bool
+	showStacks     bool
 )
 
 func NewStatusCmd() *cobra.Command {
@@ -50,7 +54,7 @@ func NewStatusCmd() *cobra.Command {
 				return err
 			}
 			if statusInfo != nil {
-				printStatus(statusInfo, output)
+				printStatus(statusInfo, output, showComponents, showStacks)
 			} else {
 				fmt.Println("K0s is not running")
 			}
@@ -60,12 +64,14 @@ func NewStatusCmd() *cobra.Command {
 
 	cmd.SilenceUsage = true
 	cmd.PersistentFlags().StringVarP(&output, "out", "o", "", "sets type of output to json or yaml")
+	cmd.Flags().BoolVar(&showComponents, "components", false, "also print the lifecycle state and the latest health check result of every component")
+	cmd.Flags().BoolVar(&showStacks, "stacks", false, "also print the status of the manifest stacks, only known to the controller leader")
 	cmd.PersistentFlags().StringVar(&config.StatusSocket, "status-socket", filepath.Join(config.K0sVars.RunDir, "status.sock"), "Full file path to the socket file.")
 
 	return cmd
 }
 
-func printStatus(status *install.K0sStatus, output string) {
+func printStatus(status *install.K0sStatus, output string, showComponents bool, showStacks bool) {
 	switch output {
 	case "json":
 		jsn, _ := json.MarshalIndent(status, "", "   ")
@@ -86,5 +92,37 @@ func printStatus(status *install.K0sStatus, output string) {
 		if status.StubFile != "" {
 			fmt.Println("Service file:", status.StubFile)
 		}
+		if len(status.Processes) > 0 {
+			fmt.Println("Processes:")
+			for _, p := range status.Processes {
+				fmt.Printf("  %s: %s (pid: %d, restarts: %d, last exit code: %d)\n", p.Name, p.State, p.Pid, p.Restarts, p.LastExitCode)
+			}
+		}
+		if showComponents && len(status.Components) > 0 {
+			fmt.Println("Components:")
+			for _, c := range status.Components {
+				fmt.Printf("  %s: %s (since: %s", c.Name, c.State, c.LastTransition.Format(time.RFC3339))
+				if !c.LastHealthCheck.IsZero() {
+					fmt.Printf(", last health check: %s", c.LastHealthCheck.Format(time.RFC3339))
+				}
+				if c.LastError != "" {
+					fmt.Printf(", last error: %s", c.LastError)
+				}
+				fmt.Println(")")
+			}
+		}
+		if showStacks && len(status.Stacks) > 0 {
+			fmt.Println("Stacks:")
+			for _, s := range status.Stacks {
+				fmt.Printf("  %s: %s (since: %s", s.Name, s.Phase, s.LastTransition.Format(time.RFC3339))
+				if len(s.DependsOn) > 0 {
+					fmt.Printf(", depends on: %s", strings.Join(s.DependsOn, ", "))
+				}
+				if s.Message != "" {
+					fmt.Printf(", %s", s.Message)
+				}
+				fmt.Println(")")
+			}
+		}
 	}
 }
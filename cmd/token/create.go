@@ -29,7 +29,10 @@ import (
 	"github.com/k0sproject/k0s/pkg/token"
 )
 
-var createTokenRole string
+var (
+	createTokenRole string
+	tokenScope      token.Scope
+)
 
 func tokenCreateCmd() *cobra.Command {
 	cmd := &cobra.Command{
@@ -37,6 +40,8 @@ func tokenCreateCmd() *cobra.Command {
 		Short: "Create join token",
 		Example: `k0s token create --role worker --expiry 100h //sets expiration time to 100 hours
 k0s token create --role worker --expiry 10m  //sets expiration time to 10 minutes
+k0s token create --role worker --max-uses 1 --node-name worker-1 --addresses 10.0.0.0/24 //single-use token for worker-1 joining from 10.0.0.0/24
+k0s token create --role worker --worker-profile gpu --labels gpu=true --taints gpu=true:NoSchedule //worker settings applied by the join
 `,
 		PreRunE: checkCreateTokenRole,
 		RunE: func(cmd *cobra.Command, args []string) error {
@@ -69,7 +74,7 @@ k0s token create --role worker --expiry 10m  //sets expiration time to 10 minute
 					return err
 				}
 
-				bootstrapConfig, err = token.CreateKubeletBootstrapConfig(cmd.Context(), c.NodeConfig.Spec.API, c.K0sVars, createTokenRole, expiry)
+				bootstrapConfig, err = token.CreateScopedBootstrapConfig(cmd.Context(), c.NodeConfig.Spec.API, c.K0sVars, createTokenRole, expiry, tokenScope)
 				return err
 			})
 			if err != nil {
@@ -84,6 +89,12 @@ k0s token create --role worker --expiry 10m  //sets expiration time to 10 minute
 	cmd.Flags().StringVar(&tokenExpiry, "expiry", "0s", "Expiration time of the token. Format 1.5h, 2h45m or 300ms.")
 	cmd.Flags().StringVar(&createTokenRole, "role", "worker", "Either worker or controller")
 	cmd.Flags().BoolVar(&waitCreate, "wait", false, "wait forever (default false)")
+	cmd.Flags().IntVar(&tokenScope.MaxUses, "max-uses", 0, "Number of joins allowed with the token, 0 for unlimited")
+	cmd.Flags().StringVar(&tokenScope.NodeName, "node-name", "", "Name of the only node allowed to join with the token")
+	cmd.Flags().StringSliceVar(&tokenScope.Addresses, "addresses", nil, "IPs and CIDRs the joining node may connect from")
+	cmd.Flags().StringVar(&tokenScope.Profile, "worker-profile", "", "Worker profile of the joining worker")
+	cmd.Flags().StringSliceVar(&tokenScope.Labels, "labels", nil, "Labels added to the joining worker, list of key=value pairs")
+	cmd.Flags().StringSliceVar(&tokenScope.Taints, "taints", nil, "Taints added to the joining worker, list of key=value:effect strings")
 
 	return cmd
 }
@@ -101,6 +112,9 @@ func ensureTokenCreationAcceptable(statusInfo *install.K0sStatus) error {
 
 func checkCreateTokenRole(cmd *cobra.Command, args []string) error {
 	err := checkTokenRole(createTokenRole)
+	if err == nil {
+		err = tokenScope.Validate(createTokenRole)
+	}
 	if err != nil {
 		cmd.SilenceUsage = true
 	}
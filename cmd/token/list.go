@@ -16,23 +16,30 @@ limitations under the License.
 package token
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/k0sproject/k0s/pkg/config"
 	"github.com/k0sproject/k0s/pkg/token"
 	"github.com/olekukonko/tablewriter"
 	"github.com/spf13/cobra"
+	"sigs.k8s.io/yaml"
 )
 
-var listTokenRole string
+var (
+	listTokenRole string
+	listOutput    string
+)
 
 func tokenListCmd() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:     "list",
-		Short:   "List join tokens",
-		Example: `k0s token list --role worker // list worker tokens`,
+		Use:   "list",
+		Short: "List join tokens",
+		Example: `k0s token list --role worker // list worker tokens
+k0s token list -o json     // list all tokens as JSON`,
 		PreRunE: checkListTokenRole,
 		RunE: func(cmd *cobra.Command, args []string) error {
 			c := CmdOpts(config.GetCmdOpts())
@@ -45,14 +52,30 @@ func tokenListCmd() *cobra.Command {
 			if err != nil {
 				return err
 			}
+			switch listOutput {
+			case "json":
+				jsn, err := json.MarshalIndent(newListedTokens(tokens, time.Now()), "", "   ")
+				if err != nil {
+					return err
+				}
+				fmt.Println(string(jsn))
+				return nil
+			case "yaml":
+				ym, err := yaml.Marshal(newListedTokens(tokens, time.Now()))
+				if err != nil {
+					return err
+				}
+				fmt.Print(string(ym))
+				return nil
+			}
+
 			if len(tokens) == 0 {
 				fmt.Println("No k0s join tokens found")
 				return nil
 			}
 
-			//fmt.Printf("Tokens: %v \n", tokens)
 			table := tablewriter.NewWriter(os.Stdout)
-			table.SetHeader([]string{"ID", "Role", "Expires at"})
+			table.SetHeader([]string{"ID", "Role", "Created at", "Expires at", "Remaining", "Uses", "Nodes"})
 			table.SetAutoWrapText(false)
 			table.SetAutoFormatHeaders(true)
 			table.SetHeaderAlignment(tablewriter.ALIGN_LEFT)
@@ -64,8 +87,9 @@ func tokenListCmd() *cobra.Command {
 			table.SetBorder(false)
 			table.SetTablePadding("\t") // pad with tabs
 			table.SetNoWhiteSpace(true)
+			now := time.Now()
 			for _, t := range tokens {
-				table.Append(t.ToArray())
+				table.Append(t.ToArray(now))
 			}
 
 			table.Render()
@@ -74,12 +98,36 @@ func tokenListCmd() *cobra.Command {
 		},
 	}
 	cmd.Flags().StringVar(&listTokenRole, "role", "", "Either worker, controller or empty for all roles")
+	cmd.Flags().StringVarP(&listOutput, "out", "o", "", "sets type of output to json or yaml")
 	cmd.PersistentFlags().AddFlagSet(config.GetPersistentFlagSet())
 	return cmd
 }
 
+// listedToken is a token as listed with -o json or yaml, along with the uses and the remaining validity shown in the table
+type listedToken struct {
+	token.Token
+	Uses int `json:"uses"`
+	// Remaining is the remaining validity of the token, "expired" once it expired, empty if it never expires
+	Remaining string `json:"remaining,omitempty"`
+}
+
+// newListedTokens returns the tokens to list, an empty list rather than nil if there are none
+func newListedTokens(tokens []token.Token, now time.Time) []listedToken {
+	listed := make([]listedToken, 0, len(tokens))
+	for _, t := range tokens {
+		listed = append(listed, listedToken{Token: t, Uses: t.Uses(), Remaining: t.Remaining(now)})
+	}
+	return listed
+}
+
 func checkListTokenRole(cmd *cobra.Command, args []string) error {
-	err := checkTokenRole(listTokenRole)
+	var err error
+	if listTokenRole != "" {
+		err = checkTokenRole(listTokenRole)
+	}
+	if err == nil && listOutput != "" && listOutput != "json" && listOutput != "yaml" {
+		err = fmt.Errorf("unsupported output %q; supported outputs are json and yaml", listOutput)
+	}
 	if err != nil {
 		cmd.SilenceUsage = true
 	}
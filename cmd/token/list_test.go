@@ -0,0 +1,59 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package token
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"sigs.k8s.io/yaml"
+
+	"github.com/k0sproject/k0s/pkg/token"
+)
+
+func TestListedTokens(t *testing.T) {
+	now := time.Date(2022, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	empty, err := json.Marshal(newListedTokens(nil, now))
+	require.NoError(t, err)
+	assert.Equal(t, "[]", string(empty))
+	emptyYAML, err := yaml.Marshal(newListedTokens(nil, now))
+	require.NoError(t, err)
+	assert.Equal(t, "[]\n", string(emptyYAML))
+
+	expiresAt := now.Add(time.Hour)
+	tokens := []token.Token{{
+		ID:        "abcdef",
+		Role:      "worker",
+		CreatedAt: now.Add(-time.Hour),
+		ExpiresAt: &expiresAt,
+		Scope:     &token.Scope{MaxUses: 3},
+		Joins:     []token.Join{{Node: "worker0"}},
+	}}
+	listed, err := json.Marshal(newListedTokens(tokens, now))
+	require.NoError(t, err)
+	var decoded []map[string]interface{}
+	require.NoError(t, json.Unmarshal(listed, &decoded))
+	require.Len(t, decoded, 1)
+	assert.Equal(t, "abcdef", decoded[0]["id"])
+	assert.Equal(t, float64(1), decoded[0]["uses"])
+	assert.Equal(t, "1h0m0s", decoded[0]["remaining"])
+	assert.NotNil(t, decoded[0]["scope"])
+	assert.Len(t, decoded[0]["joins"], 1)
+}
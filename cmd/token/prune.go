@@ -0,0 +1,70 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package token
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/k0sproject/k0s/pkg/config"
+	"github.com/k0sproject/k0s/pkg/token"
+	"github.com/spf13/cobra"
+)
+
+func tokenPruneCmd() *cobra.Command {
+	var (
+		usedUp bool
+		dryRun bool
+	)
+	cmd := &cobra.Command{
+		Use:   "prune",
+		Short: "Remove expired join tokens",
+		Example: `k0s token prune           // remove expired tokens
+k0s token prune --used-up // also remove the tokens used as many times as allowed`,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			c := CmdOpts(config.GetCmdOpts())
+			manager, err := token.NewManager(filepath.Join(c.K0sVars.AdminKubeConfigPath))
+			if err != nil {
+				return err
+			}
+
+			tokens, err := manager.List(cmd.Context(), "")
+			if err != nil {
+				return err
+			}
+			now := time.Now()
+			for _, t := range tokens {
+				if !t.Expired(now) && !(usedUp && t.UsedUp()) {
+					continue
+				}
+				if dryRun {
+					fmt.Printf("token %s would be deleted\n", t.ID)
+					continue
+				}
+				if err := manager.Remove(cmd.Context(), t.ID); err != nil {
+					return err
+				}
+				fmt.Printf("token %s deleted successfully\n", t.ID)
+			}
+			return nil
+		},
+	}
+	cmd.Flags().BoolVar(&usedUp, "used-up", false, "also remove the scoped tokens used as many times as they allow")
+	cmd.Flags().BoolVar(&dryRun, "dry-run", false, "only print the tokens that would be removed")
+	cmd.PersistentFlags().AddFlagSet(config.GetPersistentFlagSet())
+	return cmd
+}
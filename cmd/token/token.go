@@ -41,6 +41,7 @@ func NewTokenCmd() *cobra.Command {
 	cmd.AddCommand(tokenCreateCmd())
 	cmd.AddCommand(tokenListCmd())
 	cmd.AddCommand(tokenInvalidateCmd())
+	cmd.AddCommand(tokenPruneCmd())
 	return cmd
 }
 
@@ -0,0 +1,121 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package worker
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"sync"
+	"syscall"
+
+	"github.com/sirupsen/logrus"
+	"github.com/spf13/pflag"
+
+	"github.com/k0sproject/k0s/internal/pkg/stringmap"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/component"
+	"github.com/k0sproject/k0s/pkg/component/worker"
+	"github.com/k0sproject/k0s/pkg/install"
+)
+
+// workerReloader reloads the worker flags of a running worker, on SIGHUP or through the status socket
+type workerReloader struct {
+	opts         *CmdOpts
+	joinSettings *v1beta1.WorkerJoinResponse
+	components   *component.Manager
+	mutex        sync.Mutex
+}
+
+// watchSignals reloads the worker with its current flags whenever k0s receives SIGHUP, until the context is done
+func (r *workerReloader) watchSignals(ctx context.Context) {
+	hup := make(chan os.Signal, 1)
+	signal.Notify(hup, syscall.SIGHUP)
+	defer signal.Stop(hup)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-hup:
+			if err := r.reload(ctx, nil); err != nil {
+				logrus.WithError(err).Error("Failed to reload the worker")
+			}
+		}
+	}
+}
+
+// reloadRequest reloads the worker on a request through the status socket
+func (r *workerReloader) reloadRequest(ctx context.Context, reload install.Reload) error {
+	return r.reload(ctx, reload.WorkerFlags)
+}
+
+// reload applies the changed worker flags to the running worker components. The options of the worker are only
+// replaced once the components have been reconciled.
+func (r *workerReloader) reload(ctx context.Context, args []string) error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	logrus.Info("Reloading the worker")
+	reloaded, err := reloadFlags(*r.opts, args)
+	if err != nil {
+		return err
+	}
+	// The resource limits apply to the processes restarted from now on
+	if err := reloaded.setComponentResources(); err != nil {
+		return err
+	}
+
+	flags := reloaded.workerFlags(r.joinSettings)
+	if err := r.components.ReconcileNode(ctx, func(ctx context.Context, comp component.Component) (bool, error) {
+		flagsComponent, ok := comp.(worker.FlagsReconcilerComponent)
+		if !ok {
+			return false, nil
+		}
+		return true, flagsComponent.ReconcileFlags(ctx, flags)
+	}); err != nil {
+		return err
+	}
+	*r.opts = reloaded
+	logrus.Info("Reloaded the worker")
+	return nil
+}
+
+// reloadFlags returns the options with the given worker flags applied. Only the flags which a running worker can
+// apply are accepted, the options of the flags which aren't given are left as they are.
+func reloadFlags(current CmdOpts, args []string) (CmdOpts, error) {
+	reloaded := current
+	flags := pflag.NewFlagSet("reload", pflag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	flags.StringVar(&reloaded.WorkerProfile, "profile", current.WorkerProfile, "")
+	flags.StringSliceVar(&reloaded.Labels, "labels", current.Labels, "")
+	flags.StringSliceVar(&reloaded.Taints, "taints", current.Taints, "")
+	flags.StringVar(&reloaded.KubeletExtraArgs, "kubelet-extra-args", current.KubeletExtraArgs, "")
+	flags.StringToStringVarP(&reloaded.CmdLogLevels, "logging", "l", current.CmdLogLevels, "")
+	flags.StringToStringVar(&reloaded.KubeletResources, "kubelet-resources", current.KubeletResources, "")
+	flags.StringToStringVar(&reloaded.ContainerdResources, "containerd-resources", current.ContainerdResources, "")
+
+	if err := flags.Parse(args); err != nil {
+		return current, fmt.Errorf("%v, only --profile, --labels, --taints, --kubelet-extra-args, --logging, --kubelet-resources and --containerd-resources can be reloaded", err)
+	}
+	if flags.NArg() > 0 {
+		return current, fmt.Errorf("unexpected arguments: %v", flags.Args())
+	}
+	reloaded.Logging = stringmap.Merge(reloaded.CmdLogLevels, reloaded.DefaultLogLevels)
+	return reloaded, nil
+}
@@ -0,0 +1,66 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package worker
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/config"
+)
+
+func TestReloadFlags(t *testing.T) {
+	var current CmdOpts
+	current.WorkerProfile = "default"
+	current.Labels = []string{"zone=a"}
+	current.KubeletExtraArgs = "--max-pods=100"
+	current.CriSocket = "remote:unix:///run/cri.sock"
+	current.CmdLogLevels = map[string]string{"kubelet": "1"}
+	current.DefaultLogLevels = config.DefaultLogLevels()
+
+	reloaded, err := reloadFlags(current, []string{"--labels=zone=b,disk=ssd", "--logging=kubelet=4", "--kubelet-extra-args=--max-pods=200"})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"zone=b", "disk=ssd"}, reloaded.Labels)
+	assert.Equal(t, "--max-pods=200", reloaded.KubeletExtraArgs)
+	assert.Equal(t, "4", reloaded.Logging["kubelet"])
+	assert.Equal(t, "info", reloaded.Logging["containerd"])
+	assert.Equal(t, "default", reloaded.WorkerProfile, "flags which aren't given must be kept")
+	assert.Equal(t, []string{"zone=a"}, current.Labels, "the current options must be left untouched")
+
+	_, err = reloadFlags(current, []string{"--cri-socket=docker:unix:///run/docker.sock"})
+	assert.ErrorContains(t, err, "unknown flag: --cri-socket")
+	_, err = reloadFlags(current, []string{"token"})
+	assert.Error(t, err)
+}
+
+func TestWorkerFlags(t *testing.T) {
+	var c CmdOpts
+	c.WorkerProfile = "default"
+	c.Labels = []string{"zone=a"}
+	c.Logging = map[string]string{"kubelet": "1"}
+
+	flags := c.workerFlags(&v1beta1.WorkerJoinResponse{Profile: "gpu", Labels: []string{"gpu=true"}, Taints: []string{"gpu=true:NoSchedule"}})
+	assert.Equal(t, "gpu", flags.Profile)
+	assert.Equal(t, []string{"zone=a", "gpu=true"}, flags.Labels)
+	assert.Equal(t, []string{"gpu=true:NoSchedule"}, flags.Taints)
+	assert.Equal(t, []string{"zone=a"}, c.Labels, "the join settings must not be added to the options")
+
+	c.WorkerProfile = "edge"
+	assert.Equal(t, "edge", c.workerFlags(&v1beta1.WorkerJoinResponse{Profile: "gpu"}).Profile, "the profile flag takes precedence")
+}
@@ -29,12 +29,14 @@ import (
 	"github.com/k0sproject/k0s/internal/pkg/file"
 	"github.com/k0sproject/k0s/internal/pkg/stringmap"
 	"github.com/k0sproject/k0s/internal/pkg/sysinfo"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 	"github.com/k0sproject/k0s/pkg/build"
 	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/component/status"
 	"github.com/k0sproject/k0s/pkg/component/worker"
 	"github.com/k0sproject/k0s/pkg/config"
 	"github.com/k0sproject/k0s/pkg/install"
+	"github.com/k0sproject/k0s/pkg/supervisor"
 )
 
 type CmdOpts config.CLIOptions
@@ -86,7 +88,8 @@ func NewWorkerCmd() *cobra.Command {
 				return err
 			}
 
-			// Set up signal handling
+			// Set up signal handling, SIGHUP reloads the worker once its components are running
+			signal.Ignore(syscall.SIGHUP)
 			ctx, cancel := signal.NotifyContext(cmd.Context(), os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
 			defer cancel()
 
@@ -109,10 +112,31 @@ func (c *CmdOpts) StartWorker(ctx context.Context) error {
 
 	// Dump join token into kubelet-bootstrap kubeconfig if it does not already exist
 	if c.TokenArg != "" && !file.Exists(c.K0sVars.KubeletBootstrapConfigPath) {
-		if err := worker.HandleKubeletBootstrapToken(c.TokenArg, c.K0sVars); err != nil {
+		scoped, err := worker.IsScopedToken(c.TokenArg)
+		if err != nil {
 			return err
 		}
+		if scoped {
+			// scoped tokens are exchanged once, for the kubelet client certificate of the node
+			if !file.Exists(c.K0sVars.KubeletAuthConfigPath) {
+				if err := worker.JoinWithScopedToken(c.TokenArg, c.K0sVars); err != nil {
+					return fmt.Errorf("failed to join with scoped token: %w", err)
+				}
+			}
+		} else if err := worker.HandleKubeletBootstrapToken(c.TokenArg, c.K0sVars); err != nil {
+			return err
+		}
+	}
+
+	// Apply the worker settings carried by the scoped token the worker joined with
+	joinSettings, err := worker.LoadJoinSettings(c.K0sVars)
+	if err != nil {
+		return err
+	}
+	if err := c.setComponentResources(); err != nil {
+		return err
 	}
+	flags := c.workerFlags(joinSettings)
 
 	kubeletConfigClient, err := worker.LoadKubeletConfigClient(c.K0sVars)
 	if err != nil {
@@ -125,26 +149,26 @@ func (c *CmdOpts) StartWorker(ctx context.Context) error {
 	}
 	if c.CriSocket == "" {
 		componentManager.Add(ctx, &worker.ContainerD{
-			LogLevel: c.Logging["containerd"],
-			K0sVars:  c.K0sVars,
+			LogLevel:      c.Logging["containerd"],
+			K0sVars:       c.K0sVars,
+			RestartPolicy: &c.RestartPolicy,
+			LogConfig:     &c.ComponentLogs,
 		})
 	}
 
 	componentManager.Add(ctx, worker.NewOCIBundleReconciler(c.K0sVars))
-	if c.WorkerProfile == "default" && runtime.GOOS == "windows" {
-		c.WorkerProfile = "default-windows"
-	}
-
 	componentManager.Add(ctx, &worker.Kubelet{
 		CRISocket:           c.CriSocket,
 		EnableCloudProvider: c.CloudProvider,
 		K0sVars:             c.K0sVars,
 		KubeletConfigClient: kubeletConfigClient,
-		LogLevel:            c.Logging["kubelet"],
-		Profile:             c.WorkerProfile,
-		Labels:              c.Labels,
-		Taints:              c.Taints,
-		ExtraArgs:           c.KubeletExtraArgs,
+		LogLevel:            flags.LogLevels["kubelet"],
+		Profile:             flags.Profile,
+		Labels:              flags.Labels,
+		Taints:              flags.Taints,
+		ExtraArgs:           flags.KubeletExtraArgs,
+		RestartPolicy:       &c.RestartPolicy,
+		LogConfig:           &c.ComponentLogs,
 	})
 
 	if runtime.GOOS == "windows" {
@@ -152,9 +176,11 @@ func (c *CmdOpts) StartWorker(ctx context.Context) error {
 			return fmt.Errorf("no join-token given, which is required for windows bootstrap")
 		}
 		componentManager.Add(ctx, &worker.KubeProxy{
-			K0sVars:   c.K0sVars,
-			LogLevel:  c.Logging["kube-proxy"],
-			CIDRRange: c.CIDRRange,
+			K0sVars:       c.K0sVars,
+			LogLevel:      c.Logging["kube-proxy"],
+			CIDRRange:     c.CIDRRange,
+			RestartPolicy: &c.RestartPolicy,
+			LogConfig:     &c.ComponentLogs,
 		})
 		componentManager.Add(ctx, &worker.CalicoInstaller{
 			Token:      c.TokenArg,
@@ -164,7 +190,10 @@ func (c *CmdOpts) StartWorker(ctx context.Context) error {
 		})
 	}
 
+	// Controllers with a worker reload their node config only, they keep the worker flags they were started with
+	var reloader *workerReloader
 	if !c.SingleNode && !c.EnableWorker {
+		reloader = &workerReloader{opts: c, joinSettings: joinSettings, components: componentManager}
 		componentManager.Add(ctx, &status.Status{
 			StatusInformation: install.K0sStatus{
 				Pid:           os.Getpid(),
@@ -177,6 +206,7 @@ func (c *CmdOpts) StartWorker(ctx context.Context) error {
 				ClusterConfig: c.ClusterConfig,
 			},
 			Socket: config.StatusSocket,
+			Reload: reloader.reloadRequest,
 		})
 	}
 	// extract needed components
@@ -189,6 +219,9 @@ func (c *CmdOpts) StartWorker(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to start worker components: %w", err)
 	}
+	if reloader != nil {
+		go reloader.watchSignals(ctx)
+	}
 	// Wait for k0s process termination
 	<-ctx.Done()
 	logrus.Info("Shutting down k0s worker")
@@ -199,3 +232,38 @@ func (c *CmdOpts) StartWorker(ctx context.Context) error {
 	}
 	return nil
 }
+
+// setComponentResources registers the resource limits of the worker components given by the worker flags
+func (c *CmdOpts) setComponentResources() error {
+	for name, values := range map[string]map[string]string{"kubelet": c.KubeletResources, "containerd": c.ContainerdResources} {
+		resources, err := config.ParseComponentResources(values)
+		if err != nil {
+			return fmt.Errorf("invalid --%s-resources: %v", name, err)
+		}
+		supervisor.SetResources(name, resources)
+	}
+	return nil
+}
+
+// workerFlags returns the settings of the worker components given by the worker flags, along with the worker
+// settings carried by the scoped token the worker joined with
+func (c *CmdOpts) workerFlags(joinSettings *v1beta1.WorkerJoinResponse) *worker.Flags {
+	flags := &worker.Flags{
+		Profile:          c.WorkerProfile,
+		Labels:           c.Labels,
+		Taints:           c.Taints,
+		KubeletExtraArgs: c.KubeletExtraArgs,
+		LogLevels:        c.Logging,
+	}
+	if joinSettings != nil {
+		if joinSettings.Profile != "" && flags.Profile == "default" {
+			flags.Profile = joinSettings.Profile
+		}
+		flags.Labels = append(append([]string{}, c.Labels...), joinSettings.Labels...)
+		flags.Taints = append(append([]string{}, c.Taints...), joinSettings.Taints...)
+	}
+	if flags.Profile == "default" && runtime.GOOS == "windows" {
+		flags.Profile = "default-windows"
+	}
+	return flags
+}
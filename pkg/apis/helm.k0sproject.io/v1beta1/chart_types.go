@@ -16,6 +16,8 @@ limitations under the License.
 package v1beta1
 
 import (
+	"fmt"
+
 	"github.com/sirupsen/logrus"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"sigs.k8s.io/yaml"
@@ -29,6 +31,91 @@ type ChartSpec struct {
 	Version     string `json:"version,omitempty"`
 	Namespace   string `json:"namespace,omitempty"`
 	Timeout     string `json:"timeout,omitempty"`
+	// ValuesFrom lists the ConfigMaps and Secrets holding chart values. They get merged in order,
+	// the later ones taking precedence, and Values takes precedence over all of them.
+	ValuesFrom []ValuesReference `json:"valuesFrom,omitempty"`
+	// Upgrade configures how the release gets installed and upgraded
+	Upgrade *UpgradePolicy `json:"upgrade,omitempty"`
+}
+
+const (
+	// ValuesKindConfigMap is the kind of the values references to ConfigMaps
+	ValuesKindConfigMap = "ConfigMap"
+	// ValuesKindSecret is the kind of the values references to Secrets
+	ValuesKindSecret = "Secret"
+	// DefaultValuesKey is the key holding the values in the referenced ConfigMaps and Secrets
+	DefaultValuesKey = "values.yaml"
+)
+
+// ValuesReference references a ConfigMap or a Secret in the namespace of the Chart holding chart values
+type ValuesReference struct {
+	// Kind is either ConfigMap or Secret
+	// +kubebuilder:validation:Enum=ConfigMap;Secret
+	Kind string `json:"kind"`
+	// Name of the ConfigMap or Secret
+	Name string `json:"name"`
+	// ValuesKey is the key holding the YAML values, values.yaml by default
+	ValuesKey string `json:"valuesKey,omitempty"`
+	// Optional makes a missing ConfigMap, Secret or key not an error
+	Optional bool `json:"optional,omitempty"`
+}
+
+// Key returns the key holding the values
+func (r ValuesReference) Key() string {
+	if r.ValuesKey == "" {
+		return DefaultValuesKey
+	}
+	return r.ValuesKey
+}
+
+// Validate validates the reference
+func (r ValuesReference) Validate() error {
+	if r.Kind != ValuesKindConfigMap && r.Kind != ValuesKindSecret {
+		return fmt.Errorf("values reference kind must be %s or %s, got `%s`", ValuesKindConfigMap, ValuesKindSecret, r.Kind)
+	}
+	if r.Name == "" {
+		return fmt.Errorf("values reference must have Name field not empty")
+	}
+	return nil
+}
+
+// UpgradePolicy configures how the release of a chart gets installed and upgraded
+type UpgradePolicy struct {
+	// Atomic purges failed installs and rolls back failed upgrades, waiting for the release to be ready. Defaults to true.
+	Atomic *bool `json:"atomic,omitempty"`
+	// RollbackOnFailure rolls back failed upgrades to the previous revision when the upgrades aren't atomic
+	RollbackOnFailure bool `json:"rollbackOnFailure,omitempty"`
+	// Wait waits for the release resources to be ready before marking the release as successful. Defaults to true.
+	Wait *bool `json:"wait,omitempty"`
+	// WaitForJobs waits for the release jobs to complete before marking the release as successful. Defaults to true.
+	WaitForJobs *bool `json:"waitForJobs,omitempty"`
+	// Force forces resource updates through a replacement strategy. Defaults to true.
+	Force *bool `json:"force,omitempty"`
+	// CleanupOnFail deletes the resources created by a failed upgrade
+	CleanupOnFail bool `json:"cleanupOnFail,omitempty"`
+	// MaxHistory limits the number of revisions kept for the release, 0 for no limit
+	// +kubebuilder:validation:Minimum=0
+	MaxHistory int `json:"maxHistory,omitempty"`
+}
+
+// IsAtomic returns true if the installs and upgrades are atomic
+func (p *UpgradePolicy) IsAtomic() bool {
+	return p == nil || p.Atomic == nil || *p.Atomic
+}
+
+// ShouldWait returns true if the installs and upgrades wait for the release resources to be ready
+func (p *UpgradePolicy) ShouldWait() bool {
+	return p == nil || p.Wait == nil || *p.Wait
+}
+
+// ShouldWaitForJobs returns true if the installs and upgrades wait for the release jobs to complete
+func (p *UpgradePolicy) ShouldWaitForJobs() bool {
+	return p == nil || p.WaitForJobs == nil || *p.WaitForJobs
+}
+
+// ShouldForce returns true if the upgrades force resource updates
+func (p *UpgradePolicy) ShouldForce() bool {
+	return p == nil || p.Force == nil || *p.Force
 }
 
 // YamlValues returns values as map
@@ -52,8 +139,32 @@ type ChartStatus struct {
 	Namespace   string `json:"namespace,omitempty"`
 	Revision    int64  `json:"revision,omitempty"`
 	Error       string `json:"error,omitempty"`
+	// Conditions reflect the outcome of the last install or upgrade of the release
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
 }
 
+const (
+	// ConditionDeployed tells if the last install or upgrade of the release succeeded
+	ConditionDeployed = "Deployed"
+	// ConditionRolledBack tells if the release got rolled back after a failed upgrade
+	ConditionRolledBack = "RolledBack"
+
+	// ReasonInstallSucceeded is the Deployed reason of successful installs
+	ReasonInstallSucceeded = "InstallSucceeded"
+	// ReasonInstallFailed is the Deployed reason of failed installs
+	ReasonInstallFailed = "InstallFailed"
+	// ReasonUpgradeSucceeded is the Deployed reason of successful upgrades
+	ReasonUpgradeSucceeded = "UpgradeSucceeded"
+	// ReasonUpgradeFailed is the Deployed reason of failed upgrades
+	ReasonUpgradeFailed = "UpgradeFailed"
+	// ReasonValuesNotResolved is the Deployed reason of releases whose referenced values couldn't be read
+	ReasonValuesNotResolved = "ValuesNotResolved"
+	// ReasonRollbackSucceeded is the RolledBack reason of releases rolled back to their previous revision
+	ReasonRollbackSucceeded = "RollbackSucceeded"
+	// ReasonRollbackFailed is the RolledBack reason of releases that failed to roll back
+	ReasonRollbackFailed = "RollbackFailed"
+)
+
 // +kubebuilder:object:root=true
 // +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
 // +kubebuilder:subresource:status
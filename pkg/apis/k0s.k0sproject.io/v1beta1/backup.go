@@ -0,0 +1,182 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ Validateable = (*BackupSpec)(nil)
+
+// BackupSpec defines the settings for the scheduled backups taken by the controller leader
+type BackupSpec struct {
+	// Interval between two consecutive backups (e.g. 24h)
+	Interval metav1.Duration `json:"interval"`
+
+	// Directory path where the backup archives are stored
+	SavePath string `json:"savePath,omitempty"`
+
+	// S3-compatible object storage the backup archives are uploaded to instead of the save path
+	S3 *BackupS3Destination `json:"s3,omitempty"`
+
+	// Retention policy for the backup archives found in the save path
+	Retention *BackupRetention `json:"retention,omitempty"`
+
+	// Encryption of the backup archives
+	Encryption *BackupEncryption `json:"encryption,omitempty"`
+
+	// SigningKeyFile is the host path to a PEM encoded PKCS #8 Ed25519 private key
+	// used to sign the integrity manifest of the backup archives
+	SigningKeyFile string `json:"signingKeyFile,omitempty"`
+}
+
+// BackupEncryption defines how the backup archives are encrypted, exactly one of the fields must be set
+type BackupEncryption struct {
+	// PassphraseFile is the host path to a file holding the passphrase the archives are encrypted with
+	PassphraseFile string `json:"passphraseFile,omitempty"`
+
+	// Recipients is a list of age X25519 public keys (age1...) the archives are encrypted to
+	Recipients []string `json:"recipients,omitempty"`
+}
+
+// Validate validates the encryption settings correctness
+func (e *BackupEncryption) Validate() []error {
+	switch {
+	case e.PassphraseFile != "" && len(e.Recipients) > 0:
+		return []error{fmt.Errorf("only one of spec.backup.encryption.passphraseFile and spec.backup.encryption.recipients may be set")}
+	case e.PassphraseFile == "" && len(e.Recipients) == 0:
+		return []error{fmt.Errorf("one of spec.backup.encryption.passphraseFile or spec.backup.encryption.recipients must be set")}
+	case e.PassphraseFile != "" && !filepath.IsAbs(e.PassphraseFile):
+		return []error{fmt.Errorf("spec.backup.encryption.passphraseFile must be an absolute path, got %q", e.PassphraseFile)}
+	}
+	return nil
+}
+
+// BackupRetention defines which of the previously taken backup archives are kept
+type BackupRetention struct {
+	// Maximum number of archives to keep, 0 means unlimited
+	MaxCount int `json:"maxCount,omitempty"`
+
+	// Maximum age of the archives to keep, 0 means unlimited
+	MaxAge metav1.Duration `json:"maxAge,omitempty"`
+}
+
+// BackupS3Destination defines an S3-compatible object storage bucket to store the backup archives in
+type BackupS3Destination struct {
+	// Endpoint of the object storage service, e.g. s3.amazonaws.com or minio.example.com:9000
+	Endpoint string `json:"endpoint"`
+
+	// Name of the bucket the archives are stored in
+	Bucket string `json:"bucket"`
+
+	// Prefix prepended to the archive object names, e.g. k0s/cluster-1/
+	Prefix string `json:"prefix,omitempty"`
+
+	// Region of the bucket
+	Region string `json:"region,omitempty"`
+
+	// Use plain HTTP instead of HTTPS to talk to the endpoint
+	Insecure bool `json:"insecure,omitempty"`
+
+	// CAFile is the host path to a file with the CA certificate(s) used to verify the endpoint
+	CAFile string `json:"caFile,omitempty"`
+
+	// CredentialsFile is the host path to an AWS shared credentials file, the "default" profile is used
+	CredentialsFile string `json:"credentialsFile,omitempty"`
+
+	// CredentialsSecret is the name of a Secret in the kube-system namespace holding the
+	// accessKeyID, secretAccessKey and optionally sessionToken keys
+	CredentialsSecret string `json:"credentialsSecret,omitempty"`
+}
+
+// Validate validates the S3 destination correctness
+func (s *BackupS3Destination) Validate() []error {
+	var errors []error
+	if s.Endpoint == "" {
+		errors = append(errors, fmt.Errorf("spec.backup.s3.endpoint cannot be empty"))
+	}
+	if s.Bucket == "" {
+		errors = append(errors, fmt.Errorf("spec.backup.s3.bucket cannot be empty"))
+	}
+	if s.CredentialsFile != "" && s.CredentialsSecret != "" {
+		errors = append(errors, fmt.Errorf("only one of spec.backup.s3.credentialsFile and spec.backup.s3.credentialsSecret may be set"))
+	}
+	return errors
+}
+
+// DefaultBackupSpec creates BackupSpec with sane defaults
+func DefaultBackupSpec() *BackupSpec {
+	return &BackupSpec{
+		Interval: metav1.Duration{Duration: 24 * time.Hour},
+		Retention: &BackupRetention{
+			MaxCount: 7,
+		},
+	}
+}
+
+// UnmarshalJSON sets in some sane defaults when unmarshaling the data from json
+func (b *BackupSpec) UnmarshalJSON(data []byte) error {
+	*b = *DefaultBackupSpec()
+
+	type backup BackupSpec
+	jc := (*backup)(b)
+
+	return json.Unmarshal(data, jc)
+}
+
+// Validate validates the backup specs correctness
+func (b *BackupSpec) Validate() []error {
+	if b == nil {
+		return nil
+	}
+
+	var errors []error
+	if b.Interval.Duration < time.Minute {
+		errors = append(errors, fmt.Errorf("spec.backup.interval must be at least 1m, got %s", b.Interval.Duration))
+	}
+	switch {
+	case b.S3 != nil:
+		if b.SavePath != "" {
+			errors = append(errors, fmt.Errorf("only one of spec.backup.savePath and spec.backup.s3 may be set"))
+		}
+		errors = append(errors, b.S3.Validate()...)
+	case b.SavePath == "":
+		errors = append(errors, fmt.Errorf("one of spec.backup.savePath or spec.backup.s3 must be set"))
+	case !filepath.IsAbs(b.SavePath):
+		errors = append(errors, fmt.Errorf("spec.backup.savePath must be an absolute path, got %q", b.SavePath))
+	}
+	if b.Encryption != nil {
+		errors = append(errors, b.Encryption.Validate()...)
+	}
+	if b.SigningKeyFile != "" && !filepath.IsAbs(b.SigningKeyFile) {
+		errors = append(errors, fmt.Errorf("spec.backup.signingKeyFile must be an absolute path, got %q", b.SigningKeyFile))
+	}
+	if b.Retention != nil {
+		if b.Retention.MaxCount < 0 {
+			errors = append(errors, fmt.Errorf("spec.backup.retention.maxCount cannot be negative"))
+		}
+		if b.Retention.MaxAge.Duration < 0 {
+			errors = append(errors, fmt.Errorf("spec.backup.retention.maxAge cannot be negative"))
+		}
+	}
+
+	return errors
+}
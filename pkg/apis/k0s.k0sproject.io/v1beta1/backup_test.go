@@ -0,0 +1,90 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBackupSpec_Defaults(t *testing.T) {
+	yaml := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: ClusterConfig
+spec:
+  backup:
+    savePath: /var/lib/k0s-backups
+`
+	c, err := ConfigFromString(yaml)
+	require.NoError(t, err)
+	require.NotNil(t, c.Spec.Backup)
+	assert.Equal(t, 24*time.Hour, c.Spec.Backup.Interval.Duration)
+	assert.Equal(t, "/var/lib/k0s-backups", c.Spec.Backup.SavePath)
+	assert.Equal(t, 7, c.Spec.Backup.Retention.MaxCount)
+	assert.Empty(t, c.Validate())
+}
+
+func TestBackupSpec_DisabledByDefault(t *testing.T) {
+	c := DefaultClusterConfig()
+	assert.Nil(t, c.Spec.Backup)
+	assert.Nil(t, c.Spec.Backup.Validate())
+}
+
+func TestBackupSpec_Validate(t *testing.T) {
+	spec := &BackupSpec{SavePath: "relative/path", Retention: &BackupRetention{MaxCount: -1}}
+	errs := spec.Validate()
+	require.Len(t, errs, 3)
+	assert.Contains(t, errs[0].Error(), "spec.backup.interval")
+	assert.Contains(t, errs[1].Error(), "spec.backup.savePath must be an absolute path")
+	assert.Contains(t, errs[2].Error(), "spec.backup.retention.maxCount")
+}
+
+func TestBackupSpec_ValidateS3(t *testing.T) {
+	spec := DefaultBackupSpec()
+	spec.S3 = &BackupS3Destination{Endpoint: "minio:9000", Bucket: "backups"}
+	assert.Empty(t, spec.Validate())
+
+	spec.SavePath = "/var/lib/k0s-backups"
+	spec.S3.CredentialsFile = "/root/.aws/credentials"
+	spec.S3.CredentialsSecret = "s3-credentials"
+	errs := spec.Validate()
+	require.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Error(), "only one of spec.backup.savePath and spec.backup.s3")
+	assert.Contains(t, errs[1].Error(), "only one of spec.backup.s3.credentialsFile and spec.backup.s3.credentialsSecret")
+}
+
+func TestBackupSpec_ValidateEncryption(t *testing.T) {
+	spec := DefaultBackupSpec()
+	spec.SavePath = "/var/lib/k0s-backups"
+	spec.Encryption = &BackupEncryption{PassphraseFile: "/etc/k0s/backup-passphrase"}
+	spec.SigningKeyFile = "/etc/k0s/backup-signing.key"
+	assert.Empty(t, spec.Validate())
+
+	spec.Encryption.Recipients = []string{"age1ql3z7hjy54pw3hyww5ayyfg7zqgvc7w3j2elw8zmrj2kg5sfn9aqmcac8p"}
+	spec.SigningKeyFile = "backup-signing.key"
+	errs := spec.Validate()
+	require.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Error(), "only one of spec.backup.encryption.passphraseFile and spec.backup.encryption.recipients")
+	assert.Contains(t, errs[1].Error(), "spec.backup.signingKeyFile must be an absolute path")
+
+	spec.Encryption = &BackupEncryption{}
+	errs = spec.Validate()
+	require.Len(t, errs, 2)
+	assert.Contains(t, errs[0].Error(), "one of spec.backup.encryption.passphraseFile or spec.backup.encryption.recipients must be set")
+}
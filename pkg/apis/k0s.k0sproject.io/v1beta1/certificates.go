@@ -0,0 +1,231 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	RSAKeyAlgorithm     = "rsa"
+	ECDSAKeyAlgorithm   = "ecdsa"
+	Ed25519KeyAlgorithm = "ed25519"
+
+	// DefaultCAValidity is the validity of the CAs if not configured
+	DefaultCAValidity = 10 * 365 * 24 * time.Hour
+	// DefaultCertificateValidity is the validity of the leaf certificates if not configured
+	DefaultCertificateValidity = 365 * 24 * time.Hour
+)
+
+var _ Validateable = (*CertificatesSpec)(nil)
+
+// CertificateNames lists the leaf certificates generated by a controller, as used in CertificatesSpec.ValidityOverrides
+var CertificateNames = []string{
+	"admin",
+	"apiserver-etcd-client",
+	"apiserver-kubelet-client",
+	"ccm",
+	"etcd/peer",
+	"etcd/server",
+	"front-proxy-client",
+	"k0s-api",
+	"konnectivity",
+	"scheduler",
+	"server",
+}
+
+// CertificatesSpec defines the keys and the validity of the certificates generated by the controller
+type CertificatesSpec struct {
+	// KeyAlgorithm of the generated keys: rsa, ecdsa or ed25519 (default: rsa)
+	KeyAlgorithm string `json:"keyAlgorithm,omitempty"`
+
+	// KeySize is the RSA key size in bits, 2048, 3072 or 4096 (default: 2048),
+	// or the ECDSA curve size, 256 for P-256 or 384 for P-384 (default: 256). Ed25519 keys have no size.
+	KeySize int `json:"keySize,omitempty"`
+
+	// CAValidity is the validity of the CAs created by k0s (default: 87600h)
+	CAValidity metav1.Duration `json:"caValidity,omitempty"`
+
+	// Validity is the validity of the leaf certificates (default: 8760h)
+	Validity metav1.Duration `json:"validity,omitempty"`
+
+	// ValidityOverrides sets the validity of individual leaf certificates, keyed by certificate name, e.g. server or etcd/peer
+	ValidityOverrides map[string]metav1.Duration `json:"validityOverrides,omitempty"`
+
+	// CA is an externally provided Kubernetes CA, used instead of a self-signed one generated by k0s
+	CA *CASpec `json:"ca,omitempty"`
+
+	// FrontProxyCA is an externally provided front proxy CA, used instead of a self-signed one generated by k0s
+	FrontProxyCA *CASpec `json:"frontProxyCA,omitempty"`
+
+	// EtcdCA is an externally provided etcd CA, used instead of a self-signed one generated by k0s
+	EtcdCA *CASpec `json:"etcdCA,omitempty"`
+}
+
+// CASpec points to the files of an externally provided, usually intermediate, CA
+type CASpec struct {
+	// CertFile is the path of the PEM encoded CA certificate, optionally followed by the certificates of its chain up to the root
+	CertFile string `json:"certFile"`
+
+	// KeyFile is the path of the PEM encoded CA key
+	KeyFile string `json:"keyFile"`
+}
+
+// Key returns the algorithm and the size of the keys to generate, the defaults apply to a nil spec
+func (c *CertificatesSpec) Key() (string, int) {
+	algorithm, size := RSAKeyAlgorithm, 0
+	if c != nil {
+		if c.KeyAlgorithm != "" {
+			algorithm = c.KeyAlgorithm
+		}
+		size = c.KeySize
+	}
+	if size == 0 {
+		switch algorithm {
+		case RSAKeyAlgorithm:
+			size = 2048
+		case ECDSAKeyAlgorithm:
+			size = 256
+		}
+	}
+	return algorithm, size
+}
+
+// CAExpiry returns the validity of the CAs
+func (c *CertificatesSpec) CAExpiry() time.Duration {
+	if c == nil || c.CAValidity.Duration == 0 {
+		return DefaultCAValidity
+	}
+	return c.CAValidity.Duration
+}
+
+// Expiry returns the validity of the leaf certificate with the given name
+func (c *CertificatesSpec) Expiry(name string) time.Duration {
+	if c == nil {
+		return DefaultCertificateValidity
+	}
+	if validity, ok := c.ValidityOverrides[name]; ok && validity.Duration > 0 {
+		return validity.Duration
+	}
+	if c.Validity.Duration > 0 {
+		return c.Validity.Duration
+	}
+	return DefaultCertificateValidity
+}
+
+// ExternalCA returns the externally provided CA for the given k0s CA name, ca, front-proxy-ca or etcd/ca,
+// nil if k0s generates it
+func (c *CertificatesSpec) ExternalCA(name string) *CASpec {
+	if c == nil {
+		return nil
+	}
+	switch name {
+	case "ca":
+		return c.CA
+	case "front-proxy-ca":
+		return c.FrontProxyCA
+	case "etcd/ca":
+		return c.EtcdCA
+	}
+	return nil
+}
+
+// Validate validates the key, validity and CA settings
+func (c *CertificatesSpec) Validate() []error {
+	if c == nil {
+		return nil
+	}
+	var errors []error
+
+	algorithm, size := c.Key()
+	switch algorithm {
+	case RSAKeyAlgorithm:
+		if size != 2048 && size != 3072 && size != 4096 {
+			errors = append(errors, fmt.Errorf("certificates.keySize: must be 2048, 3072 or 4096 for rsa keys, got %d", size))
+		}
+	case ECDSAKeyAlgorithm:
+		if size != 256 && size != 384 {
+			errors = append(errors, fmt.Errorf("certificates.keySize: must be 256 or 384 for ecdsa keys, got %d", size))
+		}
+	case Ed25519KeyAlgorithm:
+		if size != 0 {
+			errors = append(errors, fmt.Errorf("certificates.keySize: must not be set for ed25519 keys, got %d", size))
+		}
+	default:
+		errors = append(errors, fmt.Errorf("certificates.keyAlgorithm: unsupported algorithm `%s`, must be one of rsa, ecdsa or ed25519", algorithm))
+	}
+
+	if c.CAValidity.Duration < 0 {
+		errors = append(errors, fmt.Errorf("certificates.caValidity: must not be negative, got %s", c.CAValidity.Duration))
+	}
+	if c.Validity.Duration < 0 {
+		errors = append(errors, fmt.Errorf("certificates.validity: must not be negative, got %s", c.Validity.Duration))
+	}
+	if c.Expiry("") > c.CAExpiry() {
+		errors = append(errors, fmt.Errorf("certificates.validity: must not exceed the CA validity of %s, got %s", c.CAExpiry(), c.Expiry("")))
+	}
+
+	names := make([]string, 0, len(c.ValidityOverrides))
+	for name := range c.ValidityOverrides {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		validity := c.ValidityOverrides[name].Duration
+		switch {
+		case !isCertificateName(name):
+			errors = append(errors, fmt.Errorf("certificates.validityOverrides: unknown certificate `%s`, must be one of %s", name, strings.Join(CertificateNames, ", ")))
+		case validity <= 0:
+			errors = append(errors, fmt.Errorf("certificates.validityOverrides.%s: must be positive, got %s", name, validity))
+		case validity > c.CAExpiry():
+			errors = append(errors, fmt.Errorf("certificates.validityOverrides.%s: must not exceed the CA validity of %s, got %s", name, c.CAExpiry(), validity))
+		}
+	}
+
+	errors = append(errors, c.CA.validate("certificates.ca")...)
+	errors = append(errors, c.FrontProxyCA.validate("certificates.frontProxyCA")...)
+	errors = append(errors, c.EtcdCA.validate("certificates.etcdCA")...)
+
+	return errors
+}
+
+func (c *CASpec) validate(path string) []error {
+	if c == nil {
+		return nil
+	}
+	var errors []error
+	if c.CertFile == "" {
+		errors = append(errors, fmt.Errorf("%s.certFile: must be set", path))
+	}
+	if c.KeyFile == "" {
+		errors = append(errors, fmt.Errorf("%s.keyFile: must be set", path))
+	}
+	return errors
+}
+
+func isCertificateName(name string) bool {
+	for _, n := range CertificateNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
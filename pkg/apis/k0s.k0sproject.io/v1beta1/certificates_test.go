@@ -0,0 +1,121 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestCertificates_Unmarshal(t *testing.T) {
+	yaml := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: ClusterConfig
+spec:
+  certificates:
+    keyAlgorithm: ecdsa
+    keySize: 384
+    validity: 2160h
+    validityOverrides:
+      etcd/peer: 720h
+`
+	c, err := ConfigFromString(yaml)
+	require.NoError(t, err)
+	assert.Empty(t, c.Validate())
+
+	algorithm, size := c.Spec.Certificates.Key()
+	assert.Equal(t, ECDSAKeyAlgorithm, algorithm)
+	assert.Equal(t, 384, size)
+	assert.Equal(t, DefaultCAValidity, c.Spec.Certificates.CAExpiry())
+	assert.Equal(t, 90*24*time.Hour, c.Spec.Certificates.Expiry("server"))
+	assert.Equal(t, 30*24*time.Hour, c.Spec.Certificates.Expiry("etcd/peer"))
+
+	bootstrapping := c.GetBootstrappingConfig(c.Spec.Storage)
+	assert.Equal(t, c.Spec.Certificates, bootstrapping.Spec.Certificates)
+}
+
+func TestCertificates_Defaults(t *testing.T) {
+	var spec *CertificatesSpec
+	algorithm, size := spec.Key()
+	assert.Equal(t, RSAKeyAlgorithm, algorithm)
+	assert.Equal(t, 2048, size)
+	assert.Equal(t, DefaultCAValidity, spec.CAExpiry())
+	assert.Equal(t, DefaultCertificateValidity, spec.Expiry("server"))
+	assert.Empty(t, spec.Validate())
+
+	spec = &CertificatesSpec{KeyAlgorithm: ECDSAKeyAlgorithm}
+	_, size = spec.Key()
+	assert.Equal(t, 256, size)
+
+	spec = &CertificatesSpec{KeyAlgorithm: Ed25519KeyAlgorithm}
+	_, size = spec.Key()
+	assert.Equal(t, 0, size)
+	assert.Empty(t, spec.Validate())
+}
+
+func TestCertificates_Validate(t *testing.T) {
+	spec := &CertificatesSpec{
+		KeyAlgorithm: ECDSAKeyAlgorithm,
+		KeySize:      521,
+		CAValidity:   metav1.Duration{Duration: 24 * time.Hour},
+		Validity:     metav1.Duration{Duration: 48 * time.Hour},
+		ValidityOverrides: map[string]metav1.Duration{
+			"kubelet": {Duration: time.Hour},
+			"server":  {Duration: -time.Hour},
+		},
+	}
+	errs := spec.Validate()
+	require.Len(t, errs, 4)
+	assert.Contains(t, errs[0].Error(), "certificates.keySize: must be 256 or 384")
+	assert.Contains(t, errs[1].Error(), "certificates.validity: must not exceed the CA validity")
+	assert.Contains(t, errs[2].Error(), "unknown certificate `kubelet`")
+	assert.Contains(t, errs[3].Error(), "certificates.validityOverrides.server: must be positive")
+
+	spec = &CertificatesSpec{KeyAlgorithm: "dsa"}
+	errs = spec.Validate()
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "unsupported algorithm `dsa`")
+}
+
+func TestCertificates_ExternalCA(t *testing.T) {
+	yaml := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: ClusterConfig
+spec:
+  certificates:
+    ca:
+      certFile: /etc/pki/k0s/ca-bundle.crt
+      keyFile: /etc/pki/k0s/ca.key
+    etcdCA:
+      certFile: /etc/pki/k0s/etcd-ca.crt
+`
+	c, err := ConfigFromString(yaml)
+	require.NoError(t, err)
+	errs := c.Validate()
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "certificates.etcdCA.keyFile: must be set")
+
+	assert.Equal(t, &CASpec{CertFile: "/etc/pki/k0s/ca-bundle.crt", KeyFile: "/etc/pki/k0s/ca.key"}, c.Spec.Certificates.ExternalCA("ca"))
+	assert.Nil(t, c.Spec.Certificates.ExternalCA("front-proxy-ca"))
+	assert.Equal(t, "/etc/pki/k0s/etcd-ca.crt", c.Spec.Certificates.ExternalCA("etcd/ca").CertFile)
+
+	var spec *CertificatesSpec
+	assert.Nil(t, spec.ExternalCA("ca"))
+}
@@ -48,6 +48,15 @@ type ClusterSpec struct {
 	Images            *ClusterImages         `json:"images"`
 	Extensions        *ClusterExtensions     `json:"extensions,omitempty"`
 	Konnectivity      *KonnectivitySpec      `json:"konnectivity,omitempty"`
+	Backup            *BackupSpec            `json:"backup,omitempty"`
+	// ComponentResources holds the cgroup limits of the processes run by the controller, keyed by process name
+	ComponentResources ComponentResourcesSpec `json:"componentResources,omitempty"`
+	// Certificates defines the keys and the validity of the certificates generated by the controller
+	Certificates *CertificatesSpec `json:"certificates,omitempty"`
+	// Manifests defines how the manifest stacks of the manifests directory are applied
+	Manifests *ManifestsSpec `json:"manifests,omitempty"`
+	// CSRApprover defines which certificate signing requests the controller approves automatically
+	CSRApprover *CSRApproverSpec `json:"csrApprover,omitempty"`
 }
 
 // ClusterConfigStatus defines the observed state of ClusterConfig
@@ -279,20 +288,18 @@ func (c *ClusterConfig) Validate() []error {
 	errors = append(errors, validateSpecs(c.Spec.Install)...)
 	errors = append(errors, validateSpecs(c.Spec.Extensions)...)
 	errors = append(errors, validateSpecs(c.Spec.Konnectivity)...)
+	errors = append(errors, validateSpecs(c.Spec.Backup)...)
+	errors = append(errors, validateSpecs(c.Spec.ComponentResources)...)
+	errors = append(errors, validateSpecs(c.Spec.Certificates)...)
+	errors = append(errors, validateSpecs(c.Spec.Manifests)...)
+	errors = append(errors, validateSpecs(c.Spec.CSRApprover)...)
 
 	return errors
 }
 
 // GetBootstrappingConfig returns a ClusterConfig object stripped of Cluster-Wide Settings
+// All the etcd settings are node specific, e.g. the maintenance and the tuning, so the storage is kept as a whole.
 func (c *ClusterConfig) GetBootstrappingConfig(storageSpec *StorageSpec) *ClusterConfig {
-	var etcdConfig *EtcdConfig
-	if storageSpec.Type == EtcdStorageType {
-		etcdConfig = &EtcdConfig{
-			ExternalCluster: storageSpec.Etcd.ExternalCluster,
-			PeerAddress:     storageSpec.Etcd.PeerAddress,
-		}
-		c.Spec.Storage.Etcd = etcdConfig
-	}
 	return &ClusterConfig{
 		ObjectMeta: c.ObjectMeta,
 		TypeMeta:   c.TypeMeta,
@@ -303,7 +310,12 @@ func (c *ClusterConfig) GetBootstrappingConfig(storageSpec *StorageSpec) *Cluste
 				ServiceCIDR: c.Spec.Network.ServiceCIDR,
 				DualStack:   c.Spec.Network.DualStack,
 			},
-			Install: c.Spec.Install,
+			Install:            c.Spec.Install,
+			Backup:             c.Spec.Backup,
+			ComponentResources: c.Spec.ComponentResources,
+			Certificates:       c.Spec.Certificates,
+			Manifests:          c.Spec.Manifests,
+			CSRApprover:        c.Spec.CSRApprover,
 		},
 		Status: c.Status,
 	}
@@ -316,6 +328,11 @@ func (c *ClusterConfig) GetBootstrappingConfig(storageSpec *StorageSpec) *Cluste
 // - StorageSpec
 // - Network.ServiceCIDR
 // - Install
+// - Backup
+// - ComponentResources
+// - Certificates
+// - Manifests
+// - CSRApprover
 func (c *ClusterConfig) GetClusterWideConfig() *ClusterConfig {
 	return &ClusterConfig{
 		ObjectMeta: c.ObjectMeta,
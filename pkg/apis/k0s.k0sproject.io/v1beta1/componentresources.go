@@ -0,0 +1,108 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+)
+
+var _ Validateable = (ComponentResourcesSpec)(nil)
+
+// ControllerProcessNames lists the processes run by a controller which can be given resource limits
+var ControllerProcessNames = []string{
+	"etcd",
+	"k0s-control-api",
+	"kine",
+	"konnectivity",
+	"kube-apiserver",
+	"kube-controller-manager",
+	"kube-scheduler",
+}
+
+// ComponentResourcesSpec maps the controller process names to their resource limits
+type ComponentResourcesSpec map[string]ComponentResources
+
+// Validate validates the resource limits of all processes
+func (s ComponentResourcesSpec) Validate() []error {
+	var errors []error
+	names := make([]string, 0, len(s))
+	for name := range s {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !isControllerProcessName(name) {
+			errors = append(errors, fmt.Errorf("componentResources: unknown process `%s`, must be one of %s", name, strings.Join(ControllerProcessNames, ", ")))
+			continue
+		}
+		r := s[name]
+		for _, err := range r.Validate() {
+			errors = append(errors, fmt.Errorf("componentResources.%s: %v", name, err))
+		}
+	}
+	return errors
+}
+
+func isControllerProcessName(name string) bool {
+	for _, n := range ControllerProcessNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+// ComponentResources defines the cgroup v2 limits and the OOM score adjustment of a supervised process
+type ComponentResources struct {
+	// MemoryMax is the hard memory limit written to memory.max (e.g. 2Gi)
+	MemoryMax *resource.Quantity `json:"memoryMax,omitempty"`
+
+	// CPUWeight is the relative CPU share written to cpu.weight, between 1 and 10000 (kernel default: 100)
+	CPUWeight int64 `json:"cpuWeight,omitempty"`
+
+	// PidsMax is the maximum number of tasks written to pids.max
+	PidsMax int64 `json:"pidsMax,omitempty"`
+
+	// OOMScoreAdj is written to the process' oom_score_adj, between -1000 and 1000
+	OOMScoreAdj *int `json:"oomScoreAdj,omitempty"`
+}
+
+// Validate validates the resource limits
+func (r *ComponentResources) Validate() []error {
+	var errors []error
+	if r.MemoryMax != nil && r.MemoryMax.Sign() <= 0 {
+		errors = append(errors, fmt.Errorf("memoryMax must be positive, got %s", r.MemoryMax.String()))
+	}
+	if r.CPUWeight != 0 && (r.CPUWeight < 1 || r.CPUWeight > 10000) {
+		errors = append(errors, fmt.Errorf("cpuWeight must be between 1 and 10000, got %d", r.CPUWeight))
+	}
+	if r.PidsMax < 0 {
+		errors = append(errors, fmt.Errorf("pidsMax must not be negative, got %d", r.PidsMax))
+	}
+	if r.OOMScoreAdj != nil && (*r.OOMScoreAdj < -1000 || *r.OOMScoreAdj > 1000) {
+		errors = append(errors, fmt.Errorf("oomScoreAdj must be between -1000 and 1000, got %d", *r.OOMScoreAdj))
+	}
+	return errors
+}
+
+// IsEmpty returns true if no limit is set
+func (r *ComponentResources) IsEmpty() bool {
+	return r.MemoryMax == nil && r.CPUWeight == 0 && r.PidsMax == 0 && r.OOMScoreAdj == nil
+}
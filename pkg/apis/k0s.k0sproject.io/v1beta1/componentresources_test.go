@@ -0,0 +1,66 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestComponentResources_Unmarshal(t *testing.T) {
+	yaml := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: ClusterConfig
+spec:
+  componentResources:
+    etcd:
+      memoryMax: 2Gi
+      cpuWeight: 500
+      oomScoreAdj: -900
+    kube-apiserver:
+      pidsMax: 4096
+`
+	c, err := ConfigFromString(yaml)
+	require.NoError(t, err)
+	assert.Empty(t, c.Validate())
+
+	etcd := c.Spec.ComponentResources["etcd"]
+	require.NotNil(t, etcd.MemoryMax)
+	assert.Equal(t, int64(2<<30), etcd.MemoryMax.Value())
+	assert.Equal(t, int64(500), etcd.CPUWeight)
+	require.NotNil(t, etcd.OOMScoreAdj)
+	assert.Equal(t, -900, *etcd.OOMScoreAdj)
+	assert.Equal(t, int64(4096), c.Spec.ComponentResources["kube-apiserver"].PidsMax)
+
+	bootstrapping := c.GetBootstrappingConfig(c.Spec.Storage)
+	assert.Equal(t, c.Spec.ComponentResources, bootstrapping.Spec.ComponentResources)
+}
+
+func TestComponentResources_Validate(t *testing.T) {
+	oomScoreAdj := 2000
+	spec := ComponentResourcesSpec{
+		"kubelet": {},
+		"etcd":    {CPUWeight: 20000, PidsMax: -1, OOMScoreAdj: &oomScoreAdj},
+	}
+	errs := spec.Validate()
+	require.Len(t, errs, 4)
+	assert.Contains(t, errs[0].Error(), "componentResources.etcd: cpuWeight")
+	assert.Contains(t, errs[1].Error(), "componentResources.etcd: pidsMax")
+	assert.Contains(t, errs[2].Error(), "componentResources.etcd: oomScoreAdj")
+	assert.Contains(t, errs[3].Error(), "unknown process `kubelet`")
+}
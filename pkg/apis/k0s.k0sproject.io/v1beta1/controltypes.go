@@ -49,3 +49,21 @@ type EtcdResponse struct {
 	CA             CaResponse `json:"ca"`
 	InitialCluster []string   `json:"initialCluster"`
 }
+
+// WorkerJoinRequest defines the request exchanging a scoped worker token at the control api
+type WorkerJoinRequest struct {
+	Node string `json:"node"`
+	// CSR is the PEM encoded request of the kubelet client certificate, for the identity of the node
+	CSR []byte `json:"csr"`
+}
+
+// WorkerJoinResponse defines the worker join control api response structure
+type WorkerJoinResponse struct {
+	// Certificate is the PEM encoded kubelet client certificate issued for the node
+	Certificate []byte `json:"certificate,omitempty"`
+	// APIServer is the URL of the kube API the kubelet connects to
+	APIServer string   `json:"apiServer,omitempty"`
+	Profile   string   `json:"profile,omitempty"`
+	Labels    []string `json:"labels,omitempty"`
+	Taints    []string `json:"taints,omitempty"`
+}
@@ -0,0 +1,100 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"fmt"
+	"strings"
+)
+
+// KubeletServingSignerName is the signer of the kubelet serving certificates, always handled by the CSR approver
+const KubeletServingSignerName = "kubernetes.io/kubelet-serving"
+
+var _ Validateable = (*CSRApproverSpec)(nil)
+
+// certificateUsages lists the key usages a certificate signing request can ask for
+var certificateUsages = []string{
+	"signing", "digital signature", "content commitment", "key encipherment", "key agreement",
+	"data encipherment", "cert sign", "crl sign", "encipher only", "decipher only", "any",
+	"server auth", "client auth", "code signing", "email protection", "s/mime",
+	"ipsec end system", "ipsec tunnel", "ipsec user", "timestamping", "ocsp signing",
+	"microsoft sgc", "netscape sgc",
+}
+
+// CSRApproverSpec defines which certificate signing requests the controller approves automatically
+type CSRApproverSpec struct {
+	// VerifyNodeAddresses denies the kubelet serving certificate requests whose DNS names and IP addresses
+	// aren't addresses of the requesting node
+	VerifyNodeAddresses bool `json:"verifyNodeAddresses,omitempty"`
+
+	// Rules approve the certificate signing requests of other signers
+	Rules []CSRApprovalRule `json:"rules,omitempty"`
+}
+
+// CSRApprovalRule approves the certificate signing requests for a signer
+type CSRApprovalRule struct {
+	// SignerName of the approved requests
+	SignerName string `json:"signerName"`
+
+	// Usages the approved requests may ask for, a request asking for any other usage isn't approved
+	Usages []string `json:"usages"`
+
+	// Groups restricts the approval to requests made by a member of one of the groups
+	Groups []string `json:"groups,omitempty"`
+}
+
+// Validate validates the CSR approver settings
+func (c *CSRApproverSpec) Validate() []error {
+	if c == nil {
+		return nil
+	}
+
+	var errors []error
+	signers := make(map[string]bool, len(c.Rules))
+	for i, rule := range c.Rules {
+		field := fmt.Sprintf("spec.csrApprover.rules[%d]", i)
+		switch {
+		case rule.SignerName == "":
+			errors = append(errors, fmt.Errorf("%s.signerName is required", field))
+		case rule.SignerName == KubeletServingSignerName:
+			errors = append(errors, fmt.Errorf("%s.signerName can't be %s, its requests are always handled", field, KubeletServingSignerName))
+		case signers[rule.SignerName]:
+			errors = append(errors, fmt.Errorf("%s.signerName %s is used by several rules", field, rule.SignerName))
+		case strings.Count(rule.SignerName, "/") != 1:
+			errors = append(errors, fmt.Errorf("%s.signerName must be in the form domain/path, got %q", field, rule.SignerName))
+		}
+		signers[rule.SignerName] = true
+
+		if len(rule.Usages) == 0 {
+			errors = append(errors, fmt.Errorf("%s.usages is required", field))
+		}
+		for _, usage := range rule.Usages {
+			if !isCertificateUsage(usage) {
+				errors = append(errors, fmt.Errorf("%s.usages contains the unknown usage %q", field, usage))
+			}
+		}
+	}
+	return errors
+}
+
+func isCertificateUsage(usage string) bool {
+	for _, u := range certificateUsages {
+		if u == usage {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCSRApprover_Unmarshal(t *testing.T) {
+	yaml := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: ClusterConfig
+spec:
+  csrApprover:
+    verifyNodeAddresses: true
+    rules:
+      - signerName: example.com/client
+        usages: [digital signature, client auth]
+        groups: [system:serviceaccounts:monitoring]
+`
+	c, err := ConfigFromString(yaml)
+	require.NoError(t, err)
+	assert.Empty(t, c.Validate())
+	assert.True(t, c.Spec.CSRApprover.VerifyNodeAddresses)
+	assert.Equal(t, []CSRApprovalRule{{
+		SignerName: "example.com/client",
+		Usages:     []string{"digital signature", "client auth"},
+		Groups:     []string{"system:serviceaccounts:monitoring"},
+	}}, c.Spec.CSRApprover.Rules)
+
+	bootstrapping := c.GetBootstrappingConfig(c.Spec.Storage)
+	assert.Equal(t, c.Spec.CSRApprover, bootstrapping.Spec.CSRApprover)
+	assert.Nil(t, c.GetClusterWideConfig().Spec.CSRApprover)
+}
+
+func TestCSRApprover_Validate(t *testing.T) {
+	var spec *CSRApproverSpec
+	assert.Empty(t, spec.Validate())
+
+	spec = &CSRApproverSpec{Rules: []CSRApprovalRule{
+		{SignerName: "example.com/client", Usages: []string{"client auth"}},
+		{SignerName: "example.com/client", Usages: []string{"client auth"}},
+		{SignerName: KubeletServingSignerName, Usages: []string{"server auth"}},
+		{SignerName: "example.com", Usages: []string{"server auth", "everything"}},
+		{Usages: []string{}},
+	}}
+	errors := spec.Validate()
+	require.Len(t, errors, 6)
+	assert.EqualError(t, errors[0], "spec.csrApprover.rules[1].signerName example.com/client is used by several rules")
+	assert.EqualError(t, errors[1], "spec.csrApprover.rules[2].signerName can't be kubernetes.io/kubelet-serving, its requests are always handled")
+	assert.EqualError(t, errors[2], `spec.csrApprover.rules[3].signerName must be in the form domain/path, got "example.com"`)
+	assert.EqualError(t, errors[3], `spec.csrApprover.rules[3].usages contains the unknown usage "everything"`)
+	assert.EqualError(t, errors[4], "spec.csrApprover.rules[4].signerName is required")
+	assert.EqualError(t, errors[5], "spec.csrApprover.rules[4].usages is required")
+}
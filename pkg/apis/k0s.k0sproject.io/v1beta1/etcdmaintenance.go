@@ -0,0 +1,90 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ Validateable = (*EtcdMaintenance)(nil)
+
+// EtcdMaintenance defines the maintenance of the etcd cluster managed by k0s
+type EtcdMaintenance struct {
+	// SnapshotInterval between two local snapshots taken by the leading controller, 0 disables the snapshots
+	SnapshotInterval metav1.Duration `json:"snapshotInterval,omitempty"`
+
+	// SnapshotDir is the host path of the directory the snapshots are stored in, <data-dir>/etcd-snapshots by default
+	SnapshotDir string `json:"snapshotDir,omitempty"`
+
+	// SnapshotRetention is the number of snapshots kept in the snapshot directory, 0 means unlimited
+	SnapshotRetention int `json:"snapshotRetention,omitempty"`
+
+	// CheckInterval between two checks of the database size, fragmentation and alarms of the members
+	CheckInterval metav1.Duration `json:"checkInterval,omitempty"`
+
+	// DefragThreshold is the percentage of the database file not in use above which a member gets defragmented, 0 disables the defragmentation
+	DefragThreshold int `json:"defragThreshold,omitempty"`
+}
+
+// DefaultEtcdMaintenance creates EtcdMaintenance with sane defaults
+func DefaultEtcdMaintenance() *EtcdMaintenance {
+	return &EtcdMaintenance{
+		SnapshotInterval:  metav1.Duration{Duration: 6 * time.Hour},
+		SnapshotRetention: 5,
+		CheckInterval:     metav1.Duration{Duration: 10 * time.Minute},
+		DefragThreshold:   50,
+	}
+}
+
+// UnmarshalJSON sets in some sane defaults when unmarshaling the data from json
+func (m *EtcdMaintenance) UnmarshalJSON(data []byte) error {
+	*m = *DefaultEtcdMaintenance()
+
+	type maintenance EtcdMaintenance
+	jc := (*maintenance)(m)
+
+	return json.Unmarshal(data, jc)
+}
+
+// Validate validates the etcd maintenance settings
+func (m *EtcdMaintenance) Validate() []error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+	if m.SnapshotInterval.Duration != 0 && m.SnapshotInterval.Duration < time.Minute {
+		errors = append(errors, fmt.Errorf("spec.storage.etcd.maintenance.snapshotInterval must be at least 1m, got %s", m.SnapshotInterval.Duration))
+	}
+	if m.SnapshotDir != "" && !filepath.IsAbs(m.SnapshotDir) {
+		errors = append(errors, fmt.Errorf("spec.storage.etcd.maintenance.snapshotDir must be an absolute path, got %q", m.SnapshotDir))
+	}
+	if m.SnapshotRetention < 0 {
+		errors = append(errors, fmt.Errorf("spec.storage.etcd.maintenance.snapshotRetention must not be negative, got %d", m.SnapshotRetention))
+	}
+	if m.CheckInterval.Duration < time.Minute {
+		errors = append(errors, fmt.Errorf("spec.storage.etcd.maintenance.checkInterval must be at least 1m, got %s", m.CheckInterval.Duration))
+	}
+	if m.DefragThreshold < 0 || m.DefragThreshold > 100 {
+		errors = append(errors, fmt.Errorf("spec.storage.etcd.maintenance.defragThreshold must be a percentage between 0 and 100, got %d", m.DefragThreshold))
+	}
+	return errors
+}
@@ -0,0 +1,75 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestEtcdMaintenance_Defaults(t *testing.T) {
+	yaml := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: ClusterConfig
+spec:
+  storage:
+    etcd:
+      maintenance:
+        snapshotInterval: 1h
+`
+	c, err := ConfigFromString(yaml)
+	require.NoError(t, err)
+	m := c.Spec.Storage.Etcd.Maintenance
+	require.NotNil(t, m)
+	assert.Equal(t, time.Hour, m.SnapshotInterval.Duration)
+	assert.Equal(t, 5, m.SnapshotRetention)
+	assert.Equal(t, 10*time.Minute, m.CheckInterval.Duration)
+	assert.Equal(t, 50, m.DefragThreshold)
+	assert.Empty(t, c.Validate())
+
+	assert.Nil(t, DefaultClusterConfig().Spec.Storage.Etcd.Maintenance)
+}
+
+func TestEtcdMaintenance_Validate(t *testing.T) {
+	m := &EtcdMaintenance{
+		SnapshotInterval:  metav1.Duration{Duration: time.Second},
+		SnapshotDir:       "relative/path",
+		SnapshotRetention: -1,
+		DefragThreshold:   101,
+	}
+	errs := m.Validate()
+	require.Len(t, errs, 5)
+	assert.Contains(t, errs[0].Error(), "spec.storage.etcd.maintenance.snapshotInterval")
+	assert.Contains(t, errs[1].Error(), "spec.storage.etcd.maintenance.snapshotDir must be an absolute path")
+	assert.Contains(t, errs[2].Error(), "spec.storage.etcd.maintenance.snapshotRetention")
+	assert.Contains(t, errs[3].Error(), "spec.storage.etcd.maintenance.checkInterval")
+	assert.Contains(t, errs[4].Error(), "spec.storage.etcd.maintenance.defragThreshold")
+
+	storage := &StorageSpec{
+		Type: EtcdStorageType,
+		Etcd: &EtcdConfig{
+			ExternalCluster: &ExternalCluster{Endpoints: []string{"https://etcd:2379"}, EtcdPrefix: "k0s"},
+			Maintenance:     DefaultEtcdMaintenance(),
+		},
+	}
+	errs = storage.Validate()
+	require.Len(t, errs, 1)
+	assert.Contains(t, errs[0].Error(), "not supported with an external etcd cluster")
+}
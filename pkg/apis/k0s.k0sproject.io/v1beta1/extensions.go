@@ -17,9 +17,12 @@ package v1beta1
 
 import (
 	"errors"
+	"strings"
 	"time"
 
 	"helm.sh/helm/v3/pkg/chartutil"
+
+	helmv1beta1 "github.com/k0sproject/k0s/pkg/apis/helm.k0sproject.io/v1beta1"
 )
 
 var _ Validateable = (*ClusterExtensions)(nil)
@@ -93,6 +96,10 @@ type Chart struct {
 	Values    string        `json:"values"`
 	TargetNS  string        `json:"namespace"`
 	Timeout   time.Duration `json:"timeout"`
+	// ValuesFrom lists the ConfigMaps and Secrets in kube-system holding chart values, merged in order before Values
+	ValuesFrom []helmv1beta1.ValuesReference `json:"valuesFrom,omitempty"`
+	// Upgrade configures how the release gets installed and upgraded
+	Upgrade *helmv1beta1.UpgradePolicy `json:"upgrade,omitempty"`
 }
 
 // Validate performs validation
@@ -109,10 +116,20 @@ func (c Chart) Validate() error {
 	if c.TargetNS == "" {
 		return errors.New("chart must have TargetNS field not empty")
 	}
+	for _, ref := range c.ValuesFrom {
+		if err := ref.Validate(); err != nil {
+			return err
+		}
+	}
+	if c.Upgrade != nil && c.Upgrade.MaxHistory < 0 {
+		return errors.New("chart upgrade MaxHistory must not be negative")
+	}
 	return nil
 }
 
-// Repository describes single repository entry. Fields map to the CLI flags for the "helm add" command
+// Repository describes single repository entry. Fields map to the CLI flags for the "helm add" command.
+// Repositories with an oci:// URL are OCI registries, k0s logs in to them with the given credentials
+// so that charts can be referenced by their oci:// URL.
 type Repository struct {
 	Name     string `json:"name"`
 	URL      string `json:"url"`
@@ -132,6 +149,9 @@ func (r Repository) Validate() error {
 	if r.URL == "" {
 		return errors.New("repository must have URL field not empty")
 	}
+	if strings.HasPrefix(r.URL, "oci://") && (r.CAFile != "" || r.CertFile != "" || r.KeyFile != "") {
+		return errors.New("repository with an oci:// URL doesn't support the CAFile, CertFile and KeyFile fields")
+	}
 	return nil
 }
 
@@ -19,6 +19,8 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+
+	helmv1beta1 "github.com/k0sproject/k0s/pkg/apis/helm.k0sproject.io/v1beta1"
 )
 
 func TestValidation(t *testing.T) {
@@ -55,6 +57,15 @@ func TestValidation(t *testing.T) {
 			}
 			assert.NoError(t, chart.Validate())
 		})
+		t.Run("invalid_values_reference", func(t *testing.T) {
+			chart := Chart{
+				Name:       "release",
+				ChartName:  "k0s/chart",
+				TargetNS:   "default",
+				ValuesFrom: []helmv1beta1.ValuesReference{{Kind: "Pod", Name: "values"}},
+			}
+			assert.Error(t, chart.Validate())
+		})
 	})
 
 	t.Run("repository_validation", func(t *testing.T) {
@@ -78,6 +89,23 @@ func TestValidation(t *testing.T) {
 			}
 			assert.NoError(t, repo.Validate())
 		})
+		t.Run("oci_repo_with_certs", func(t *testing.T) {
+			repo := Repository{
+				Name:   "registry",
+				URL:    "oci://registry.example.com/charts",
+				CAFile: "/etc/ssl/registry-ca.crt",
+			}
+			assert.Error(t, repo.Validate())
+		})
+		t.Run("oci_repo_with_credentials", func(t *testing.T) {
+			repo := Repository{
+				Name:     "registry",
+				URL:      "oci://registry.example.com/charts",
+				Username: "user",
+				Password: "secret",
+			}
+			assert.NoError(t, repo.Validate())
+		})
 
 	})
 
@@ -0,0 +1,56 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package v1beta1
+
+import (
+	"fmt"
+	"path/filepath"
+	"time"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+var _ Validateable = (*ManifestsSpec)(nil)
+
+// ManifestsSpec defines how the controller applies the manifest stacks found in the manifests directory
+type ManifestsSpec struct {
+	// ServerSideApply makes the applier use Kubernetes server-side apply, with k0s as field manager,
+	// instead of the client-side last-applied-configuration annotation
+	ServerSideApply bool `json:"serverSideApply,omitempty"`
+
+	// DriftCheckInterval is the interval at which the applied resources are compared against the manifests
+	// and re-applied if modified or deleted in the cluster, 0 disables the drift check
+	DriftCheckInterval metav1.Duration `json:"driftCheckInterval,omitempty"`
+
+	// ValuesFile is the path of a YAML file whose content is available as .Values to the manifest templates
+	ValuesFile string `json:"valuesFile,omitempty"`
+}
+
+// Validate validates the manifests settings
+func (m *ManifestsSpec) Validate() []error {
+	if m == nil {
+		return nil
+	}
+
+	var errors []error
+	if m.DriftCheckInterval.Duration != 0 && m.DriftCheckInterval.Duration < 10*time.Second {
+		errors = append(errors, fmt.Errorf("spec.manifests.driftCheckInterval must be at least 10s, got %s", m.DriftCheckInterval.Duration))
+	}
+	if m.ValuesFile != "" && !filepath.IsAbs(m.ValuesFile) {
+		errors = append(errors, fmt.Errorf("spec.manifests.valuesFile must be an absolute path, got %q", m.ValuesFile))
+	}
+	return errors
+}
@@ -20,9 +20,13 @@ import (
 	"fmt"
 	"k8s.io/utils/strings/slices"
 	"path/filepath"
+	"sort"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"github.com/k0sproject/k0s/internal/pkg/iface"
 	"github.com/k0sproject/k0s/pkg/constant"
@@ -34,6 +38,51 @@ const (
 	KineStorageType = "kine"
 )
 
+// supported etcd auto compaction modes
+const (
+	EtcdAutoCompactionPeriodic = "periodic"
+	EtcdAutoCompactionRevision = "revision"
+)
+
+const (
+	// EtcdDefaultQuotaBackendBytes is the database size above which etcd raises the NOSPACE alarm, unless configured otherwise
+	EtcdDefaultQuotaBackendBytes int64 = 2 * 1024 * 1024 * 1024
+	// etcdMaxQuotaBackendBytes is the biggest database size etcd supports
+	etcdMaxQuotaBackendBytes int64 = 8 * 1024 * 1024 * 1024
+	// etcdMaxElectionTimeout is the longest election timeout etcd accepts
+	etcdMaxElectionTimeout = 50 * time.Second
+)
+
+// etcdOwnedArgs are the etcd arguments k0s sets itself, which can't be overridden with extraArgs.
+// The tuning arguments having a field of their own are part of them too.
+var etcdOwnedArgs = []string{
+	"name",
+	"data-dir",
+	"listen-client-urls",
+	"advertise-client-urls",
+	"listen-peer-urls",
+	"initial-advertise-peer-urls",
+	"initial-cluster",
+	"initial-cluster-state",
+	"initial-cluster-token",
+	"client-cert-auth",
+	"trusted-ca-file",
+	"cert-file",
+	"key-file",
+	"peer-client-cert-auth",
+	"peer-trusted-ca-file",
+	"peer-cert-file",
+	"peer-key-file",
+	"auth-token",
+	"log-level",
+	"quota-backend-bytes",
+	"snapshot-count",
+	"heartbeat-interval",
+	"election-timeout",
+	"auto-compaction-mode",
+	"auto-compaction-retention",
+}
+
 var _ Validateable = (*StorageSpec)(nil)
 
 // StorageSpec defines the storage related config options
@@ -105,6 +154,13 @@ func (s *StorageSpec) Validate() []error {
 	if s.Etcd != nil && s.Etcd.ExternalCluster != nil {
 		errors = append(errors, validateRequiredProperties(s.Etcd.ExternalCluster)...)
 		errors = append(errors, validateOptionalTLSProperties(s.Etcd.ExternalCluster)...)
+		if s.Etcd.Maintenance != nil {
+			errors = append(errors, fmt.Errorf("spec.storage.etcd.maintenance is not supported with an external etcd cluster"))
+		}
+	}
+	if s.Etcd != nil {
+		errors = append(errors, s.Etcd.Maintenance.Validate()...)
+		errors = append(errors, s.Etcd.validateTuning()...)
 	}
 
 	return errors
@@ -117,6 +173,113 @@ type EtcdConfig struct {
 
 	// Node address used for etcd cluster peering
 	PeerAddress string `json:"peerAddress"`
+
+	// Maintenance of the etcd cluster managed by k0s: snapshots, defragmentation and database usage reporting
+	Maintenance *EtcdMaintenance `json:"maintenance,omitempty"`
+
+	// QuotaBackendBytes is the database size above which etcd raises the NOSPACE alarm (default: 2GiB, maximum: 8GiB)
+	QuotaBackendBytes int64 `json:"quotaBackendBytes,omitempty"`
+
+	// SnapshotCount is the number of committed transactions triggering a snapshot of the raft log to disk
+	SnapshotCount uint64 `json:"snapshotCount,omitempty"`
+
+	// HeartbeatInterval is the time between two heartbeats of the leader
+	HeartbeatInterval metav1.Duration `json:"heartbeatInterval,omitempty"`
+
+	// ElectionTimeout is the time a follower waits for a heartbeat before starting an election, at least 5 times the heartbeat interval
+	ElectionTimeout metav1.Duration `json:"electionTimeout,omitempty"`
+
+	// AutoCompaction of the etcd history
+	AutoCompaction *EtcdAutoCompaction `json:"autoCompaction,omitempty"`
+
+	// Map of key-values (strings) for any extra arguments to pass down to etcd process
+	ExtraArgs map[string]string `json:"extraArgs,omitempty"`
+}
+
+// EtcdAutoCompaction defines the automatic compaction of the etcd history
+type EtcdAutoCompaction struct {
+	// Mode of the compaction: periodic or revision
+	// +kubebuilder:validation:Enum=periodic;revision
+	Mode string `json:"mode"`
+
+	// Retention is the duration of history kept in periodic mode, e.g. 1h, or the number of revisions kept in revision mode
+	Retention string `json:"retention"`
+}
+
+// GetQuotaBackendBytes returns the database size above which etcd raises the NOSPACE alarm
+func (e *EtcdConfig) GetQuotaBackendBytes() int64 {
+	if e == nil || e.QuotaBackendBytes == 0 {
+		return EtcdDefaultQuotaBackendBytes
+	}
+	return e.QuotaBackendBytes
+}
+
+func (e *EtcdConfig) hasTuning() bool {
+	return e.QuotaBackendBytes != 0 || e.SnapshotCount != 0 || e.HeartbeatInterval.Duration != 0 ||
+		e.ElectionTimeout.Duration != 0 || e.AutoCompaction != nil || len(e.ExtraArgs) > 0
+}
+
+func (e *EtcdConfig) validateTuning() []error {
+	var errors []error
+
+	if e.IsExternalClusterUsed() {
+		if e.hasTuning() {
+			errors = append(errors, fmt.Errorf("spec.storage.etcd: quotaBackendBytes, snapshotCount, heartbeatInterval, electionTimeout, autoCompaction and extraArgs are not supported with an external etcd cluster"))
+		}
+		return errors
+	}
+
+	if e.QuotaBackendBytes < 0 || e.QuotaBackendBytes > etcdMaxQuotaBackendBytes {
+		errors = append(errors, fmt.Errorf("spec.storage.etcd.quotaBackendBytes must be between 0 and %d, got %d", etcdMaxQuotaBackendBytes, e.QuotaBackendBytes))
+	}
+
+	heartbeat, election := e.HeartbeatInterval.Duration, e.ElectionTimeout.Duration
+	if heartbeat < 0 || heartbeat%time.Millisecond != 0 {
+		errors = append(errors, fmt.Errorf("spec.storage.etcd.heartbeatInterval must be a positive number of milliseconds, got %s", heartbeat))
+	}
+	if election < 0 || election%time.Millisecond != 0 || election > etcdMaxElectionTimeout {
+		errors = append(errors, fmt.Errorf("spec.storage.etcd.electionTimeout must be a positive number of milliseconds of at most %s, got %s", etcdMaxElectionTimeout, election))
+	}
+	// etcd's own defaults are 100ms and 1s
+	if heartbeat == 0 {
+		heartbeat = 100 * time.Millisecond
+	}
+	if election == 0 {
+		election = time.Second
+	}
+	if election < 5*heartbeat {
+		errors = append(errors, fmt.Errorf("spec.storage.etcd.electionTimeout must be at least 5 times the heartbeat interval %s, got %s", heartbeat, election))
+	}
+
+	if c := e.AutoCompaction; c != nil {
+		switch c.Mode {
+		case EtcdAutoCompactionPeriodic:
+			if _, err := time.ParseDuration(c.Retention); err != nil {
+				if hours, err := strconv.Atoi(c.Retention); err != nil || hours < 0 {
+					errors = append(errors, fmt.Errorf("spec.storage.etcd.autoCompaction.retention must be a duration or a number of hours in periodic mode, got %q", c.Retention))
+				}
+			}
+		case EtcdAutoCompactionRevision:
+			if revisions, err := strconv.Atoi(c.Retention); err != nil || revisions < 0 {
+				errors = append(errors, fmt.Errorf("spec.storage.etcd.autoCompaction.retention must be a number of revisions in revision mode, got %q", c.Retention))
+			}
+		default:
+			errors = append(errors, fmt.Errorf("spec.storage.etcd.autoCompaction.mode must be %s or %s, got %q", EtcdAutoCompactionPeriodic, EtcdAutoCompactionRevision, c.Mode))
+		}
+	}
+
+	names := make([]string, 0, len(e.ExtraArgs))
+	for name := range e.ExtraArgs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if slices.Contains(etcdOwnedArgs, strings.TrimLeft(name, "-")) {
+			errors = append(errors, fmt.Errorf("spec.storage.etcd.extraArgs: %q is managed by k0s and can't be overridden", name))
+		}
+	}
+
+	return errors
 }
 
 // ExternalCluster defines external etcd cluster related config options
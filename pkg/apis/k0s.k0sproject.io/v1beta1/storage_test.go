@@ -17,9 +17,11 @@ package v1beta1
 
 import (
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/suite"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
 func TestStorageSpec_IsJoinable(t *testing.T) {
@@ -320,3 +322,57 @@ func TestStorageSuite(t *testing.T) {
 
 	suite.Run(t, storageSuite)
 }
+
+func TestEtcdConfig_ValidateTuning(t *testing.T) {
+	yaml := `
+apiVersion: k0s.k0sproject.io/v1beta1
+kind: ClusterConfig
+spec:
+  storage:
+    etcd:
+      quotaBackendBytes: 4294967296
+      snapshotCount: 50000
+      heartbeatInterval: 250ms
+      electionTimeout: 2500ms
+      autoCompaction:
+        mode: periodic
+        retention: 30m
+      extraArgs:
+        experimental-warning-apply-duration: 200ms
+`
+	c, err := ConfigFromString(yaml)
+	if assert.NoError(t, err) {
+		assert.Empty(t, c.Validate())
+		assert.Equal(t, int64(4294967296), c.Spec.Storage.Etcd.GetQuotaBackendBytes())
+
+		// the tuning is node specific and must reach the etcd started by the controller
+		bootstrapping := c.GetBootstrappingConfig(c.Spec.Storage)
+		assert.Equal(t, uint64(50000), bootstrapping.Spec.Storage.Etcd.SnapshotCount)
+		assert.Equal(t, "200ms", bootstrapping.Spec.Storage.Etcd.ExtraArgs["experimental-warning-apply-duration"])
+	}
+	assert.Equal(t, EtcdDefaultQuotaBackendBytes, DefaultEtcdConfig().GetQuotaBackendBytes())
+
+	e := &EtcdConfig{
+		QuotaBackendBytes: 16 * 1024 * 1024 * 1024,
+		HeartbeatInterval: metav1.Duration{Duration: 500 * time.Millisecond},
+		AutoCompaction:    &EtcdAutoCompaction{Mode: EtcdAutoCompactionRevision, Retention: "1h"},
+		ExtraArgs:         map[string]string{"--data-dir": "/tmp", "name": "foo", "max-txn-ops": "256"},
+	}
+	errs := e.validateTuning()
+	if assert.Len(t, errs, 5) {
+		assert.Contains(t, errs[0].Error(), "spec.storage.etcd.quotaBackendBytes")
+		assert.Contains(t, errs[1].Error(), "spec.storage.etcd.electionTimeout must be at least 5 times the heartbeat interval")
+		assert.Contains(t, errs[2].Error(), "spec.storage.etcd.autoCompaction.retention")
+		assert.Contains(t, errs[3].Error(), `"--data-dir" is managed by k0s`)
+		assert.Contains(t, errs[4].Error(), `"name" is managed by k0s`)
+	}
+
+	e = &EtcdConfig{
+		ExternalCluster: &ExternalCluster{Endpoints: []string{"https://etcd:2379"}, EtcdPrefix: "k0s"},
+		SnapshotCount:   10000,
+	}
+	errs = e.validateTuning()
+	if assert.Len(t, errs, 1) {
+		assert.Contains(t, errs[0].Error(), "not supported with an external etcd cluster")
+	}
+}
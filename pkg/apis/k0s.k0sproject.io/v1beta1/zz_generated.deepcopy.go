@@ -7,6 +7,8 @@ package v1beta1
 
 import (
 	"encoding/json"
+	helm_k0sproject_iov1beta1 "github.com/k0sproject/k0s/pkg/apis/helm.k0sproject.io/v1beta1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
 )
 
@@ -37,6 +39,150 @@ func (in *APISpec) DeepCopy() *APISpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupEncryption) DeepCopyInto(out *BackupEncryption) {
+	*out = *in
+	if in.Recipients != nil {
+		in, out := &in.Recipients, &out.Recipients
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupEncryption.
+func (in *BackupEncryption) DeepCopy() *BackupEncryption {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupEncryption)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupRetention) DeepCopyInto(out *BackupRetention) {
+	*out = *in
+	out.MaxAge = in.MaxAge
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupRetention.
+func (in *BackupRetention) DeepCopy() *BackupRetention {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupRetention)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupS3Destination) DeepCopyInto(out *BackupS3Destination) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupS3Destination.
+func (in *BackupS3Destination) DeepCopy() *BackupS3Destination {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupS3Destination)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *BackupSpec) DeepCopyInto(out *BackupSpec) {
+	*out = *in
+	out.Interval = in.Interval
+	if in.S3 != nil {
+		in, out := &in.S3, &out.S3
+		*out = new(BackupS3Destination)
+		**out = **in
+	}
+	if in.Retention != nil {
+		in, out := &in.Retention, &out.Retention
+		*out = new(BackupRetention)
+		**out = **in
+	}
+	if in.Encryption != nil {
+		in, out := &in.Encryption, &out.Encryption
+		*out = new(BackupEncryption)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new BackupSpec.
+func (in *BackupSpec) DeepCopy() *BackupSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(BackupSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CASpec) DeepCopyInto(out *CASpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CASpec.
+func (in *CASpec) DeepCopy() *CASpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CASpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CSRApprovalRule) DeepCopyInto(out *CSRApprovalRule) {
+	*out = *in
+	if in.Usages != nil {
+		in, out := &in.Usages, &out.Usages
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Groups != nil {
+		in, out := &in.Groups, &out.Groups
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSRApprovalRule.
+func (in *CSRApprovalRule) DeepCopy() *CSRApprovalRule {
+	if in == nil {
+		return nil
+	}
+	out := new(CSRApprovalRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CSRApproverSpec) DeepCopyInto(out *CSRApproverSpec) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]CSRApprovalRule, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CSRApproverSpec.
+func (in *CSRApproverSpec) DeepCopy() *CSRApproverSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CSRApproverSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *CaResponse) DeepCopyInto(out *CaResponse) {
 	*out = *in
@@ -105,9 +251,58 @@ func (in *CalicoImageSpec) DeepCopy() *CalicoImageSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificatesSpec) DeepCopyInto(out *CertificatesSpec) {
+	*out = *in
+	out.CAValidity = in.CAValidity
+	out.Validity = in.Validity
+	if in.ValidityOverrides != nil {
+		in, out := &in.ValidityOverrides, &out.ValidityOverrides
+		*out = make(map[string]v1.Duration, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.CA != nil {
+		in, out := &in.CA, &out.CA
+		*out = new(CASpec)
+		**out = **in
+	}
+	if in.FrontProxyCA != nil {
+		in, out := &in.FrontProxyCA, &out.FrontProxyCA
+		*out = new(CASpec)
+		**out = **in
+	}
+	if in.EtcdCA != nil {
+		in, out := &in.EtcdCA, &out.EtcdCA
+		*out = new(CASpec)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificatesSpec.
+func (in *CertificatesSpec) DeepCopy() *CertificatesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificatesSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Chart) DeepCopyInto(out *Chart) {
 	*out = *in
+	if in.ValuesFrom != nil {
+		in, out := &in.ValuesFrom, &out.ValuesFrom
+		*out = make([]helm_k0sproject_iov1beta1.ValuesReference, len(*in))
+		copy(*out, *in)
+	}
+	if in.Upgrade != nil {
+		in, out := &in.Upgrade, &out.Upgrade
+		*out = new(helm_k0sproject_iov1beta1.UpgradePolicy)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new Chart.
@@ -125,7 +320,9 @@ func (in ChartsSettings) DeepCopyInto(out *ChartsSettings) {
 	{
 		in := &in
 		*out = make(ChartsSettings, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -329,6 +526,33 @@ func (in *ClusterSpec) DeepCopyInto(out *ClusterSpec) {
 		*out = new(KonnectivitySpec)
 		**out = **in
 	}
+	if in.Backup != nil {
+		in, out := &in.Backup, &out.Backup
+		*out = new(BackupSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ComponentResources != nil {
+		in, out := &in.ComponentResources, &out.ComponentResources
+		*out = make(ComponentResourcesSpec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+	if in.Certificates != nil {
+		in, out := &in.Certificates, &out.Certificates
+		*out = new(CertificatesSpec)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Manifests != nil {
+		in, out := &in.Manifests, &out.Manifests
+		*out = new(ManifestsSpec)
+		**out = **in
+	}
+	if in.CSRApprover != nil {
+		in, out := &in.CSRApprover, &out.CSRApprover
+		*out = new(CSRApproverSpec)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ClusterSpec.
@@ -356,6 +580,52 @@ func (in *ClusterTelemetry) DeepCopy() *ClusterTelemetry {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ComponentResources) DeepCopyInto(out *ComponentResources) {
+	*out = *in
+	if in.MemoryMax != nil {
+		in, out := &in.MemoryMax, &out.MemoryMax
+		x := (*in).DeepCopy()
+		*out = &x
+	}
+	if in.OOMScoreAdj != nil {
+		in, out := &in.OOMScoreAdj, &out.OOMScoreAdj
+		*out = new(int)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentResources.
+func (in *ComponentResources) DeepCopy() *ComponentResources {
+	if in == nil {
+		return nil
+	}
+	out := new(ComponentResources)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in ComponentResourcesSpec) DeepCopyInto(out *ComponentResourcesSpec) {
+	{
+		in := &in
+		*out = make(ComponentResourcesSpec, len(*in))
+		for key, val := range *in {
+			(*out)[key] = *val.DeepCopy()
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ComponentResourcesSpec.
+func (in ComponentResourcesSpec) DeepCopy() ComponentResourcesSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ComponentResourcesSpec)
+	in.DeepCopyInto(out)
+	return *out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *ControllerManagerSpec) DeepCopyInto(out *ControllerManagerSpec) {
 	*out = *in
@@ -393,6 +663,21 @@ func (in *DualStack) DeepCopy() *DualStack {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdAutoCompaction) DeepCopyInto(out *EtcdAutoCompaction) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdAutoCompaction.
+func (in *EtcdAutoCompaction) DeepCopy() *EtcdAutoCompaction {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdAutoCompaction)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EtcdConfig) DeepCopyInto(out *EtcdConfig) {
 	*out = *in
@@ -401,6 +686,25 @@ func (in *EtcdConfig) DeepCopyInto(out *EtcdConfig) {
 		*out = new(ExternalCluster)
 		(*in).DeepCopyInto(*out)
 	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(EtcdMaintenance)
+		**out = **in
+	}
+	out.HeartbeatInterval = in.HeartbeatInterval
+	out.ElectionTimeout = in.ElectionTimeout
+	if in.AutoCompaction != nil {
+		in, out := &in.AutoCompaction, &out.AutoCompaction
+		*out = new(EtcdAutoCompaction)
+		**out = **in
+	}
+	if in.ExtraArgs != nil {
+		in, out := &in.ExtraArgs, &out.ExtraArgs
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdConfig.
@@ -413,6 +717,23 @@ func (in *EtcdConfig) DeepCopy() *EtcdConfig {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *EtcdMaintenance) DeepCopyInto(out *EtcdMaintenance) {
+	*out = *in
+	out.SnapshotInterval = in.SnapshotInterval
+	out.CheckInterval = in.CheckInterval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new EtcdMaintenance.
+func (in *EtcdMaintenance) DeepCopy() *EtcdMaintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(EtcdMaintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *EtcdRequest) DeepCopyInto(out *EtcdRequest) {
 	*out = *in
@@ -480,7 +801,9 @@ func (in *HelmExtensions) DeepCopyInto(out *HelmExtensions) {
 	if in.Charts != nil {
 		in, out := &in.Charts, &out.Charts
 		*out = make(ChartsSettings, len(*in))
-		copy(*out, *in)
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
@@ -606,6 +929,22 @@ func (in *KubeRouterImageSpec) DeepCopy() *KubeRouterImageSpec {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ManifestsSpec) DeepCopyInto(out *ManifestsSpec) {
+	*out = *in
+	out.DriftCheckInterval = in.DriftCheckInterval
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ManifestsSpec.
+func (in *ManifestsSpec) DeepCopy() *ManifestsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ManifestsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *Network) DeepCopyInto(out *Network) {
 	*out = *in
@@ -763,6 +1102,56 @@ func (in *SystemUser) DeepCopy() *SystemUser {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkerJoinRequest) DeepCopyInto(out *WorkerJoinRequest) {
+	*out = *in
+	if in.CSR != nil {
+		in, out := &in.CSR, &out.CSR
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkerJoinRequest.
+func (in *WorkerJoinRequest) DeepCopy() *WorkerJoinRequest {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkerJoinRequest)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *WorkerJoinResponse) DeepCopyInto(out *WorkerJoinResponse) {
+	*out = *in
+	if in.Certificate != nil {
+		in, out := &in.Certificate, &out.Certificate
+		*out = make([]byte, len(*in))
+		copy(*out, *in)
+	}
+	if in.Labels != nil {
+		in, out := &in.Labels, &out.Labels
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Taints != nil {
+		in, out := &in.Taints, &out.Taints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new WorkerJoinResponse.
+func (in *WorkerJoinResponse) DeepCopy() *WorkerJoinResponse {
+	if in == nil {
+		return nil
+	}
+	out := new(WorkerJoinResponse)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *WorkerProfile) DeepCopyInto(out *WorkerProfile) {
 	*out = *in
@@ -16,6 +16,7 @@ limitations under the License.
 package applier
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"fmt"
@@ -41,13 +42,22 @@ type Applier struct {
 	Name string
 	Dir  string
 
+	// ServerSideApply makes the applier use server-side apply
+	ServerSideApply bool
+	// RevertDrift makes the applier revert the changes made in the cluster to the resources of the stack when applying it
+	RevertDrift bool
+	// TemplateData holds the variables of the manifest templates, which can't be rendered if nil
+	TemplateData *TemplateData
+
 	log             *logrus.Entry
 	clientFactory   kubernetes.ClientFactoryInterface
 	client          dynamic.Interface
 	discoveryClient discovery.CachedDiscoveryInterface
 
 	restClientGetter resource.RESTClientGetter
-	resourceBuilder  *resource.Builder
+
+	// applied are the resources of the latest successful apply
+	applied []*unstructured.Unstructured
 }
 
 // NewApplier creates new Applier
@@ -59,10 +69,6 @@ func NewApplier(dir string, kubeClientFactory kubernetes.ClientFactoryInterface)
 	})
 
 	clientGetter := &restClientGetter{clientFactory: kubeClientFactory}
-	resourceBuilder := resource.NewBuilder(clientGetter).
-		Unstructured().
-		ContinueOnError().
-		Flatten()
 
 	return Applier{
 		log:              log,
@@ -70,7 +76,6 @@ func NewApplier(dir string, kubeClientFactory kubernetes.ClientFactoryInterface)
 		Name:             name,
 		clientFactory:    kubeClientFactory,
 		restClientGetter: clientGetter,
-		resourceBuilder:  resourceBuilder,
 	}
 }
 
@@ -110,7 +115,7 @@ func (a *Applier) Apply(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	files, err := filepath.Glob(path.Join(a.Dir, "*.yaml"))
+	files, err := a.manifestFiles()
 	if err != nil {
 		return err
 	}
@@ -118,12 +123,7 @@ func (a *Applier) Apply(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	stack := Stack{
-		Name:      a.Name,
-		Resources: resources,
-		Client:    a.client,
-		Discovery: a.discoveryClient,
-	}
+	stack := a.stack(resources)
 	a.log.Debug("applying stack")
 	err = stack.Apply(ctx, true)
 	if err != nil {
@@ -131,6 +131,7 @@ func (a *Applier) Apply(ctx context.Context) error {
 		a.discoveryClient.Invalidate()
 	} else {
 		a.log.Debug("successfully applied stack")
+		a.applied = resources
 	}
 
 	return err
@@ -142,28 +143,129 @@ func (a *Applier) Delete(ctx context.Context) error {
 	if err != nil {
 		return err
 	}
-	stack := Stack{
-		Name:      a.Name,
-		Resources: []*unstructured.Unstructured{},
-		Client:    a.client,
-		Discovery: a.discoveryClient,
-	}
+	stack := a.stack([]*unstructured.Unstructured{})
 	logrus.Debugf("about to delete a stack %s with empty apply", a.Name)
 	err = stack.Apply(ctx, true)
 	return err
 }
 
+// CheckDrift compares the resources in the cluster against the manifests and re-applies the stack
+// if any of them got modified or deleted. It returns the drifted resources.
+func (a *Applier) CheckDrift(ctx context.Context) ([]Drift, error) {
+	err := a.lazyInit()
+	if err != nil {
+		return nil, err
+	}
+	files, err := a.manifestFiles()
+	if err != nil {
+		return nil, err
+	}
+	resources, err := a.parseFiles(files)
+	if err != nil {
+		return nil, err
+	}
+	stack := a.stack(resources)
+	stack.RevertDrift = true
+	drifts, err := stack.DetectDrift(ctx)
+	if err != nil {
+		a.discoveryClient.Invalidate()
+		return nil, err
+	}
+	if len(drifts) == 0 {
+		a.log.Debug("no drift detected")
+		return nil, nil
+	}
+
+	a.log.Infof("%d resources drifted, re-applying stack", len(drifts))
+	if err := stack.Apply(ctx, true); err != nil {
+		a.discoveryClient.Invalidate()
+		return drifts, err
+	}
+	return drifts, nil
+}
+
+// Ready checks that the resources of the latest successful apply selected by the metadata are ready
+func (a *Applier) Ready(ctx context.Context, metadata *StackMetadata) error {
+	if err := a.lazyInit(); err != nil {
+		return err
+	}
+	stack := a.stack(a.applied)
+	return stack.Ready(ctx, metadata)
+}
+
+func (a *Applier) stack(resources []*unstructured.Unstructured) Stack {
+	return Stack{
+		Name:            a.Name,
+		Resources:       resources,
+		Client:          a.client,
+		Discovery:       a.discoveryClient,
+		ServerSideApply: a.ServerSideApply,
+		RevertDrift:     a.RevertDrift,
+	}
+}
+
+// manifestFiles lists the manifests of the stack, leaving out its metadata file
+func (a *Applier) manifestFiles() ([]string, error) {
+	var manifests []string
+	for _, pattern := range []string{"*.yaml", "*" + TemplateSuffix} {
+		files, err := filepath.Glob(path.Join(a.Dir, pattern))
+		if err != nil {
+			return nil, err
+		}
+		for _, file := range files {
+			if filepath.Base(file) != MetadataFile {
+				manifests = append(manifests, file)
+			}
+		}
+	}
+	return manifests, nil
+}
+
+// HasTemplates returns true if the stack has manifest templates
+func (a *Applier) HasTemplates() bool {
+	templates, _ := filepath.Glob(path.Join(a.Dir, "*"+TemplateSuffix))
+	return len(templates) > 0
+}
+
 func (a *Applier) parseFiles(files []string) ([]*unstructured.Unstructured, error) {
 	var resources []*unstructured.Unstructured
 	if len(files) == 0 {
 		return resources, nil
 	}
 
-	r := a.resourceBuilder.
-		FilenameParam(false, &resource.FilenameOptions{Filenames: files}).
-		Do()
+	// The builder accumulates the file names, it can't be reused between parses
+	builder := resource.NewBuilder(a.restClientGetter).
+		Unstructured().
+		ContinueOnError().
+		Flatten()
+	var manifests []string
+	sources := 0
+	for _, file := range files {
+		if !isTemplate(file) {
+			manifests = append(manifests, file)
+			continue
+		}
+		if a.TemplateData == nil {
+			return nil, fmt.Errorf("can't render %s without template data", filepath.Base(file))
+		}
+		rendered, err := renderTemplate(file, a.TemplateData)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		builder = builder.Stream(bytes.NewReader(rendered), file)
+		sources++
+	}
+	if len(manifests) > 0 {
+		builder = builder.FilenameParam(false, &resource.FilenameOptions{Filenames: manifests})
+		sources++
+	}
+	if sources == 0 {
+		return resources, nil
+	}
 
-	objects, err := r.Infos()
+	objects, err := builder.Do().Infos()
 	if err != nil {
 		// don't return an error on file removal
 		if !errors.Is(err, os.ErrNotExist) {
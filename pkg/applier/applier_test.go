@@ -22,11 +22,17 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	kubetesting "k8s.io/client-go/testing"
 
 	kubeutil "github.com/k0sproject/k0s/internal/testutil"
 )
@@ -153,3 +159,154 @@ spec:
 	assert.Error(t, err)
 	assert.True(t, errors.IsNotFound(err))
 }
+
+func TestApplierCheckDrift(t *testing.T) {
+	dir := t.TempDir()
+	template := `
+apiVersion: v1
+kind: List
+items:
+  - kind: ConfigMap
+    apiVersion: v1
+    metadata:
+      name: drift-test
+      namespace: kube-system
+    data:
+      foo: bar
+  - kind: Pod
+    apiVersion: v1
+    metadata:
+      name: drift-test
+      namespace: kube-system
+    spec:
+      containers:
+      - name: nginx
+        image: nginx:1.15
+`
+	require.NoError(t, os.WriteFile(fmt.Sprintf("%s/test-list.yaml", dir), []byte(template), 0400))
+
+	fakes := kubeutil.NewFakeClientFactory()
+	verbs := []string{"get", "list", "delete", "create"}
+	fakes.RawDiscovery.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: corev1.SchemeGroupVersion.String(),
+			APIResources: []metav1.APIResource{
+				{Name: "pods", Namespaced: true, Kind: "Pod", Verbs: verbs},
+				{Name: "configmaps", Namespaced: true, Kind: "ConfigMap", Verbs: verbs},
+			},
+		},
+	}
+
+	a := NewApplier(dir, fakes)
+	ctx := context.Background()
+	require.NoError(t, a.Apply(ctx))
+
+	drifts, err := a.CheckDrift(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, drifts)
+
+	cmGV, _ := schema.ParseResourceArg("configmaps.v1.")
+	podGV, _ := schema.ParseResourceArg("pods.v1.")
+	cm, err := a.client.Resource(*cmGV).Namespace("kube-system").Get(ctx, "drift-test", metav1.GetOptions{})
+	require.NoError(t, err)
+	require.NoError(t, unstructured.SetNestedField(cm.Object, "edited", "data", "foo"))
+	require.NoError(t, unstructured.SetNestedField(cm.Object, "added", "data", "other"))
+	_, err = a.client.Resource(*cmGV).Namespace("kube-system").Update(ctx, cm, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	// Applying the unchanged stack leaves the drift alone, unless the drift check is enabled
+	require.NoError(t, a.Apply(ctx))
+	cm, err = a.client.Resource(*cmGV).Namespace("kube-system").Get(ctx, "drift-test", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "edited", cm.Object["data"].(map[string]interface{})["foo"])
+
+	require.NoError(t, a.client.Resource(*podGV).Namespace("kube-system").Delete(ctx, "drift-test", metav1.DeleteOptions{}))
+
+	drifts, err = a.CheckDrift(ctx)
+	require.NoError(t, err)
+	require.Len(t, drifts, 2)
+	assert.Equal(t, "ConfigMap", drifts[0].Resource.GetKind())
+	assert.False(t, drifts[0].Deleted())
+	assert.Equal(t, []string{"data.foo"}, drifts[0].Fields)
+	assert.Equal(t, "Pod", drifts[1].Resource.GetKind())
+	assert.True(t, drifts[1].Deleted())
+
+	cm, err = a.client.Resource(*cmGV).Namespace("kube-system").Get(ctx, "drift-test", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"foo": "bar", "other": "added"}, cm.Object["data"], "fields not defined in the stack must be kept")
+	_, err = a.client.Resource(*podGV).Namespace("kube-system").Get(ctx, "drift-test", metav1.GetOptions{})
+	assert.NoError(t, err)
+
+	drifts, err = a.CheckDrift(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, drifts)
+}
+
+func TestApplierServerSideApply(t *testing.T) {
+	dir := t.TempDir()
+	template := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: ssa-test
+  namespace: kube-system
+data:
+  foo: bar
+`
+	require.NoError(t, os.WriteFile(fmt.Sprintf("%s/test-cm.yaml", dir), []byte(template), 0400))
+
+	fakes := kubeutil.NewFakeClientFactory()
+	fakes.RawDiscovery.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: corev1.SchemeGroupVersion.String(),
+			APIResources: []metav1.APIResource{
+				{Name: "configmaps", Namespaced: true, Kind: "ConfigMap", Verbs: []string{"get", "list", "delete", "create"}},
+			},
+		},
+	}
+	// The fake client doesn't implement apply patches, store the applied objects as is
+	fakeDynamic := fakes.DynamicClient.(*dynamicfake.FakeDynamicClient)
+	var applied []string
+	fakeDynamic.PrependReactor("patch", "*", func(action kubetesting.Action) (bool, runtime.Object, error) {
+		patch := action.(kubetesting.PatchAction)
+		if patch.GetPatchType() != types.ApplyPatchType {
+			return false, nil, nil
+		}
+		applied = append(applied, patch.GetName())
+		obj := &unstructured.Unstructured{}
+		if err := obj.UnmarshalJSON(patch.GetPatch()); err != nil {
+			return true, nil, err
+		}
+		tracker := fakeDynamic.Tracker()
+		if _, err := tracker.Get(patch.GetResource(), patch.GetNamespace(), patch.GetName()); errors.IsNotFound(err) {
+			return true, obj, tracker.Create(patch.GetResource(), obj, patch.GetNamespace())
+		}
+		return true, obj, tracker.Update(patch.GetResource(), obj, patch.GetNamespace())
+	})
+
+	a := NewApplier(dir, fakes)
+	a.ServerSideApply = true
+	ctx := context.Background()
+	require.NoError(t, a.Apply(ctx))
+	assert.Equal(t, []string{"ssa-test"}, applied)
+
+	// unchanged resources are not applied again
+	require.NoError(t, a.Apply(ctx))
+	assert.Len(t, applied, 1)
+
+	cmGV, _ := schema.ParseResourceArg("configmaps.v1.")
+	cm, err := a.client.Resource(*cmGV).Namespace("kube-system").Get(ctx, "ssa-test", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "bar", cm.Object["data"].(map[string]interface{})["foo"])
+	require.NoError(t, unstructured.SetNestedField(cm.Object, "edited", "data", "foo"))
+	_, err = a.client.Resource(*cmGV).Namespace("kube-system").Update(ctx, cm, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	drifts, err := a.CheckDrift(ctx)
+	require.NoError(t, err)
+	require.Len(t, drifts, 1)
+	assert.Len(t, applied, 2)
+	cm, err = a.client.Resource(*cmGV).Namespace("kube-system").Get(ctx, "ssa-test", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "bar", cm.Object["data"].(map[string]interface{})["foo"])
+}
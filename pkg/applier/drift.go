@@ -0,0 +1,158 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package applier
+
+import (
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+
+	"github.com/k0sproject/k0s/internal/pkg/stringslice"
+)
+
+// Drift is a stack resource modified or deleted in the cluster after it got applied
+type Drift struct {
+	// Resource is the resource as defined in the stack
+	Resource *unstructured.Unstructured
+	// Live is the resource found in the cluster, nil if it got deleted
+	Live *unstructured.Unstructured
+	// Fields are the paths of the fields differing from the stack
+	Fields []string
+}
+
+// Deleted returns true if the resource got deleted from the cluster
+func (d *Drift) Deleted() bool {
+	return d.Live == nil
+}
+
+func (d *Drift) String() string {
+	if d.Deleted() {
+		return fmt.Sprintf("%s deleted", generateResourceID(*d.Resource))
+	}
+	return fmt.Sprintf("%s modified: %s", generateResourceID(*d.Resource), strings.Join(d.Fields, ", "))
+}
+
+// driftIgnoredFields are the top level fields not compared by the drift detection, as the API server never returns them as is
+var driftIgnoredFields = []string{"status", "stringData"}
+
+// diffResource returns the paths of the fields defined in the local resource that differ in the server one.
+// Fields only set on the server, such as the defaulted ones, are not considered.
+func diffResource(local, server *unstructured.Unstructured) []string {
+	var diffs []string
+	for field, value := range local.Object {
+		if stringslice.Contains(driftIgnoredFields, field) {
+			continue
+		}
+		diffs = append(diffs, diffFields(field, value, server.Object[field])...)
+	}
+	sort.Strings(diffs)
+	return diffs
+}
+
+func diffFields(path string, local, server interface{}) []string {
+	// The API server omits the fields set to their zero value
+	if server == nil && isZeroValue(local) {
+		return nil
+	}
+	switch local := local.(type) {
+	case nil:
+		return nil
+	case map[string]interface{}:
+		server, ok := server.(map[string]interface{})
+		if !ok {
+			return []string{path}
+		}
+		var diffs []string
+		for k, v := range local {
+			diffs = append(diffs, diffFields(path+"."+k, v, server[k])...)
+		}
+		return diffs
+	case []interface{}:
+		server, ok := server.([]interface{})
+		if !ok || len(server) != len(local) {
+			if len(local) == 0 && len(server) == 0 {
+				return nil
+			}
+			return []string{path}
+		}
+		var diffs []string
+		for i := range local {
+			diffs = append(diffs, diffFields(fmt.Sprintf("%s[%d]", path, i), local[i], server[i])...)
+		}
+		return diffs
+	default:
+		if !scalarEqual(local, server) {
+			return []string{path}
+		}
+		return nil
+	}
+}
+
+// scalarEqual compares two scalar values, numbers regardless of their type and quantities regardless of their format
+func scalarEqual(local, server interface{}) bool {
+	if reflect.DeepEqual(local, server) {
+		return true
+	}
+	if l, ok := toFloat(local); ok {
+		s, ok := toFloat(server)
+		return ok && l == s
+	}
+	if l, ok := local.(string); ok {
+		s, ok := server.(string)
+		if !ok || strings.TrimSpace(l) == "" {
+			return false
+		}
+		lq, err := resource.ParseQuantity(l)
+		if err != nil {
+			return false
+		}
+		sq, err := resource.ParseQuantity(s)
+		return err == nil && lq.Cmp(sq) == 0
+	}
+	return false
+}
+
+// isZeroValue returns true if the value is false, an empty string, zero, or an empty map or list
+func isZeroValue(value interface{}) bool {
+	switch v := value.(type) {
+	case bool:
+		return !v
+	case string:
+		return v == ""
+	case map[string]interface{}:
+		return len(v) == 0
+	case []interface{}:
+		return len(v) == 0
+	}
+	f, ok := toFloat(value)
+	return ok && f == 0
+}
+
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int64:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	}
+	return 0, false
+}
@@ -0,0 +1,103 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package applier
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestDiffResource(t *testing.T) {
+	local := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "Deployment",
+		"spec": map[string]interface{}{
+			"replicas": int64(2),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":      "coredns",
+							"resources": map[string]interface{}{"limits": map[string]interface{}{"cpu": "0.5"}},
+						},
+					},
+					"volumes": []interface{}{},
+				},
+			},
+		},
+		"stringData": map[string]interface{}{"foo": "bar"},
+	}}
+	server := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "Deployment",
+		"spec": map[string]interface{}{
+			"replicas":             float64(2),
+			"revisionHistoryLimit": int64(10),
+			"template": map[string]interface{}{
+				"spec": map[string]interface{}{
+					"containers": []interface{}{
+						map[string]interface{}{
+							"name":                     "coredns",
+							"imagePullPolicy":          "IfNotPresent",
+							"resources":                map[string]interface{}{"limits": map[string]interface{}{"cpu": "500m"}},
+							"terminationMessagePath":   "/dev/termination-log",
+							"terminationMessagePolicy": "File",
+						},
+					},
+				},
+			},
+		},
+		"status": map[string]interface{}{"replicas": int64(1)},
+	}}
+	assert.Empty(t, diffResource(local, server), "defaulted fields and equivalent values are no drift")
+
+	spec := server.Object["spec"].(map[string]interface{})
+	spec["replicas"] = int64(5)
+	containers := spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"].([]interface{})
+	containers[0].(map[string]interface{})["name"] = "edited"
+	assert.Equal(t, []string{"spec.replicas", "spec.template.spec.containers[0].name"}, diffResource(local, server))
+
+	spec["template"].(map[string]interface{})["spec"].(map[string]interface{})["containers"] = []interface{}{containers[0], containers[0]}
+	assert.Equal(t, []string{"spec.replicas", "spec.template.spec.containers"}, diffResource(local, server))
+}
+
+func TestDiffResourceOmittedZeroValues(t *testing.T) {
+	local := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind": "Pod",
+		"metadata": map[string]interface{}{
+			"name":        "coredns",
+			"annotations": map[string]interface{}{},
+		},
+		"spec": map[string]interface{}{
+			"automountServiceAccountToken": false,
+			"hostNetwork":                  false,
+			"priority":                     int64(0),
+			"subdomain":                    "",
+			"volumes":                      []interface{}{},
+		},
+	}}
+	server := &unstructured.Unstructured{Object: map[string]interface{}{
+		"kind":     "Pod",
+		"metadata": map[string]interface{}{"name": "coredns"},
+		"spec":     map[string]interface{}{},
+	}}
+	assert.Empty(t, diffResource(local, server), "zero values omitted by the API server are no drift")
+
+	podSpec := local.Object["spec"].(map[string]interface{})
+	podSpec["automountServiceAccountToken"] = true
+	podSpec["subdomain"] = "dns"
+	assert.Equal(t, []string{"spec.automountServiceAccountToken", "spec.subdomain"}, diffResource(local, server))
+}
@@ -19,8 +19,13 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"sync"
 
 	"github.com/k0sproject/k0s/internal/pkg/dir"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/component/controller"
 	"github.com/k0sproject/k0s/pkg/constant"
@@ -34,6 +39,7 @@ import (
 type Manager struct {
 	K0sVars           constant.CfgVars
 	KubeClientFactory kubeutil.ClientFactoryInterface
+	NodeConfig        *v1beta1.ClusterConfig
 
 	// client               kubernetes.Interface
 	applier       Applier
@@ -41,6 +47,12 @@ type Manager struct {
 	cancelWatcher context.CancelFunc
 	log           *logrus.Entry
 	stacks        map[string]*StackApplier
+	stacksMu      sync.Mutex
+
+	// clusterConfig is the latest cluster-wide config, providing the variables of the manifest templates
+	clusterConfig    *v1beta1.ClusterConfig
+	lastTemplateData *TemplateData
+	clusterConfigMu  sync.Mutex
 
 	LeaderElector controller.LeaderElector
 }
@@ -107,6 +119,14 @@ func (m *Manager) runWatchers(ctx context.Context) error {
 	// Doing it the other way round introduces a race condition when directories
 	// get created after the initial listing but before the watch starts.
 
+	// The values file is watched through its directory, as editors tend to replace files instead of writing them
+	valuesFile := m.valuesFile()
+	if valuesFile != "" {
+		if err := watcher.Add(filepath.Dir(valuesFile)); err != nil {
+			log.WithError(err).Warn("failed to watch the manifest values")
+		}
+	}
+
 	dirs, err := dir.GetAll(m.bundlePath)
 	if err != nil {
 		return err
@@ -131,6 +151,10 @@ func (m *Manager) runWatchers(ctx context.Context) error {
 			if !ok {
 				return nil
 			}
+			if valuesFile != "" && filepath.Clean(event.Name) == valuesFile {
+				m.reloadValues()
+				continue
+			}
 			switch event.Op {
 			case fsnotify.Create:
 				if dir.IsDirectory(event.Name) {
@@ -154,16 +178,21 @@ func (m *Manager) createStack(ctx context.Context, name string) error {
 		return nil
 	}
 	m.log.WithField("stack", name).Info("registering new stack")
-	sa, err := NewStackApplier(ctx, name, m.KubeClientFactory)
+	sa, err := NewStackApplier(ctx, name, m.KubeClientFactory, m.NodeConfig.Spec.Manifests)
 	if err != nil {
 		return err
 	}
 
+	sa.lookup = m.stackStatus
+	sa.templateData = m.templateData
+
 	go func() {
 		_ = sa.Start()
 	}()
 
+	m.stacksMu.Lock()
 	m.stacks[name] = sa
+	m.stacksMu.Unlock()
 	return nil
 }
 
@@ -183,10 +212,123 @@ func (m *Manager) removeStack(ctx context.Context, name string) error {
 		return err
 	}
 	m.log.WithField("stack", name).Info("stack deleted succesfully")
+	m.stacksMu.Lock()
 	delete(m.stacks, name)
+	m.stacksMu.Unlock()
 
 	return nil
 }
 
+// Statuses returns the status of the stacks, sorted by name
+func (m *Manager) Statuses() []StackStatus {
+	m.stacksMu.Lock()
+	defer m.stacksMu.Unlock()
+	var statuses []StackStatus
+	for _, sa := range m.stacks {
+		statuses = append(statuses, sa.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// stackStatus returns the status of the stack with the given name
+func (m *Manager) stackStatus(name string) (StackStatus, bool) {
+	m.stacksMu.Lock()
+	defer m.stacksMu.Unlock()
+	for _, sa := range m.stacks {
+		if sa.applier.Name == name {
+			return sa.Status(), true
+		}
+	}
+	return StackStatus{}, false
+}
+
+// SetClusterConfig updates the cluster-wide config providing the variables of the manifest templates,
+// re-applying the stacks with templates if the variables changed
+func (m *Manager) SetClusterConfig(clusterConfig *v1beta1.ClusterConfig) {
+	m.clusterConfigMu.Lock()
+	defer m.clusterConfigMu.Unlock()
+	m.clusterConfig = clusterConfig
+	m.refreshTemplateData()
+}
+
+// reloadValues re-reads the manifest values, re-applying the stacks with templates if they changed
+func (m *Manager) reloadValues() {
+	m.clusterConfigMu.Lock()
+	defer m.clusterConfigMu.Unlock()
+	if m.clusterConfig == nil {
+		return
+	}
+	m.refreshTemplateData()
+}
+
+// refreshTemplateData re-computes the template variables and re-applies the stacks with templates if they changed.
+// The caller must hold clusterConfigMu.
+func (m *Manager) refreshTemplateData() {
+	templateData, err := NewTemplateData(m.NodeConfig, m.clusterConfig)
+	if err != nil {
+		m.log.WithError(err).Warn("failed to get the template variables")
+		return
+	}
+	if m.lastTemplateData == nil || reflect.DeepEqual(templateData, m.lastTemplateData) {
+		m.lastTemplateData = templateData
+		return
+	}
+	m.lastTemplateData = templateData
+
+	m.stacksMu.Lock()
+	defer m.stacksMu.Unlock()
+	for _, sa := range m.stacks {
+		if sa.applier.HasTemplates() {
+			m.log.WithField("stack", sa.applier.Name).Info("template variables changed, re-applying stack")
+			sa.Reapply()
+		}
+	}
+}
+
+// valuesFile returns the cleaned path of the manifest values file, empty if none is configured
+func (m *Manager) valuesFile() string {
+	if spec := m.NodeConfig.Spec.Manifests; spec != nil && spec.ValuesFile != "" {
+		return filepath.Clean(spec.ValuesFile)
+	}
+	return ""
+}
+
+// templateData returns the variables of the manifest templates, nil if the cluster config isn't known yet
+func (m *Manager) templateData() (*TemplateData, error) {
+	m.clusterConfigMu.Lock()
+	defer m.clusterConfigMu.Unlock()
+	if m.clusterConfig == nil {
+		return nil, nil
+	}
+	return NewTemplateData(m.NodeConfig, m.clusterConfig)
+}
+
 // Health-check interface
 func (m *Manager) Healthy() error { return nil }
+
+// ConfigReconciler passes the cluster-wide config to the manifest templates of the applier Manager.
+// The Manager being a node component, it isn't reconciled itself.
+type ConfigReconciler struct {
+	Manager *Manager
+}
+
+var _ component.ReconcilerComponent = (*ConfigReconciler)(nil)
+
+// Init does nothing
+func (r *ConfigReconciler) Init(_ context.Context) error { return nil }
+
+// Run does nothing
+func (r *ConfigReconciler) Run(_ context.Context) error { return nil }
+
+// Reconcile updates the cluster-wide config of the Manager
+func (r *ConfigReconciler) Reconcile(_ context.Context, clusterConfig *v1beta1.ClusterConfig) error {
+	r.Manager.SetClusterConfig(clusterConfig)
+	return nil
+}
+
+// Stop does nothing
+func (r *ConfigReconciler) Stop() error { return nil }
+
+// Healthy does nothing
+func (r *ConfigReconciler) Healthy() error { return nil }
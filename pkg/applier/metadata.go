@@ -0,0 +1,98 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package applier
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"sigs.k8s.io/yaml"
+)
+
+// MetadataFile is the name of the optional file declaring the dependencies and the readiness checks of a stack, in the stack directory
+const MetadataFile = "k0s-stack.yaml"
+
+// StackMetadata declares how a stack relates to the other stacks
+type StackMetadata struct {
+	// DependsOn lists the stacks which must be ready before this one gets applied
+	DependsOn []string `json:"dependsOn,omitempty"`
+
+	// Readiness selects the resources of the stack which must be ready for the stack to be ready.
+	// All the CustomResourceDefinitions, Deployments, StatefulSets and DaemonSets of the stack are selected if empty.
+	Readiness []ReadinessCheck `json:"readiness,omitempty"`
+}
+
+// ReadinessCheck selects stack resources by kind, and optionally by name and namespace
+type ReadinessCheck struct {
+	Kind      string `json:"kind"`
+	Name      string `json:"name,omitempty"`
+	Namespace string `json:"namespace,omitempty"`
+}
+
+// loadMetadata reads the metadata of the stack in the given directory, the metadata being empty if the stack has none
+func loadMetadata(dir string) (*StackMetadata, error) {
+	metadata := &StackMetadata{}
+	data, err := os.ReadFile(filepath.Join(dir, MetadataFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return metadata, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if err := yaml.UnmarshalStrict(data, metadata); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", MetadataFile, err)
+	}
+	if err := metadata.Validate(filepath.Base(dir)); err != nil {
+		return nil, fmt.Errorf("invalid %s: %w", MetadataFile, err)
+	}
+	return metadata, nil
+}
+
+// Validate validates the metadata of the stack with the given name
+func (m *StackMetadata) Validate(name string) error {
+	for _, dep := range m.DependsOn {
+		switch dep {
+		case "":
+			return fmt.Errorf("empty stack name in dependsOn")
+		case name:
+			return fmt.Errorf("stack `%s` can't depend on itself", name)
+		}
+	}
+	for _, check := range m.Readiness {
+		if check.Kind == "" {
+			return fmt.Errorf("readiness checks must have a kind")
+		}
+	}
+	return nil
+}
+
+// selects returns true if the resource is subject to the readiness checks
+func (m *StackMetadata) selects(resource *unstructured.Unstructured) bool {
+	if len(m.Readiness) == 0 {
+		_, ok := readinessChecks[resource.GroupVersionKind().GroupKind()]
+		return ok
+	}
+	for _, check := range m.Readiness {
+		if check.Kind == resource.GetKind() &&
+			(check.Name == "" || check.Name == resource.GetName()) &&
+			(check.Namespace == "" || check.Namespace == resource.GetNamespace()) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package applier
+
+import (
+	"fmt"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// readinessChecks are the kinds of resources having a readiness check, the other resources being ready once applied
+var readinessChecks = map[schema.GroupKind]func(*unstructured.Unstructured) error{
+	{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}: crdReady,
+	{Group: "apps", Kind: "Deployment"}:                               deploymentReady,
+	{Group: "apps", Kind: "StatefulSet"}:                              statefulSetReady,
+	{Group: "apps", Kind: "DaemonSet"}:                                daemonSetReady,
+}
+
+// resourceReady returns nil if the resource, as found in the cluster, is ready, or an error describing why it's not
+func resourceReady(resource *unstructured.Unstructured) error {
+	check, ok := readinessChecks[resource.GroupVersionKind().GroupKind()]
+	if !ok {
+		return nil
+	}
+	if err := check(resource); err != nil {
+		return fmt.Errorf("%s %s not ready: %w", resource.GetKind(), namespacedName(resource), err)
+	}
+	return nil
+}
+
+func crdReady(crd *unstructured.Unstructured) error {
+	conditions, _, _ := unstructured.NestedSlice(crd.Object, "status", "conditions")
+	for _, c := range conditions {
+		condition, ok := c.(map[string]interface{})
+		if ok && condition["type"] == "Established" && condition["status"] == "True" {
+			return nil
+		}
+	}
+	return fmt.Errorf("not established")
+}
+
+func deploymentReady(deployment *unstructured.Unstructured) error {
+	if err := generationObserved(deployment); err != nil {
+		return err
+	}
+	replicas := desiredReplicas(deployment)
+	updated, _, _ := unstructured.NestedInt64(deployment.Object, "status", "updatedReplicas")
+	available, _, _ := unstructured.NestedInt64(deployment.Object, "status", "availableReplicas")
+	if updated < replicas {
+		return fmt.Errorf("%d of %d replicas updated", updated, replicas)
+	}
+	if available < replicas {
+		return fmt.Errorf("%d of %d replicas available", available, replicas)
+	}
+	return nil
+}
+
+func statefulSetReady(statefulSet *unstructured.Unstructured) error {
+	if err := generationObserved(statefulSet); err != nil {
+		return err
+	}
+	replicas := desiredReplicas(statefulSet)
+	ready, _, _ := unstructured.NestedInt64(statefulSet.Object, "status", "readyReplicas")
+	if ready < replicas {
+		return fmt.Errorf("%d of %d replicas ready", ready, replicas)
+	}
+	return nil
+}
+
+func daemonSetReady(daemonSet *unstructured.Unstructured) error {
+	if err := generationObserved(daemonSet); err != nil {
+		return err
+	}
+	desired, _, _ := unstructured.NestedInt64(daemonSet.Object, "status", "desiredNumberScheduled")
+	updated, _, _ := unstructured.NestedInt64(daemonSet.Object, "status", "updatedNumberScheduled")
+	available, _, _ := unstructured.NestedInt64(daemonSet.Object, "status", "numberAvailable")
+	if updated < desired {
+		return fmt.Errorf("%d of %d pods updated", updated, desired)
+	}
+	if available < desired {
+		return fmt.Errorf("%d of %d pods available", available, desired)
+	}
+	return nil
+}
+
+// generationObserved checks that the controller of the resource caught up with its latest spec
+func generationObserved(resource *unstructured.Unstructured) error {
+	observed, _, _ := unstructured.NestedInt64(resource.Object, "status", "observedGeneration")
+	if observed < resource.GetGeneration() {
+		return fmt.Errorf("generation %d not observed yet", resource.GetGeneration())
+	}
+	return nil
+}
+
+func desiredReplicas(resource *unstructured.Unstructured) int64 {
+	replicas, found, _ := unstructured.NestedInt64(resource.Object, "spec", "replicas")
+	if !found {
+		return 1
+	}
+	return replicas
+}
+
+func namespacedName(resource *unstructured.Unstructured) string {
+	if resource.GetNamespace() == "" {
+		return resource.GetName()
+	}
+	return resource.GetNamespace() + "/" + resource.GetName()
+}
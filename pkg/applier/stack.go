@@ -20,6 +20,7 @@ import (
 	"crypto/md5"
 	"encoding/hex"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -46,6 +47,9 @@ const (
 
 	// LastConfigAnnotation defines the annotation to be used for last applied configs
 	LastConfigAnnotation = "k0s.k0sproject.io/last-applied-configuration"
+
+	// FieldManager is the field manager of the resources applied with server-side apply
+	FieldManager = "k0s"
 )
 
 // Stack is a k8s resource bundle
@@ -56,6 +60,11 @@ type Stack struct {
 	Client        dynamic.Interface
 	Discovery     discovery.CachedDiscoveryInterface
 
+	// ServerSideApply makes the stack use server-side apply instead of the last-applied-configuration annotation
+	ServerSideApply bool
+	// RevertDrift makes the stack re-apply the resources modified in the cluster even if their checksum matches
+	RevertDrift bool
+
 	log *logrus.Entry
 }
 
@@ -80,42 +89,12 @@ func (s *Stack) Apply(ctx context.Context, prune bool) error {
 
 	for _, resource := range sortedResources {
 		s.prepareResource(resource)
-		mapping, err := mapper.RESTMapping(resource.GroupVersionKind().GroupKind(), resource.GroupVersionKind().Version)
+		drClient, err := s.clientForResource(mapper, *resource)
 		if err != nil {
-			return fmt.Errorf("mapping error: %s", err)
+			return err
 		}
-		var drClient dynamic.ResourceInterface
-		if mapping.Scope.Name() == meta.RESTScopeNameNamespace {
-			drClient = s.Client.Resource(mapping.Resource).Namespace(resource.GetNamespace())
-		} else {
-			drClient = s.Client.Resource(mapping.Resource)
-		}
-		serverResource, err := drClient.Get(ctx, resource.GetName(), metav1.GetOptions{})
-		if apiErrors.IsNotFound(err) {
-			_, err := drClient.Create(ctx, resource, metav1.CreateOptions{})
-			if err != nil {
-				return fmt.Errorf("cannot create resource %s: %s", resource.GetName(), err)
-			}
-		} else if err != nil {
-			return fmt.Errorf("unknown api error: %s", err)
-		} else { // The resource already exists, we need to update/patch it
-			localChecksum := resource.GetAnnotations()[ChecksumAnnotation]
-			if serverResource.GetAnnotations()[ChecksumAnnotation] == localChecksum {
-				s.log.Debug("resource checksums match, no need to update")
-				s.keepResource(resource)
-				continue
-			}
-			if serverResource.GetAnnotations()[LastConfigAnnotation] == "" {
-				s.log.Debug("doing plain update as no last-config label present")
-				resource.SetResourceVersion(serverResource.GetResourceVersion())
-				_, err = drClient.Update(ctx, resource, metav1.UpdateOptions{})
-			} else {
-				s.log.Debug("patching resource")
-				err = s.patchResource(ctx, drClient, serverResource, resource)
-			}
-			if err != nil {
-				return fmt.Errorf("can't update resource:%v", err)
-			}
+		if err := s.applyResource(ctx, drClient, resource); err != nil {
+			return err
 		}
 		s.keepResource(resource)
 	}
@@ -128,6 +107,110 @@ func (s *Stack) Apply(ctx context.Context, prune bool) error {
 	return err
 }
 
+func (s *Stack) applyResource(ctx context.Context, drClient dynamic.ResourceInterface, resource *unstructured.Unstructured) error {
+	serverResource, err := drClient.Get(ctx, resource.GetName(), metav1.GetOptions{})
+	if apiErrors.IsNotFound(err) {
+		if s.ServerSideApply {
+			return s.serverSideApply(ctx, drClient, resource)
+		}
+		_, err := drClient.Create(ctx, resource, metav1.CreateOptions{})
+		if err != nil {
+			return fmt.Errorf("cannot create resource %s: %s", resource.GetName(), err)
+		}
+		return nil
+	} else if err != nil {
+		return fmt.Errorf("unknown api error: %s", err)
+	}
+
+	// The resource already exists, we need to update/patch it unless neither the stack nor the cluster changed it
+	localChecksum := resource.GetAnnotations()[ChecksumAnnotation]
+	if serverResource.GetAnnotations()[ChecksumAnnotation] == localChecksum {
+		if !s.RevertDrift {
+			s.log.Debug("resource checksums match, no need to update")
+			return nil
+		}
+		drifted := diffResource(resource, serverResource)
+		if len(drifted) == 0 {
+			s.log.Debug("resource checksums match, no need to update")
+			return nil
+		}
+		s.log.Infof("resource %s drifted, re-applying fields %s", generateResourceID(*resource), strings.Join(drifted, ", "))
+		if s.ServerSideApply {
+			return s.serverSideApply(ctx, drClient, resource)
+		}
+		return s.revertDrift(ctx, drClient, resource)
+	}
+
+	if s.ServerSideApply {
+		err = s.serverSideApply(ctx, drClient, resource)
+	} else if serverResource.GetAnnotations()[LastConfigAnnotation] == "" {
+		s.log.Debug("doing plain update as no last-config label present")
+		resource.SetResourceVersion(serverResource.GetResourceVersion())
+		_, err = drClient.Update(ctx, resource, metav1.UpdateOptions{})
+	} else {
+		s.log.Debug("patching resource")
+		err = s.patchResource(ctx, drClient, serverResource, resource)
+	}
+	if err != nil {
+		return fmt.Errorf("can't update resource:%v", err)
+	}
+	return nil
+}
+
+// DetectDrift compares the stack resources against the ones in the cluster. Resources which changed in the stack
+// since they got applied are not considered drifted, applying the stack takes care of them.
+func (s *Stack) DetectDrift(ctx context.Context) ([]Drift, error) {
+	s.log = logrus.WithField("stack", s.Name)
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(s.Discovery)
+
+	var drifts []Drift
+	for _, resource := range s.Resources {
+		resource = resource.DeepCopy()
+		s.prepareResource(resource)
+		drClient, err := s.clientForResource(mapper, *resource)
+		if err != nil {
+			return nil, err
+		}
+		serverResource, err := drClient.Get(ctx, resource.GetName(), metav1.GetOptions{})
+		if apiErrors.IsNotFound(err) {
+			drifts = append(drifts, Drift{Resource: resource})
+			continue
+		} else if err != nil {
+			return nil, fmt.Errorf("unknown api error: %s", err)
+		}
+		if serverResource.GetAnnotations()[ChecksumAnnotation] != resource.GetAnnotations()[ChecksumAnnotation] {
+			continue
+		}
+		if fields := diffResource(resource, serverResource); len(fields) > 0 {
+			drifts = append(drifts, Drift{Resource: resource, Live: serverResource, Fields: fields})
+		}
+	}
+
+	return drifts, nil
+}
+
+// Ready checks that the resources of the stack selected by the metadata are ready in the cluster
+func (s *Stack) Ready(ctx context.Context, metadata *StackMetadata) error {
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(s.Discovery)
+	for _, resource := range s.Resources {
+		if !metadata.selects(resource) {
+			continue
+		}
+		drClient, err := s.clientForResource(mapper, *resource)
+		if err != nil {
+			return err
+		}
+		serverResource, err := drClient.Get(ctx, resource.GetName(), metav1.GetOptions{})
+		if err != nil {
+			return fmt.Errorf("failed to get %s %s: %w", resource.GetKind(), namespacedName(resource), err)
+		}
+		if err := resourceReady(serverResource); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (s *Stack) keepResource(resource *unstructured.Unstructured) {
 	resourceID := generateResourceID(*resource)
 	logrus.WithField("stack", s.Name).Debugf("marking resource to be kept: %s", resourceID)
@@ -345,6 +428,37 @@ func (s *Stack) patchResource(ctx context.Context, drClient dynamic.ResourceInte
 	return nil
 }
 
+// serverSideApply applies the resource with server-side apply, taking over the fields owned by other managers
+func (s *Stack) serverSideApply(ctx context.Context, drClient dynamic.ResourceInterface, resource *unstructured.Unstructured) error {
+	data, err := resource.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal resource %s: %w", resource.GetName(), err)
+	}
+	force := true
+	_, err = drClient.Patch(ctx, resource.GetName(), types.ApplyPatchType, data, metav1.PatchOptions{
+		FieldManager: FieldManager,
+		Force:        &force,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to apply resource %s: %w", resource.GetName(), err)
+	}
+	return nil
+}
+
+// revertDrift patches the resource with all the fields defined in the stack, the last applied configuration
+// being identical to the stack the three-way patch would be empty
+func (s *Stack) revertDrift(ctx context.Context, drClient dynamic.ResourceInterface, resource *unstructured.Unstructured) error {
+	data, err := resource.MarshalJSON()
+	if err != nil {
+		return fmt.Errorf("failed to marshal resource %s: %w", resource.GetName(), err)
+	}
+	_, err = drClient.Patch(ctx, resource.GetName(), types.MergePatchType, data, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("failed to patch resource: %w", err)
+	}
+	return nil
+}
+
 func (s *Stack) prepareResource(resource *unstructured.Unstructured) {
 	checksum := resourceChecksum(resource)
 	lastAppliedConfig, _ := resource.MarshalJSON()
@@ -17,10 +17,17 @@ package applier
 
 import (
 	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
 	"time"
 
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/util/retry"
 
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 	"github.com/k0sproject/k0s/pkg/debounce"
 	"github.com/k0sproject/k0s/pkg/kubernetes"
 
@@ -28,20 +35,40 @@ import (
 	"github.com/sirupsen/logrus"
 )
 
+const (
+	dependencyPollInterval = 2 * time.Second
+	readinessPollInterval  = 5 * time.Second
+)
+
 // StackApplier handles each directory as a Stack and watches for changes
 type StackApplier struct {
 	Path string
 
-	fsWatcher *fsnotify.Watcher
-	applier   Applier
-	log       *logrus.Entry
+	fsWatcher          *fsnotify.Watcher
+	applier            Applier
+	kubeClientFactory  kubernetes.ClientFactoryInterface
+	driftCheckInterval time.Duration
+	log                *logrus.Entry
+
+	// mu serializes the applies triggered by the file watcher and by the drift check
+	mu sync.Mutex
+	// metadata of the latest successful apply
+	metadata *StackMetadata
+
+	// lookup returns the status of the stack with the given name
+	lookup func(name string) (StackStatus, bool)
+	// templateData returns the variables of the manifest templates, nil until the cluster config is known
+	templateData func() (*TemplateData, error)
+
+	status   StackStatus
+	statusMu sync.Mutex
 
 	ctx    context.Context
 	cancel context.CancelFunc
 }
 
 // NewStackApplier crates new stack applier to manage a stack
-func NewStackApplier(ctx context.Context, path string, kubeClientFactory kubernetes.ClientFactoryInterface) (*StackApplier, error) {
+func NewStackApplier(ctx context.Context, path string, kubeClientFactory kubernetes.ClientFactoryInterface, spec *v1beta1.ManifestsSpec) (*StackApplier, error) {
 	watcher, err := fsnotify.NewWatcher()
 	if err != nil {
 		return nil, err
@@ -55,10 +82,23 @@ func NewStackApplier(ctx context.Context, path string, kubeClientFactory kuberne
 	log.WithField("path", path).Debug("created stack applier")
 
 	sa := &StackApplier{
-		Path:      path,
-		fsWatcher: watcher,
-		applier:   applier,
-		log:       log,
+		Path:              path,
+		fsWatcher:         watcher,
+		applier:           applier,
+		kubeClientFactory: kubeClientFactory,
+		log:               log,
+		lookup:            func(string) (StackStatus, bool) { return StackStatus{}, false },
+		templateData:      func() (*TemplateData, error) { return nil, nil },
+		status: StackStatus{
+			Name:           applier.Name,
+			Phase:          StackPending,
+			LastTransition: time.Now(),
+		},
+	}
+	if spec != nil {
+		sa.applier.ServerSideApply = spec.ServerSideApply
+		sa.driftCheckInterval = spec.DriftCheckInterval.Duration
+		sa.applier.RevertDrift = sa.driftCheckInterval > 0
 	}
 
 	sa.ctx, sa.cancel = context.WithCancel(ctx)
@@ -74,24 +114,264 @@ func (s *StackApplier) Start() error {
 		Filter:  s.triggersApply,
 		Callback: func(fsnotify.Event) {
 			s.log.Debug("Debouncer triggering, applying...")
-			err := retry.OnError(retry.DefaultRetry, func(err error) bool {
-				return true
-			}, func() error {
-				return s.applier.Apply(s.ctx)
-			})
-			if err != nil {
-				s.log.WithError(err).Error("Failed to apply manifests")
-			}
+			s.apply()
 		},
 	}
 
 	// Send an artificial event to ensure that an initial apply will happen.
-	go func() { s.fsWatcher.Events <- fsnotify.Event{} }()
+	s.Reapply()
+
+	go s.runReadinessCheck()
+	if s.driftCheckInterval > 0 {
+		go s.runDriftCheck()
+	}
 
 	_ = debouncer.Run(s.ctx)
 	return nil
 }
 
+// Reapply triggers an apply of the stack, as if its files changed
+func (s *StackApplier) Reapply() {
+	go func() {
+		select {
+		case s.fsWatcher.Events <- fsnotify.Event{}:
+		case <-s.ctx.Done():
+		}
+	}()
+}
+
+// apply applies the stack once the stacks it depends on are ready
+func (s *StackApplier) apply() {
+	metadata, templateData, ok := s.waitForPrerequisites()
+	if !ok {
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.applier.TemplateData = templateData
+	s.setStatus(StackApplying, "")
+	err := retry.OnError(retry.DefaultRetry, func(err error) bool {
+		return true
+	}, func() error {
+		return s.applier.Apply(s.ctx)
+	})
+	if err != nil {
+		s.log.WithError(err).Error("Failed to apply manifests")
+		s.setStatus(StackFailed, err.Error())
+		return
+	}
+	s.metadata = metadata
+	s.setStatus(StackApplied, "")
+	s.checkReadiness()
+}
+
+// waitForPrerequisites loads the stack metadata and waits for the stacks it depends on to be ready, and for the
+// variables of its templates if it has any. The metadata gets reloaded while waiting, as the file watcher events
+// are only processed once the stack got applied.
+func (s *StackApplier) waitForPrerequisites() (*StackMetadata, *TemplateData, bool) {
+	for {
+		metadata, templateData, err := s.prerequisites()
+		if err != nil {
+			s.log.WithError(err).Error("Failed to prepare the stack")
+			s.setStatus(StackFailed, err.Error())
+		} else if metadata != nil {
+			return metadata, templateData, true
+		}
+
+		select {
+		case <-time.After(dependencyPollInterval):
+		case <-s.ctx.Done():
+			return nil, nil, false
+		}
+	}
+}
+
+// prerequisites returns the stack metadata and the template variables once the stack can be applied, or nil if it must wait
+func (s *StackApplier) prerequisites() (*StackMetadata, *TemplateData, error) {
+	metadata, err := loadMetadata(s.Path)
+	if err != nil {
+		return nil, nil, err
+	}
+	s.setDependsOn(metadata.DependsOn)
+	if waitingFor := s.unreadyDependency(metadata.DependsOn); waitingFor != "" {
+		s.setStatus(StackWaiting, waitingFor)
+		return nil, nil, nil
+	}
+
+	if !s.applier.HasTemplates() {
+		return metadata, nil, nil
+	}
+	templateData, err := s.templateData()
+	if err != nil {
+		return nil, nil, err
+	}
+	if templateData == nil {
+		s.setStatus(StackWaiting, "waiting for the cluster config to render the templates")
+		return nil, nil, nil
+	}
+	return metadata, templateData, nil
+}
+
+// unreadyDependency describes the first of the given stacks which isn't ready, if any
+func (s *StackApplier) unreadyDependency(dependsOn []string) string {
+	if cycle := s.dependencyCycle(dependsOn); len(cycle) > 0 {
+		return fmt.Sprintf("dependency cycle %s", strings.Join(cycle, " -> "))
+	}
+	for _, dep := range dependsOn {
+		status, ok := s.lookup(dep)
+		if !ok {
+			return fmt.Sprintf("waiting for stack %s, not found", dep)
+		}
+		if status.Phase != StackReady {
+			return fmt.Sprintf("waiting for stack %s, %s", dep, status.Phase)
+		}
+	}
+	return ""
+}
+
+// dependencyCycle returns the chain of dependencies leading back to the stack, if any
+func (s *StackApplier) dependencyCycle(dependsOn []string) []string {
+	visited := map[string]bool{}
+	var visit func(chain []string, dependsOn []string) []string
+	visit = func(chain []string, dependsOn []string) []string {
+		for _, dep := range dependsOn {
+			depChain := append(chain[:len(chain):len(chain)], dep)
+			if dep == s.applier.Name {
+				return depChain
+			}
+			if visited[dep] {
+				continue
+			}
+			visited[dep] = true
+			if status, ok := s.lookup(dep); ok {
+				if cycle := visit(depChain, status.DependsOn); cycle != nil {
+					return cycle
+				}
+			}
+		}
+		return nil
+	}
+	return visit([]string{s.applier.Name}, dependsOn)
+}
+
+// runReadinessCheck periodically checks if the resources of the applied stack got ready
+func (s *StackApplier) runReadinessCheck() {
+	ticker := time.NewTicker(readinessPollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.mu.Lock()
+			s.checkReadiness()
+			s.mu.Unlock()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+// checkReadiness moves the applied stack to ready once its resources are, the caller holding mu
+func (s *StackApplier) checkReadiness() {
+	if s.Status().Phase != StackApplied {
+		return
+	}
+	if err := s.applier.Ready(s.ctx, s.metadata); err != nil {
+		s.log.WithError(err).Debug("stack not ready")
+		s.setStatus(StackApplied, err.Error())
+		return
+	}
+	s.setStatus(StackReady, "")
+}
+
+// runDriftCheck periodically re-applies the stack if its resources got modified or deleted in the cluster
+func (s *StackApplier) runDriftCheck() {
+	ticker := time.NewTicker(s.driftCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.checkDrift()
+		case <-s.ctx.Done():
+			return
+		}
+	}
+}
+
+func (s *StackApplier) checkDrift() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if phase := s.Status().Phase; phase != StackApplied && phase != StackReady {
+		return
+	}
+
+	drifts, err := s.applier.CheckDrift(s.ctx)
+	for i := range drifts {
+		s.log.Warnf("drift detected: %s", &drifts[i])
+		s.reportDrift(&drifts[i], err)
+	}
+	if err != nil {
+		s.log.WithError(err).Error("Failed to check the manifests for drift")
+	}
+}
+
+// reportDrift creates an event about the drifted resource and the outcome of its re-apply
+func (s *StackApplier) reportDrift(drift *Drift, applyErr error) {
+	client, err := s.kubeClientFactory.GetClient()
+	if err != nil {
+		s.log.WithError(err).Error("failed to get kube client")
+		return
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		s.log.WithError(err).Error("failed to get hostname")
+	}
+
+	resource := drift.Resource
+	e := &corev1.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: "k0s.",
+		},
+		EventTime:      metav1.NowMicro(),
+		FirstTimestamp: metav1.Now(),
+		LastTimestamp:  metav1.Now(),
+		InvolvedObject: corev1.ObjectReference{
+			Kind:       resource.GetKind(),
+			APIVersion: resource.GetAPIVersion(),
+			Namespace:  resource.GetNamespace(),
+			Name:       resource.GetName(),
+		},
+		Action:              "DriftCorrection",
+		ReportingController: "k0s-controller",
+		ReportingInstance:   hostname,
+		Type:                corev1.EventTypeWarning,
+	}
+	if drift.Deleted() {
+		e.Reason = "ResourceDeleted"
+		e.Message = fmt.Sprintf("Resource of stack %s deleted from the cluster", s.applier.Name)
+	} else {
+		e.Reason = "ResourceModified"
+		e.Message = fmt.Sprintf("Resource of stack %s modified in the cluster, fields: %s", s.applier.Name, strings.Join(drift.Fields, ", "))
+		e.InvolvedObject.UID = drift.Live.GetUID()
+		e.InvolvedObject.ResourceVersion = drift.Live.GetResourceVersion()
+	}
+	if applyErr != nil {
+		e.Message += fmt.Sprintf(", re-applying failed: %v", applyErr)
+	} else {
+		e.Message += ", re-applied"
+	}
+
+	namespace := resource.GetNamespace()
+	if namespace == "" {
+		namespace = metav1.NamespaceDefault
+	}
+	if _, err := client.CoreV1().Events(namespace).Create(s.ctx, e, metav1.CreateOptions{}); err != nil {
+		s.log.WithError(err).Error("failed to create drift event")
+	}
+}
+
 // Stop stops the stack applier.
 func (s *StackApplier) Stop() {
 	s.log.WithField("stack", s.Path).Info("Stopping stack")
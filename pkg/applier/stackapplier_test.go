@@ -0,0 +1,168 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package applier
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	kubeutil "github.com/k0sproject/k0s/internal/testutil"
+)
+
+func TestLoadMetadata(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "operator")
+	require.NoError(t, os.Mkdir(dir, 0700))
+
+	metadata, err := loadMetadata(dir)
+	require.NoError(t, err)
+	assert.Empty(t, metadata.DependsOn)
+
+	writeMetadata := func(content string) {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, MetadataFile), []byte(content), 0600))
+	}
+	writeMetadata(`
+dependsOn: [crds]
+readiness:
+  - kind: Deployment
+    name: operator
+`)
+	metadata, err = loadMetadata(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"crds"}, metadata.DependsOn)
+	assert.Equal(t, []ReadinessCheck{{Kind: "Deployment", Name: "operator"}}, metadata.Readiness)
+
+	writeMetadata(`dependsOn: [operator]`)
+	_, err = loadMetadata(dir)
+	assert.ErrorContains(t, err, "stack `operator` can't depend on itself")
+
+	writeMetadata(`dependOn: [crds]`)
+	_, err = loadMetadata(dir)
+	assert.ErrorContains(t, err, "unknown field")
+}
+
+func TestResourceReady(t *testing.T) {
+	crd := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apiextensions.k8s.io/v1",
+		"kind":       "CustomResourceDefinition",
+		"metadata":   map[string]interface{}{"name": "foos.example.com"},
+	}}
+	assert.EqualError(t, resourceReady(crd), "CustomResourceDefinition foos.example.com not ready: not established")
+	crd.Object["status"] = map[string]interface{}{"conditions": []interface{}{
+		map[string]interface{}{"type": "NamesAccepted", "status": "True"},
+		map[string]interface{}{"type": "Established", "status": "True"},
+	}}
+	assert.NoError(t, resourceReady(crd))
+
+	deployment := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "apps/v1",
+		"kind":       "Deployment",
+		"metadata":   map[string]interface{}{"name": "operator", "namespace": "default", "generation": int64(2)},
+		"spec":       map[string]interface{}{"replicas": int64(2)},
+		"status":     map[string]interface{}{"observedGeneration": int64(1)},
+	}}
+	assert.EqualError(t, resourceReady(deployment), "Deployment default/operator not ready: generation 2 not observed yet")
+	deployment.Object["status"] = map[string]interface{}{"observedGeneration": int64(2), "updatedReplicas": int64(2), "availableReplicas": int64(1)}
+	assert.EqualError(t, resourceReady(deployment), "Deployment default/operator not ready: 1 of 2 replicas available")
+	deployment.Object["status"].(map[string]interface{})["availableReplicas"] = int64(2)
+	assert.NoError(t, resourceReady(deployment))
+
+	configMap := &unstructured.Unstructured{Object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"}}
+	assert.NoError(t, resourceReady(configMap))
+}
+
+func TestApplierReady(t *testing.T) {
+	dir := t.TempDir()
+	template := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: operator
+  namespace: default
+spec:
+  selector:
+    matchLabels:
+      app: operator
+  template:
+    metadata:
+      labels:
+        app: operator
+    spec:
+      containers:
+      - name: operator
+        image: operator:latest
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "operator.yaml"), []byte(template), 0400))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, MetadataFile), []byte("dependsOn: [crds]"), 0400))
+
+	fakes := kubeutil.NewFakeClientFactory()
+	fakes.RawDiscovery.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: appsv1.SchemeGroupVersion.String(),
+			APIResources: []metav1.APIResource{
+				{Name: "deployments", Namespaced: true, Kind: "Deployment", Verbs: []string{"get", "list", "delete", "create"}},
+			},
+		},
+	}
+
+	a := NewApplier(dir, fakes)
+	ctx := context.Background()
+	require.NoError(t, a.Apply(ctx), "the metadata file must not be applied")
+	metadata := &StackMetadata{}
+	assert.EqualError(t, a.Ready(ctx, metadata), "Deployment default/operator not ready: 0 of 1 replicas updated")
+
+	deployGV, _ := schema.ParseResourceArg("deployments.v1.apps")
+	deployment, err := a.client.Resource(*deployGV).Namespace("default").Get(ctx, "operator", metav1.GetOptions{})
+	require.NoError(t, err)
+	deployment.Object["status"] = map[string]interface{}{"updatedReplicas": int64(1), "availableReplicas": int64(1)}
+	_, err = a.client.Resource(*deployGV).Namespace("default").Update(ctx, deployment, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	assert.NoError(t, a.Ready(ctx, metadata))
+
+	// readiness checks not selecting the deployment
+	assert.NoError(t, a.Ready(ctx, &StackMetadata{Readiness: []ReadinessCheck{{Kind: "Deployment", Name: "other"}}}))
+}
+
+func TestStackApplierDependencies(t *testing.T) {
+	statuses := map[string]StackStatus{
+		"crds":     {Name: "crds", Phase: StackReady},
+		"operator": {Name: "operator", Phase: StackApplied, DependsOn: []string{"crds"}},
+		"cr":       {Name: "cr", Phase: StackWaiting, DependsOn: []string{"operator", "app"}},
+	}
+	newStackApplier := func(name string) *StackApplier {
+		return &StackApplier{
+			applier: Applier{Name: name},
+			lookup: func(name string) (StackStatus, bool) {
+				status, ok := statuses[name]
+				return status, ok
+			},
+		}
+	}
+
+	sa := newStackApplier("app")
+	assert.Empty(t, sa.unreadyDependency([]string{"crds"}))
+	assert.Equal(t, "waiting for stack operator, Applied", sa.unreadyDependency([]string{"crds", "operator"}))
+	assert.Equal(t, "waiting for stack missing, not found", sa.unreadyDependency([]string{"missing"}))
+	assert.Equal(t, "dependency cycle app -> cr -> app", sa.unreadyDependency([]string{"crds", "cr"}))
+}
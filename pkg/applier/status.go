@@ -0,0 +1,71 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package applier
+
+import (
+	"time"
+)
+
+// StackPhase is the phase of a manifest stack
+type StackPhase string
+
+const (
+	// StackPending is the phase of a stack not applied yet
+	StackPending StackPhase = "Pending"
+	// StackWaiting is the phase of a stack waiting for its dependencies to be ready, or for the variables of its templates
+	StackWaiting StackPhase = "Waiting"
+	// StackApplying is the phase of a stack being applied
+	StackApplying StackPhase = "Applying"
+	// StackApplied is the phase of an applied stack whose resources are not ready yet
+	StackApplied StackPhase = "Applied"
+	// StackReady is the phase of an applied stack whose resources are ready
+	StackReady StackPhase = "Ready"
+	// StackFailed is the phase of a stack which failed to apply
+	StackFailed StackPhase = "Failed"
+)
+
+// StackStatus is the status of a manifest stack
+type StackStatus struct {
+	Name           string
+	Phase          StackPhase
+	Message        string   `json:",omitempty"`
+	DependsOn      []string `json:",omitempty"`
+	LastTransition time.Time
+}
+
+// Status returns the status of the stack
+func (s *StackApplier) Status() StackStatus {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	return s.status
+}
+
+func (s *StackApplier) setStatus(phase StackPhase, message string) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	if s.status.Phase != phase {
+		s.log.Infof("stack %s", phase)
+		s.status.Phase = phase
+		s.status.LastTransition = time.Now()
+	}
+	s.status.Message = message
+}
+
+func (s *StackApplier) setDependsOn(dependsOn []string) {
+	s.statusMu.Lock()
+	defer s.statusMu.Unlock()
+	s.status.DependsOn = dependsOn
+}
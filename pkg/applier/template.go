@@ -0,0 +1,136 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package applier
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig"
+	"sigs.k8s.io/yaml"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+)
+
+// TemplateSuffix is the suffix of the manifest templates, rendered before being applied
+const TemplateSuffix = ".yaml.tpl"
+
+// TemplateData holds the variables available to the manifest templates
+type TemplateData struct {
+	// ClusterDomain is the cluster DNS domain, e.g. cluster.local
+	ClusterDomain string
+	// ServiceCIDR is the network range of the services
+	ServiceCIDR string
+	// PodCIDR is the network range of the pods
+	PodCIDR string
+	// DNSAddress is the service address of the cluster DNS
+	DNSAddress string
+	// APIAddress is the address of the Kubernetes API, the external one if configured
+	APIAddress string
+	// APIPort is the port of the Kubernetes API
+	APIPort int
+	// APIAddressURL is the URL of the Kubernetes API, the external one if configured
+	APIAddressURL string
+	// ImageRepository is the repository overriding the one of the system images, if configured
+	ImageRepository string
+	// Values are the user supplied values of spec.manifests.valuesFile
+	Values map[string]interface{}
+}
+
+// NewTemplateData collects the template variables from the node config and from the cluster-wide config
+func NewTemplateData(nodeConfig, clusterConfig *v1beta1.ClusterConfig) (*TemplateData, error) {
+	data := &TemplateData{
+		APIAddress:    nodeConfig.Spec.API.APIAddress(),
+		APIPort:       nodeConfig.Spec.API.Port,
+		APIAddressURL: nodeConfig.Spec.API.APIAddressURL(),
+		Values:        map[string]interface{}{},
+	}
+
+	// the node config only carries the service network, the defaults fill in the rest
+	defaults := v1beta1.DefaultNetwork()
+	network := nodeConfig.Spec.Network
+	if clusterConfig.Spec.Network != nil {
+		network = clusterConfig.Spec.Network
+	}
+	if network == nil {
+		network = defaults
+	}
+	data.ClusterDomain = network.ClusterDomain
+	if data.ClusterDomain == "" {
+		data.ClusterDomain = defaults.ClusterDomain
+	}
+	data.PodCIDR = network.PodCIDR
+	if data.PodCIDR == "" {
+		data.PodCIDR = defaults.PodCIDR
+	}
+	switch {
+	case network.ServiceCIDR != "":
+		data.ServiceCIDR = network.ServiceCIDR
+	case nodeConfig.Spec.Network != nil && nodeConfig.Spec.Network.ServiceCIDR != "":
+		data.ServiceCIDR = nodeConfig.Spec.Network.ServiceCIDR
+	default:
+		data.ServiceCIDR = defaults.ServiceCIDR
+	}
+	dnsAddress, err := (&v1beta1.Network{ServiceCIDR: data.ServiceCIDR}).DNSAddress()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the DNS address: %w", err)
+	}
+	data.DNSAddress = dnsAddress
+	if clusterConfig.Spec.Images != nil {
+		data.ImageRepository = clusterConfig.Spec.Images.Repository
+	}
+
+	if spec := nodeConfig.Spec.Manifests; spec != nil && spec.ValuesFile != "" {
+		values, err := os.ReadFile(spec.ValuesFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read the manifest values: %w", err)
+		}
+		if err := yaml.Unmarshal(values, &data.Values); err != nil {
+			return nil, fmt.Errorf("failed to parse the manifest values %s: %w", spec.ValuesFile, err)
+		}
+	}
+
+	return data, nil
+}
+
+// renderTemplate renders the manifest template file with the given variables
+func renderTemplate(file string, data *TemplateData) ([]byte, error) {
+	content, err := os.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	name := filepath.Base(file)
+	tpl, err := template.New(name).
+		Funcs(sprig.TxtFuncMap()).
+		Option("missingkey=error").
+		Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse template %s: %w", name, err)
+	}
+	var buf bytes.Buffer
+	if err := tpl.Execute(&buf, data); err != nil {
+		return nil, fmt.Errorf("failed to render template %s: %w", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+func isTemplate(file string) bool {
+	return strings.HasSuffix(file, TemplateSuffix)
+}
@@ -0,0 +1,142 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package applier
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	kubeutil "github.com/k0sproject/k0s/internal/testutil"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+)
+
+func TestNewTemplateData(t *testing.T) {
+	valuesFile := filepath.Join(t.TempDir(), "values.yaml")
+	require.NoError(t, os.WriteFile(valuesFile, []byte("replicas: 3\ningress:\n  host: example.com\n"), 0600))
+
+	nodeConfig := v1beta1.DefaultClusterConfig()
+	nodeConfig.Spec.API.Address = "10.0.0.1"
+	nodeConfig.Spec.Manifests = &v1beta1.ManifestsSpec{ValuesFile: valuesFile}
+	clusterConfig := v1beta1.DefaultClusterConfig()
+	clusterConfig.Spec.Network.ClusterDomain = "example.local"
+	clusterConfig.Spec.Images.Repository = "registry.example.com"
+
+	data, err := NewTemplateData(nodeConfig, clusterConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "example.local", data.ClusterDomain)
+	assert.Equal(t, "10.96.0.0/12", data.ServiceCIDR)
+	assert.Equal(t, "10.96.0.10", data.DNSAddress)
+	assert.Equal(t, "10.0.0.1", data.APIAddress)
+	assert.Equal(t, 6443, data.APIPort)
+	assert.Equal(t, "https://10.0.0.1:6443", data.APIAddressURL)
+	assert.Equal(t, "registry.example.com", data.ImageRepository)
+	assert.Equal(t, float64(3), data.Values["replicas"])
+
+	nodeConfig.Spec.Manifests.ValuesFile = filepath.Join(t.TempDir(), "missing.yaml")
+	_, err = NewTemplateData(nodeConfig, clusterConfig)
+	assert.ErrorContains(t, err, "failed to read the manifest values")
+}
+
+func TestApplierTemplates(t *testing.T) {
+	dir := t.TempDir()
+	template := `
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: template-test
+  namespace: kube-system
+data:
+  domain: {{ .ClusterDomain }}
+  host: {{ .Values.ingress.host | quote }}
+  replicas: {{ index .Values "replicas" | default 1 | quote }}
+`
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "cm.yaml.tpl"), []byte(template), 0400))
+
+	fakes := kubeutil.NewFakeClientFactory()
+	fakes.RawDiscovery.Resources = []*metav1.APIResourceList{
+		{
+			GroupVersion: corev1.SchemeGroupVersion.String(),
+			APIResources: []metav1.APIResource{
+				{Name: "configmaps", Namespaced: true, Kind: "ConfigMap", Verbs: []string{"get", "list", "delete", "create"}},
+			},
+		},
+	}
+
+	a := NewApplier(dir, fakes)
+	assert.True(t, a.HasTemplates())
+	ctx := context.Background()
+	assert.ErrorContains(t, a.Apply(ctx), "can't render cm.yaml.tpl without template data")
+
+	a.TemplateData = &TemplateData{ClusterDomain: "cluster.local", Values: map[string]interface{}{}}
+	assert.ErrorContains(t, a.Apply(ctx), `map has no entry for key "ingress"`)
+
+	a.TemplateData.Values["ingress"] = map[string]interface{}{"host": "example.com"}
+	require.NoError(t, a.Apply(ctx))
+	cmGV, _ := schema.ParseResourceArg("configmaps.v1.")
+	cm, err := a.client.Resource(*cmGV).Namespace("kube-system").Get(ctx, "template-test", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"domain": "cluster.local", "host": "example.com", "replicas": "1"}, cm.Object["data"])
+}
+
+func TestNewTemplateDataNetworkFallback(t *testing.T) {
+	nodeConfig := v1beta1.DefaultClusterConfig()
+	nodeConfig.Spec.Network = &v1beta1.Network{ServiceCIDR: "10.100.0.0/16"}
+	clusterConfig := v1beta1.DefaultClusterConfig()
+	clusterConfig.Spec.Network = nil
+
+	data, err := NewTemplateData(nodeConfig, clusterConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "cluster.local", data.ClusterDomain)
+	assert.Equal(t, "10.244.0.0/16", data.PodCIDR)
+	assert.Equal(t, "10.100.0.0/16", data.ServiceCIDR)
+	assert.Equal(t, "10.100.0.10", data.DNSAddress)
+
+	nodeConfig.Spec.Network = nil
+	data, err = NewTemplateData(nodeConfig, clusterConfig)
+	require.NoError(t, err)
+	assert.Equal(t, "10.96.0.0/12", data.ServiceCIDR)
+	assert.Equal(t, "10.96.0.10", data.DNSAddress)
+}
+
+func TestManagerReloadValues(t *testing.T) {
+	valuesFile := filepath.Join(t.TempDir(), "values.yaml")
+	require.NoError(t, os.WriteFile(valuesFile, []byte("replicas: 3\n"), 0600))
+
+	nodeConfig := v1beta1.DefaultClusterConfig()
+	nodeConfig.Spec.Manifests = &v1beta1.ManifestsSpec{ValuesFile: valuesFile}
+	m := &Manager{NodeConfig: nodeConfig, log: logrus.WithField("component", "applier-manager")}
+
+	// nothing to render before the cluster config is known
+	m.reloadValues()
+	assert.Nil(t, m.lastTemplateData)
+
+	m.SetClusterConfig(v1beta1.DefaultClusterConfig())
+	require.NotNil(t, m.lastTemplateData)
+	assert.Equal(t, float64(3), m.lastTemplateData.Values["replicas"])
+
+	require.NoError(t, os.WriteFile(valuesFile, []byte("replicas: 5\n"), 0600))
+	m.reloadValues()
+	assert.Equal(t, float64(5), m.lastTemplateData.Values["replicas"])
+}
@@ -23,7 +23,9 @@ import (
 	"io"
 	"os"
 	"path"
+	"path/filepath"
 
+	"github.com/k0sproject/k0s/internal/pkg/dir"
 	"github.com/k0sproject/k0s/internal/pkg/file"
 	"github.com/sirupsen/logrus"
 )
@@ -74,3 +76,16 @@ func (c configurationStep) Restore(restoreFrom, restoreTo string) error {
 	logrus.Infof("restoring from `%s` to `%s`", objectPathInArchive, c.restoredConfigPath)
 	return file.Copy(objectPathInArchive, c.restoredConfigPath)
 }
+
+func (c configurationStep) ValidateRestore(restoreFrom string) error {
+	if c.restoredConfigPath == "-" || c.restoredConfigPath == "" {
+		return nil
+	}
+	if !dir.IsDirectory(filepath.Dir(c.restoredConfigPath)) {
+		return fmt.Errorf("the directory of the restored k0s.yaml `%s` does not exist", c.restoredConfigPath)
+	}
+	if file.Exists(c.restoredConfigPath) {
+		logrus.Warnf("restored k0s.yaml will overwrite `%s`", c.restoredConfigPath)
+	}
+	return nil
+}
@@ -0,0 +1,170 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/k0sproject/k0s/internal/pkg/file"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+)
+
+// Destination is a location the backup archives are saved to and fetched from
+type Destination interface {
+	// Save stores the archive read from the given reader under the given name
+	Save(ctx context.Context, name string, archive io.Reader) error
+	// Open fetches the archive stored under the given name
+	Open(ctx context.Context, name string) (io.ReadCloser, error)
+	// String describes the destination for logging purposes
+	String() string
+}
+
+// ArchiveStore is a Destination which is able to list and remove the stored archives
+type ArchiveStore interface {
+	Destination
+	// List returns all the backup archives found in the store
+	List(ctx context.Context) ([]Archive, error)
+	// Remove deletes the archive stored under the given name
+	Remove(ctx context.Context, name string) error
+}
+
+// Archive describes a backup archive found in an ArchiveStore
+type Archive struct {
+	Name    string
+	ModTime time.Time
+}
+
+// LocalDestination stores backup archives in a local directory
+type LocalDestination struct {
+	Dir string
+}
+
+var _ ArchiveStore = (*LocalDestination)(nil)
+
+// NewLocalDestination creates a destination for the given directory
+func NewLocalDestination(dir string) *LocalDestination {
+	return &LocalDestination{Dir: dir}
+}
+
+func (d *LocalDestination) String() string {
+	return d.Dir
+}
+
+// Save writes the archive into the directory via a temporary file, so that no
+// partially written archives are left behind
+func (d *LocalDestination) Save(_ context.Context, name string, archive io.Reader) error {
+	tmp, err := os.CreateTemp(d.Dir, "."+name+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	if _, err := io.Copy(tmp, archive); err != nil {
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(d.Dir, name))
+}
+
+func (d *LocalDestination) Open(_ context.Context, name string) (io.ReadCloser, error) {
+	return os.Open(filepath.Join(d.Dir, name))
+}
+
+func (d *LocalDestination) List(_ context.Context) ([]Archive, error) {
+	entries, err := os.ReadDir(d.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	var archives []Archive
+	for _, entry := range entries {
+		if !entry.Type().IsRegular() || !isArchiveName(entry.Name()) {
+			continue
+		}
+		info, err := entry.Info()
+		if err != nil {
+			return nil, fmt.Errorf("failed to stat backup archive `%s`: %v", entry.Name(), err)
+		}
+		archives = append(archives, Archive{Name: info.Name(), ModTime: info.ModTime()})
+	}
+	return archives, nil
+}
+
+func (d *LocalDestination) Remove(_ context.Context, name string) error {
+	return os.Remove(filepath.Join(d.Dir, name))
+}
+
+// StreamDestination writes archives to and reads archives from standard streams
+type StreamDestination struct {
+	In  io.Reader
+	Out io.Writer
+}
+
+var _ Destination = (*StreamDestination)(nil)
+
+// NewStdioDestination creates a destination writing to stdout and reading from stdin
+func NewStdioDestination() *StreamDestination {
+	return &StreamDestination{In: os.Stdin, Out: os.Stdout}
+}
+
+func (d *StreamDestination) String() string {
+	return "-"
+}
+
+func (d *StreamDestination) Save(_ context.Context, _ string, archive io.Reader) error {
+	_, err := io.Copy(d.Out, archive)
+	return err
+}
+
+func (d *StreamDestination) Open(_ context.Context, _ string) (io.ReadCloser, error) {
+	return io.NopCloser(d.In), nil
+}
+
+// NewSource returns the destination to read the backup archive given on the
+// command line from, along with the archive's name in that destination. The
+// archive is read from stdin for "-", and from the given S3 bucket if set.
+func NewSource(ctx context.Context, archivePath string, s3 *v1beta1.BackupS3Destination) (Destination, string, error) {
+	switch {
+	case s3.Bucket != "":
+		if errs := s3.Validate(); len(errs) > 0 {
+			return nil, "", errs[0]
+		}
+		// Credentials secrets can't be used, the API may not be available
+		src, err := NewS3Destination(ctx, s3, nil)
+		if err != nil {
+			return nil, "", err
+		}
+		return src, archivePath, nil
+	case archivePath == "-":
+		return NewStdioDestination(), archivePath, nil
+	default:
+		if !file.Exists(archivePath) {
+			return nil, "", fmt.Errorf("given file %s does not exist", archivePath)
+		}
+		return NewLocalDestination(filepath.Dir(archivePath)), filepath.Base(archivePath), nil
+	}
+}
@@ -0,0 +1,75 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLocalDestination(t *testing.T) {
+	ctx := context.TODO()
+	dir := t.TempDir()
+	dest := NewLocalDestination(dir)
+
+	require.NoError(t, dest.Save(ctx, "k0s_backup_1.tar.gz", strings.NewReader("archive")))
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	require.Len(t, entries, 1, "temporary files should have been cleaned up")
+
+	archives, err := dest.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, archives, 1)
+	assert.Equal(t, "k0s_backup_1.tar.gz", archives[0].Name)
+
+	r, err := dest.Open(ctx, "k0s_backup_1.tar.gz")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	assert.NoError(t, r.Close())
+	require.NoError(t, err)
+	assert.Equal(t, "archive", string(data))
+
+	require.NoError(t, dest.Remove(ctx, "k0s_backup_1.tar.gz"))
+	archives, err = dest.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, archives)
+}
+
+func TestStreamDestination(t *testing.T) {
+	ctx := context.TODO()
+	var out bytes.Buffer
+	dest := &StreamDestination{In: strings.NewReader("input"), Out: &out}
+
+	require.NoError(t, dest.Save(ctx, "ignored", strings.NewReader("archive")))
+	assert.Equal(t, "archive", out.String())
+
+	r, err := dest.Open(ctx, "ignored")
+	require.NoError(t, err)
+	data, err := io.ReadAll(r)
+	require.NoError(t, err)
+	assert.Equal(t, "input", string(data))
+}
@@ -0,0 +1,148 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"crypto/ed25519"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	"filippo.io/age"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+)
+
+// encryptedArchiveSuffix is appended to the names of encrypted archives
+const encryptedArchiveSuffix = ".age"
+
+// Encryption holds the age recipients used to encrypt backup archives and the
+// age identities used to decrypt them
+type Encryption struct {
+	Recipients []age.Recipient
+	Identities []age.Identity
+}
+
+// NewEncryption creates an Encryption for the given spec.backup.encryption settings
+func NewEncryption(spec *v1beta1.BackupEncryption) (*Encryption, error) {
+	if spec.PassphraseFile != "" {
+		return NewPassphraseEncryption(spec.PassphraseFile)
+	}
+	return NewRecipientsEncryption(spec.Recipients)
+}
+
+// NewPassphraseEncryption creates an Encryption for the passphrase read from the given file
+func NewPassphraseEncryption(passphraseFile string) (*Encryption, error) {
+	data, err := os.ReadFile(passphraseFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read passphrase file: %v", err)
+	}
+	passphrase := strings.TrimRight(string(data), "\r\n")
+	if passphrase == "" {
+		return nil, fmt.Errorf("passphrase file %s is empty", passphraseFile)
+	}
+
+	recipient, err := age.NewScryptRecipient(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	identity, err := age.NewScryptIdentity(passphrase)
+	if err != nil {
+		return nil, err
+	}
+	return &Encryption{
+		Recipients: []age.Recipient{recipient},
+		Identities: []age.Identity{identity},
+	}, nil
+}
+
+// NewRecipientsEncryption creates an Encryption for the given age X25519 public keys (age1...)
+func NewRecipientsEncryption(recipients []string) (*Encryption, error) {
+	e := &Encryption{}
+	for _, r := range recipients {
+		recipient, err := age.ParseX25519Recipient(r)
+		if err != nil {
+			return nil, fmt.Errorf("invalid age recipient %q: %v", r, err)
+		}
+		e.Recipients = append(e.Recipients, recipient)
+	}
+	return e, nil
+}
+
+// NewIdentitiesEncryption creates an Encryption for the age identities read from the given file
+func NewIdentitiesEncryption(identityFile string) (*Encryption, error) {
+	f, err := os.Open(identityFile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read age identity file: %v", err)
+	}
+	defer f.Close()
+	identities, err := age.ParseIdentities(f)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse age identity file: %v", err)
+	}
+	return &Encryption{Identities: identities}, nil
+}
+
+// LoadSigningKey reads a PEM encoded PKCS #8 Ed25519 private key from the given file
+func LoadSigningKey(path string) (ed25519.PrivateKey, error) {
+	block, err := readPEM(path, "PRIVATE KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse signing key %s: %v", path, err)
+	}
+	signingKey, ok := key.(ed25519.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("signing key %s is not an Ed25519 key", path)
+	}
+	return signingKey, nil
+}
+
+// LoadVerifyKey reads a PEM encoded PKIX Ed25519 public key from the given file
+func LoadVerifyKey(path string) (ed25519.PublicKey, error) {
+	block, err := readPEM(path, "PUBLIC KEY")
+	if err != nil {
+		return nil, err
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse verification key %s: %v", path, err)
+	}
+	verifyKey, ok := key.(ed25519.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("verification key %s is not an Ed25519 key", path)
+	}
+	return verifyKey, nil
+}
+
+func readPEM(path string, blockType string) (*pem.Block, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != blockType {
+		return nil, fmt.Errorf("%s does not contain a PEM encoded %s", path, blockType)
+	}
+	return block, nil
+}
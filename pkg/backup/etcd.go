@@ -24,6 +24,7 @@ import (
 	"os"
 	"path/filepath"
 
+	"github.com/sirupsen/logrus"
 	"go.etcd.io/etcd/client/v3/snapshot"
 	"go.uber.org/zap"
 
@@ -100,3 +101,18 @@ func (e etcdStep) Restore(restoreFrom, _ string) error {
 
 	return nil
 }
+
+func (e etcdStep) ValidateRestore(restoreFrom string) error {
+	snapshotPath := filepath.Join(restoreFrom, etcdBackup)
+	if !file.Exists(snapshotPath) {
+		return fmt.Errorf("etcd snapshot not found at %s", snapshotPath)
+	}
+
+	// checks the snapshot's integrity hash
+	status, err := utilsnapshot.NewV3(zap.NewNop()).Status(snapshotPath)
+	if err != nil {
+		return fmt.Errorf("invalid etcd snapshot: %v", err)
+	}
+	logrus.Infof("etcd snapshot at revision %d with %d keys will be restored to `%s`", status.Revision, status.TotalKey, e.etcdDataDir)
+	return nil
+}
@@ -0,0 +1,229 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/k0sproject/k0s/internal/pkg/file"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/etcd"
+)
+
+const (
+	externalEtcdBackup = "etcd-keys.json"
+
+	// externalEtcdPageSize is the number of keys fetched per request
+	externalEtcdPageSize = 500
+	// externalEtcdTxnSize is the number of keys written per transaction, bounded by etcd's max-txn-ops
+	externalEtcdTxnSize = 100
+)
+
+// etcdKeyValue is a single key of an external etcd cluster
+type etcdKeyValue struct {
+	Key   []byte `json:"key"`
+	Value []byte `json:"value"`
+}
+
+// externalEtcdStep backs up and restores the keys below the configured etcd
+// prefix of an external etcd cluster. External clusters may be shared, so
+// other keys are left alone and no snapshot of the whole cluster is taken.
+type externalEtcdStep struct {
+	certRootDir string
+	etcdCertDir string
+	etcdConfig  *v1beta1.EtcdConfig
+	tmpDir      string
+}
+
+func newExternalEtcdStep(tmpDir string, certRootDir string, etcdCertDir string, etcdConfig *v1beta1.EtcdConfig) *externalEtcdStep {
+	return &externalEtcdStep{tmpDir: tmpDir, certRootDir: certRootDir, etcdCertDir: etcdCertDir, etcdConfig: etcdConfig}
+}
+
+func (e externalEtcdStep) Name() string {
+	return fmt.Sprintf("external etcd prefix %s", e.keyPrefix())
+}
+
+// keyPrefix returns the prefix kube-apiserver stores its keys under for the configured etcdPrefix
+func (e externalEtcdStep) keyPrefix() string {
+	return path.Join("/", e.etcdConfig.ExternalCluster.EtcdPrefix) + "/"
+}
+
+func (e externalEtcdStep) client() (*clientv3.Client, error) {
+	etcdClient, err := etcd.NewClient(e.certRootDir, e.etcdCertDir, e.etcdConfig)
+	if err != nil {
+		return nil, err
+	}
+	return clientv3.New(*etcdClient.Config)
+}
+
+func (e externalEtcdStep) Backup() (StepResult, error) {
+	cli, err := e.client()
+	if err != nil {
+		return StepResult{}, err
+	}
+	defer cli.Close()
+
+	path := filepath.Join(e.tmpDir, externalEtcdBackup)
+	f, err := os.Create(path)
+	if err != nil {
+		return StepResult{}, fmt.Errorf("failed to create external etcd backup: %v", err)
+	}
+	defer f.Close()
+
+	count, err := dumpEtcdKeys(context.TODO(), cli.KV, e.keyPrefix(), f)
+	if err != nil {
+		return StepResult{}, fmt.Errorf("failed to back-up external etcd: %v", err)
+	}
+	if err := f.Close(); err != nil {
+		return StepResult{}, fmt.Errorf("failed to back-up external etcd: %v", err)
+	}
+	logrus.Infof("exported %d keys below %s from external etcd", count, e.keyPrefix())
+	return StepResult{filesForBackup: []string{path}}, nil
+}
+
+func (e externalEtcdStep) Restore(restoreFrom, _ string) error {
+	snapshotPath := filepath.Join(restoreFrom, externalEtcdBackup)
+	if !file.Exists(snapshotPath) {
+		return fmt.Errorf("external etcd snapshot not found at %s", snapshotPath)
+	}
+	f, err := os.Open(snapshotPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	cli, err := e.client()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+
+	logrus.Infof("restoring keys below %s to external etcd", e.keyPrefix())
+	count, err := restoreEtcdKeys(context.TODO(), cli.KV, e.keyPrefix(), f)
+	if err != nil {
+		return fmt.Errorf("failed to restore external etcd: %v", err)
+	}
+	logrus.Infof("restored %d keys to external etcd", count)
+	return nil
+}
+
+func (e externalEtcdStep) ValidateRestore(restoreFrom string) error {
+	snapshotPath := filepath.Join(restoreFrom, externalEtcdBackup)
+	count, err := countJSONObjects(snapshotPath, &etcdKeyValue{})
+	if err != nil {
+		return fmt.Errorf("invalid external etcd snapshot: %v", err)
+	}
+
+	cli, err := e.client()
+	if err != nil {
+		return err
+	}
+	defer cli.Close()
+	for _, endpoint := range cli.Endpoints() {
+		ctx, cancel := context.WithTimeout(context.TODO(), 5*time.Second)
+		_, err := cli.Status(ctx, endpoint)
+		cancel()
+		if err != nil {
+			return fmt.Errorf("can't reach external etcd endpoint %s: %v", endpoint, err)
+		}
+	}
+	logrus.Infof("%d keys will be restored below %s to external etcd", count, e.keyPrefix())
+	return nil
+}
+
+// dumpEtcdKeys writes all the keys below prefix as a stream of JSON objects,
+// reading them page by page at a single revision
+func dumpEtcdKeys(ctx context.Context, kv clientv3.KV, prefix string, w io.Writer) (int, error) {
+	enc := json.NewEncoder(w)
+	end := clientv3.GetPrefixRangeEnd(prefix)
+	key := prefix
+	var rev int64
+	count := 0
+	for {
+		opts := []clientv3.OpOption{clientv3.WithRange(end), clientv3.WithLimit(externalEtcdPageSize)}
+		if rev != 0 {
+			opts = append(opts, clientv3.WithRev(rev))
+		}
+		resp, err := kv.Get(ctx, key, opts...)
+		if err != nil {
+			return count, err
+		}
+		if rev == 0 {
+			rev = resp.Header.Revision
+		}
+		for _, kv := range resp.Kvs {
+			if err := enc.Encode(&etcdKeyValue{Key: kv.Key, Value: kv.Value}); err != nil {
+				return count, err
+			}
+			count++
+		}
+		if !resp.More || len(resp.Kvs) == 0 {
+			return count, nil
+		}
+		key = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+}
+
+// restoreEtcdKeys replaces all the keys below prefix with the ones read from r.
+// Leases aren't part of the backup, so restored keys never expire.
+func restoreEtcdKeys(ctx context.Context, kv clientv3.KV, prefix string, r io.Reader) (int, error) {
+	if _, err := kv.Delete(ctx, prefix, clientv3.WithPrefix()); err != nil {
+		return 0, fmt.Errorf("failed to delete existing keys: %v", err)
+	}
+
+	dec := json.NewDecoder(r)
+	ops := make([]clientv3.Op, 0, externalEtcdTxnSize)
+	count := 0
+	flush := func() error {
+		if len(ops) == 0 {
+			return nil
+		}
+		if _, err := kv.Txn(ctx).Then(ops...).Commit(); err != nil {
+			return err
+		}
+		count += len(ops)
+		ops = ops[:0]
+		return nil
+	}
+	for {
+		var item etcdKeyValue
+		if err := dec.Decode(&item); err == io.EOF {
+			break
+		} else if err != nil {
+			return count, fmt.Errorf("failed to read external etcd backup: %v", err)
+		}
+		ops = append(ops, clientv3.OpPut(string(item.Key), string(item.Value)))
+		if len(ops) == externalEtcdTxnSize {
+			if err := flush(); err != nil {
+				return count, err
+			}
+		}
+	}
+	return count, flush()
+}
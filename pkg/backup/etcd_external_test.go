@@ -0,0 +1,89 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/mvccpb"
+	clientv3 "go.etcd.io/etcd/client/v3"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+)
+
+// fakeKV serves ranged gets from a sorted set of keys
+type fakeKV struct {
+	clientv3.KV
+	keys []string
+	revs []int64
+}
+
+func (f *fakeKV) Get(_ context.Context, key string, opts ...clientv3.OpOption) (*clientv3.GetResponse, error) {
+	op := clientv3.OpGet(key, opts...)
+	f.revs = append(f.revs, op.Rev())
+	resp := &clientv3.GetResponse{Header: &etcdserverpb.ResponseHeader{Revision: 7}}
+	for _, k := range f.keys {
+		if k < key || k >= string(op.RangeBytes()) {
+			continue
+		}
+		if int64(len(resp.Kvs)) == externalEtcdPageSize {
+			resp.More = true
+			break
+		}
+		resp.Kvs = append(resp.Kvs, &mvccpb.KeyValue{Key: []byte(k), Value: []byte("v" + k)})
+	}
+	return resp, nil
+}
+
+func TestDumpEtcdKeys(t *testing.T) {
+	kv := &fakeKV{keys: []string{"/other/key", "/registry-other/key"}}
+	for i := 0; i < externalEtcdPageSize+10; i++ {
+		kv.keys = append(kv.keys, fmt.Sprintf("/registry/pods/%04d", i))
+	}
+	sort.Strings(kv.keys)
+
+	var buf bytes.Buffer
+	count, err := dumpEtcdKeys(context.TODO(), kv, "/registry/", &buf)
+	require.NoError(t, err)
+	assert.Equal(t, externalEtcdPageSize+10, count)
+	assert.Equal(t, []int64{0, 7}, kv.revs, "following pages should be read at the first page's revision")
+
+	dec := json.NewDecoder(&buf)
+	for i := 0; i < count; i++ {
+		var item etcdKeyValue
+		require.NoError(t, dec.Decode(&item))
+		assert.True(t, strings.HasPrefix(string(item.Key), "/registry/pods/"), string(item.Key))
+		assert.Equal(t, "v"+string(item.Key), string(item.Value))
+	}
+	assert.False(t, dec.More())
+}
+
+func TestExternalEtcdKeyPrefix(t *testing.T) {
+	step := newExternalEtcdStep("", "", "", &v1beta1.EtcdConfig{ExternalCluster: &v1beta1.ExternalCluster{EtcdPrefix: "k0s-tenant"}})
+	assert.Equal(t, "/k0s-tenant/", step.keyPrefix())
+}
@@ -83,6 +83,19 @@ func (d FileSystemStep) Restore(restoreFrom, restoreTo string) error {
 	return file.Copy(objectPathInArchive, objectPathInRestored)
 }
 
+func (d FileSystemStep) ValidateRestore(restoreFrom string) error {
+	_, childName := path.Split(d.path)
+	objectPathInArchive := path.Join(restoreFrom, childName)
+	if _, err := os.Stat(objectPathInArchive); os.IsNotExist(err) {
+		logrus.Debugf("Path `%s` not found in the archive, it will be skipped", objectPathInArchive)
+		return nil
+	} else if err != nil {
+		return err
+	}
+	logrus.Infof("`%s` will be restored", childName)
+	return nil
+}
+
 // NewFilesystemStep constructor
 func NewFilesystemStep(path string) FileSystemStep {
 	return FileSystemStep{path: path}
@@ -0,0 +1,79 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"fmt"
+
+	"github.com/spf13/pflag"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+)
+
+// GetS3FlagSet returns the flags configuring an S3 destination for the backup and restore commands
+func GetS3FlagSet(s3 *v1beta1.BackupS3Destination) *pflag.FlagSet {
+	flagset := &pflag.FlagSet{}
+	flagset.StringVar(&s3.Endpoint, "s3-endpoint", "", "endpoint of the S3-compatible object storage, e.g. minio.example.com:9000")
+	flagset.StringVar(&s3.Bucket, "s3-bucket", "", "S3 bucket to use for the backup archives, enables the S3 destination")
+	flagset.StringVar(&s3.Prefix, "s3-prefix", "", "prefix prepended to the backup archive object names")
+	flagset.StringVar(&s3.Region, "s3-region", "", "region of the S3 bucket")
+	flagset.BoolVar(&s3.Insecure, "s3-insecure", false, "use plain HTTP to talk to the S3 endpoint")
+	flagset.StringVar(&s3.CAFile, "s3-ca-file", "", "path to the CA certificate(s) used to verify the S3 endpoint")
+	flagset.StringVar(&s3.CredentialsFile, "s3-credentials-file", "", "path to an AWS shared credentials file holding the S3 credentials")
+	flagset.StringVar(&s3.CredentialsSecret, "s3-credentials-secret", "", "name of a Secret in the kube-system namespace holding the S3 credentials")
+	return flagset
+}
+
+// DecryptionOptions configure how backup archives are decrypted and verified when they are read
+type DecryptionOptions struct {
+	PassphraseFile string
+	IdentityFile   string
+	VerifyKeyFile  string
+}
+
+// GetDecryptionFlagSet returns the flags configuring the decryption and verification of backup archives
+func GetDecryptionFlagSet(o *DecryptionOptions) *pflag.FlagSet {
+	flagset := &pflag.FlagSet{}
+	flagset.StringVar(&o.PassphraseFile, "encryption-passphrase-file", "", "decrypt the backup archive with the passphrase read from the given file")
+	flagset.StringVar(&o.IdentityFile, "encryption-identity-file", "", "decrypt the backup archive with the age identities read from the given file")
+	flagset.StringVar(&o.VerifyKeyFile, "verify-key", "", "require the backup archive manifest to be signed by the PEM encoded Ed25519 public key read from the given file")
+	return flagset
+}
+
+// Apply configures the given manager according to the options
+func (o *DecryptionOptions) Apply(mgr *Manager) error {
+	var err error
+	switch {
+	case o.PassphraseFile != "" && o.IdentityFile != "":
+		return fmt.Errorf("only one of --encryption-passphrase-file and --encryption-identity-file may be given")
+	case o.PassphraseFile != "":
+		mgr.Encryption, err = NewPassphraseEncryption(o.PassphraseFile)
+	case o.IdentityFile != "":
+		mgr.Encryption, err = NewIdentitiesEncryption(o.IdentityFile)
+	}
+	if err != nil {
+		return err
+	}
+	if o.VerifyKeyFile != "" {
+		if mgr.VerifyKey, err = LoadVerifyKey(o.VerifyKeyFile); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,315 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/go-sql-driver/mysql"
+	"github.com/sirupsen/logrus"
+
+	// register the postgres driver
+	_ "github.com/lib/pq"
+
+	"github.com/k0sproject/k0s/internal/pkg/file"
+)
+
+const (
+	kineSQLBackup = "kine-sql-backup.json"
+
+	// kineDefaultDatabase is the database kine uses if the datasource doesn't name one
+	kineDefaultDatabase = "kubernetes"
+
+	kineSelectRows = "SELECT id, name, created, deleted, create_revision, prev_revision, lease, value, old_value FROM kine ORDER BY id"
+)
+
+// kineRow is a single row of the kine table
+type kineRow struct {
+	ID             int64  `json:"id"`
+	Name           string `json:"name"`
+	Created        int64  `json:"created"`
+	Deleted        int64  `json:"deleted"`
+	CreateRevision int64  `json:"createRevision"`
+	PrevRevision   int64  `json:"prevRevision"`
+	Lease          int64  `json:"lease"`
+	Value          []byte `json:"value"`
+	OldValue       []byte `json:"oldValue"`
+}
+
+// kineDialect holds the SQL flavour specifics of a kine backend
+type kineDialect struct {
+	driver string
+	// createTable mirrors kine's own table definition, kine adds the indexes when it starts
+	createTable string
+	insertRow   string
+	// afterRestore is run once all the rows are restored, if set
+	afterRestore string
+}
+
+var (
+	kineMySQLDialect = &kineDialect{
+		driver: "mysql",
+		createTable: `CREATE TABLE IF NOT EXISTS kine (
+			id BIGINT UNSIGNED AUTO_INCREMENT,
+			name VARCHAR(630) CHARACTER SET ascii,
+			created INTEGER,
+			deleted INTEGER,
+			create_revision BIGINT UNSIGNED,
+			prev_revision BIGINT UNSIGNED,
+			lease INTEGER,
+			value MEDIUMBLOB,
+			old_value MEDIUMBLOB,
+			PRIMARY KEY (id)
+		)`,
+		insertRow: "INSERT INTO kine (id, name, created, deleted, create_revision, prev_revision, lease, value, old_value) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)",
+	}
+
+	kinePostgresDialect = &kineDialect{
+		driver: "postgres",
+		createTable: `CREATE TABLE IF NOT EXISTS kine (
+			id SERIAL PRIMARY KEY,
+			name VARCHAR(630),
+			created INTEGER,
+			deleted INTEGER,
+			create_revision INTEGER,
+			prev_revision INTEGER,
+			lease INTEGER,
+			value bytea,
+			old_value bytea
+		)`,
+		insertRow: "INSERT INTO kine (id, name, created, deleted, create_revision, prev_revision, lease, value, old_value) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)",
+		// rows are inserted with explicit ids, so the sequence has to catch up
+		afterRestore: "SELECT setval(pg_get_serial_sequence('kine', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM kine",
+	}
+)
+
+// kineSQLStep backs up and restores the kine table of a MySQL or PostgreSQL database
+type kineSQLStep struct {
+	dialect *kineDialect
+	dsn     string
+	tmpDir  string
+}
+
+func newKineSQLStep(tmpDir string, dataSource string) (*kineSQLStep, error) {
+	dialect, dsn, err := parseKineDataSource(dataSource)
+	if err != nil {
+		return nil, err
+	}
+	return &kineSQLStep{dialect: dialect, dsn: dsn, tmpDir: tmpDir}, nil
+}
+
+// parseKineDataSource returns the SQL dialect and the driver specific DSN for
+// the given kine datasource, using kine's default database if none is given
+func parseKineDataSource(dataSource string) (*kineDialect, string, error) {
+	switch {
+	case strings.HasPrefix(dataSource, "mysql://"):
+		cfg, err := mysql.ParseDSN(strings.TrimPrefix(dataSource, "mysql://"))
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse kine datasource: %v", err)
+		}
+		if cfg.DBName == "" {
+			cfg.DBName = kineDefaultDatabase
+		}
+		return kineMySQLDialect, cfg.FormatDSN(), nil
+	case strings.HasPrefix(dataSource, "postgres://"), strings.HasPrefix(dataSource, "postgresql://"):
+		u, err := url.Parse(dataSource)
+		if err != nil {
+			return nil, "", fmt.Errorf("failed to parse kine datasource: %v", err)
+		}
+		if u.Path == "" || u.Path == "/" {
+			u.Path = "/" + kineDefaultDatabase
+		}
+		return kinePostgresDialect, u.String(), nil
+	default:
+		return nil, "", errors.New("unsupported kine datasource")
+	}
+}
+
+func (s *kineSQLStep) Name() string {
+	return fmt.Sprintf("kine %s database", s.dialect.driver)
+}
+
+func (s *kineSQLStep) Backup() (StepResult, error) {
+	db, err := sql.Open(s.dialect.driver, s.dsn)
+	if err != nil {
+		return StepResult{}, err
+	}
+	defer db.Close()
+
+	path := filepath.Join(s.tmpDir, kineSQLBackup)
+	f, err := os.Create(path)
+	if err != nil {
+		return StepResult{}, fmt.Errorf("failed to create kine backup: %v", err)
+	}
+	defer f.Close()
+
+	logrus.Debugf("exporting kine %s database to %v", s.dialect.driver, path)
+	count, err := dumpKineRows(context.TODO(), db, f)
+	if err != nil {
+		return StepResult{}, fmt.Errorf("failed to back-up kine %s database: %v", s.dialect.driver, err)
+	}
+	if err := f.Close(); err != nil {
+		return StepResult{}, fmt.Errorf("failed to back-up kine %s database: %v", s.dialect.driver, err)
+	}
+	logrus.Infof("exported %d rows from the kine %s database", count, s.dialect.driver)
+	return StepResult{filesForBackup: []string{path}}, nil
+}
+
+func (s *kineSQLStep) Restore(restoreFrom, _ string) error {
+	snapshotPath := filepath.Join(restoreFrom, kineSQLBackup)
+	if !file.Exists(snapshotPath) {
+		return fmt.Errorf("kine %s snapshot not found at %s", s.dialect.driver, snapshotPath)
+	}
+	f, err := os.Open(snapshotPath)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	db, err := sql.Open(s.dialect.driver, s.dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	logrus.Infof("restoring kine %s database", s.dialect.driver)
+	count, err := restoreKineRows(context.TODO(), db, s.dialect, f)
+	if err != nil {
+		return fmt.Errorf("failed to restore kine %s database: %v", s.dialect.driver, err)
+	}
+	logrus.Infof("restored %d rows to the kine %s database", count, s.dialect.driver)
+	return nil
+}
+
+func (s *kineSQLStep) ValidateRestore(restoreFrom string) error {
+	snapshotPath := filepath.Join(restoreFrom, kineSQLBackup)
+	count, err := countJSONObjects(snapshotPath, &kineRow{})
+	if err != nil {
+		return fmt.Errorf("invalid kine %s snapshot: %v", s.dialect.driver, err)
+	}
+
+	db, err := sql.Open(s.dialect.driver, s.dsn)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := db.PingContext(context.TODO()); err != nil {
+		return fmt.Errorf("can't connect to the kine %s database: %v", s.dialect.driver, err)
+	}
+	logrus.Infof("%d rows will be restored to the kine %s database", count, s.dialect.driver)
+	return nil
+}
+
+// countJSONObjects checks that the file at path is a stream of JSON objects
+// decodable into v and returns their count
+func countJSONObjects(path string, v interface{}) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+
+	dec := json.NewDecoder(f)
+	count := 0
+	for {
+		if err := dec.Decode(v); err == io.EOF {
+			return count, nil
+		} else if err != nil {
+			return count, err
+		}
+		count++
+	}
+}
+
+// dumpKineRows writes all rows of the kine table as a stream of JSON objects
+func dumpKineRows(ctx context.Context, db *sql.DB, w io.Writer) (int, error) {
+	rows, err := db.QueryContext(ctx, kineSelectRows)
+	if err != nil {
+		return 0, err
+	}
+	defer rows.Close()
+
+	enc := json.NewEncoder(w)
+	count := 0
+	for rows.Next() {
+		var r kineRow
+		if err := rows.Scan(&r.ID, &r.Name, &r.Created, &r.Deleted, &r.CreateRevision, &r.PrevRevision, &r.Lease, &r.Value, &r.OldValue); err != nil {
+			return count, err
+		}
+		if err := enc.Encode(&r); err != nil {
+			return count, err
+		}
+		count++
+	}
+	return count, rows.Err()
+}
+
+// restoreKineRows replaces the content of the kine table with the rows read
+// from r, in a single transaction
+func restoreKineRows(ctx context.Context, db *sql.DB, dialect *kineDialect, r io.Reader) (int, error) {
+	if _, err := db.ExecContext(ctx, dialect.createTable); err != nil {
+		return 0, fmt.Errorf("failed to create the kine table: %v", err)
+	}
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	if _, err := tx.ExecContext(ctx, "DELETE FROM kine"); err != nil {
+		return 0, fmt.Errorf("failed to clear the kine table: %v", err)
+	}
+	stmt, err := tx.PrepareContext(ctx, dialect.insertRow)
+	if err != nil {
+		return 0, err
+	}
+	defer stmt.Close()
+
+	dec := json.NewDecoder(r)
+	count := 0
+	for {
+		var row kineRow
+		if err := dec.Decode(&row); err == io.EOF {
+			break
+		} else if err != nil {
+			return count, fmt.Errorf("failed to read kine backup: %v", err)
+		}
+		if _, err := stmt.ExecContext(ctx, row.ID, row.Name, row.Created, row.Deleted, row.CreateRevision, row.PrevRevision, row.Lease, row.Value, row.OldValue); err != nil {
+			return count, fmt.Errorf("failed to insert row %d: %v", row.ID, err)
+		}
+		count++
+	}
+
+	if dialect.afterRestore != "" {
+		if _, err := tx.ExecContext(ctx, dialect.afterRestore); err != nil {
+			return count, err
+		}
+	}
+	return count, tx.Commit()
+}
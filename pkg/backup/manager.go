@@ -20,17 +20,20 @@ limitations under the License.
 package backup
 
 import (
+	"context"
+	"crypto/ed25519"
 	"fmt"
 	"io"
+	"io/fs"
 	"os"
 	"path"
 	"path/filepath"
 	"strings"
 
+	"filippo.io/age"
 	"github.com/sirupsen/logrus"
 
 	"github.com/k0sproject/k0s/internal/pkg/archive"
-	"github.com/k0sproject/k0s/internal/pkg/file"
 	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 	"github.com/k0sproject/k0s/pkg/config"
 	"github.com/k0sproject/k0s/pkg/constant"
@@ -41,10 +44,36 @@ type Manager struct {
 	steps   []Backuper
 	tmpDir  string
 	dataDir string
+
+	// Encryption is used to encrypt created and decrypt restored archives, if set
+	Encryption *Encryption
+	// SigningKey is used to sign the manifest of created archives, if set
+	SigningKey ed25519.PrivateKey
+	// VerifyKey is used to verify the manifest signature of restored archives, if set
+	VerifyKey ed25519.PublicKey
+	// DryRun only validates restores without writing anything
+	DryRun bool
+}
+
+// Inspection describes the content of a backup archive
+type Inspection struct {
+	Archive string `json:"archive"`
+	// Metadata is nil for archives created by older k0s versions
+	Metadata    *Metadata              `json:"metadata,omitempty"`
+	StorageType string                 `json:"storageType"`
+	Steps       []string               `json:"steps"`
+	Files       []ArchiveFile          `json:"files"`
+	Config      *v1beta1.ClusterConfig `json:"config"`
+}
+
+// ArchiveFile is a single file contained in a backup archive
+type ArchiveFile struct {
+	Name string `json:"name"`
+	Size int64  `json:"size"`
 }
 
-// RunBackup backups cluster
-func (bm *Manager) RunBackup(nodeSpec *v1beta1.ClusterSpec, vars constant.CfgVars, savePathDir string) error {
+// RunBackup backups cluster and saves the archive to the given destination
+func (bm *Manager) RunBackup(ctx context.Context, nodeSpec *v1beta1.ClusterSpec, vars constant.CfgVars, dest Destination) error {
 	configLoader := config.ClientConfigLoadingRules{}
 	_, err := configLoader.Load()
 	if err != nil {
@@ -65,36 +94,57 @@ func (bm *Manager) RunBackup(nodeSpec *v1beta1.ClusterSpec, vars constant.CfgVar
 		assets = append(assets, result.filesForBackup...)
 	}
 
-	if savePathDir == "-" {
-		return createArchive(os.Stdout, assets, bm.dataDir)
+	metadataPath, err := newMetadata(nodeSpec, bm.steps).save(bm.tmpDir)
+	if err != nil {
+		return fmt.Errorf("failed to write backup metadata: %v", err)
 	}
+	assets = append(assets, metadataPath)
 
 	backupFileName := fmt.Sprintf("k0s_backup_%s.tar.gz", timeStamp())
+	if bm.Encryption != nil {
+		backupFileName += encryptedArchiveSuffix
+	}
 	if err := bm.save(backupFileName, assets); err != nil {
 		return fmt.Errorf("failed to create archive `%s`: %v", backupFileName, err)
 	}
-	srcBackupFile := filepath.Join(bm.tmpDir, backupFileName)
-	destBackupFile := filepath.Join(savePathDir, backupFileName)
-	if err := file.Copy(srcBackupFile, destBackupFile); err != nil {
-		return fmt.Errorf("failed to rename temporary archive: %v", err)
+
+	archiveFile, err := os.Open(filepath.Join(bm.tmpDir, backupFileName))
+	if err != nil {
+		return err
+	}
+	defer archiveFile.Close()
+	if err := dest.Save(ctx, backupFileName, archiveFile); err != nil {
+		return fmt.Errorf("failed to save archive `%s` to %s: %v", backupFileName, dest, err)
 	}
-	logrus.Infof("archive %s created successfully", destBackupFile)
+	logrus.Infof("archive %s created successfully in %s", backupFileName, dest)
 	return nil
 }
 
 func (bm *Manager) discoverSteps(configFilePath string, nodeSpec *v1beta1.ClusterSpec, vars constant.CfgVars, action string, restoredConfigPath string) {
-	if nodeSpec.Storage.Type == v1beta1.EtcdStorageType && !nodeSpec.Storage.Etcd.IsExternalClusterUsed() {
+	switch {
+	case nodeSpec.Storage.Type == v1beta1.EtcdStorageType && nodeSpec.Storage.Etcd.IsExternalClusterUsed():
+		bm.Add(newExternalEtcdStep(bm.tmpDir, vars.CertRootDir, vars.EtcdCertDir, nodeSpec.Storage.Etcd))
+	case nodeSpec.Storage.Type == v1beta1.EtcdStorageType:
 		bm.Add(newEtcdStep(bm.tmpDir, vars.CertRootDir, vars.EtcdCertDir, nodeSpec.Storage.Etcd.PeerAddress, vars.EtcdDataDir))
-	} else if nodeSpec.Storage.Type == v1beta1.KineStorageType && strings.HasPrefix(nodeSpec.Storage.Kine.DataSource, "sqlite://") {
+	case nodeSpec.Storage.Type == v1beta1.KineStorageType && strings.HasPrefix(nodeSpec.Storage.Kine.DataSource, "sqlite://"):
 		bm.Add(newSqliteStep(bm.tmpDir, nodeSpec.Storage.Kine.DataSource, vars.DataDir))
-	} else {
-		logrus.Warnf("only internal etcd and sqlite %s are supported. Other storage backends must be backed-up/restored manually.", action)
+	case nodeSpec.Storage.Type == v1beta1.KineStorageType:
+		step, err := newKineSQLStep(bm.tmpDir, nodeSpec.Storage.Kine.DataSource)
+		if err != nil {
+			logrus.Warnf("only sqlite, mysql and postgres kine %s is supported (%v). Other storage backends must be backed-up/restored manually.", action, err)
+			break
+		}
+		bm.Add(step)
+	default:
+		logrus.Warnf("only etcd and kine %s is supported. Other storage backends must be backed-up/restored manually.", action)
 	}
 	bm.dataDir = vars.DataDir
 	for _, path := range []string{
 		vars.CertRootDir,
 		vars.ManifestsDir,
 		vars.OCIBundleDir,
+		vars.HelmChartsDir,
+		vars.StorageConfigPath,
 		vars.HelmHome,
 		vars.HelmRepositoryConfig,
 	} {
@@ -123,44 +173,61 @@ func (bm Manager) save(backupFileName string, assets []string) error {
 		return fmt.Errorf("error creating archive file: %v", err)
 	}
 	defer out.Close()
-	// Create the archive and write the output to the "out" Writer
-	err = createArchive(out, assets, bm.dataDir)
-	if err != nil {
-		logrus.Fatalf("error creating archive: %v", err)
+
+	if bm.Encryption == nil {
+		// Create the archive and write the output to the "out" Writer
+		if err := createArchive(out, assets, bm.dataDir, bm.SigningKey); err != nil {
+			return fmt.Errorf("error creating archive: %v", err)
+		}
+		return out.Close()
 	}
 
-	destinationFile := filepath.Join(bm.tmpDir, backupFileName)
-	err = file.Copy(archiveFile, destinationFile)
+	encrypted, err := age.Encrypt(out, bm.Encryption.Recipients...)
 	if err != nil {
-		return fmt.Errorf("failed to copy archive file from temporary directory: %v", err)
+		return fmt.Errorf("failed to encrypt archive: %v", err)
 	}
-	return nil
+	if err := createArchive(encrypted, assets, bm.dataDir, bm.SigningKey); err != nil {
+		return fmt.Errorf("error creating archive: %v", err)
+	}
+	if err := encrypted.Close(); err != nil {
+		return fmt.Errorf("failed to encrypt archive: %v", err)
+	}
+	return out.Close()
 }
 
-// RunRestore restores cluster
-func (bm *Manager) RunRestore(archivePath string, k0sVars constant.CfgVars, desiredRestoredConfigPath string) error {
-	var input io.Reader
-	if archivePath == "-" {
-		input = os.Stdin
-	} else {
-		i, err := os.Open(archivePath)
-		if err != nil {
-			return err
-		}
-		defer i.Close()
-		input = i
+// RunRestore restores cluster from the archive with the given name fetched from the given destination.
+// In dry-run mode, the archive is only checked and every step validates it can be restored.
+func (bm *Manager) RunRestore(ctx context.Context, src Destination, archiveName string, k0sVars constant.CfgVars, desiredRestoredConfigPath string) error {
+	defer os.RemoveAll(bm.tmpDir)
+	if err := bm.extract(ctx, src, archiveName); err != nil {
+		return err
+	}
+	metadata, err := loadMetadata(bm.tmpDir)
+	if err != nil {
+		return err
 	}
-	if err := archive.Extract(input, bm.tmpDir); err != nil {
-		return fmt.Errorf("failed to unpack backup archive `%s`: %v", archivePath, err)
+	if err := metadata.checkCompatibility(); err != nil {
+		return err
 	}
-	defer os.RemoveAll(bm.tmpDir)
 	cfg, err := bm.getConfigForRestore(k0sVars)
 	if err != nil {
 		return fmt.Errorf("failed to parse backed-up configuration file, check the backup archive: %v", err)
 	}
 	bm.discoverSteps(fmt.Sprintf("%s/k0s.yaml", bm.tmpDir), cfg.Spec, k0sVars, "restore", desiredRestoredConfigPath)
-	logrus.Info("Starting restore")
 
+	logrus.Info("Validating restore")
+	for _, step := range bm.steps {
+		logrus.Debug("Validating restore step: ", step.Name())
+		if err := step.ValidateRestore(bm.tmpDir); err != nil {
+			return fmt.Errorf("failed to validate restore on step `%s`: %v", step.Name(), err)
+		}
+	}
+	if bm.DryRun {
+		logrus.Infof("dry-run: backup archive `%s` can be restored", archiveName)
+		return nil
+	}
+
+	logrus.Info("Starting restore")
 	for _, step := range bm.steps {
 		logrus.Info("Restore step: ", step.Name())
 		if err := step.Restore(bm.tmpDir, bm.dataDir); err != nil {
@@ -170,6 +237,77 @@ func (bm *Manager) RunRestore(archivePath string, k0sVars constant.CfgVars, desi
 	return nil
 }
 
+// Inspect describes the content of the archive with the given name fetched from the given destination
+func (bm *Manager) Inspect(ctx context.Context, src Destination, archiveName string, k0sVars constant.CfgVars) (*Inspection, error) {
+	defer os.RemoveAll(bm.tmpDir)
+	if err := bm.extract(ctx, src, archiveName); err != nil {
+		return nil, err
+	}
+
+	inspection := &Inspection{Archive: archiveName}
+	var err error
+	if inspection.Metadata, err = loadMetadata(bm.tmpDir); err != nil {
+		return nil, err
+	}
+	if inspection.Config, err = bm.getConfigForRestore(k0sVars); err != nil {
+		return nil, fmt.Errorf("failed to parse backed-up configuration file, check the backup archive: %v", err)
+	}
+	inspection.StorageType = inspection.Config.Spec.Storage.Type
+	if inspection.Metadata != nil {
+		inspection.Steps = inspection.Metadata.Steps
+	} else {
+		bm.discoverSteps(fmt.Sprintf("%s/k0s.yaml", bm.tmpDir), inspection.Config.Spec, k0sVars, "restore", "")
+		for _, step := range bm.steps {
+			inspection.Steps = append(inspection.Steps, step.Name())
+		}
+	}
+
+	err = filepath.WalkDir(bm.tmpDir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || !d.Type().IsRegular() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(bm.tmpDir, path)
+		if err != nil {
+			return err
+		}
+		inspection.Files = append(inspection.Files, ArchiveFile{Name: rel, Size: info.Size()})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the files in the backup archive: %v", err)
+	}
+	return inspection, nil
+}
+
+// extract decrypts and unpacks the archive into the manager's temporary
+// directory and verifies its integrity
+func (bm *Manager) extract(ctx context.Context, src Destination, archiveName string) error {
+	input, err := src.Open(ctx, archiveName)
+	if err != nil {
+		return fmt.Errorf("failed to open backup archive `%s` from %s: %v", archiveName, src, err)
+	}
+	defer input.Close()
+
+	var r io.Reader = input
+	if bm.Encryption != nil {
+		r, err = age.Decrypt(input, bm.Encryption.Identities...)
+		if err != nil {
+			return fmt.Errorf("failed to decrypt backup archive `%s`: %v", archiveName, err)
+		}
+	} else if strings.HasSuffix(archiveName, encryptedArchiveSuffix) {
+		return fmt.Errorf("backup archive `%s` is encrypted, a passphrase or identity is required", archiveName)
+	}
+
+	if err := archive.Extract(r, bm.tmpDir); err != nil {
+		return fmt.Errorf("failed to unpack backup archive `%s`: %v", archiveName, err)
+	}
+	return verifyManifest(bm.tmpDir, bm.VerifyKey)
+}
+
 func (bm Manager) getConfigForRestore(k0sVars constant.CfgVars) (*v1beta1.ClusterConfig, error) {
 	configFromBackup := path.Join(bm.tmpDir, "k0s.yaml")
 	logrus.Debugf("Using k0s.yaml from: %s", configFromBackup)
@@ -204,6 +342,8 @@ type Backuper interface {
 	Name() string
 	Backup() (StepResult, error)
 	Restore(from, to string) error
+	// ValidateRestore checks that the step's data in the extracted archive can be restored, without writing anything
+	ValidateRestore(from string) error
 }
 
 // StepResult backup result for the particular step
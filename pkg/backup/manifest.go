@@ -0,0 +1,138 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/k0sproject/k0s/internal/pkg/file"
+)
+
+const (
+	manifestFileName          = "backup-manifest.json"
+	manifestSignatureFileName = "backup-manifest.json.sig"
+)
+
+// manifest lists the SHA-256 checksums of all the files in a backup archive
+type manifest struct {
+	Files map[string]string `json:"files"`
+}
+
+func (m *manifest) marshal() ([]byte, error) {
+	return json.MarshalIndent(m, "", "  ")
+}
+
+// verifyManifest checks the files extracted from a backup archive into dir
+// against the archive's manifest. If verifyKey is given, the manifest has to
+// be signed by the matching private key.
+func verifyManifest(dir string, verifyKey ed25519.PublicKey) error {
+	manifestPath := filepath.Join(dir, manifestFileName)
+	if !file.Exists(manifestPath) {
+		if verifyKey != nil {
+			return fmt.Errorf("the backup archive has no integrity manifest, can't verify its signature")
+		}
+		logrus.Warn("the backup archive has no integrity manifest, skipping integrity check")
+		return nil
+	}
+
+	data, err := os.ReadFile(manifestPath)
+	if err != nil {
+		return err
+	}
+
+	if verifyKey != nil {
+		signature, err := os.ReadFile(filepath.Join(dir, manifestSignatureFileName))
+		if err != nil {
+			return fmt.Errorf("failed to read the manifest signature: %v", err)
+		}
+		if !ed25519.Verify(verifyKey, data, signature) {
+			return fmt.Errorf("invalid manifest signature")
+		}
+		logrus.Info("backup manifest signature verified")
+	}
+
+	var m manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return fmt.Errorf("failed to parse the manifest: %v", err)
+	}
+
+	seen := make(map[string]bool, len(m.Files))
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		if rel == manifestFileName || rel == manifestSignatureFileName {
+			return nil
+		}
+		expected, ok := m.Files[rel]
+		if !ok {
+			return fmt.Errorf("`%s` is not listed in the manifest", rel)
+		}
+		actual, err := fileChecksum(path)
+		if err != nil {
+			return err
+		}
+		if actual != expected {
+			return fmt.Errorf("checksum mismatch for `%s`", rel)
+		}
+		seen[rel] = true
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("backup archive integrity check failed: %w", err)
+	}
+	for name := range m.Files {
+		if !seen[name] {
+			return fmt.Errorf("backup archive integrity check failed: `%s` is missing", name)
+		}
+	}
+
+	logrus.Infof("backup archive integrity verified, %d files checked", len(seen))
+	return nil
+}
+
+func fileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
@@ -0,0 +1,150 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"crypto/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"filippo.io/age"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k0sproject/k0s/internal/pkg/archive"
+)
+
+func createTestArchive(t *testing.T, signingKey ed25519.PrivateKey) []byte {
+	baseDir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(baseDir, "pki"), 0700))
+	files := []string{filepath.Join(baseDir, "pki", "ca.key"), filepath.Join(baseDir, "k0s.yaml")}
+	for _, f := range files {
+		require.NoError(t, os.WriteFile(f, []byte(filepath.Base(f)), 0600))
+	}
+	files = append([]string{filepath.Join(baseDir, "pki")}, files...)
+
+	var buf bytes.Buffer
+	require.NoError(t, createArchive(&buf, files, baseDir, signingKey))
+	return buf.Bytes()
+}
+
+func extractTestArchive(t *testing.T, data []byte) string {
+	dir := t.TempDir()
+	require.NoError(t, archive.Extract(bytes.NewReader(data), dir))
+	return dir
+}
+
+func TestManifest(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	otherPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	t.Run("unsigned", func(t *testing.T) {
+		dir := extractTestArchive(t, createTestArchive(t, nil))
+		assert.NoError(t, verifyManifest(dir, nil))
+		assert.ErrorContains(t, verifyManifest(dir, pub), "failed to read the manifest signature")
+	})
+
+	t.Run("signed", func(t *testing.T) {
+		dir := extractTestArchive(t, createTestArchive(t, priv))
+		assert.NoError(t, verifyManifest(dir, nil))
+		assert.NoError(t, verifyManifest(dir, pub))
+		assert.ErrorContains(t, verifyManifest(dir, otherPub), "invalid manifest signature")
+	})
+
+	t.Run("tampered", func(t *testing.T) {
+		dir := extractTestArchive(t, createTestArchive(t, priv))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "pki", "ca.key"), []byte("evil"), 0600))
+		assert.ErrorContains(t, verifyManifest(dir, pub), "checksum mismatch for `pki/ca.key`")
+	})
+
+	t.Run("added", func(t *testing.T) {
+		dir := extractTestArchive(t, createTestArchive(t, priv))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "pki", "evil.key"), []byte("evil"), 0600))
+		assert.ErrorContains(t, verifyManifest(dir, pub), "`pki/evil.key` is not listed in the manifest")
+	})
+
+	t.Run("removed", func(t *testing.T) {
+		dir := extractTestArchive(t, createTestArchive(t, priv))
+		require.NoError(t, os.Remove(filepath.Join(dir, "k0s.yaml")))
+		assert.ErrorContains(t, verifyManifest(dir, pub), "`k0s.yaml` is missing")
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		dir := t.TempDir()
+		assert.NoError(t, verifyManifest(dir, nil))
+		assert.ErrorContains(t, verifyManifest(dir, pub), "no integrity manifest")
+	})
+}
+
+func TestEncryption(t *testing.T) {
+	passphraseFile := filepath.Join(t.TempDir(), "passphrase")
+	require.NoError(t, os.WriteFile(passphraseFile, []byte("correct horse battery staple\n"), 0600))
+	passphrase, err := NewPassphraseEncryption(passphraseFile)
+	require.NoError(t, err)
+
+	identity, err := age.GenerateX25519Identity()
+	require.NoError(t, err)
+	identityFile := filepath.Join(t.TempDir(), "identity")
+	require.NoError(t, os.WriteFile(identityFile, []byte(identity.String()+"\n"), 0600))
+	recipients, err := NewRecipientsEncryption([]string{identity.Recipient().String()})
+	require.NoError(t, err)
+	identities, err := NewIdentitiesEncryption(identityFile)
+	require.NoError(t, err)
+
+	_, err = NewRecipientsEncryption([]string{"not-a-key"})
+	assert.ErrorContains(t, err, `invalid age recipient "not-a-key"`)
+
+	for _, test := range []struct {
+		name     string
+		encrypt  *Encryption
+		decrypt  *Encryption
+		succeeds bool
+	}{
+		{"passphrase", passphrase, passphrase, true},
+		{"recipients", recipients, identities, true},
+		{"wrong_identity", recipients, passphrase, false},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			plain := createTestArchive(t, nil)
+
+			var encrypted bytes.Buffer
+			w, err := age.Encrypt(&encrypted, test.encrypt.Recipients...)
+			require.NoError(t, err)
+			_, err = w.Write(plain)
+			require.NoError(t, err)
+			require.NoError(t, w.Close())
+			assert.NotContains(t, encrypted.String(), "ca.key")
+
+			r, err := age.Decrypt(&encrypted, test.decrypt.Identities...)
+			if !test.succeeds {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			dir := t.TempDir()
+			require.NoError(t, archive.Extract(r, dir))
+			assert.NoError(t, verifyManifest(dir, nil))
+		})
+	}
+}
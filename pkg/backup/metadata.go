@@ -0,0 +1,131 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/sirupsen/logrus"
+
+	"github.com/k0sproject/k0s/internal/pkg/file"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/build"
+)
+
+const (
+	metadataFileName = "backup-metadata.json"
+
+	// metadataFormatVersion is bumped whenever the archive layout changes incompatibly
+	metadataFormatVersion = 1
+)
+
+// Metadata describes what produced a backup archive
+type Metadata struct {
+	// FormatVersion is the version of the archive layout
+	FormatVersion int `json:"formatVersion"`
+	// K0sVersion is the version of the k0s binary that created the archive
+	K0sVersion string `json:"k0sVersion"`
+	// CreatedAt is the time the backup was taken at
+	CreatedAt time.Time `json:"createdAt"`
+	// Hostname is the name of the controller the backup was taken on
+	Hostname string `json:"hostname,omitempty"`
+	// StorageType is the type of the cluster's datastore
+	StorageType string `json:"storageType"`
+	// Steps are the names of the steps that contributed to the archive
+	Steps []string `json:"steps"`
+}
+
+func newMetadata(nodeSpec *v1beta1.ClusterSpec, steps []Backuper) *Metadata {
+	hostname, _ := os.Hostname()
+	m := &Metadata{
+		FormatVersion: metadataFormatVersion,
+		K0sVersion:    build.Version,
+		CreatedAt:     time.Now().UTC(),
+		Hostname:      hostname,
+		StorageType:   nodeSpec.Storage.Type,
+	}
+	for _, step := range steps {
+		m.Steps = append(m.Steps, step.Name())
+	}
+	return m
+}
+
+func (m *Metadata) save(dir string) (string, error) {
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, metadataFileName)
+	return path, os.WriteFile(path, data, 0600)
+}
+
+// loadMetadata reads the metadata from the extracted archive in dir, older
+// archives don't have any, in which case nil is returned
+func loadMetadata(dir string) (*Metadata, error) {
+	path := filepath.Join(dir, metadataFileName)
+	if !file.Exists(path) {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var m Metadata
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("failed to parse backup metadata: %v", err)
+	}
+	return &m, nil
+}
+
+// checkCompatibility returns an error if the archive can't be restored by this
+// k0s binary, i.e. if it has an unknown layout or was created by a newer minor
+// version of k0s. Version mismatches that are expected to work are logged.
+func (m *Metadata) checkCompatibility() error {
+	if m == nil {
+		logrus.Warn("the backup archive has no metadata, it was created by k0s older than the current version")
+		return nil
+	}
+	if m.FormatVersion > metadataFormatVersion {
+		return fmt.Errorf("the backup archive format version %d is not supported, this k0s supports up to version %d", m.FormatVersion, metadataFormatVersion)
+	}
+
+	archiveVersion, err := semver.NewVersion(m.K0sVersion)
+	if err != nil {
+		logrus.Warnf("can't parse the k0s version %q the backup archive was created with, skipping version check", m.K0sVersion)
+		return nil
+	}
+	currentVersion, err := semver.NewVersion(build.Version)
+	if err != nil {
+		logrus.Warnf("can't parse the current k0s version %q, skipping version check", build.Version)
+		return nil
+	}
+	switch {
+	case archiveVersion.Major() > currentVersion.Major(),
+		archiveVersion.Major() == currentVersion.Major() && archiveVersion.Minor() > currentVersion.Minor():
+		return fmt.Errorf("the backup archive was created by k0s %s, which is newer than the current version %s", m.K0sVersion, build.Version)
+	case archiveVersion.Major() != currentVersion.Major() || archiveVersion.Minor() != currentVersion.Minor():
+		logrus.Warnf("the backup archive was created by k0s %s, the current version is %s", m.K0sVersion, build.Version)
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/build"
+)
+
+func TestMetadata_SaveLoad(t *testing.T) {
+	dir := t.TempDir()
+
+	m, err := loadMetadata(dir)
+	require.NoError(t, err)
+	assert.Nil(t, m, "archives without metadata should be accepted")
+
+	spec := v1beta1.DefaultClusterSpec()
+	steps := []Backuper{NewFilesystemStep("/var/lib/k0s/pki")}
+	_, err = newMetadata(spec, steps).save(dir)
+	require.NoError(t, err)
+
+	m, err = loadMetadata(dir)
+	require.NoError(t, err)
+	require.NotNil(t, m)
+	assert.Equal(t, metadataFormatVersion, m.FormatVersion)
+	assert.Equal(t, v1beta1.EtcdStorageType, m.StorageType)
+	assert.Equal(t, []string{"filesystem path `/var/lib/k0s/pki`"}, m.Steps)
+}
+
+func TestMetadata_CheckCompatibility(t *testing.T) {
+	defer func(version string) { build.Version = version }(build.Version)
+	build.Version = "v1.24.2+k0s.0"
+
+	for _, test := range []struct {
+		name     string
+		metadata *Metadata
+		err      string
+	}{
+		{"no_metadata", nil, ""},
+		{"same_version", &Metadata{FormatVersion: 1, K0sVersion: "v1.24.2+k0s.0"}, ""},
+		{"older_patch", &Metadata{FormatVersion: 1, K0sVersion: "v1.24.1+k0s.0"}, ""},
+		{"newer_patch", &Metadata{FormatVersion: 1, K0sVersion: "v1.24.3+k0s.0"}, ""},
+		{"older_minor", &Metadata{FormatVersion: 1, K0sVersion: "v1.23.8+k0s.0"}, ""},
+		{"newer_minor", &Metadata{FormatVersion: 1, K0sVersion: "v1.25.0+k0s.0"}, "newer than the current version"},
+		{"unparseable", &Metadata{FormatVersion: 1, K0sVersion: "dev"}, ""},
+		{"newer_format", &Metadata{FormatVersion: 2, K0sVersion: "v1.24.2+k0s.0"}, "format version 2 is not supported"},
+	} {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.metadata.checkCompatibility()
+			if test.err == "" {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorContains(t, err, test.err)
+			}
+		})
+	}
+}
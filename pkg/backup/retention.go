@@ -0,0 +1,84 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// isArchiveName returns true if the given file name is one of a backup archive
+func isArchiveName(name string) bool {
+	if !strings.HasPrefix(name, "k0s_backup_") {
+		return false
+	}
+	return strings.HasSuffix(name, ".tar.gz") || strings.HasSuffix(name, ".tar.gz"+encryptedArchiveSuffix)
+}
+
+// PruneArchives removes the backup archives from the given store which exceed
+// the given retention limits. The newest archives are always kept first. A zero
+// maxCount or maxAge disables the respective limit. The names of the removed
+// archives are returned.
+func PruneArchives(ctx context.Context, store ArchiveStore, maxCount int, maxAge time.Duration) ([]string, error) {
+	archives, err := store.List(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list backup archives in %s: %v", store, err)
+	}
+
+	// newest first
+	sort.Slice(archives, func(i, j int) bool {
+		return archives[i].ModTime.After(archives[j].ModTime)
+	})
+
+	var pruned []string
+	now := time.Now()
+	for i, a := range archives {
+		tooMany := maxCount > 0 && i >= maxCount
+		tooOld := maxAge > 0 && now.Sub(a.ModTime) > maxAge
+		if !tooMany && !tooOld {
+			continue
+		}
+		if err := store.Remove(ctx, a.Name); err != nil {
+			return pruned, fmt.Errorf("failed to remove backup archive `%s`: %v", a.Name, err)
+		}
+		pruned = append(pruned, a.Name)
+	}
+
+	return pruned, nil
+}
+
+// LatestArchiveTime returns the modification time of the newest backup archive
+// in the given store, the zero time if the store holds no archives.
+func LatestArchiveTime(ctx context.Context, store ArchiveStore) (time.Time, error) {
+	archives, err := store.List(ctx)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("failed to list backup archives in %s: %v", store, err)
+	}
+
+	var latest time.Time
+	for _, a := range archives {
+		if a.ModTime.After(latest) {
+			latest = a.ModTime
+		}
+	}
+	return latest, nil
+}
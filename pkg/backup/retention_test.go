@@ -0,0 +1,94 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func createArchives(t *testing.T, dir string, ages ...time.Duration) []string {
+	var names []string
+	now := time.Now()
+	for i, age := range ages {
+		name := "k0s_backup_" + string(rune('a'+i)) + ".tar.gz"
+		path := filepath.Join(dir, name)
+		require.NoError(t, os.WriteFile(path, []byte("backup"), 0600))
+		modTime := now.Add(-age)
+		require.NoError(t, os.Chtimes(path, modTime, modTime))
+		names = append(names, name)
+	}
+	return names
+}
+
+func TestPruneArchives(t *testing.T) {
+	t.Run("max_count", func(t *testing.T) {
+		dir := t.TempDir()
+		archives := createArchives(t, dir, 3*time.Hour, 1*time.Hour, 2*time.Hour)
+		unrelated := filepath.Join(dir, "unrelated.tar.gz")
+		require.NoError(t, os.WriteFile(unrelated, []byte("foo"), 0600))
+
+		pruned, err := PruneArchives(context.TODO(), NewLocalDestination(dir), 2, 0)
+		require.NoError(t, err)
+		assert.Equal(t, []string{archives[0]}, pruned)
+		assert.NoFileExists(t, filepath.Join(dir, archives[0]))
+		assert.FileExists(t, filepath.Join(dir, archives[1]))
+		assert.FileExists(t, filepath.Join(dir, archives[2]))
+		assert.FileExists(t, unrelated)
+	})
+
+	t.Run("max_age", func(t *testing.T) {
+		dir := t.TempDir()
+		archives := createArchives(t, dir, 48*time.Hour, 1*time.Hour, 25*time.Hour)
+
+		pruned, err := PruneArchives(context.TODO(), NewLocalDestination(dir), 0, 24*time.Hour)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{archives[0], archives[2]}, pruned)
+		assert.FileExists(t, filepath.Join(dir, archives[1]))
+	})
+
+	t.Run("unlimited", func(t *testing.T) {
+		dir := t.TempDir()
+		createArchives(t, dir, 48*time.Hour, 1*time.Hour)
+
+		pruned, err := PruneArchives(context.TODO(), NewLocalDestination(dir), 0, 0)
+		require.NoError(t, err)
+		assert.Empty(t, pruned)
+	})
+}
+
+func TestLatestArchiveTime(t *testing.T) {
+	dir := t.TempDir()
+	store := NewLocalDestination(dir)
+
+	latest, err := LatestArchiveTime(context.TODO(), store)
+	require.NoError(t, err)
+	assert.True(t, latest.IsZero())
+
+	createArchives(t, dir, 3*time.Hour, 1*time.Hour, 2*time.Hour)
+	latest, err = LatestArchiveTime(context.TODO(), store)
+	require.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(-1*time.Hour), latest, time.Minute)
+}
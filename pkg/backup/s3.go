@@ -0,0 +1,163 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package backup
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+)
+
+// S3Destination stores backup archives in a bucket of an S3-compatible object storage
+type S3Destination struct {
+	client *minio.Client
+	bucket string
+	prefix string
+}
+
+var _ ArchiveStore = (*S3Destination)(nil)
+
+// NewS3Destination creates a destination for the given S3 bucket. The kube
+// client is only used to look up credentials stored in a Secret and may be nil
+// otherwise.
+func NewS3Destination(ctx context.Context, spec *v1beta1.BackupS3Destination, kubeClient kubernetes.Interface) (*S3Destination, error) {
+	creds, err := s3Credentials(ctx, spec, kubeClient)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if spec.CAFile != "" {
+		caData, err := os.ReadFile(spec.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read S3 CA file: %v", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caData) {
+			return nil, fmt.Errorf("no certificates found in S3 CA file %s", spec.CAFile)
+		}
+		transport.TLSClientConfig = &tls.Config{RootCAs: pool}
+	}
+
+	client, err := minio.New(spec.Endpoint, &minio.Options{
+		Creds:     creds,
+		Secure:    !spec.Insecure,
+		Region:    spec.Region,
+		Transport: transport,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create S3 client: %v", err)
+	}
+
+	return &S3Destination{
+		client: client,
+		bucket: spec.Bucket,
+		prefix: spec.Prefix,
+	}, nil
+}
+
+func s3Credentials(ctx context.Context, spec *v1beta1.BackupS3Destination, kubeClient kubernetes.Interface) (*credentials.Credentials, error) {
+	switch {
+	case spec.CredentialsFile != "":
+		if _, err := os.Stat(spec.CredentialsFile); err != nil {
+			return nil, fmt.Errorf("failed to read S3 credentials file: %v", err)
+		}
+		return credentials.NewFileAWSCredentials(spec.CredentialsFile, "default"), nil
+
+	case spec.CredentialsSecret != "":
+		if kubeClient == nil {
+			return nil, fmt.Errorf("a kubernetes client is required to read the S3 credentials secret %q", spec.CredentialsSecret)
+		}
+		secret, err := kubeClient.CoreV1().Secrets("kube-system").Get(ctx, spec.CredentialsSecret, metav1.GetOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to get S3 credentials secret: %v", err)
+		}
+		accessKeyID, secretAccessKey := string(secret.Data["accessKeyID"]), string(secret.Data["secretAccessKey"])
+		if accessKeyID == "" || secretAccessKey == "" {
+			return nil, fmt.Errorf("S3 credentials secret %q must contain the accessKeyID and secretAccessKey keys", spec.CredentialsSecret)
+		}
+		return credentials.NewStaticV4(accessKeyID, secretAccessKey, string(secret.Data["sessionToken"])), nil
+
+	default:
+		// fall back to the well-known environment variables
+		return credentials.NewChainCredentials([]credentials.Provider{
+			&credentials.EnvAWS{},
+			&credentials.EnvMinio{},
+		}), nil
+	}
+}
+
+func (d *S3Destination) String() string {
+	return fmt.Sprintf("s3://%s/%s", d.bucket, d.prefix)
+}
+
+func (d *S3Destination) objectName(name string) string {
+	return d.prefix + name
+}
+
+func (d *S3Destination) Save(ctx context.Context, name string, archive io.Reader) error {
+	_, err := d.client.PutObject(ctx, d.bucket, d.objectName(name), archive, -1, minio.PutObjectOptions{
+		ContentType: "application/gzip",
+	})
+	return err
+}
+
+func (d *S3Destination) Open(ctx context.Context, name string) (io.ReadCloser, error) {
+	obj, err := d.client.GetObject(ctx, d.bucket, d.objectName(name), minio.GetObjectOptions{})
+	if err != nil {
+		return nil, err
+	}
+	// GetObject is lazy, make sure the archive actually exists
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		return nil, err
+	}
+	return obj, nil
+}
+
+func (d *S3Destination) List(ctx context.Context) ([]Archive, error) {
+	var archives []Archive
+	for obj := range d.client.ListObjects(ctx, d.bucket, minio.ListObjectsOptions{Prefix: d.prefix}) {
+		if obj.Err != nil {
+			return nil, obj.Err
+		}
+		name := strings.TrimPrefix(obj.Key, d.prefix)
+		if strings.Contains(name, "/") || !isArchiveName(name) {
+			continue
+		}
+		archives = append(archives, Archive{Name: name, ModTime: obj.LastModified})
+	}
+	return archives, nil
+}
+
+func (d *S3Destination) Remove(ctx context.Context, name string) error {
+	return d.client.RemoveObject(ctx, d.bucket, d.objectName(name), minio.RemoveObjectOptions{})
+}
@@ -99,6 +99,19 @@ func (s *sqliteStep) Restore(restoreFrom string, _ string) error {
 	return nil
 }
 
+func (s *sqliteStep) ValidateRestore(restoreFrom string) error {
+	snapshotPath := filepath.Join(restoreFrom, kineBackup)
+	if !file.Exists(snapshotPath) {
+		return fmt.Errorf("sqlite snapshot not found at %s", snapshotPath)
+	}
+	dbPath, err := s.getKineDBPath()
+	if err != nil {
+		return err
+	}
+	logrus.Infof("sqlite db will be restored to `%s`", dbPath)
+	return nil
+}
+
 func (s *sqliteStep) getKineDBPath() (string, error) {
 	u, err := url.Parse(s.dataSource)
 	if err != nil {
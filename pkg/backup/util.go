@@ -21,6 +21,9 @@ package backup
 import (
 	"archive/tar"
 	"compress/gzip"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
 	"io"
 	"os"
@@ -33,24 +36,55 @@ import (
 
 const timeStampLayout = "2006-01-02T15_04_05_000Z"
 
-// createArchive compresses and adds files to the backup archive file
-func createArchive(archive io.Writer, files []string, baseDir string) error {
+// createArchive compresses and adds files to the backup archive file, along
+// with a manifest of their checksums, which is signed if signingKey is given
+func createArchive(archive io.Writer, files []string, baseDir string, signingKey ed25519.PrivateKey) error {
 	gw := gzip.NewWriter(archive)
 	defer gw.Close()
 	tw := tar.NewWriter(gw)
 	defer tw.Close()
 
+	m := manifest{Files: make(map[string]string, len(files))}
+
 	// Iterate over files and add them to the tar archive
 	for _, file := range files {
-		err := addToArchive(tw, file, baseDir)
+		err := addToArchive(tw, file, baseDir, &m)
 		if err != nil {
 			return fmt.Errorf("failed to add file to backup archive: %v", err)
 		}
 	}
+
+	data, err := m.marshal()
+	if err != nil {
+		return err
+	}
+	if err := addDataToArchive(tw, manifestFileName, data); err != nil {
+		return fmt.Errorf("failed to add manifest to backup archive: %v", err)
+	}
+	if signingKey != nil {
+		if err := addDataToArchive(tw, manifestSignatureFileName, ed25519.Sign(signingKey, data)); err != nil {
+			return fmt.Errorf("failed to add manifest signature to backup archive: %v", err)
+		}
+	}
 	return nil
 }
 
-func addToArchive(tw *tar.Writer, filename string, baseDir string) error {
+func addDataToArchive(tw *tar.Writer, name string, data []byte) error {
+	header := &tar.Header{
+		Typeflag: tar.TypeReg,
+		Name:     name,
+		Mode:     0600,
+		Size:     int64(len(data)),
+		ModTime:  time.Now(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return err
+	}
+	_, err := tw.Write(data)
+	return err
+}
+
+func addToArchive(tw *tar.Writer, filename string, baseDir string, m *manifest) error {
 	// Open the file which will be written into the archive
 	file, err := os.Open(filename)
 	if err != nil {
@@ -85,11 +119,13 @@ func addToArchive(tw *tar.Writer, filename string, baseDir string) error {
 	}
 
 	if !dir.IsDirectory(filename) {
-		// Copy file content to tar archive
-		_, err = io.Copy(tw, file)
+		// Copy file content to tar archive, recording its checksum in the manifest
+		h := sha256.New()
+		_, err = io.Copy(io.MultiWriter(tw, h), file)
 		if err != nil {
 			return fmt.Errorf("failed to copy file contents info archive: %v", err)
 		}
+		m.Files[filepath.Clean(header.Name)] = hex.EncodeToString(h.Sum(nil))
 	}
 	return nil
 }
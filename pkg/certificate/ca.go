@@ -0,0 +1,204 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package certificate
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/cloudflare/cfssl/helpers"
+	"github.com/sirupsen/logrus"
+
+	"github.com/k0sproject/k0s/internal/pkg/file"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+// CABundleFile returns the file holding the chain of the given CA certificate file up to the root, to be trusted by the
+// clients of the control plane. Only the externally provided CAs have a bundle besides their certificate file, which
+// holds the CA certificate alone as it's used to authenticate clients.
+func CABundleFile(certFile string) string {
+	if bundleFile := caBundlePath(certFile); file.Exists(bundleFile) {
+		return bundleFile
+	}
+	return certFile
+}
+
+func caBundlePath(certFile string) string {
+	return strings.TrimSuffix(certFile, ".crt") + "-bundle.crt"
+}
+
+// installCA validates the externally provided CA and copies it to the given cert and key files, where the k0s components expect it.
+// The cert file only gets the CA certificate, as it's trusted to authenticate clients, and the whole chain goes to the bundle
+// next to it, so that the clients of the control plane, join tokens included, can verify it up to the root, see CABundleFile.
+func installCA(ca *v1beta1.CASpec, certFile, keyFile string) error {
+	srcCert, srcKey := ca.CertFile, ca.KeyFile
+	if !file.Exists(srcCert) && file.Exists(certFile) && file.Exists(keyFile) {
+		// Controllers joining the cluster get the CA synced from the existing ones
+		srcCert, srcKey = CABundleFile(certFile), keyFile
+		logrus.Debugf("CA %s not found, using the one in place at %s", ca.CertFile, srcCert)
+	}
+
+	certPEM, err := os.ReadFile(srcCert)
+	if err != nil {
+		return fmt.Errorf("failed to read CA certificate: %w", err)
+	}
+	keyPEM, err := os.ReadFile(srcKey)
+	if err != nil {
+		return fmt.Errorf("failed to read CA key: %w", err)
+	}
+	bundle, err := validateCA(certPEM, keyPEM)
+	if err != nil {
+		return fmt.Errorf("invalid CA %s: %w", srcCert, err)
+	}
+
+	if err := writeIfChanged(keyFile, keyPEM, constant.CertSecureMode); err != nil {
+		return err
+	}
+	if err := writeIfChanged(caBundlePath(certFile), certPEM, constant.CertMode); err != nil {
+		return err
+	}
+	return writeIfChanged(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: bundle[0].Raw}), constant.CertMode)
+}
+
+// validateCA checks that the first certificate of the bundle is a CA matching the key, and that it chains up to the rest of the bundle.
+// It returns the parsed bundle.
+func validateCA(certPEM, keyPEM []byte) ([]*x509.Certificate, error) {
+	bundle, err := helpers.ParseCertificatesPEM(certPEM)
+	if err != nil {
+		return nil, err
+	}
+	if len(bundle) == 0 {
+		return nil, fmt.Errorf("no PEM encoded certificate found")
+	}
+	ca := bundle[0]
+	if !ca.IsCA {
+		return nil, fmt.Errorf("`%s` is not a CA certificate", ca.Subject.CommonName)
+	}
+	if ca.KeyUsage != 0 && ca.KeyUsage&x509.KeyUsageCertSign == 0 {
+		return nil, fmt.Errorf("`%s` is not allowed to sign certificates", ca.Subject.CommonName)
+	}
+
+	key, err := helpers.ParsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse the key: %w", err)
+	}
+	if pub, ok := key.Public().(interface{ Equal(crypto.PublicKey) bool }); !ok || !pub.Equal(ca.PublicKey) {
+		return nil, fmt.Errorf("the key doesn't match the certificate of `%s`", ca.Subject.CommonName)
+	}
+
+	return bundle, verifyChain(ca, bundle)
+}
+
+// loadCA reads the CA certificate bundle and the key to sign certificates with, the signing CA being the first in the bundle
+func loadCA(certFile, keyFile string) ([]*x509.Certificate, crypto.Signer, error) {
+	certPEM, err := os.ReadFile(CABundleFile(certFile))
+	if err != nil {
+		return nil, nil, err
+	}
+	bundle, err := helpers.ParseCertificatesPEM(certPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", certFile, err)
+	}
+	if len(bundle) == 0 {
+		return nil, nil, fmt.Errorf("no PEM encoded certificate found in %s", certFile)
+	}
+	keyPEM, err := os.ReadFile(keyFile)
+	if err != nil {
+		return nil, nil, err
+	}
+	key, err := helpers.ParsePrivateKeyPEM(keyPEM)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to parse %s: %w", keyFile, err)
+	}
+	return bundle, key, nil
+}
+
+// intermediatesPEM returns the PEM encoded certificates of the bundle that aren't self-signed, the chain a certificate issued
+// with the bundle has to present for its peers to verify it up to the root
+func intermediatesPEM(bundle []*x509.Certificate) []byte {
+	var chain []byte
+	for _, c := range bundle {
+		if !isSelfSigned(c) {
+			chain = append(chain, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+		}
+	}
+	return chain
+}
+
+func isSelfSigned(c *x509.Certificate) bool {
+	return bytes.Equal(c.RawSubject, c.RawIssuer) && c.CheckSignatureFrom(c) == nil
+}
+
+// verifyChain verifies the certificate against the CA bundle. The self-signed certificates of the bundle are the trust anchors,
+// or the last certificate of the bundle if it doesn't include the root.
+func verifyChain(cert *x509.Certificate, bundle []*x509.Certificate) error {
+	roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+	hasRoot := false
+	for _, c := range bundle {
+		if isSelfSigned(c) {
+			roots.AddCert(c)
+			hasRoot = true
+		} else {
+			intermediates.AddCert(c)
+		}
+	}
+	if !hasRoot {
+		roots.AddCert(bundle[len(bundle)-1])
+	}
+
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	})
+	return err
+}
+
+// verifyIssued verifies the PEM encoded certificate against the CA bundle it was issued with
+func verifyIssued(certPEM []byte, bundle []*x509.Certificate) error {
+	cert, err := helpers.ParseCertificatePEM(certPEM)
+	if err != nil {
+		return err
+	}
+	return verifyChain(cert, bundle)
+}
+
+// issuedBy checks if the given issuer is the CA of the given bundle file
+func issuedBy(issuerCN, caCertFile string) bool {
+	certPEM, err := os.ReadFile(caCertFile)
+	if err != nil {
+		return false
+	}
+	bundle, err := helpers.ParseCertificatesPEM(certPEM)
+	if err != nil || len(bundle) == 0 {
+		return false
+	}
+	return bundle[0].Subject.CommonName == issuerCN
+}
+
+func writeIfChanged(path string, data []byte, mode os.FileMode) error {
+	if current, err := os.ReadFile(path); err == nil && bytes.Equal(current, data) {
+		return nil
+	}
+	logrus.Infof("installing %s", path)
+	return os.WriteFile(path, data, mode)
+}
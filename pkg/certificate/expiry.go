@@ -0,0 +1,142 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package certificate
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Expiry describes the validity of a certificate file
+type Expiry struct {
+	// Name is the path of the certificate relative to the scanned dir, without the .crt extension, e.g. etcd/server
+	Name      string    `json:"name"`
+	Path      string    `json:"path"`
+	Subject   string    `json:"subject"`
+	Issuer    string    `json:"issuer"`
+	IsCA      bool      `json:"isCA"`
+	NotBefore time.Time `json:"notBefore"`
+	NotAfter  time.Time `json:"notAfter"`
+	// Managed is true if the certificate is issued by one of the k0s CAs, generated or externally provided, and thus gets rotated by k0s
+	Managed bool `json:"managed"`
+}
+
+// Remaining returns the time left until the certificate expires, negative if it has expired
+func (e *Expiry) Remaining(now time.Time) time.Duration {
+	return e.NotAfter.Sub(now)
+}
+
+// Lifetime returns the total validity period of the certificate
+func (e *Expiry) Lifetime() time.Duration {
+	return e.NotAfter.Sub(e.NotBefore)
+}
+
+// DaysRemaining returns the number of whole days left until the certificate expires
+func (e *Expiry) DaysRemaining(now time.Time) int {
+	return int(e.Remaining(now).Hours() / 24)
+}
+
+// ScanExpiry returns the expiry of all the .crt files but the CA bundles found in the given dirs and their subdirs, sorted by path.
+// Dirs that don't exist are skipped, as are files that can't be parsed.
+func ScanExpiry(dirs ...string) ([]Expiry, error) {
+	seen := map[string]bool{}
+	var expiries []Expiry
+	for _, dir := range dirs {
+		err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				if path == dir && os.IsNotExist(err) {
+					return filepath.SkipDir
+				}
+				return err
+			}
+			// the CA bundles repeat the CA certificates
+			if d.IsDir() || filepath.Ext(path) != ".crt" || strings.HasSuffix(path, "-bundle.crt") || seen[path] {
+				return nil
+			}
+			seen[path] = true
+
+			expiry, err := readExpiry(path)
+			if err != nil {
+				logrus.Warnf("unable to parse certificate file at %s: %v", path, err)
+				return nil
+			}
+			rel, err := filepath.Rel(dir, path)
+			if err != nil {
+				return err
+			}
+			expiry.Name = filepath.ToSlash(strings.TrimSuffix(rel, ".crt"))
+			expiries = append(expiries, expiry)
+			return nil
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan %s for certificates: %w", dir, err)
+		}
+	}
+	markIssuedByCAFiles(expiries)
+	sort.Slice(expiries, func(i, j int) bool { return expiries[i].Path < expiries[j].Path })
+	return expiries, nil
+}
+
+// markIssuedByCAFiles marks the leaf certificates issued by the CAs in the k0s CA files as managed,
+// the CAs provided externally having other names than the generated ones
+func markIssuedByCAFiles(expiries []Expiry) {
+	cas := map[string]bool{}
+	for _, e := range expiries {
+		switch filepath.Base(e.Path) {
+		case "ca.crt", "front-proxy-ca.crt":
+			cas[e.Subject] = e.IsCA
+		}
+	}
+	for i := range expiries {
+		if !expiries[i].IsCA && cas[expiries[i].Issuer] {
+			expiries[i].Managed = true
+		}
+	}
+}
+
+// readExpiry parses the first certificate of the given PEM file
+func readExpiry(path string) (Expiry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Expiry{}, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return Expiry{}, fmt.Errorf("no PEM encoded certificate found")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return Expiry{}, err
+	}
+	return Expiry{
+		Path:      path,
+		Subject:   cert.Subject.CommonName,
+		Issuer:    cert.Issuer.CommonName,
+		IsCA:      cert.IsCA,
+		NotBefore: cert.NotBefore,
+		NotAfter:  cert.NotAfter,
+		Managed:   isK0sCA(cert.Issuer.CommonName),
+	}, nil
+}
@@ -0,0 +1,63 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package certificate
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+func TestScanExpiry(t *testing.T) {
+	k0sVars := constant.GetConfig(t.TempDir())
+	require.NoError(t, os.MkdirAll(k0sVars.EtcdCertDir, 0755))
+	m := Manager{K0sVars: k0sVars}
+
+	require.NoError(t, m.EnsureCA("etcd/ca", "etcd-ca"))
+	_, err := m.EnsureCertificate(Request{
+		Name:   "etcd/server",
+		CN:     "etcd-server",
+		O:      "etcd-server",
+		CACert: filepath.Join(k0sVars.EtcdCertDir, "ca.crt"),
+		CAKey:  filepath.Join(k0sVars.EtcdCertDir, "ca.key"),
+	}, "root")
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(filepath.Join(k0sVars.CertRootDir, "garbage.crt"), []byte("garbage"), 0644))
+
+	expiries, err := ScanExpiry(k0sVars.CertRootDir, k0sVars.EtcdCertDir, filepath.Join(k0sVars.DataDir, "nonexistent"))
+	require.NoError(t, err)
+	require.Len(t, expiries, 2, "the etcd dir is scanned once and unparsable files are skipped")
+
+	now := time.Now()
+	ca, server := expiries[0], expiries[1]
+	assert.Equal(t, "etcd/ca", ca.Name)
+	assert.True(t, ca.IsCA)
+	assert.True(t, ca.Managed)
+	assert.Equal(t, 3649, ca.DaysRemaining(now))
+
+	assert.Equal(t, "etcd/server", server.Name)
+	assert.Equal(t, "etcd-server", server.Subject)
+	assert.Equal(t, "etcd-ca", server.Issuer)
+	assert.False(t, server.IsCA)
+	assert.True(t, server.Managed)
+	assert.Equal(t, 364, server.DaysRemaining(now))
+}
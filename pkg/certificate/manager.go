@@ -23,19 +23,20 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"time"
 
 	"github.com/cloudflare/cfssl/certinfo"
-	"github.com/cloudflare/cfssl/cli"
 	"github.com/cloudflare/cfssl/cli/genkey"
-	"github.com/cloudflare/cfssl/cli/sign"
 	"github.com/cloudflare/cfssl/csr"
 	"github.com/cloudflare/cfssl/initca"
 	"github.com/cloudflare/cfssl/signer"
+	"github.com/cloudflare/cfssl/signer/local"
 	"github.com/sirupsen/logrus"
 
 	"github.com/k0sproject/k0s/internal/pkg/file"
 	"github.com/k0sproject/k0s/internal/pkg/stringslice"
 	"github.com/k0sproject/k0s/internal/pkg/users"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 	"github.com/k0sproject/k0s/pkg/constant"
 )
 
@@ -58,6 +59,8 @@ type Certificate struct {
 // Manager is the certificate manager
 type Manager struct {
 	K0sVars constant.CfgVars
+	// Config selects the key algorithm and the validity of the generated certificates, the defaults apply if nil
+	Config *v1beta1.CertificatesSpec
 }
 
 // EnsureCA makes sure the given CA certs and key is created.
@@ -65,19 +68,31 @@ func (m *Manager) EnsureCA(name, cn string) error {
 	keyFile := filepath.Join(m.K0sVars.CertRootDir, fmt.Sprintf("%s.key", name))
 	certFile := filepath.Join(m.K0sVars.CertRootDir, fmt.Sprintf("%s.crt", name))
 
+	if ca := m.Config.ExternalCA(name); ca != nil {
+		return installCA(ca, certFile, keyFile)
+	}
+
 	if file.Exists(keyFile) && file.Exists(certFile) {
 		return nil
 	}
 
-	req := new(csr.CertificateRequest)
-	req.KeyRequest = csr.NewKeyRequest()
-	req.KeyRequest.A = "rsa"
-	req.KeyRequest.S = 2048
-	req.CN = cn
-	req.CA = &csr.CAConfig{
-		Expiry: "87600h",
+	algorithm, size := m.Config.Key()
+	var cert, key []byte
+	var err error
+	if algorithm == v1beta1.Ed25519KeyAlgorithm {
+		// cfssl can't generate Ed25519 keys
+		cert, key, err = newEd25519CA(cn, m.Config.CAExpiry())
+	} else {
+		req := new(csr.CertificateRequest)
+		req.KeyRequest = csr.NewKeyRequest()
+		req.KeyRequest.A = algorithm
+		req.KeyRequest.S = size
+		req.CN = cn
+		req.CA = &csr.CAConfig{
+			Expiry: m.Config.CAExpiry().String(),
+		}
+		cert, _, key, err = initca.New(req)
 	}
-	cert, _, key, err := initca.New(req)
 	if err != nil {
 		return err
 	}
@@ -114,21 +129,28 @@ func (m *Manager) EnsureCertificate(certReq Request, ownerName string) (Certific
 			},
 		}
 
-		req.KeyRequest.A = "rsa"
-		req.KeyRequest.S = 2048
+		algorithm, size := m.Config.Key()
+		req.KeyRequest.A = algorithm
+		req.KeyRequest.S = size
 		req.Hosts = stringslice.Unique(certReq.Hostnames)
 
 		var key, csrBytes []byte
-		g := &csr.Generator{Validator: genkey.Validator}
-		csrBytes, key, err := g.ProcessRequest(&req)
+		var err error
+		if algorithm == v1beta1.Ed25519KeyAlgorithm {
+			csrBytes, key, err = newEd25519CSR(&req)
+		} else {
+			g := &csr.Generator{Validator: genkey.Validator}
+			csrBytes, key, err = g.ProcessRequest(&req)
+		}
 		if err != nil {
 			return Certificate{}, err
 		}
-		config := cli.Config{
-			CAFile:    certReq.CACert,
-			CAKeyFile: certReq.CAKey,
+		bundle, caKey, err := loadCA(certReq.CACert, certReq.CAKey)
+		if err != nil {
+			return Certificate{}, err
 		}
-		s, err := sign.SignerFromConfig(config)
+		expiry := m.Config.Expiry(certReq.Name)
+		s, err := local.NewSigner(caKey, bundle[0], signer.DefaultSigAlgo(caKey), signingPolicy(expiry))
 		if err != nil {
 			return Certificate{}, err
 		}
@@ -138,11 +160,20 @@ func (m *Manager) EnsureCertificate(certReq Request, ownerName string) (Certific
 			Request: string(csrBytes),
 			Profile: "kubernetes",
 		}
+		// don't outlive the CA, which may be an external one with a shorter validity
+		if time.Now().Add(expiry).After(bundle[0].NotAfter) {
+			signReq.NotAfter = bundle[0].NotAfter
+		}
 
 		cert, err = s.Sign(signReq)
 		if err != nil {
 			return Certificate{}, err
 		}
+		if err := verifyIssued(cert, bundle); err != nil {
+			return Certificate{}, fmt.Errorf("certificate %s doesn't chain to the CA %s: %w", certFile, certReq.CACert, err)
+		}
+		// the certificate comes with the intermediate CAs, so that peers trusting the root only can verify it
+		cert = append(cert, intermediatesPEM(bundle)...)
 		c := Certificate{
 			Key:  string(key),
 			Cert: string(cert),
@@ -204,7 +235,7 @@ func (m *Manager) regenerateCert(certReq Request, keyFile string, certFile strin
 		return true
 	}
 
-	if isManagedByK0s(cert) {
+	if isManagedByK0s(cert) || issuedBy(cert.Issuer.CommonName, certReq.CACert) {
 		return true
 	}
 
@@ -214,7 +245,12 @@ func (m *Manager) regenerateCert(certReq Request, keyFile string, certFile strin
 
 // checks if the cert issuer (CA) is a k0s setup one
 func isManagedByK0s(cert *certinfo.Certificate) bool {
-	switch cert.Issuer.CommonName {
+	return isK0sCA(cert.Issuer.CommonName)
+}
+
+// isK0sCA checks if the given common name is the one of a CA set up by k0s
+func isK0sCA(cn string) bool {
+	switch cn {
 	case "kubernetes-ca":
 		return true
 	case "kubernetes-front-proxy-ca":
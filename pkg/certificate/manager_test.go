@@ -0,0 +1,242 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package certificate
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+func TestManagerKeyAlgorithms(t *testing.T) {
+	for _, test := range []struct {
+		algorithm string
+		size      int
+		check     func(t *testing.T, key interface{})
+	}{
+		{v1beta1.RSAKeyAlgorithm, 3072, func(t *testing.T, key interface{}) {
+			if assert.IsType(t, &rsa.PublicKey{}, key) {
+				assert.Equal(t, 3072, key.(*rsa.PublicKey).N.BitLen())
+			}
+		}},
+		{v1beta1.ECDSAKeyAlgorithm, 384, func(t *testing.T, key interface{}) {
+			if assert.IsType(t, &ecdsa.PublicKey{}, key) {
+				assert.Equal(t, "P-384", key.(*ecdsa.PublicKey).Curve.Params().Name)
+			}
+		}},
+		{v1beta1.Ed25519KeyAlgorithm, 0, func(t *testing.T, key interface{}) {
+			assert.IsType(t, ed25519.PublicKey{}, key)
+		}},
+	} {
+		t.Run(test.algorithm, func(t *testing.T) {
+			k0sVars := constant.GetConfig(t.TempDir())
+			require.NoError(t, os.MkdirAll(k0sVars.CertRootDir, 0755))
+			m := Manager{
+				K0sVars: k0sVars,
+				Config: &v1beta1.CertificatesSpec{
+					KeyAlgorithm: test.algorithm,
+					KeySize:      test.size,
+					CAValidity:   metav1.Duration{Duration: 365 * 24 * time.Hour},
+					Validity:     metav1.Duration{Duration: 90 * 24 * time.Hour},
+					ValidityOverrides: map[string]metav1.Duration{
+						"server": {Duration: 7 * 24 * time.Hour},
+					},
+				},
+			}
+
+			require.NoError(t, m.EnsureCA("ca", "kubernetes-ca"))
+			ca := readCert(t, filepath.Join(k0sVars.CertRootDir, "ca.crt"))
+			assert.True(t, ca.IsCA)
+			assert.Equal(t, "kubernetes-ca", ca.Subject.CommonName)
+			assert.InDelta(t, 365*24*time.Hour, time.Until(ca.NotAfter), float64(10*time.Minute))
+			test.check(t, ca.PublicKey)
+
+			for name, validity := range map[string]time.Duration{
+				"server": 7 * 24 * time.Hour,
+				"admin":  90 * 24 * time.Hour,
+			} {
+				c, err := m.EnsureCertificate(Request{
+					Name:      name,
+					CN:        name,
+					O:         "system:masters",
+					CACert:    filepath.Join(k0sVars.CertRootDir, "ca.crt"),
+					CAKey:     filepath.Join(k0sVars.CertRootDir, "ca.key"),
+					Hostnames: []string{"localhost", "127.0.0.1"},
+				}, "root")
+				require.NoError(t, err)
+				_, err = tls.X509KeyPair([]byte(c.Cert), []byte(c.Key))
+				require.NoError(t, err, "the key doesn't match the certificate")
+
+				cert := readCert(t, filepath.Join(k0sVars.CertRootDir, name+".crt"))
+				assert.InDelta(t, validity, time.Until(cert.NotAfter), float64(10*time.Minute), name)
+				assert.NoError(t, cert.CheckSignatureFrom(ca), name)
+				assert.Equal(t, []string{"localhost"}, cert.DNSNames)
+				assert.Len(t, cert.IPAddresses, 1)
+				test.check(t, cert.PublicKey)
+			}
+		})
+	}
+}
+
+func TestManagerExternalCA(t *testing.T) {
+	dir := t.TempDir()
+	root, rootKey := newTestCA(t, "Example Root CA", nil, nil)
+	intermediate, intermediateKey := newTestCA(t, "Example Kubernetes CA", root, rootKey)
+
+	bundle := append(pemCert(intermediate), pemCert(root)...)
+	keyDER, err := x509.MarshalPKCS8PrivateKey(intermediateKey)
+	require.NoError(t, err)
+	certFile, keyFile := filepath.Join(dir, "ca-bundle.crt"), filepath.Join(dir, "ca.key")
+	require.NoError(t, os.WriteFile(certFile, bundle, 0644))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600))
+
+	k0sVars := constant.GetConfig(filepath.Join(dir, "k0s"))
+	require.NoError(t, os.MkdirAll(k0sVars.CertRootDir, 0755))
+	m := Manager{
+		K0sVars: k0sVars,
+		Config: &v1beta1.CertificatesSpec{
+			CA: &v1beta1.CASpec{CertFile: certFile, KeyFile: keyFile},
+		},
+	}
+	require.NoError(t, m.EnsureCA("ca", "kubernetes-ca"))
+	installed, err := os.ReadFile(filepath.Join(k0sVars.CertRootDir, "ca.crt"))
+	require.NoError(t, err)
+	assert.Equal(t, pemCert(intermediate), installed, "only the intermediate should be trusted to authenticate clients")
+	bundleFile := CABundleFile(filepath.Join(k0sVars.CertRootDir, "ca.crt"))
+	assert.Equal(t, filepath.Join(k0sVars.CertRootDir, "ca-bundle.crt"), bundleFile)
+	installed, err = os.ReadFile(bundleFile)
+	require.NoError(t, err)
+	assert.Equal(t, bundle, installed, "the full chain should be installed in the bundle")
+
+	req := Request{
+		Name:   "admin",
+		CN:     "kubernetes-admin",
+		O:      "system:masters",
+		CACert: filepath.Join(k0sVars.CertRootDir, "ca.crt"),
+		CAKey:  filepath.Join(k0sVars.CertRootDir, "ca.key"),
+	}
+	_, err = m.EnsureCertificate(req, "root")
+	require.NoError(t, err)
+	admin := readCert(t, filepath.Join(k0sVars.CertRootDir, "admin.crt"))
+	assert.Equal(t, "Example Kubernetes CA", admin.Issuer.CommonName)
+	assert.False(t, admin.NotAfter.After(intermediate.NotAfter), "the certificate shouldn't outlive its CA")
+
+	roots, intermediates := x509.NewCertPool(), x509.NewCertPool()
+	roots.AddCert(root)
+	intermediates.AddCert(intermediate)
+	_, err = admin.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	assert.NoError(t, err, "the certificate should chain up to the root")
+	adminPEM, err := os.ReadFile(filepath.Join(k0sVars.CertRootDir, "admin.crt"))
+	require.NoError(t, err)
+	assert.True(t, bytes.HasSuffix(adminPEM, pemCert(intermediate)), "the certificate should come with the intermediate")
+	assert.NotContains(t, string(adminPEM), string(pemCert(root)), "the certificate shouldn't come with the root")
+
+	assert.True(t, m.regenerateCert(req, filepath.Join(k0sVars.CertRootDir, "admin.key"), filepath.Join(k0sVars.CertRootDir, "admin.crt")),
+		"certificates issued by the external CA should be managed by k0s")
+	expiries, err := ScanExpiry(k0sVars.CertRootDir)
+	require.NoError(t, err)
+	require.Len(t, expiries, 2)
+	assert.True(t, expiries[0].Managed, "admin")
+	assert.False(t, expiries[1].Managed, "ca")
+
+	// a joining controller has the CA synced, but not the external files
+	require.NoError(t, os.Remove(certFile))
+	assert.NoError(t, m.EnsureCA("ca", "kubernetes-ca"))
+	installed, err = os.ReadFile(bundleFile)
+	require.NoError(t, err)
+	assert.Equal(t, bundle, installed, "the bundle should be kept")
+
+	// the CA synced to a joining controller is the bundle, written to its CA file
+	require.NoError(t, os.Remove(bundleFile))
+	require.NoError(t, os.WriteFile(filepath.Join(k0sVars.CertRootDir, "ca.crt"), bundle, 0644))
+	assert.NoError(t, m.EnsureCA("ca", "kubernetes-ca"))
+	installed, err = os.ReadFile(filepath.Join(k0sVars.CertRootDir, "ca.crt"))
+	require.NoError(t, err)
+	assert.Equal(t, pemCert(intermediate), installed)
+	installed, err = os.ReadFile(bundleFile)
+	require.NoError(t, err)
+	assert.Equal(t, bundle, installed)
+
+	// a key not matching the CA
+	otherKey, err := x509.MarshalPKCS8PrivateKey(rootKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(certFile, bundle, 0644))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: otherKey}), 0600))
+	assert.ErrorContains(t, m.EnsureCA("ca", "kubernetes-ca"), "the key doesn't match")
+
+	// an intermediate not chaining up to the root provided
+	otherRoot, _ := newTestCA(t, "Other Root CA", nil, nil)
+	require.NoError(t, os.WriteFile(certFile, append(pemCert(intermediate), pemCert(otherRoot)...), 0644))
+	require.NoError(t, os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: keyDER}), 0600))
+	assert.ErrorContains(t, m.EnsureCA("ca", "kubernetes-ca"), "certificate signed by unknown authority")
+}
+
+func newTestCA(t *testing.T, cn string, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	serial, err := rand.Int(rand.Reader, big.NewInt(1<<62))
+	require.NoError(t, err)
+	tpl := &x509.Certificate{
+		SerialNumber:          serial,
+		Subject:               pkix.Name{CommonName: cn},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(180 * 24 * time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	if parent == nil {
+		parent, parentKey = tpl, key
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tpl, parent, key.Public(), parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, key
+}
+
+func pemCert(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+func readCert(t *testing.T, path string) *x509.Certificate {
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	block, _ := pem.Decode(data)
+	require.NotNil(t, block)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	require.NoError(t, err)
+	return cert
+}
@@ -72,3 +72,16 @@ type ReconcilerComponent interface {
 	// configuration. Reconcile may only be called after Init and before Stop.
 	Reconcile(context.Context, *v1beta1.ClusterConfig) error
 }
+
+// NodeReconcilerComponent defines the component interface that is reconciled
+// based on changes of the node-level configuration, i.e. when the node config
+// file is reloaded without restarting k0s.
+type NodeReconcilerComponent interface {
+	Component
+
+	// ReconcileNodeConfig aligns the running component with the changed node
+	// configuration, restarting its processes only if they are affected by
+	// the change. ReconcileNodeConfig may only be called after Run and before
+	// Stop.
+	ReconcileNodeConfig(context.Context, *v1beta1.ClusterConfig) error
+}
@@ -24,6 +24,8 @@ import (
 	"net/http"
 	"os"
 	"path"
+	"reflect"
+	"sort"
 	"strings"
 
 	"github.com/sirupsen/logrus"
@@ -33,6 +35,7 @@ import (
 	"github.com/k0sproject/k0s/internal/pkg/users"
 	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 	"github.com/k0sproject/k0s/pkg/assets"
+	"github.com/k0sproject/k0s/pkg/certificate"
 	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/constant"
 	"github.com/k0sproject/k0s/pkg/supervisor"
@@ -43,6 +46,8 @@ type APIServer struct {
 	ClusterConfig      *v1beta1.ClusterConfig
 	K0sVars            constant.CfgVars
 	LogLevel           string
+	RestartPolicy      *supervisor.RestartPolicy
+	LogConfig          *supervisor.LogConfig
 	Storage            component.Component
 	EnableKonnectivity bool
 	gid                int
@@ -50,7 +55,7 @@ type APIServer struct {
 	uid                int
 }
 
-var _ component.Component = (*APIServer)(nil)
+var _ component.NodeReconcilerComponent = (*APIServer)(nil)
 
 var apiDefaultArgs = map[string]string{
 	"allow-privileged":                   "true",
@@ -91,9 +96,58 @@ func (a *APIServer) Init(_ context.Context) error {
 // Run runs kube api
 func (a *APIServer) Run(_ context.Context) error {
 	logrus.Info("Starting kube-apiserver")
+	if a.EnableKonnectivity {
+		if err := a.writeKonnectivityConfig(); err != nil {
+			return err
+		}
+	}
+	args, err := a.buildArgs(a.ClusterConfig)
+	if err != nil {
+		return err
+	}
+	return a.start(args)
+}
+
+// ReconcileNodeConfig restarts kube-apiserver if its arguments are affected by the node config change
+func (a *APIServer) ReconcileNodeConfig(_ context.Context, nodeConfig *v1beta1.ClusterConfig) error {
+	args, err := a.buildArgs(nodeConfig)
+	if err != nil {
+		return err
+	}
+	if reflect.DeepEqual(args, a.supervisor.Args) {
+		logrus.WithField("component", "kube-apiserver").Info("node config reload has nothing to do")
+		a.ClusterConfig = nodeConfig
+		return nil
+	}
+
+	logrus.WithField("component", "kube-apiserver").Info("restarting kube-apiserver to apply the reloaded node config")
+	if err := a.supervisor.Stop(); err != nil {
+		return err
+	}
+	a.ClusterConfig = nodeConfig
+	return a.start(args)
+}
+
+func (a *APIServer) start(args []string) error {
+	a.supervisor = supervisor.Supervisor{
+		Name:          "kube-apiserver",
+		BinPath:       assets.BinPath("kube-apiserver", a.K0sVars.BinDir),
+		RunDir:        a.K0sVars.RunDir,
+		DataDir:       a.K0sVars.DataDir,
+		Args:          args,
+		UID:           a.uid,
+		GID:           a.gid,
+		RestartPolicy: a.RestartPolicy,
+		LogConfig:     a.LogConfig,
+	}
+	return a.supervisor.Supervise()
+}
+
+// buildArgs returns the sorted kube-apiserver arguments for the given config
+func (a *APIServer) buildArgs(clusterConfig *v1beta1.ClusterConfig) ([]string, error) {
 	args := stringmap.StringMap{
-		"advertise-address":                a.ClusterConfig.Spec.API.Address,
-		"secure-port":                      fmt.Sprintf("%d", a.ClusterConfig.Spec.API.Port),
+		"advertise-address":                clusterConfig.Spec.API.Address,
+		"secure-port":                      fmt.Sprintf("%d", clusterConfig.Spec.API.Port),
 		"authorization-mode":               "Node,RBAC",
 		"client-ca-file":                   path.Join(a.K0sVars.CertRootDir, "ca.crt"),
 		"enable-bootstrap-token-auth":      "true",
@@ -105,7 +159,7 @@ func (a *APIServer) Run(_ context.Context) error {
 		"requestheader-allowed-names":      "front-proxy-client",
 		"requestheader-client-ca-file":     path.Join(a.K0sVars.CertRootDir, "front-proxy-ca.crt"),
 		"service-account-key-file":         path.Join(a.K0sVars.CertRootDir, "sa.pub"),
-		"service-cluster-ip-range":         a.ClusterConfig.Spec.Network.BuildServiceCIDR(a.ClusterConfig.Spec.API.Address),
+		"service-cluster-ip-range":         clusterConfig.Spec.Network.BuildServiceCIDR(clusterConfig.Spec.API.Address),
 		"tls-cert-file":                    path.Join(a.K0sVars.CertRootDir, "server.crt"),
 		"tls-private-key-file":             path.Join(a.K0sVars.CertRootDir, "server.key"),
 		"service-account-signing-key-file": path.Join(a.K0sVars.CertRootDir, "sa.key"),
@@ -113,31 +167,27 @@ func (a *APIServer) Run(_ context.Context) error {
 		"service-account-jwks-uri":         "https://kubernetes.default.svc/openid/v1/jwks",
 		"profiling":                        "false",
 		"v":                                a.LogLevel,
-		"kubelet-certificate-authority":    path.Join(a.K0sVars.CertRootDir, "ca.crt"),
+		"kubelet-certificate-authority":    certificate.CABundleFile(path.Join(a.K0sVars.CertRootDir, "ca.crt")),
 		"enable-admission-plugins":         "NodeRestriction,PodSecurityPolicy",
 	}
 
 	apiAudiences := []string{"https://kubernetes.default.svc"}
 
 	if a.EnableKonnectivity {
-		err := a.writeKonnectivityConfig()
-		if err != nil {
-			return err
-		}
 		args["egress-selector-config-file"] = path.Join(a.K0sVars.DataDir, "konnectivity.conf")
 		apiAudiences = append(apiAudiences, "system:konnectivity-server")
 	}
 
 	args["api-audiences"] = strings.Join(apiAudiences, ",")
 
-	for name, value := range a.ClusterConfig.Spec.API.ExtraArgs {
+	for name, value := range clusterConfig.Spec.API.ExtraArgs {
 		if args[name] != "" {
 			logrus.Warnf("overriding apiserver flag with user provided value: %s", name)
 		}
 		args[name] = value
 	}
 
-	if a.ClusterConfig.Spec.Network.DualStack.Enabled {
+	if clusterConfig.Spec.Network.DualStack.Enabled {
 		args = v1beta1.EnableFeatureGate(args, v1beta1.DualStackFeatureGate)
 	}
 	args = v1beta1.EnableFeatureGate(args, v1beta1.ServiceInternalTrafficPolicyFeatureGate)
@@ -146,32 +196,18 @@ func (a *APIServer) Run(_ context.Context) error {
 			args[name] = value
 		}
 	}
-	if a.ClusterConfig.Spec.API.ExternalAddress != "" || a.ClusterConfig.Spec.API.TunneledNetworkingMode {
+	if clusterConfig.Spec.API.ExternalAddress != "" || clusterConfig.Spec.API.TunneledNetworkingMode {
 		args["endpoint-reconciler-type"] = "none"
 	}
 
-	var apiServerArgs []string
-	for name, value := range args {
-		apiServerArgs = append(apiServerArgs, fmt.Sprintf("--%s=%s", name, value))
-	}
+	apiServerArgs := args.ToDashedArgs()
+	sort.Strings(apiServerArgs)
 
-	a.supervisor = supervisor.Supervisor{
-		Name:    "kube-apiserver",
-		BinPath: assets.BinPath("kube-apiserver", a.K0sVars.BinDir),
-		RunDir:  a.K0sVars.RunDir,
-		DataDir: a.K0sVars.DataDir,
-		Args:    apiServerArgs,
-		UID:     a.uid,
-		GID:     a.gid,
-	}
-
-	etcdArgs, err := getEtcdArgs(a.ClusterConfig.Spec.Storage, a.K0sVars)
+	etcdArgs, err := getEtcdArgs(clusterConfig.Spec.Storage, a.K0sVars)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	a.supervisor.Args = append(a.supervisor.Args, etcdArgs...)
-
-	return a.supervisor.Supervise()
+	return append(apiServerArgs, etcdArgs...), nil
 }
 
 func (a *APIServer) writeKonnectivityConfig() error {
@@ -198,6 +234,9 @@ func (a *APIServer) Stop() error {
 
 // Health-check interface
 func (a *APIServer) Healthy() error {
+	if err := a.supervisor.Healthy(); err != nil {
+		return err
+	}
 	// Load client cert so the api can authenitcate the request.
 	certFile := path.Join(a.K0sVars.CertRootDir, "admin.crt")
 	keyFile := path.Join(a.K0sVars.CertRootDir, "admin.key")
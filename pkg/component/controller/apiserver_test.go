@@ -101,3 +101,26 @@ func (a *apiServerSuite) TestGetEtcdArgs() {
 		a.Contains(result[1], "--etcd-prefix=k0s-tenant-1")
 	})
 }
+
+func (a *apiServerSuite) TestBuildArgs() {
+	apiServer := &APIServer{
+		K0sVars: constant.CfgVars{
+			CertRootDir: "/var/lib/k0s/pki",
+			EtcdCertDir: "/var/lib/k0s/pki/etcd",
+		},
+	}
+	cfg := v1beta1.DefaultClusterConfig()
+
+	args, err := apiServer.buildArgs(cfg)
+	a.Require().NoError(err)
+	again, err := apiServer.buildArgs(cfg)
+	a.Require().NoError(err)
+	a.Equal(args, again, "the arguments have to be stable to detect node config changes")
+
+	changed := cfg.DeepCopy()
+	changed.Spec.API.ExtraArgs = map[string]string{"audit-log-path": "/var/log/audit.log"}
+	changedArgs, err := apiServer.buildArgs(changed)
+	a.Require().NoError(err)
+	a.NotEqual(args, changedArgs)
+	a.Contains(changedArgs, "--audit-log-path=/var/log/audit.log")
+}
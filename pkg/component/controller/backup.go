@@ -0,0 +1,237 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controller
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/k0sproject/k0s/internal/pkg/dir"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/backup"
+	"github.com/k0sproject/k0s/pkg/component"
+	"github.com/k0sproject/k0s/pkg/constant"
+	kubeutil "github.com/k0sproject/k0s/pkg/kubernetes"
+)
+
+// Backup periodically creates backup archives on the leading controller and
+// prunes the old ones according to the configured retention policy
+type Backup struct {
+	ClusterConfig     *v1beta1.ClusterConfig
+	K0sVars           constant.CfgVars
+	LeaderElector     LeaderElector
+	KubeClientFactory kubeutil.ClientFactoryInterface
+
+	log      *logrus.Entry
+	stop     context.CancelFunc
+	leaderCh chan struct{}
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+var _ component.Component = (*Backup)(nil)
+
+// NewBackup creates the scheduled backup component
+func NewBackup(clusterConfig *v1beta1.ClusterConfig, k0sVars constant.CfgVars, leaderElector LeaderElector, kubeClientFactory kubeutil.ClientFactoryInterface) *Backup {
+	return &Backup{
+		ClusterConfig:     clusterConfig,
+		K0sVars:           k0sVars,
+		LeaderElector:     leaderElector,
+		KubeClientFactory: kubeClientFactory,
+		log:               logrus.WithFields(logrus.Fields{"component": "backup"}),
+		leaderCh:          make(chan struct{}, 1),
+	}
+}
+
+// Init makes sure the backup save path exists
+func (b *Backup) Init(_ context.Context) error {
+	// re-schedule on acquiring the lease, the previous leader may have missed a backup
+	b.LeaderElector.AddAcquiredLeaseCallback(func() {
+		select {
+		case b.leaderCh <- struct{}{}:
+		default:
+		}
+	})
+
+	if b.ClusterConfig.Spec.Backup.S3 != nil {
+		return nil
+	}
+	if err := dir.Init(b.ClusterConfig.Spec.Backup.SavePath, constant.BackupDirMode); err != nil {
+		return fmt.Errorf("failed to initialize backup save path: %w", err)
+	}
+	return nil
+}
+
+// Run starts the backup loop
+func (b *Backup) Run(ctx context.Context) error {
+	ctx, b.stop = context.WithCancel(ctx)
+	interval := b.ClusterConfig.Spec.Backup.Interval.Duration
+	b.log.Infof("scheduling backups every %s", interval)
+
+	go func() {
+		defer b.stop()
+		timer := time.NewTimer(b.nextBackupIn(ctx, interval))
+		defer timer.Stop()
+		for {
+			select {
+			case <-timer.C:
+				err := b.runBackup(ctx)
+				b.setLastErr(err)
+				if err != nil {
+					// don't retry right away, the newest archive is still the one of the missed backup
+					timer.Reset(interval)
+				} else {
+					timer.Reset(b.nextBackupIn(ctx, interval))
+				}
+			case <-b.leaderCh:
+				if !timer.Stop() {
+					<-timer.C
+				}
+				timer.Reset(b.nextBackupIn(ctx, interval))
+			case <-ctx.Done():
+				b.log.Info("backup context done")
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops the backup loop
+func (b *Backup) Stop() error {
+	if b.stop != nil {
+		b.stop()
+	}
+	return nil
+}
+
+// Healthy reports the error of the last failed backup, if any
+func (b *Backup) Healthy() error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.lastErr
+}
+
+func (b *Backup) setLastErr(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.lastErr = err
+}
+
+// nextBackupIn returns the delay until the next backup is due, one interval
+// after the newest archive of the store. A leader without any archive backs up
+// right away, the other controllers check again after an interval.
+func (b *Backup) nextBackupIn(ctx context.Context, interval time.Duration) time.Duration {
+	if !b.LeaderElector.IsLeader() {
+		return interval
+	}
+
+	store, err := b.archiveStore(ctx)
+	if err != nil {
+		b.log.WithError(err).Warn("can't find the latest backup, scheduling the next one in an interval")
+		return interval
+	}
+	latest, err := backup.LatestArchiveTime(ctx, store)
+	if err != nil {
+		b.log.WithError(err).Warn("can't find the latest backup, scheduling the next one in an interval")
+		return interval
+	}
+
+	next := time.Until(latest.Add(interval))
+	if next < 0 {
+		next = 0
+	}
+	b.log.Infof("next backup in %s", next.Round(time.Second))
+	return next
+}
+
+func (b *Backup) runBackup(ctx context.Context) error {
+	if !b.LeaderElector.IsLeader() {
+		b.log.Debug("not the leader, skipping scheduled backup")
+		return nil
+	}
+
+	if err := b.backup(ctx); err != nil {
+		b.log.WithError(err).Error("scheduled backup failed")
+		return err
+	}
+	return nil
+}
+
+func (b *Backup) backup(ctx context.Context) error {
+	spec := b.ClusterConfig.Spec.Backup
+	store, err := b.archiveStore(ctx)
+	if err != nil {
+		return err
+	}
+
+	mgr, err := backup.NewBackupManager()
+	if err != nil {
+		return err
+	}
+	if spec.Encryption != nil {
+		if mgr.Encryption, err = backup.NewEncryption(spec.Encryption); err != nil {
+			return err
+		}
+	}
+	if spec.SigningKeyFile != "" {
+		if mgr.SigningKey, err = backup.LoadSigningKey(spec.SigningKeyFile); err != nil {
+			return err
+		}
+	}
+	if err := mgr.RunBackup(ctx, b.ClusterConfig.Spec, b.K0sVars, store); err != nil {
+		return err
+	}
+
+	if spec.Retention == nil {
+		return nil
+	}
+	pruned, err := backup.PruneArchives(ctx, store, spec.Retention.MaxCount, spec.Retention.MaxAge.Duration)
+	for _, name := range pruned {
+		b.log.Infof("removed backup archive %s from %s according to the retention policy", name, store)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to prune backup archives: %w", err)
+	}
+	return nil
+}
+
+func (b *Backup) archiveStore(ctx context.Context) (backup.ArchiveStore, error) {
+	spec := b.ClusterConfig.Spec.Backup
+	if spec.S3 == nil {
+		return backup.NewLocalDestination(spec.SavePath), nil
+	}
+
+	var kubeClient kubernetes.Interface
+	if spec.S3.CredentialsSecret != "" {
+		client, err := b.KubeClientFactory.GetClient()
+		if err != nil {
+			return nil, fmt.Errorf("can't create kubernetes client for S3 credentials: %w", err)
+		}
+		kubeClient = client
+	}
+	return backup.NewS3Destination(ctx, spec.S3, kubeClient)
+}
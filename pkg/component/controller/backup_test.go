@@ -0,0 +1,59 @@
+//go:build !windows
+// +build !windows
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controller
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+func TestBackupNextBackupIn(t *testing.T) {
+	ctx := context.Background()
+	interval := 24 * time.Hour
+	savePath := t.TempDir()
+	cfg := v1beta1.DefaultClusterConfig()
+	cfg.Spec.Backup = &v1beta1.BackupSpec{Interval: metav1.Duration{Duration: interval}, SavePath: savePath}
+	leaderElector := &DummyLeaderElector{Leader: false}
+	b := NewBackup(cfg, constant.CfgVars{}, leaderElector, nil)
+
+	assert.Equal(t, interval, b.nextBackupIn(ctx, interval), "only the leader backs up")
+
+	leaderElector.Leader = true
+	assert.Equal(t, time.Duration(0), b.nextBackupIn(ctx, interval), "no backup yet")
+
+	archive := filepath.Join(savePath, "k0s_backup_2022-01-01T00_00_00Z.tar.gz")
+	require.NoError(t, os.WriteFile(archive, []byte("backup"), 0600))
+	modTime := time.Now().Add(-20 * time.Hour)
+	require.NoError(t, os.Chtimes(archive, modTime, modTime))
+	assert.InDelta(t, 4*time.Hour, b.nextBackupIn(ctx, interval), float64(time.Minute))
+
+	modTime = time.Now().Add(-25 * time.Hour)
+	require.NoError(t, os.Chtimes(archive, modTime, modTime))
+	assert.Equal(t, time.Duration(0), b.nextBackupIn(ctx, interval), "backup overdue")
+}
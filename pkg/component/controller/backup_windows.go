@@ -0,0 +1,44 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controller
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/component"
+	"github.com/k0sproject/k0s/pkg/constant"
+	kubeutil "github.com/k0sproject/k0s/pkg/kubernetes"
+)
+
+// Backup is not supported on Windows
+type Backup struct{}
+
+var _ component.Component = (*Backup)(nil)
+
+// NewBackup creates the scheduled backup component
+func NewBackup(*v1beta1.ClusterConfig, constant.CfgVars, LeaderElector, kubeutil.ClientFactoryInterface) *Backup {
+	return &Backup{}
+}
+
+func (b *Backup) Init(_ context.Context) error {
+	return fmt.Errorf("scheduled backups are not supported on Windows")
+}
+
+func (b *Backup) Run(_ context.Context) error { return nil }
+func (b *Backup) Stop() error                 { return nil }
+func (b *Backup) Healthy() error              { return nil }
@@ -0,0 +1,186 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controller
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/k0sproject/k0s/pkg/certificate"
+	"github.com/k0sproject/k0s/pkg/component"
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/supervisor"
+)
+
+// certificateConsumers are the processes reading the k0s managed certificates on startup only
+var certificateConsumers = []string{
+	"etcd",
+	"k0s-control-api",
+	"konnectivity",
+	"kube-apiserver",
+	"kube-controller-manager",
+	"kube-scheduler",
+}
+
+// CertificateRotation periodically checks the expiry of the certificates in the cert dirs
+// and rotates the k0s managed leaf certificates ahead of their expiry
+type CertificateRotation struct {
+	K0sVars constant.CfgVars
+	// Renew regenerates the k0s managed leaf certificates
+	Renew func(context.Context) error
+	// RenewBefore is how long before their expiry the certificates get rotated, defaults to 30 days.
+	// Certificates with a lifetime shorter than three times RenewBefore get rotated after two thirds of it.
+	RenewBefore time.Duration
+	// CheckInterval is how often the expiry gets checked, defaults to 1 hour
+	CheckInterval time.Duration
+
+	log  *logrus.Entry
+	stop context.CancelFunc
+	// rotateMutex serializes the rotations
+	rotateMutex sync.Mutex
+	mutex       sync.Mutex
+	// expired lists the managed certificates found expired by the latest scan
+	expired []string
+}
+
+var _ component.Component = (*CertificateRotation)(nil)
+
+// Init sets the defaults
+func (r *CertificateRotation) Init(_ context.Context) error {
+	r.log = logrus.WithField("component", "certificate-rotation")
+	if r.RenewBefore == 0 {
+		r.RenewBefore = 30 * 24 * time.Hour
+	}
+	if r.CheckInterval == 0 {
+		r.CheckInterval = time.Hour
+	}
+	return nil
+}
+
+// Run checks the certificates right away and then every CheckInterval
+func (r *CertificateRotation) Run(ctx context.Context) error {
+	ctx, r.stop = context.WithCancel(ctx)
+	go func() {
+		ticker := time.NewTicker(r.CheckInterval)
+		defer ticker.Stop()
+		for {
+			if err := r.check(ctx); err != nil {
+				r.log.WithError(err).Error("Certificate check failed")
+			}
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+	return nil
+}
+
+// Stop stops the periodic checks
+func (r *CertificateRotation) Stop() error {
+	if r.stop != nil {
+		r.stop()
+	}
+	return nil
+}
+
+// Healthy returns an error if the latest check found expired k0s managed certificates
+func (r *CertificateRotation) Healthy() error {
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	if len(r.expired) > 0 {
+		return fmt.Errorf("certificates expired: %s", strings.Join(r.expired, ", "))
+	}
+	return nil
+}
+
+// check rotates the certificates if any k0s managed leaf certificate expires within RenewBefore
+func (r *CertificateRotation) check(ctx context.Context) error {
+	expiries, err := r.scan()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	var due []string
+	for _, e := range expiries {
+		if e.Remaining(now) >= r.renewBefore(&e) {
+			continue
+		}
+		switch {
+		case !e.Managed:
+			r.log.Warnf("certificate %s expires in %d days, it's not managed by k0s and needs to be renewed manually", e.Path, e.DaysRemaining(now))
+		case e.IsCA:
+			r.log.Warnf("CA certificate %s expires in %d days, k0s doesn't rotate CAs", e.Path, e.DaysRemaining(now))
+		default:
+			due = append(due, e.Name)
+		}
+	}
+	if len(due) == 0 {
+		return nil
+	}
+	r.log.Infof("certificates %s expire within %s, rotating", strings.Join(due, ", "), r.RenewBefore)
+	return r.Rotate(ctx)
+}
+
+// renewBefore returns how long before its expiry the given certificate gets rotated
+func (r *CertificateRotation) renewBefore(e *certificate.Expiry) time.Duration {
+	if third := e.Lifetime() / 3; third < r.RenewBefore {
+		return third
+	}
+	return r.RenewBefore
+}
+
+// Rotate regenerates the k0s managed leaf certificates and restarts the processes using them
+func (r *CertificateRotation) Rotate(ctx context.Context) error {
+	r.rotateMutex.Lock()
+	defer r.rotateMutex.Unlock()
+
+	if err := r.Renew(ctx); err != nil {
+		return fmt.Errorf("failed to renew the certificates: %w", err)
+	}
+	r.log.Info("certificates renewed, restarting the processes using them")
+	if err := supervisor.Restart(certificateConsumers...); err != nil {
+		return err
+	}
+	_, err := r.scan()
+	return err
+}
+
+// scan reads the expiry of all certificates and records the expired managed ones
+func (r *CertificateRotation) scan() ([]certificate.Expiry, error) {
+	expiries, err := certificate.ScanExpiry(r.K0sVars.CertRootDir, r.K0sVars.EtcdCertDir)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var expired []string
+	for _, e := range expiries {
+		if e.Managed && e.Remaining(now) <= 0 {
+			expired = append(expired, e.Name)
+		}
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+	r.expired = expired
+	return expiries, nil
+}
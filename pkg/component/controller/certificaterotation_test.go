@@ -0,0 +1,107 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controller
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k0sproject/k0s/pkg/certificate"
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+func TestCertificateRotationCheck(t *testing.T) {
+	ctx := context.Background()
+	k0sVars := constant.GetConfig(t.TempDir())
+	require.NoError(t, os.MkdirAll(k0sVars.CertRootDir, 0755))
+	certManager := certificate.Manager{K0sVars: k0sVars}
+	require.NoError(t, certManager.EnsureCA("ca", "kubernetes-ca"))
+	_, err := certManager.EnsureCertificate(certificate.Request{
+		Name:   "admin",
+		CN:     "kubernetes-admin",
+		O:      "system:masters",
+		CACert: filepath.Join(k0sVars.CertRootDir, "ca.crt"),
+		CAKey:  filepath.Join(k0sVars.CertRootDir, "ca.key"),
+	}, "root")
+	require.NoError(t, err)
+
+	renewals := 0
+	r := &CertificateRotation{
+		K0sVars: k0sVars,
+		Renew: func(context.Context) error {
+			renewals++
+			return nil
+		},
+	}
+	require.NoError(t, r.Init(ctx))
+	assert.Equal(t, 30*24*time.Hour, r.RenewBefore)
+
+	// the leaf certificate is valid for a year
+	require.NoError(t, r.check(ctx))
+	assert.Equal(t, 0, renewals)
+
+	// a certificate issued 300 days ago expiring in 20 days is due
+	writeCert(t, filepath.Join(k0sVars.CertRootDir, "old.crt"), time.Now().Add(-300*24*time.Hour), time.Now().Add(20*24*time.Hour))
+	require.NoError(t, r.check(ctx))
+	assert.Equal(t, 1, renewals, "the old certificate should have been rotated")
+	assert.NoError(t, r.Healthy())
+
+	// a short-lived certificate is due after two thirds of its lifetime
+	writeCert(t, filepath.Join(k0sVars.CertRootDir, "old.crt"), time.Now().Add(-70*time.Minute), time.Now().Add(20*time.Minute))
+	require.NoError(t, r.check(ctx))
+	assert.Equal(t, 2, renewals, "the short-lived certificate should have been rotated")
+
+	writeCert(t, filepath.Join(k0sVars.CertRootDir, "old.crt"), time.Now().Add(-time.Hour), time.Now().Add(-time.Minute))
+	require.NoError(t, r.check(ctx))
+	assert.Error(t, r.Healthy(), "an expired certificate should fail the health check")
+}
+
+// writeCert writes a certificate with the given validity, issued by a throwaway CA named like the k0s one
+func writeCert(t *testing.T, path string, notBefore, notAfter time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ca := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "kubernetes-ca"},
+		NotBefore:             notBefore,
+		NotAfter:              notAfter.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	leaf := &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "old"},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, leaf, ca, key.Public(), key)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644))
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/k0sproject/k0s/internal/pkg/users"
 	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 	"github.com/k0sproject/k0s/pkg/assets"
+	"github.com/k0sproject/k0s/pkg/certificate"
 	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/constant"
 	"github.com/k0sproject/k0s/pkg/supervisor"
@@ -38,6 +39,8 @@ type Manager struct {
 	gid            int
 	K0sVars        constant.CfgVars
 	LogLevel       string
+	RestartPolicy  *supervisor.RestartPolicy
+	LogConfig      *supervisor.LogConfig
 	supervisor     *supervisor.Supervisor
 	uid            int
 	previousConfig stringmap.StringMap
@@ -90,7 +93,7 @@ func (a *Manager) Reconcile(_ context.Context, clusterConfig *v1beta1.ClusterCon
 		"cluster-signing-cert-file":        path.Join(a.K0sVars.CertRootDir, "ca.crt"),
 		"cluster-signing-key-file":         path.Join(a.K0sVars.CertRootDir, "ca.key"),
 		"requestheader-client-ca-file":     path.Join(a.K0sVars.CertRootDir, "front-proxy-ca.crt"),
-		"root-ca-file":                     path.Join(a.K0sVars.CertRootDir, "ca.crt"),
+		"root-ca-file":                     certificate.CABundleFile(path.Join(a.K0sVars.CertRootDir, "ca.crt")),
 		"service-account-private-key-file": path.Join(a.K0sVars.CertRootDir, "sa.key"),
 		"cluster-cidr":                     clusterConfig.Spec.Network.BuildPodCIDR(),
 		"service-cluster-ip-range":         clusterConfig.Spec.Network.BuildServiceCIDR(clusterConfig.Spec.API.Address),
@@ -139,13 +142,15 @@ func (a *Manager) Reconcile(_ context.Context, clusterConfig *v1beta1.ClusterCon
 	}
 
 	a.supervisor = &supervisor.Supervisor{
-		Name:    "kube-controller-manager",
-		BinPath: assets.BinPath("kube-controller-manager", a.K0sVars.BinDir),
-		RunDir:  a.K0sVars.RunDir,
-		DataDir: a.K0sVars.DataDir,
-		Args:    args.ToDashedArgs(),
-		UID:     a.uid,
-		GID:     a.gid,
+		Name:          "kube-controller-manager",
+		BinPath:       assets.BinPath("kube-controller-manager", a.K0sVars.BinDir),
+		RunDir:        a.K0sVars.RunDir,
+		DataDir:       a.K0sVars.DataDir,
+		Args:          args.ToDashedArgs(),
+		UID:           a.uid,
+		GID:           a.gid,
+		RestartPolicy: a.RestartPolicy,
+		LogConfig:     a.LogConfig,
 	}
 	a.previousConfig = args
 	return a.supervisor.Supervise()
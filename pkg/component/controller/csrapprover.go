@@ -19,19 +19,27 @@ import (
 	"context"
 	"crypto/x509"
 	"encoding/pem"
+	"errors"
 	"fmt"
+	"net"
+	"os"
 	"reflect"
 	"strings"
-	"sync/atomic"
-	"time"
+	"sync"
 
 	"github.com/sirupsen/logrus"
 	authorization "k8s.io/api/authorization/v1"
 	v1 "k8s.io/api/certificates/v1"
 	core "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/informers"
 	clientset "k8s.io/client-go/kubernetes"
+	certlisters "k8s.io/client-go/listers/certificates/v1"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/util/workqueue"
 
+	"github.com/k0sproject/k0s/internal/pkg/stringslice"
 	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 	"github.com/k0sproject/k0s/pkg/component"
 	k8sutil "github.com/k0sproject/k0s/pkg/kubernetes"
@@ -44,28 +52,37 @@ var kubeletServerUsages = []v1.KeyUsage{
 	v1.UsageServerAuth,
 }
 
+// csrRecognizer is an approval policy: the CSRs it recognizes are approved once the requester is authorized,
+// unless verify returns an error, in which case they are denied, or retried later for a csrRetryError
 type csrRecognizer struct {
 	recognize      func(csr *v1.CertificateSigningRequest, x509cr *x509.CertificateRequest) bool
+	verify         func(ctx context.Context, csr *v1.CertificateSigningRequest, x509cr *x509.CertificateRequest) error
 	permission     authorization.ResourceAttributes
 	successMessage string
 }
 
+// csrRetryError is returned by the verifications that can't tell yet if a CSR is valid, e.g. for lack of node
+// addresses, so that the CSR gets retried with a backoff instead of being denied
+type csrRetryError struct{ error }
+
+func (e csrRetryError) Unwrap() error { return e.error }
+
 type CSRApprover struct {
-	L    *logrus.Entry
-	stop context.CancelFunc
+	L *logrus.Entry
 
 	ClusterConfig     *v1beta1.ClusterConfig
 	KubeClientFactory kubeutil.ClientFactoryInterface
 	leaderElector     LeaderElector
 	clientset         clientset.Interface
+
+	mutex        sync.Mutex
+	stopWatching context.CancelFunc
 }
 
 var _ component.Component = (*CSRApprover)(nil)
 
 // NewCSRApprover creates the CSRApprover component
 func NewCSRApprover(c *v1beta1.ClusterConfig, leaderElector LeaderElector, kubeClientFactory k8sutil.ClientFactoryInterface) *CSRApprover {
-	d := atomic.Value{}
-	d.Store(true)
 	return &CSRApprover{
 		ClusterConfig:     c,
 		leaderElector:     leaderElector,
@@ -78,103 +95,192 @@ func (a *CSRApprover) Healthy() error { return nil }
 
 // Stop stops the CSRApprover
 func (a *CSRApprover) Stop() error {
-	a.stop()
+	a.stopWatch()
 	return nil
 }
 
-// Init initializes the component needs
-func (a *CSRApprover) Init(_ context.Context) error {
+// Init initializes the component needs and watches the CSRs while the controller is the leader
+func (a *CSRApprover) Init(ctx context.Context) error {
 	var err error
 	a.clientset, err = a.KubeClientFactory.GetClient()
 	if err != nil {
 		return fmt.Errorf("can't create kubernetes rest client for CSR check: %v", err)
 	}
 
+	a.leaderElector.AddAcquiredLeaseCallback(func() { a.startWatch(ctx) })
+	a.leaderElector.AddLostLeaseCallback(a.stopWatch)
 	return nil
 }
 
-// Run every 10 seconds checks for newly issued CSRs and approves them
-func (a *CSRApprover) Run(ctx context.Context) error {
-	ctx, a.stop = context.WithCancel(ctx)
-	go func() {
-		defer a.stop()
-		ticker := time.NewTicker(10 * time.Second) // TODO: sometimes this should be refactored so it watches instead of polls for CSRs
-		defer ticker.Stop()
-		for {
-			select {
-			case <-ticker.C:
-				err := a.approveCSR(ctx)
-				if err != nil {
-					a.L.Warnf("CSR approval failed: %s", err.Error())
-				}
-			case <-ctx.Done():
-				a.L.Info("CSR Approver context done")
-				return
-			}
+// Run does nothing, the CSRs are watched once the leader lease is acquired
+func (a *CSRApprover) Run(_ context.Context) error {
+	return nil
+}
+
+func (a *CSRApprover) startWatch(ctx context.Context) {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.stopWatching != nil {
+		return
+	}
+	ctx, a.stopWatching = context.WithCancel(ctx)
+	go a.watch(ctx)
+}
+
+func (a *CSRApprover) stopWatch() {
+	a.mutex.Lock()
+	defer a.mutex.Unlock()
+	if a.stopWatching != nil {
+		a.stopWatching()
+		a.stopWatching = nil
+	}
+}
+
+// watch handles the CSRs as they are created or updated, until the context is done.
+// CSRs failing to be handled are retried with an exponential backoff.
+func (a *CSRApprover) watch(ctx context.Context) {
+	queue := workqueue.NewRateLimitingQueue(workqueue.DefaultControllerRateLimiter())
+	defer queue.ShutDown()
+
+	factory := informers.NewSharedInformerFactory(a.clientset, 0)
+	informer := factory.Certificates().V1().CertificateSigningRequests()
+	enqueue := func(obj interface{}) {
+		if key, err := cache.MetaNamespaceKeyFunc(obj); err == nil {
+			queue.Add(key)
 		}
+	}
+	informer.Informer().AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc:    enqueue,
+		UpdateFunc: func(_, obj interface{}) { enqueue(obj) },
+	})
+	lister := informer.Lister()
+
+	a.L.Info("Watching CSRs")
+	factory.Start(ctx.Done())
+	if !cache.WaitForCacheSync(ctx.Done(), informer.Informer().HasSynced) {
+		return
+	}
+	go func() {
+		<-ctx.Done()
+		a.L.Info("Stopped watching CSRs")
+		queue.ShutDown()
 	}()
+	for a.processNextCSR(ctx, queue, lister) {
+	}
+}
 
-	return nil
+func (a *CSRApprover) processNextCSR(ctx context.Context, queue workqueue.RateLimitingInterface, lister certlisters.CertificateSigningRequestLister) bool {
+	key, shutdown := queue.Get()
+	if shutdown {
+		return false
+	}
+	defer queue.Done(key)
+
+	csr, err := lister.Get(key.(string))
+	if err == nil {
+		err = a.handleCSR(ctx, csr.DeepCopy())
+	} else if apierrors.IsNotFound(err) {
+		err = nil
+	}
+	if err != nil {
+		a.L.WithError(err).Warnf("Failed to handle CSR %s, retrying", key)
+		queue.AddRateLimited(key)
+		return true
+	}
+	queue.Forget(key)
+	return true
 }
 
+// handleCSR approves or denies a CSR recognized by one of the policies.
 // Majority of this code has been adapted from https://github.com/kontena/kubelet-rubber-stamp
-func (a *CSRApprover) approveCSR(ctx context.Context) error {
-	if !a.leaderElector.IsLeader() {
-		a.L.Debug("not the leader, can't approve certificates")
+func (a *CSRApprover) handleCSR(ctx context.Context, csr *v1.CertificateSigningRequest) error {
+	if approved, denied := getCertApprovalCondition(&csr.Status); approved || denied {
+		a.L.Debugf("CSR %s is approved=%t || denied=%t. Carry on", csr.Name, approved, denied)
 		return nil
 	}
 
-	opts := metav1.ListOptions{
-		FieldSelector: "spec.signerName=kubernetes.io/kubelet-serving",
-	}
-
-	csrs, err := a.clientset.CertificatesV1().CertificateSigningRequests().List(ctx, opts)
+	x509cr, err := parseCSR(csr)
 	if err != nil {
-		a.L.Errorf("can't fetch CSRs: %v", err)
-		return fmt.Errorf("can't fetch CSRs: %v", err)
+		// the request won't change, so retrying is pointless
+		a.L.Errorf("unable to parse csr %q: %v", csr.Name, err)
+		return nil
 	}
 
-	for _, csr := range csrs.Items {
-		if approved, denied := getCertApprovalCondition(&csr.Status); approved || denied {
-			a.L.Debugf("CSR %s is approved=%t || denied=%t. Carry on", csr.Name, approved, denied)
+	for _, recognizer := range a.recognizers() {
+		if !recognizer.recognize(csr, x509cr) {
 			continue
 		}
 
-		x509cr, err := parseCSR(&csr)
+		approved, err := a.authorize(ctx, csr, recognizer.permission)
 		if err != nil {
-			a.L.Errorf("unable to parse csr %q: %v", csr.Name, err)
-			return fmt.Errorf("unable to parse csr %q: %v", csr.Name, err)
+			return fmt.Errorf("SubjectAccessReview failed: %w", err)
+		}
+		if !approved {
+			a.L.Warnf("%s isn't authorized to request csr %s, leaving it pending", csr.Spec.Username, csr.Name)
+			return nil
 		}
 
-		for _, recognizer := range a.recognizers() {
-			if !recognizer.recognize(&csr, x509cr) {
-				continue
-			}
-
-			approved, err := a.authorize(ctx, &csr, recognizer.permission)
-			if err != nil {
-				a.L.Warningf("SubjectAccessReview failed: %s", err)
-				return err
-			}
-
-			if approved {
-				a.L.Infof("approving csr %s with SANs: %s, IP Addresses:%s", csr.ObjectMeta.Name, x509cr.DNSNames, x509cr.IPAddresses)
-				appendApprovalCondition(&csr, recognizer.successMessage)
-				_, err = a.clientset.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.Name, &csr, metav1.UpdateOptions{})
-				if err != nil {
-					a.L.Errorf("error updating approval for csr: %v", err)
-					return fmt.Errorf("error updating approval for csr: %v", err)
+		if recognizer.verify != nil {
+			if err := recognizer.verify(ctx, csr, x509cr); err != nil {
+				if errors.As(err, &csrRetryError{}) {
+					return fmt.Errorf("can't verify csr %s yet: %w", csr.Name, err)
 				}
-			} else {
-				return fmt.Errorf("failed to perform SubjectAccessReview")
+				return a.deny(ctx, csr, err.Error())
 			}
-			return nil
 		}
+
+		a.L.Infof("approving csr %s with SANs: %s, IP Addresses:%s", csr.ObjectMeta.Name, x509cr.DNSNames, x509cr.IPAddresses)
+		appendApprovalCondition(csr, recognizer.successMessage)
+		_, err = a.clientset.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{})
+		if err != nil {
+			return fmt.Errorf("error updating approval for csr: %v", err)
+		}
+		return nil
 	}
 
 	return nil
 }
 
+// deny denies the CSR and records an event explaining why
+func (a *CSRApprover) deny(ctx context.Context, csr *v1.CertificateSigningRequest, message string) error {
+	a.L.Warnf("denying csr %s: %s", csr.Name, message)
+	appendDenialCondition(csr, message)
+	_, err := a.clientset.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{})
+	if err != nil {
+		return fmt.Errorf("error updating denial for csr: %v", err)
+	}
+
+	hostname, _ := os.Hostname()
+	e := &core.Event{
+		ObjectMeta: metav1.ObjectMeta{
+			GenerateName: csr.Name + ".",
+		},
+		EventTime:      metav1.NowMicro(),
+		FirstTimestamp: metav1.Now(),
+		LastTimestamp:  metav1.Now(),
+		Count:          1,
+		InvolvedObject: core.ObjectReference{
+			Kind:            "CertificateSigningRequest",
+			APIVersion:      v1.SchemeGroupVersion.String(),
+			Name:            csr.Name,
+			UID:             csr.UID,
+			ResourceVersion: csr.ResourceVersion,
+		},
+		Action:              "CSRApproval",
+		Reason:              "CSRDenied",
+		Message:             message,
+		Type:                core.EventTypeWarning,
+		ReportingController: "k0s-controller",
+		ReportingInstance:   hostname,
+		Source:              core.EventSource{Component: "k0s-csrapprover", Host: hostname},
+	}
+	// events of cluster scoped objects are recorded in the default namespace
+	if _, err := a.clientset.CoreV1().Events(metav1.NamespaceDefault).Create(ctx, e, metav1.CreateOptions{}); err != nil {
+		a.L.WithError(err).Warnf("failed to create event for the denial of csr %s", csr.Name)
+	}
+	return nil
+}
+
 func (a *CSRApprover) authorize(ctx context.Context, csr *v1.CertificateSigningRequest, rattrs authorization.ResourceAttributes) (bool, error) {
 	extra := make(map[string]authorization.ExtraValue)
 	for k, v := range csr.Spec.Extra {
@@ -200,17 +306,38 @@ func (a *CSRApprover) authorize(ctx context.Context, csr *v1.CertificateSigningR
 }
 
 func (a *CSRApprover) recognizers() []csrRecognizer {
-	recognizers := []csrRecognizer{
-		{
-			recognize:      a.isNodeServingCert,
-			permission:     authorization.ResourceAttributes{Group: "certificates.k8s.io", Resource: "certificatesigningrequests", Verb: "create"},
-			successMessage: "Auto approving kubelet serving certificate after SubjectAccessReview.",
-		},
+	var spec *v1beta1.CSRApproverSpec
+	if a.ClusterConfig != nil && a.ClusterConfig.Spec != nil {
+		spec = a.ClusterConfig.Spec.CSRApprover
+	}
+	permission := authorization.ResourceAttributes{Group: "certificates.k8s.io", Resource: "certificatesigningrequests", Verb: "create"}
+
+	nodeServing := csrRecognizer{
+		recognize:      a.isNodeServingCert,
+		permission:     permission,
+		successMessage: "Auto approving kubelet serving certificate after SubjectAccessReview.",
+	}
+	if spec == nil {
+		return []csrRecognizer{nodeServing}
+	}
+	if spec.VerifyNodeAddresses {
+		nodeServing.verify = a.verifyNodeAddresses
+	}
+	recognizers := []csrRecognizer{nodeServing}
+	for _, rule := range spec.Rules {
+		recognizers = append(recognizers, csrRecognizer{
+			recognize:      a.ruleRecognizer(rule),
+			permission:     permission,
+			successMessage: fmt.Sprintf("Auto approving %s certificate after SubjectAccessReview.", rule.SignerName),
+		})
 	}
 	return recognizers
 }
 
 func (a *CSRApprover) isNodeServingCert(csr *v1.CertificateSigningRequest, x509cr *x509.CertificateRequest) bool {
+	if csr.Spec.SignerName != v1.KubeletServingSignerName {
+		return false
+	}
 	if !reflect.DeepEqual([]string{"system:nodes"}, x509cr.Subject.Organization) {
 		a.L.Warningf("Org does not match: %s", x509cr.Subject.Organization)
 		return false
@@ -233,6 +360,120 @@ func (a *CSRApprover) isNodeServingCert(csr *v1.CertificateSigningRequest, x509c
 	return true
 }
 
+// verifyNodeAddresses checks that the DNS names and IP addresses of a kubelet serving certificate are addresses of the node.
+// The kubelet may request its certificate before its node is registered or has reported its addresses, the CSR gets retried then.
+func (a *CSRApprover) verifyNodeAddresses(ctx context.Context, _ *v1.CertificateSigningRequest, x509cr *x509.CertificateRequest) error {
+	if len(x509cr.EmailAddresses) > 0 || len(x509cr.URIs) > 0 {
+		return fmt.Errorf("kubelet serving certificates can't have email or URI SANs")
+	}
+
+	nodeName := strings.TrimPrefix(x509cr.Subject.CommonName, "system:node:")
+	node, err := a.clientset.CoreV1().Nodes().Get(ctx, nodeName, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return csrRetryError{fmt.Errorf("node %s doesn't exist yet", nodeName)}
+	} else if err != nil {
+		return csrRetryError{err}
+	}
+	if len(node.Status.Addresses) == 0 {
+		return csrRetryError{fmt.Errorf("node %s has no addresses yet", nodeName)}
+	}
+
+	var names []string
+	var ips []net.IP
+	for _, address := range node.Status.Addresses {
+		switch address.Type {
+		case core.NodeHostName, core.NodeInternalDNS, core.NodeExternalDNS:
+			names = append(names, address.Address)
+		case core.NodeInternalIP, core.NodeExternalIP:
+			if ip := net.ParseIP(address.Address); ip != nil {
+				ips = append(ips, ip)
+			}
+		}
+	}
+
+DNSNames:
+	for _, name := range x509cr.DNSNames {
+		for _, n := range names {
+			if strings.EqualFold(name, n) {
+				continue DNSNames
+			}
+		}
+		return fmt.Errorf("DNS name %s isn't an address of node %s", name, nodeName)
+	}
+IPAddresses:
+	for _, ip := range x509cr.IPAddresses {
+		for _, i := range ips {
+			if ip.Equal(i) {
+				continue IPAddresses
+			}
+		}
+		return fmt.Errorf("IP address %s isn't an address of node %s", ip, nodeName)
+	}
+	return nil
+}
+
+// ruleRecognizer recognizes the CSRs matching an approval rule. CSRs asking for the system:masters group are never
+// recognized, as they would grant unrestricted access to the cluster. The subject of client certificates must be the
+// requester itself, so that nobody gets a certificate authenticating as another user or group.
+func (a *CSRApprover) ruleRecognizer(rule v1beta1.CSRApprovalRule) func(*v1.CertificateSigningRequest, *x509.CertificateRequest) bool {
+	return func(csr *v1.CertificateSigningRequest, x509cr *x509.CertificateRequest) bool {
+		if csr.Spec.SignerName != rule.SignerName {
+			return false
+		}
+		for _, org := range x509cr.Subject.Organization {
+			if org == "system:masters" {
+				a.L.Warningf("csr %s asks for the system:masters group, not approving it", csr.Name)
+				return false
+			}
+		}
+		if isClientCSR(csr) {
+			if x509cr.Subject.CommonName != csr.Spec.Username {
+				a.L.Warningf("csr %s asks for a client certificate of %q, not of its requester %q, not approving it", csr.Name, x509cr.Subject.CommonName, csr.Spec.Username)
+				return false
+			}
+			for _, org := range x509cr.Subject.Organization {
+				if !stringslice.Contains(csr.Spec.Groups, org) {
+					a.L.Warningf("csr %s asks for a client certificate of the group %q, %s isn't a member of, not approving it", csr.Name, org, csr.Spec.Username)
+					return false
+				}
+			}
+		}
+		if len(csr.Spec.Usages) == 0 {
+			return false
+		}
+		for _, usage := range csr.Spec.Usages {
+			if !stringslice.Contains(rule.Usages, string(usage)) {
+				a.L.Infof("csr %s asks for usage %q, not allowed for %s", csr.Name, usage, rule.SignerName)
+				return false
+			}
+		}
+		if len(rule.Groups) == 0 {
+			return true
+		}
+		for _, group := range csr.Spec.Groups {
+			if stringslice.Contains(rule.Groups, group) {
+				return true
+			}
+		}
+		a.L.Infof("%s isn't a member of the groups allowed for %s", csr.Spec.Username, rule.SignerName)
+		return false
+	}
+}
+
+// isClientCSR checks if the CSR asks for a certificate authenticating clients
+func isClientCSR(csr *v1.CertificateSigningRequest) bool {
+	switch csr.Spec.SignerName {
+	case v1.KubeAPIServerClientSignerName, v1.KubeAPIServerClientKubeletSignerName:
+		return true
+	}
+	for _, usage := range csr.Spec.Usages {
+		if usage == v1.UsageClientAuth {
+			return true
+		}
+	}
+	return false
+}
+
 func hasExactUsages(csr *v1.CertificateSigningRequest, usages []v1.KeyUsage) bool {
 	if len(usages) != len(csr.Spec.Usages) {
 		return false
@@ -287,3 +528,12 @@ func appendApprovalCondition(csr *v1.CertificateSigningRequest, message string)
 		Status:  core.ConditionTrue,
 	})
 }
+
+func appendDenialCondition(csr *v1.CertificateSigningRequest, message string) {
+	csr.Status.Conditions = append(csr.Status.Conditions, v1.CertificateSigningRequestCondition{
+		Type:    v1.CertificateDenied,
+		Reason:  "Autodenied by K0s CSRApprover",
+		Message: message,
+		Status:  core.ConditionTrue,
+	})
+}
@@ -23,43 +23,34 @@ import (
 	"crypto/x509"
 	"crypto/x509/pkix"
 	"encoding/pem"
+	"net"
 	"testing"
+	"time"
 
-	"github.com/k0sproject/k0s/internal/testutil"
-	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorization "k8s.io/api/authorization/v1"
 	certv1 "k8s.io/api/certificates/v1"
 	core "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+
+	"github.com/k0sproject/k0s/internal/testutil"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 )
 
 func TestBasicCRSApprover(t *testing.T) {
-	fakeFactory := testutil.NewFakeClientFactory()
-
+	fakeFactory := newCSRApproverClientFactory()
 	client, err := fakeFactory.GetClient()
-	assert.NoError(t, err)
+	require.NoError(t, err)
 
 	ctx := context.TODO()
-
-	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
-	if err != nil {
-		t.Fatal(err)
-	}
-
-	req := pemWithPrivateKey(privateKey)
-
-	csrReq := &certv1.CertificateSigningRequest{
-		ObjectMeta: metav1.ObjectMeta{
-			Name: "csrapprover_test",
-		},
-		Spec: certv1.CertificateSigningRequestSpec{
-			Request:    req,
-			SignerName: "kubernetes.io/kubelet-serving",
-		},
-	}
-
-	newCsr, err := client.CertificatesV1().CertificateSigningRequests().Create(ctx, csrReq, metav1.CreateOptions{})
-	assert.NoError(t, err)
+	csr := kubeletServingCSR(t, "worker-serving", "worker", []string{"worker"}, []net.IP{net.ParseIP("10.0.0.1")})
+	_, err = client.CertificatesV1().CertificateSigningRequests().Create(ctx, csr, metav1.CreateOptions{})
+	require.NoError(t, err)
 
 	config := &v1beta1.ClusterConfig{
 		Spec: &v1beta1.ClusterSpec{
@@ -69,28 +60,176 @@ func TestBasicCRSApprover(t *testing.T) {
 			},
 		},
 	}
+	leaderElector := &DummyLeaderElector{Leader: true}
+	c := NewCSRApprover(config, leaderElector, fakeFactory)
+	require.NoError(t, c.Init(ctx))
+	require.NoError(t, c.Run(ctx))
+	// the CSRs are only watched once the lease is acquired
+	require.NoError(t, leaderElector.Run(ctx))
+	defer func() { assert.NoError(t, c.Stop()) }()
+
+	assert.Eventually(t, func() bool {
+		csr, err := client.CertificatesV1().CertificateSigningRequests().Get(ctx, csr.Name, metav1.GetOptions{})
+		require.NoError(t, err)
+		return hasCondition(csr, certv1.CertificateApproved)
+	}, 10*time.Second, 50*time.Millisecond)
+}
+
+func TestCSRApproverVerifyNodeAddresses(t *testing.T) {
+	node := &core.Node{
+		ObjectMeta: metav1.ObjectMeta{Name: "worker"},
+		Status: core.NodeStatus{Addresses: []core.NodeAddress{
+			{Type: core.NodeHostName, Address: "worker"},
+			{Type: core.NodeInternalIP, Address: "10.0.0.1"},
+		}},
+	}
+	fakeFactory := newCSRApproverClientFactory(node)
+	client, err := fakeFactory.GetClient()
+	require.NoError(t, err)
+
+	ctx := context.TODO()
+	config := &v1beta1.ClusterConfig{Spec: &v1beta1.ClusterSpec{
+		CSRApprover: &v1beta1.CSRApproverSpec{VerifyNodeAddresses: true},
+	}}
 	c := NewCSRApprover(config, &DummyLeaderElector{Leader: true}, fakeFactory)
+	require.NoError(t, c.Init(ctx))
+
+	valid := createCSR(t, client, kubeletServingCSR(t, "valid", "worker", []string{"worker"}, []net.IP{net.ParseIP("10.0.0.1")}))
+	require.NoError(t, c.handleCSR(ctx, valid))
+	assert.True(t, hasCondition(getCSR(t, client, valid.Name), certv1.CertificateApproved))
+
+	foreign := createCSR(t, client, kubeletServingCSR(t, "foreign", "worker", []string{"worker"}, []net.IP{net.ParseIP("10.0.0.2")}))
+	require.NoError(t, c.handleCSR(ctx, foreign))
+	assert.True(t, hasCondition(getCSR(t, client, foreign.Name), certv1.CertificateDenied))
+
+	events, err := client.CoreV1().Events(metav1.NamespaceDefault).List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, events.Items, 1)
+	assert.Equal(t, foreign.Name, events.Items[0].InvolvedObject.Name)
+	assert.Equal(t, "CSRDenied", events.Items[0].Reason)
+	assert.Equal(t, "IP address 10.0.0.2 isn't an address of node worker", events.Items[0].Message)
+
+	// the CSRs of nodes not registered yet, or without addresses, are retried
+	unknown := createCSR(t, client, kubeletServingCSR(t, "unknown", "worker-2", []string{"worker-2"}, nil))
+	assert.EqualError(t, c.handleCSR(ctx, unknown), "can't verify csr unknown yet: node worker-2 doesn't exist yet")
+	_, err = client.CoreV1().Nodes().Create(ctx, &core.Node{ObjectMeta: metav1.ObjectMeta{Name: "worker-2"}}, metav1.CreateOptions{})
+	require.NoError(t, err)
+	assert.EqualError(t, c.handleCSR(ctx, unknown), "can't verify csr unknown yet: node worker-2 has no addresses yet")
+	csr := getCSR(t, client, unknown.Name)
+	assert.False(t, hasCondition(csr, certv1.CertificateApproved))
+	assert.False(t, hasCondition(csr, certv1.CertificateDenied))
+}
 
-	assert.NoError(t, c.Init(ctx))
-	assert.NoError(t, c.approveCSR(ctx))
+func TestCSRApproverRules(t *testing.T) {
+	fakeFactory := newCSRApproverClientFactory()
+	client, err := fakeFactory.GetClient()
+	require.NoError(t, err)
 
-	csr, err := client.CertificatesV1().CertificateSigningRequests().Get(ctx, newCsr.Name, metav1.GetOptions{})
-	assert.NoError(t, err)
-	assert.NotNil(t, csr)
-	assert.True(t, csr.Name == newCsr.Name)
-	for _, c := range csr.Status.Conditions {
-		assert.True(t, c.Type == certv1.CertificateApproved && c.Reason == "Autoapproved by K0S CSRApprover" && c.Status == core.ConditionTrue)
+	ctx := context.TODO()
+	config := &v1beta1.ClusterConfig{Spec: &v1beta1.ClusterSpec{
+		CSRApprover: &v1beta1.CSRApproverSpec{Rules: []v1beta1.CSRApprovalRule{{
+			SignerName: "example.com/client",
+			Usages:     []string{"digital signature", "client auth"},
+			Groups:     []string{"monitoring"},
+		}, {
+			SignerName: certv1.KubeAPIServerClientSignerName,
+			Usages:     []string{"digital signature", "key encipherment", "client auth"},
+		}}},
+	}}
+	c := NewCSRApprover(config, &DummyLeaderElector{Leader: true}, fakeFactory)
+	require.NoError(t, c.Init(ctx))
+
+	signerCSR := func(signerName, name, commonName, org string, usages ...certv1.KeyUsage) *certv1.CertificateSigningRequest {
+		privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+		require.NoError(t, err)
+		return createCSR(t, client, &certv1.CertificateSigningRequest{
+			ObjectMeta: metav1.ObjectMeta{Name: name},
+			Spec: certv1.CertificateSigningRequestSpec{
+				Request:    pemWithTemplate(&x509.CertificateRequest{Subject: pkix.Name{CommonName: commonName, Organization: []string{org}}}, privateKey),
+				SignerName: signerName,
+				Usages:     usages,
+				Username:   "exporter",
+				Groups:     []string{"monitoring"},
+			},
+		})
+	}
+
+	clientCSR := func(name, commonName, org string, usages ...certv1.KeyUsage) *certv1.CertificateSigningRequest {
+		return signerCSR("example.com/client", name, commonName, org, usages...)
+	}
+
+	for _, test := range []struct {
+		csr      *certv1.CertificateSigningRequest
+		approved bool
+	}{
+		{clientCSR("matching", "exporter", "monitoring", certv1.UsageDigitalSignature, certv1.UsageClientAuth), true},
+		{clientCSR("other-usage", "exporter", "monitoring", certv1.UsageServerAuth), false},
+		{clientCSR("masters", "exporter", "system:masters", certv1.UsageClientAuth), false},
+		// client certificates only get approved for the identity of their requester
+		{clientCSR("other-user", "system:kube-controller-manager", "monitoring", certv1.UsageClientAuth), false},
+		{clientCSR("other-group", "exporter", "system:nodes", certv1.UsageClientAuth), false},
+		{signerCSR(certv1.KubeAPIServerClientSignerName, "kube-client", "exporter", "monitoring", certv1.UsageDigitalSignature), true},
+		{signerCSR(certv1.KubeAPIServerClientSignerName, "kube-client-other-user", "system:kube-scheduler", "monitoring", certv1.UsageDigitalSignature), false},
+	} {
+		t.Run(test.csr.Name, func(t *testing.T) {
+			require.NoError(t, c.handleCSR(ctx, test.csr))
+			csr := getCSR(t, client, test.csr.Name)
+			assert.Equal(t, test.approved, hasCondition(csr, certv1.CertificateApproved))
+			assert.False(t, hasCondition(csr, certv1.CertificateDenied))
+		})
 	}
 }
 
-func pemWithPrivateKey(pk crypto.PrivateKey) []byte {
+// newCSRApproverClientFactory returns a fake client factory authorizing all the CSRs
+func newCSRApproverClientFactory(objects ...runtime.Object) testutil.FakeClientFactory {
+	fakeFactory := testutil.NewFakeClientFactory(objects...)
+	fakeFactory.Client.(*fake.Clientset).PrependReactor("create", "subjectaccessreviews", func(k8stesting.Action) (bool, runtime.Object, error) {
+		return true, &authorization.SubjectAccessReview{Status: authorization.SubjectAccessReviewStatus{Allowed: true}}, nil
+	})
+	return fakeFactory
+}
+
+func kubeletServingCSR(t *testing.T, name, nodeName string, dnsNames []string, ips []net.IP) *certv1.CertificateSigningRequest {
+	privateKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
 	template := &x509.CertificateRequest{
 		Subject: pkix.Name{
-			CommonName:   "something",
-			Organization: []string{"test"},
+			CommonName:   "system:node:" + nodeName,
+			Organization: []string{"system:nodes"},
 		},
+		DNSNames:    dnsNames,
+		IPAddresses: ips,
+	}
+	return &certv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: name},
+		Spec: certv1.CertificateSigningRequestSpec{
+			Request:    pemWithTemplate(template, privateKey),
+			SignerName: certv1.KubeletServingSignerName,
+			Usages:     kubeletServerUsages,
+			Username:   "system:node:" + nodeName,
+		},
+	}
+}
+
+func createCSR(t *testing.T, client kubernetes.Interface, csr *certv1.CertificateSigningRequest) *certv1.CertificateSigningRequest {
+	created, err := client.CertificatesV1().CertificateSigningRequests().Create(context.TODO(), csr, metav1.CreateOptions{})
+	require.NoError(t, err)
+	return created
+}
+
+func getCSR(t *testing.T, client kubernetes.Interface, name string) *certv1.CertificateSigningRequest {
+	csr, err := client.CertificatesV1().CertificateSigningRequests().Get(context.TODO(), name, metav1.GetOptions{})
+	require.NoError(t, err)
+	return csr
+}
+
+func hasCondition(csr *certv1.CertificateSigningRequest, conditionType certv1.RequestConditionType) bool {
+	for _, c := range csr.Status.Conditions {
+		if c.Type == conditionType && c.Status == core.ConditionTrue {
+			return true
+		}
 	}
-	return pemWithTemplate(template, pk)
+	return false
 }
 
 func pemWithTemplate(template *x509.CertificateRequest, key crypto.PrivateKey) []byte {
@@ -20,7 +20,10 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"sort"
+	"strconv"
 	"strings"
 	"time"
 
@@ -43,18 +46,21 @@ import (
 
 // Etcd implement the component interface to run etcd
 type Etcd struct {
-	CertManager certificate.Manager
-	Config      *v1beta1.EtcdConfig
-	JoinClient  *token.JoinClient
-	K0sVars     constant.CfgVars
-	LogLevel    string
+	CertManager   certificate.Manager
+	Config        *v1beta1.EtcdConfig
+	JoinClient    *token.JoinClient
+	K0sVars       constant.CfgVars
+	LogLevel      string
+	RestartPolicy *supervisor.RestartPolicy
+	LogConfig     *supervisor.LogConfig
 
 	supervisor supervisor.Supervisor
+	args       stringmap.StringMap
 	uid        int
 	gid        int
 }
 
-var _ component.Component = (*Etcd)(nil)
+var _ component.NodeReconcilerComponent = (*Etcd)(nil)
 
 // Init extracts the needed binaries
 func (e *Etcd) Init(_ context.Context) error {
@@ -191,6 +197,51 @@ func (e *Etcd) Run(ctx context.Context) error {
 		args["--auth-token"] = auth
 	}
 
+	e.args = args
+	return e.start(e.buildArgs(e.Config))
+}
+
+// ReconcileNodeConfig restarts etcd if its tuning arguments have been changed
+func (e *Etcd) ReconcileNodeConfig(_ context.Context, nodeConfig *v1beta1.ClusterConfig) error {
+	storage := nodeConfig.Spec.Storage
+	if storage.Type != v1beta1.EtcdStorageType || storage.Etcd == nil {
+		return fmt.Errorf("changing the storage type from etcd to %s requires a restart", storage.Type)
+	}
+	if e.Config.IsExternalClusterUsed() {
+		logrus.WithField("component", "etcd").Info("node config reload has nothing to do, using an external etcd cluster")
+		e.Config = storage.Etcd
+		return nil
+	}
+	args := e.buildArgs(storage.Etcd)
+	if reflect.DeepEqual(args, e.supervisor.Args) {
+		logrus.WithField("component", "etcd").Info("node config reload has nothing to do")
+		e.Config = storage.Etcd
+		return nil
+	}
+
+	logrus.WithField("component", "etcd").Info("restarting etcd to apply the reloaded tuning arguments")
+	if err := e.supervisor.Stop(); err != nil {
+		return err
+	}
+	e.Config = storage.Etcd
+	return e.start(args)
+}
+
+// buildArgs returns the sorted etcd arguments, the tuning arguments of the given config override the ones of k0s
+func (e *Etcd) buildArgs(config *v1beta1.EtcdConfig) []string {
+	args := stringmap.StringMap{}
+	for name, value := range e.args {
+		args[name] = value
+	}
+	for name, value := range tuningArgs(config) {
+		args[name] = value
+	}
+	sorted := args.ToArgs()
+	sort.Strings(sorted)
+	return sorted
+}
+
+func (e *Etcd) start(args []string) error {
 	logrus.Debugf("starting etcd with args: %v", args)
 
 	e.supervisor = supervisor.Supervisor{
@@ -198,20 +249,52 @@ func (e *Etcd) Run(ctx context.Context) error {
 		BinPath:       assets.BinPath("etcd", e.K0sVars.BinDir),
 		RunDir:        e.K0sVars.RunDir,
 		DataDir:       e.K0sVars.DataDir,
-		Args:          args.ToArgs(),
+		Args:          args,
 		UID:           e.uid,
 		GID:           e.gid,
 		KeepEnvPrefix: true,
+		RestartPolicy: e.RestartPolicy,
+		LogConfig:     e.LogConfig,
 	}
 
 	return e.supervisor.Supervise()
 }
 
+// tuningArgs returns the etcd arguments for the tuning fields and the extra arguments of the config
+func tuningArgs(config *v1beta1.EtcdConfig) stringmap.StringMap {
+	args := stringmap.StringMap{}
+	if config.QuotaBackendBytes != 0 {
+		args["--quota-backend-bytes"] = strconv.FormatInt(config.QuotaBackendBytes, 10)
+	}
+	if config.SnapshotCount != 0 {
+		args["--snapshot-count"] = strconv.FormatUint(config.SnapshotCount, 10)
+	}
+	if config.HeartbeatInterval.Duration != 0 {
+		args["--heartbeat-interval"] = strconv.FormatInt(config.HeartbeatInterval.Milliseconds(), 10)
+	}
+	if config.ElectionTimeout.Duration != 0 {
+		args["--election-timeout"] = strconv.FormatInt(config.ElectionTimeout.Milliseconds(), 10)
+	}
+	if config.AutoCompaction != nil {
+		args["--auto-compaction-mode"] = config.AutoCompaction.Mode
+		args["--auto-compaction-retention"] = config.AutoCompaction.Retention
+	}
+	for name, value := range config.ExtraArgs {
+		args["--"+strings.TrimLeft(name, "-")] = value
+	}
+	return args
+}
+
 // Stop stops etcd
 func (e *Etcd) Stop() error {
 	return e.supervisor.Stop()
 }
 
+// RenewCertificates regenerates the k0s managed etcd certificates, etcd needs a restart to pick them up
+func (e *Etcd) RenewCertificates(ctx context.Context) error {
+	return e.setupCerts(ctx)
+}
+
 func (e *Etcd) setupCerts(ctx context.Context) error {
 	etcdCaCert := filepath.Join(e.K0sVars.EtcdCertDir, "ca.crt")
 	etcdCaCertKey := filepath.Join(e.K0sVars.EtcdCertDir, "ca.key")
@@ -280,6 +363,9 @@ func (e *Etcd) setupCerts(ctx context.Context) error {
 
 // Health-check interface
 func (e *Etcd) Healthy() error {
+	if err := e.supervisor.Healthy(); err != nil {
+		return err
+	}
 	logrus.WithField("component", "etcd").Debug("checking etcd endpoint for health")
 	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
 	defer cancel()
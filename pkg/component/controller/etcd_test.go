@@ -0,0 +1,70 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controller
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/k0sproject/k0s/internal/pkg/stringmap"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+)
+
+func TestEtcdTuningArgs(t *testing.T) {
+	e := &Etcd{Config: v1beta1.DefaultEtcdConfig()}
+	assert.Empty(t, tuningArgs(e.Config))
+
+	e.Config.QuotaBackendBytes = 4 << 30
+	e.Config.SnapshotCount = 50000
+	e.Config.HeartbeatInterval = metav1.Duration{Duration: 250 * time.Millisecond}
+	e.Config.ElectionTimeout = metav1.Duration{Duration: 2500 * time.Millisecond}
+	e.Config.AutoCompaction = &v1beta1.EtcdAutoCompaction{Mode: "revision", Retention: "1000"}
+	e.Config.ExtraArgs = map[string]string{"max-txn-ops": "256", "--enable-pprof": "true"}
+
+	assert.Equal(t, stringmap.StringMap{
+		"--quota-backend-bytes":       "4294967296",
+		"--snapshot-count":            "50000",
+		"--heartbeat-interval":        "250",
+		"--election-timeout":          "2500",
+		"--auto-compaction-mode":      "revision",
+		"--auto-compaction-retention": "1000",
+		"--max-txn-ops":               "256",
+		"--enable-pprof":              "true",
+	}, tuningArgs(e.Config))
+}
+
+func TestEtcdReconcileNodeConfig(t *testing.T) {
+	e := &Etcd{Config: v1beta1.DefaultEtcdConfig(), args: stringmap.StringMap{"--log-level": "info", "--name": "node"}}
+	e.supervisor.Args = e.buildArgs(e.Config)
+	assert.Equal(t, []string{"--log-level=info", "--name=node"}, e.supervisor.Args)
+
+	tuned := v1beta1.DefaultEtcdConfig()
+	tuned.SnapshotCount = 50000
+	tuned.ExtraArgs = map[string]string{"log-level": "debug"}
+	assert.Equal(t, []string{"--log-level=debug", "--name=node", "--snapshot-count=50000"}, e.buildArgs(tuned))
+
+	nodeConfig := v1beta1.DefaultClusterConfig()
+	nodeConfig.Spec.Storage.Etcd = v1beta1.DefaultEtcdConfig()
+	assert.NoError(t, e.ReconcileNodeConfig(context.Background(), nodeConfig), "unchanged tuning arguments must not restart etcd")
+	assert.Same(t, nodeConfig.Spec.Storage.Etcd, e.Config)
+
+	nodeConfig.Spec.Storage = &v1beta1.StorageSpec{Type: v1beta1.KineStorageType, Kine: v1beta1.DefaultKineConfig("/var/lib/k0s")}
+	assert.Error(t, e.ReconcileNodeConfig(context.Background(), nodeConfig))
+}
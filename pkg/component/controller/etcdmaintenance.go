@@ -0,0 +1,396 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+
+	"github.com/k0sproject/k0s/internal/pkg/dir"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/component"
+	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/etcd"
+)
+
+const (
+	etcdSnapshotPrefix = "etcd-snapshot-"
+	etcdSnapshotSuffix = ".db"
+	// etcdSnapshotTimeFormat sorts lexically in chronological order
+	etcdSnapshotTimeFormat = "20060102T150405Z"
+
+	// etcdQuotaWarningPercent is the database usage above which the checks warn
+	etcdQuotaWarningPercent = 80
+	// etcdDefragMinDBSize is the database size below which the members are not worth defragmenting
+	etcdDefragMinDBSize = 64 * 1024 * 1024
+	// etcdDefragLock serializes the defragmentation of the members, as a member doesn't serve requests while defragmenting
+	etcdDefragLock = "/k0s/etcd-maintenance/defrag"
+)
+
+// EtcdMaintenance maintains the etcd cluster managed by k0s. The leading controller takes periodic local
+// snapshots and recovers the cluster from the NOSPACE alarm by compacting it and defragmenting the alarmed
+// members. Each controller checks the
+// database of its own member, the only one it can reach, and defragments it when too fragmented, one member
+// at a time thanks to a cluster wide lock.
+type EtcdMaintenance struct {
+	Config        *v1beta1.EtcdConfig
+	K0sVars       constant.CfgVars
+	LeaderElector LeaderElector
+
+	log    *logrus.Entry
+	stop   context.CancelFunc
+	done   chan struct{}
+	client etcdMaintenanceClient
+
+	mu      sync.Mutex
+	lastErr error
+}
+
+// etcdMaintenanceClient is the subset of the etcd client used by the maintenance
+type etcdMaintenanceClient interface {
+	Status(ctx context.Context, endpoint string) (*etcdStatus, error)
+	Defragment(ctx context.Context, endpoint string) error
+	Compact(ctx context.Context, revision int64) error
+	Alarms(ctx context.Context) ([]*etcdserverpb.AlarmMember, error)
+	MemberClientURLs(ctx context.Context) (map[uint64]string, error)
+	DisarmAlarm(ctx context.Context, alarm *etcdserverpb.AlarmMember) error
+	Snapshot(ctx context.Context, path string) error
+	WithLock(ctx context.Context, name string, fn func() error) error
+}
+
+// etcdStatus is the part of the member status the maintenance cares about
+type etcdStatus struct {
+	MemberID    uint64
+	Revision    int64
+	DBSize      int64
+	DBSizeInUse int64
+}
+
+// fragmentation returns the percentage of the database file not in use
+func (s *etcdStatus) fragmentation() int {
+	if s.DBSize == 0 {
+		return 0
+	}
+	return int(100 * (s.DBSize - s.DBSizeInUse) / s.DBSize)
+}
+
+type etcdClientAdapter struct {
+	*etcd.Client
+}
+
+func (a etcdClientAdapter) Status(ctx context.Context, endpoint string) (*etcdStatus, error) {
+	resp, err := a.Client.Status(ctx, endpoint)
+	if err != nil {
+		return nil, err
+	}
+	return &etcdStatus{
+		MemberID:    resp.Header.MemberId,
+		Revision:    resp.Header.Revision,
+		DBSize:      resp.DbSize,
+		DBSizeInUse: resp.DbSizeInUse,
+	}, nil
+}
+
+var _ component.Component = (*EtcdMaintenance)(nil)
+
+// NewEtcdMaintenance creates the etcd maintenance component
+func NewEtcdMaintenance(config *v1beta1.EtcdConfig, k0sVars constant.CfgVars, leaderElector LeaderElector) *EtcdMaintenance {
+	return &EtcdMaintenance{
+		Config:        config,
+		K0sVars:       k0sVars,
+		LeaderElector: leaderElector,
+		log:           logrus.WithFields(logrus.Fields{"component": "etcd-maintenance"}),
+	}
+}
+
+// Init makes sure the snapshot directory exists
+func (m *EtcdMaintenance) Init(_ context.Context) error {
+	if m.Config.Maintenance.SnapshotInterval.Duration == 0 {
+		return nil
+	}
+	if err := dir.Init(m.snapshotDir(), constant.EtcdDataDirMode); err != nil {
+		return fmt.Errorf("failed to initialize etcd snapshot directory: %w", err)
+	}
+	return nil
+}
+
+// Run starts the maintenance loop
+func (m *EtcdMaintenance) Run(ctx context.Context) error {
+	closeClient := func() {}
+	if m.client == nil {
+		client, err := etcd.NewClient(m.K0sVars.CertRootDir, m.K0sVars.EtcdCertDir, m.Config)
+		if err != nil {
+			return fmt.Errorf("can't create etcd client: %w", err)
+		}
+		m.client = etcdClientAdapter{client}
+		closeClient = client.Close
+	}
+
+	ctx, m.stop = context.WithCancel(ctx)
+	m.done = make(chan struct{})
+	spec := m.Config.Maintenance
+	if spec.SnapshotInterval.Duration > 0 {
+		m.log.Infof("scheduling etcd snapshots every %s", spec.SnapshotInterval.Duration)
+	}
+
+	go func() {
+		defer close(m.done)
+		defer closeClient()
+		defer m.stop()
+		checks := time.NewTicker(spec.CheckInterval.Duration)
+		defer checks.Stop()
+		var snapshots <-chan time.Time
+		if spec.SnapshotInterval.Duration > 0 {
+			ticker := time.NewTicker(spec.SnapshotInterval.Duration)
+			defer ticker.Stop()
+			snapshots = ticker.C
+		}
+		for {
+			select {
+			case <-checks.C:
+				m.setLastErr(m.check(ctx))
+			case <-snapshots:
+				m.setLastErr(m.runSnapshot(ctx))
+			case <-ctx.Done():
+				m.log.Info("etcd maintenance context done")
+				return
+			}
+		}
+	}()
+
+	return nil
+}
+
+// Stop stops the maintenance loop and closes the etcd client once it's done
+func (m *EtcdMaintenance) Stop() error {
+	if m.stop != nil {
+		m.stop()
+		<-m.done
+	}
+	return nil
+}
+
+// Healthy reports the error of the last failed maintenance task, if any
+func (m *EtcdMaintenance) Healthy() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.lastErr
+}
+
+func (m *EtcdMaintenance) setLastErr(err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastErr = err
+}
+
+func (m *EtcdMaintenance) endpoint() string {
+	return m.Config.GetEndpoints()[0]
+}
+
+func (m *EtcdMaintenance) snapshotDir() string {
+	if m.Config.Maintenance.SnapshotDir != "" {
+		return m.Config.Maintenance.SnapshotDir
+	}
+	return filepath.Join(m.K0sVars.DataDir, "etcd-snapshots")
+}
+
+// check reports the database usage of the local member, recovers the cluster from the NOSPACE alarm
+// on the leading controller and defragments the local member if needed
+func (m *EtcdMaintenance) check(ctx context.Context) error {
+	status, err := m.client.Status(ctx, m.endpoint())
+	if err != nil {
+		m.log.WithError(err).Error("failed to get the etcd member status")
+		return fmt.Errorf("failed to get the etcd member status: %w", err)
+	}
+
+	quota := m.Config.GetQuotaBackendBytes()
+	usage := int(100 * status.DBSize / quota)
+	log := m.log.WithFields(logrus.Fields{
+		"dbSize":        status.DBSize,
+		"dbSizeInUse":   status.DBSizeInUse,
+		"quota":         quota,
+		"quotaUsage":    fmt.Sprintf("%d%%", usage),
+		"fragmentation": fmt.Sprintf("%d%%", status.fragmentation()),
+	})
+	if usage >= etcdQuotaWarningPercent {
+		log.Warn("etcd database is close to its space quota")
+	} else {
+		log.Info("etcd database usage")
+	}
+
+	if m.LeaderElector.IsLeader() {
+		noSpace, err := m.recoverFromNoSpace(ctx, status)
+		if err != nil {
+			m.log.WithError(err).Error("failed to recover etcd from the NOSPACE alarm")
+			return err
+		}
+		if noSpace {
+			// the alarmed members just got defragmented, the next check takes care of the local one if needed
+			return nil
+		}
+	}
+
+	threshold := m.Config.Maintenance.DefragThreshold
+	if threshold == 0 || status.DBSize < etcdDefragMinDBSize || status.fragmentation() < threshold {
+		return nil
+	}
+	if err := m.client.WithLock(ctx, etcdDefragLock, func() error { return m.defragmentMember(ctx) }); err != nil {
+		m.log.WithError(err).Error("failed to defragment etcd member")
+		return err
+	}
+	return nil
+}
+
+// recoverFromNoSpace compacts the history of the cluster if the NOSPACE alarm is raised, and defragments
+// each alarmed member through its own client URL before disarming its alarm, as only a defragmented member
+// gets its space back. It returns true if the alarm was raised.
+func (m *EtcdMaintenance) recoverFromNoSpace(ctx context.Context, status *etcdStatus) (bool, error) {
+	alarms, err := m.client.Alarms(ctx)
+	if err != nil {
+		return false, fmt.Errorf("failed to list etcd alarms: %w", err)
+	}
+	var noSpace []*etcdserverpb.AlarmMember
+	for _, alarm := range alarms {
+		if alarm.Alarm == etcdserverpb.AlarmType_NOSPACE {
+			noSpace = append(noSpace, alarm)
+		} else {
+			m.log.Warnf("etcd alarm %s raised on member %x", alarm.Alarm, alarm.MemberID)
+		}
+	}
+	if len(noSpace) == 0 {
+		return false, nil
+	}
+
+	m.log.Warnf("etcd space quota exceeded, compacting the history up to revision %d", status.Revision)
+	if err := m.client.Compact(ctx, status.Revision); err != nil && !strings.Contains(err.Error(), "required revision has been compacted") {
+		return true, fmt.Errorf("failed to compact etcd: %w", err)
+	}
+	urls, err := m.client.MemberClientURLs(ctx)
+	if err != nil {
+		return true, fmt.Errorf("failed to list etcd members: %w", err)
+	}
+	// the defragmentation lock can't be taken, etcd refusing new leases until the alarm is disarmed
+	for _, alarm := range noSpace {
+		url, ok := urls[alarm.MemberID]
+		if !ok {
+			return true, fmt.Errorf("etcd member %x raising the alarm %s has no client URL", alarm.MemberID, alarm.Alarm)
+		}
+		if err := m.defragment(ctx, url); err != nil {
+			return true, err
+		}
+		if err := m.client.DisarmAlarm(ctx, alarm); err != nil {
+			return true, fmt.Errorf("failed to disarm etcd alarm %s on member %x: %w", alarm.Alarm, alarm.MemberID, err)
+		}
+		m.log.Infof("disarmed etcd alarm %s on member %x", alarm.Alarm, alarm.MemberID)
+	}
+	return true, nil
+}
+
+// defragmentMember defragments the local member
+func (m *EtcdMaintenance) defragmentMember(ctx context.Context) error {
+	return m.defragment(ctx, m.endpoint())
+}
+
+// defragment defragments the member serving the given endpoint
+func (m *EtcdMaintenance) defragment(ctx context.Context, endpoint string) error {
+	start := time.Now()
+	m.log.Infof("defragmenting etcd member %s", endpoint)
+	if err := m.client.Defragment(ctx, endpoint); err != nil {
+		return fmt.Errorf("failed to defragment etcd member %s: %w", endpoint, err)
+	}
+	status, err := m.client.Status(ctx, endpoint)
+	if err != nil {
+		return fmt.Errorf("failed to get the status of etcd member %s: %w", endpoint, err)
+	}
+	m.log.Infof("defragmented etcd member %s in %s, database size is now %d bytes", endpoint, time.Since(start).Round(time.Millisecond), status.DBSize)
+	return nil
+}
+
+func (m *EtcdMaintenance) runSnapshot(ctx context.Context) error {
+	if !m.LeaderElector.IsLeader() {
+		m.log.Debug("not the leader, skipping etcd snapshot")
+		return nil
+	}
+
+	if err := m.snapshot(ctx, time.Now()); err != nil {
+		m.log.WithError(err).Error("etcd snapshot failed")
+		return err
+	}
+	return nil
+}
+
+// snapshot saves a snapshot of the local member in the snapshot directory and prunes the old ones
+func (m *EtcdMaintenance) snapshot(ctx context.Context, now time.Time) error {
+	snapshotDir := m.snapshotDir()
+	path := filepath.Join(snapshotDir, etcdSnapshotPrefix+now.UTC().Format(etcdSnapshotTimeFormat)+etcdSnapshotSuffix)
+	// the snapshot gets written to a temporary file first, so that a failed one never looks like a snapshot
+	if err := m.client.Snapshot(ctx, path+".part"); err != nil {
+		return fmt.Errorf("failed to take etcd snapshot: %w", err)
+	}
+	if err := os.Rename(path+".part", path); err != nil {
+		return fmt.Errorf("failed to save etcd snapshot: %w", err)
+	}
+	m.log.Infof("saved etcd snapshot %s", path)
+
+	pruned, err := pruneEtcdSnapshots(snapshotDir, m.Config.Maintenance.SnapshotRetention)
+	for _, name := range pruned {
+		m.log.Infof("removed etcd snapshot %s according to the retention policy", name)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to prune etcd snapshots: %w", err)
+	}
+	return nil
+}
+
+// pruneEtcdSnapshots removes the oldest snapshots of the directory, keeping the given number of them
+func pruneEtcdSnapshots(snapshotDir string, keep int) ([]string, error) {
+	if keep == 0 {
+		return nil, nil
+	}
+	entries, err := os.ReadDir(snapshotDir)
+	if err != nil {
+		return nil, err
+	}
+	var snapshots []string
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.Type().IsRegular() && strings.HasPrefix(name, etcdSnapshotPrefix) && strings.HasSuffix(name, etcdSnapshotSuffix) {
+			snapshots = append(snapshots, name)
+		}
+	}
+	if len(snapshots) <= keep {
+		return nil, nil
+	}
+
+	sort.Strings(snapshots)
+	var pruned []string
+	for _, name := range snapshots[:len(snapshots)-keep] {
+		if err := os.Remove(filepath.Join(snapshotDir, name)); err != nil {
+			return pruned, err
+		}
+		pruned = append(pruned, name)
+	}
+	return pruned, nil
+}
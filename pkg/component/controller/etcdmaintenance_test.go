@@ -0,0 +1,176 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controller
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+type fakeEtcdMaintenanceClient struct {
+	status       etcdStatus
+	alarms       []*etcdserverpb.AlarmMember
+	clientURLs   map[uint64]string
+	calls        []string
+	locked       []string
+	defragmented []string
+	compact      int64
+}
+
+func (c *fakeEtcdMaintenanceClient) Status(context.Context, string) (*etcdStatus, error) {
+	status := c.status
+	return &status, nil
+}
+
+func (c *fakeEtcdMaintenanceClient) Defragment(_ context.Context, endpoint string) error {
+	c.calls = append(c.calls, "defragment")
+	c.defragmented = append(c.defragmented, endpoint)
+	c.status.DBSize = c.status.DBSizeInUse
+	return nil
+}
+
+func (c *fakeEtcdMaintenanceClient) Compact(_ context.Context, revision int64) error {
+	c.calls = append(c.calls, "compact")
+	c.compact = revision
+	return nil
+}
+
+func (c *fakeEtcdMaintenanceClient) Alarms(context.Context) ([]*etcdserverpb.AlarmMember, error) {
+	return c.alarms, nil
+}
+
+func (c *fakeEtcdMaintenanceClient) MemberClientURLs(context.Context) (map[uint64]string, error) {
+	return c.clientURLs, nil
+}
+
+func (c *fakeEtcdMaintenanceClient) DisarmAlarm(_ context.Context, alarm *etcdserverpb.AlarmMember) error {
+	c.calls = append(c.calls, fmt.Sprintf("disarm %x", alarm.MemberID))
+	return nil
+}
+
+func (c *fakeEtcdMaintenanceClient) Snapshot(_ context.Context, path string) error {
+	return os.WriteFile(path, []byte("snapshot"), 0600)
+}
+
+func (c *fakeEtcdMaintenanceClient) WithLock(_ context.Context, name string, fn func() error) error {
+	c.locked = append(c.locked, name)
+	return fn()
+}
+
+func newTestEtcdMaintenance(t *testing.T, leader bool, client etcdMaintenanceClient) *EtcdMaintenance {
+	config := v1beta1.DefaultEtcdConfig()
+	config.Maintenance = v1beta1.DefaultEtcdMaintenance()
+	config.Maintenance.SnapshotRetention = 2
+	m := NewEtcdMaintenance(config, constant.CfgVars{DataDir: t.TempDir()}, &DummyLeaderElector{Leader: leader})
+	m.client = client
+	return m
+}
+
+func TestEtcdMaintenanceDefragment(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("not_fragmented", func(t *testing.T) {
+		client := &fakeEtcdMaintenanceClient{status: etcdStatus{DBSize: 200 << 20, DBSizeInUse: 150 << 20}}
+		require.NoError(t, newTestEtcdMaintenance(t, true, client).check(ctx))
+		assert.Empty(t, client.calls)
+	})
+
+	t.Run("too_small", func(t *testing.T) {
+		client := &fakeEtcdMaintenanceClient{status: etcdStatus{DBSize: 10 << 20, DBSizeInUse: 1 << 20}}
+		require.NoError(t, newTestEtcdMaintenance(t, true, client).check(ctx))
+		assert.Empty(t, client.calls)
+	})
+
+	t.Run("fragmented", func(t *testing.T) {
+		client := &fakeEtcdMaintenanceClient{status: etcdStatus{DBSize: 200 << 20, DBSizeInUse: 80 << 20}}
+		require.NoError(t, newTestEtcdMaintenance(t, false, client).check(ctx))
+		assert.Equal(t, []string{"defragment"}, client.calls)
+		assert.Equal(t, []string{etcdDefragLock}, client.locked, "members must be defragmented one at a time")
+	})
+
+	t.Run("no_space", func(t *testing.T) {
+		alarms := []*etcdserverpb.AlarmMember{
+			{MemberID: 1, Alarm: etcdserverpb.AlarmType_NOSPACE},
+			{MemberID: 2, Alarm: etcdserverpb.AlarmType_NOSPACE},
+		}
+		clientURLs := map[uint64]string{1: "https://10.0.0.1:2379", 2: "https://10.0.0.2:2379", 3: "https://10.0.0.3:2379"}
+		status := etcdStatus{Revision: 42, DBSize: 2 << 30, DBSizeInUse: 2 << 30}
+
+		// only the leader handles the alarm
+		client := &fakeEtcdMaintenanceClient{status: status, alarms: alarms, clientURLs: clientURLs}
+		require.NoError(t, newTestEtcdMaintenance(t, false, client).check(ctx))
+		assert.Empty(t, client.calls)
+
+		client = &fakeEtcdMaintenanceClient{status: status, alarms: alarms, clientURLs: clientURLs}
+		require.NoError(t, newTestEtcdMaintenance(t, true, client).check(ctx))
+		assert.Equal(t, []string{"compact", "defragment", "disarm 1", "defragment", "disarm 2"}, client.calls)
+		assert.Equal(t, []string{"https://10.0.0.1:2379", "https://10.0.0.2:2379"}, client.defragmented, "each alarmed member must be defragmented before its alarm is disarmed")
+		assert.Equal(t, int64(42), client.compact)
+		assert.Empty(t, client.locked, "the lock can't be taken while the NOSPACE alarm is raised")
+
+		// an alarm is never disarmed without defragmenting its member
+		client = &fakeEtcdMaintenanceClient{status: status, alarms: alarms, clientURLs: map[uint64]string{1: "https://10.0.0.1:2379"}}
+		assert.ErrorContains(t, newTestEtcdMaintenance(t, true, client).check(ctx), "etcd member 2 raising the alarm NOSPACE has no client URL")
+		assert.Equal(t, []string{"compact", "defragment", "disarm 1"}, client.calls)
+	})
+}
+
+func TestEtcdMaintenanceStop(t *testing.T) {
+	m := newTestEtcdMaintenance(t, true, &fakeEtcdMaintenanceClient{})
+	require.NoError(t, m.Run(context.Background()))
+	require.NoError(t, m.Stop())
+	select {
+	case <-m.done:
+	default:
+		assert.Fail(t, "the maintenance loop must be done once stopped")
+	}
+}
+
+func TestEtcdMaintenanceSnapshot(t *testing.T) {
+	ctx := context.Background()
+	m := newTestEtcdMaintenance(t, true, &fakeEtcdMaintenanceClient{})
+	dir := m.snapshotDir()
+	require.NoError(t, m.Init(ctx))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "unrelated.db"), nil, 0600))
+
+	now := time.Date(2022, 6, 1, 10, 0, 0, 0, time.UTC)
+	for i := 0; i < 3; i++ {
+		require.NoError(t, m.snapshot(ctx, now.Add(time.Duration(i)*time.Hour)))
+	}
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, entry := range entries {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{
+		"etcd-snapshot-20220601T110000Z.db",
+		"etcd-snapshot-20220601T120000Z.db",
+		"unrelated.db",
+	}, names)
+}
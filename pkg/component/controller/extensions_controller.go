@@ -31,16 +31,23 @@ import (
 	"github.com/k0sproject/k0s/pkg/helm"
 	kubeutil "github.com/k0sproject/k0s/pkg/kubernetes"
 	"github.com/sirupsen/logrus"
+	"helm.sh/helm/v3/pkg/chartutil"
 	"helm.sh/helm/v3/pkg/release"
+	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/manager"
 	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"sigs.k8s.io/yaml"
 )
 
 // Helm watch for Chart crd
@@ -203,8 +210,7 @@ func (cr *ChartReconciler) uninstall(ctx context.Context, chart v1beta1.Chart) e
 
 const defaultTimeout = time.Duration(10 * time.Minute)
 
-func (cr *ChartReconciler) updateOrInstallChart(ctx context.Context, chart v1beta1.Chart) error {
-	var err error
+func (cr *ChartReconciler) updateOrInstallChart(ctx context.Context, chart v1beta1.Chart) (err error) {
 	var chartRelease *release.Release
 	timeout, err := time.ParseDuration(chart.Spec.Timeout)
 	if err != nil {
@@ -218,6 +224,14 @@ func (cr *ChartReconciler) updateOrInstallChart(ctx context.Context, chart v1bet
 	defer func() {
 		cr.updateStatus(ctx, chart, chartRelease, err)
 	}()
+
+	values, err := cr.values(ctx, chart)
+	if err != nil {
+		setChartCondition(&chart, v1beta1.ConditionDeployed, metav1.ConditionFalse, v1beta1.ReasonValuesNotResolved, err.Error())
+		return fmt.Errorf("can't resolve values for `%s`: %v", chart.GetName(), err)
+	}
+	opts := releaseOptions(chart.Spec.Upgrade, timeout)
+
 	if chart.Status.ReleaseName == "" {
 		// new chartRelease
 		cr.L.Tracef("Start update or install %s", chart.Spec.ChartName)
@@ -225,27 +239,174 @@ func (cr *ChartReconciler) updateOrInstallChart(ctx context.Context, chart v1bet
 			chart.Spec.Version,
 			chart.Spec.ReleaseName,
 			chart.Spec.Namespace,
-			chart.Spec.YamlValues(),
-			timeout,
+			values,
+			opts,
 		)
 		if err != nil {
+			setChartCondition(&chart, v1beta1.ConditionDeployed, metav1.ConditionFalse, v1beta1.ReasonInstallFailed, err.Error())
 			return fmt.Errorf("can't reconcile installation for `%s`: %v", chart.GetName(), err)
 		}
-	} else {
-		// update
-		chartRelease, err = cr.helm.UpgradeChart(chart.Spec.ChartName,
-			chart.Status.Version,
-			chart.Status.ReleaseName,
-			chart.Status.Namespace,
-			chart.Spec.YamlValues(),
-			timeout,
-		)
+		setChartCondition(&chart, v1beta1.ConditionDeployed, metav1.ConditionTrue, v1beta1.ReasonInstallSucceeded, "")
+		return nil
+	}
+
+	// update
+	chartRelease, err = cr.helm.UpgradeChart(chart.Spec.ChartName,
+		chart.Status.Version,
+		chart.Status.ReleaseName,
+		chart.Status.Namespace,
+		values,
+		opts,
+	)
+	if err != nil {
+		setChartCondition(&chart, v1beta1.ConditionDeployed, metav1.ConditionFalse, v1beta1.ReasonUpgradeFailed, err.Error())
+		chartRelease = cr.rollback(&chart, opts)
+		return fmt.Errorf("can't reconcile upgrade for `%s`: %v", chart.GetName(), err)
+	}
+	setChartCondition(&chart, v1beta1.ConditionDeployed, metav1.ConditionTrue, v1beta1.ReasonUpgradeSucceeded, "")
+	meta.RemoveStatusCondition(&chart.Status.Conditions, v1beta1.ConditionRolledBack)
+	return nil
+}
+
+// rollback handles a failed upgrade: atomic upgrades got rolled back by helm already,
+// the others are rolled back to the previous revision if the chart asks for it.
+// It returns the release rolled back to, nil if the release wasn't rolled back.
+func (cr *ChartReconciler) rollback(chart *v1beta1.Chart, opts helm.ReleaseOptions) *release.Release {
+	latest, err := cr.helm.GetRelease(chart.Status.ReleaseName, chart.Status.Namespace)
+	if err != nil {
+		cr.L.WithError(err).Warnf("Can't get release %s/%s after a failed upgrade", chart.Status.Namespace, chart.Status.ReleaseName)
+		return nil
+	}
+	if int64(latest.Version) <= chart.Status.Revision {
+		// the upgrade failed before creating a new revision, there's nothing to roll back
+		return nil
+	}
+
+	if !opts.Atomic {
+		if chart.Spec.Upgrade == nil || !chart.Spec.Upgrade.RollbackOnFailure {
+			meta.RemoveStatusCondition(&chart.Status.Conditions, v1beta1.ConditionRolledBack)
+			return nil
+		}
+		if err := cr.helm.RollbackRelease(chart.Status.ReleaseName, chart.Status.Namespace, opts); err != nil {
+			setChartCondition(chart, v1beta1.ConditionRolledBack, metav1.ConditionFalse, v1beta1.ReasonRollbackFailed, err.Error())
+			return nil
+		}
+		if latest, err = cr.helm.GetRelease(chart.Status.ReleaseName, chart.Status.Namespace); err != nil {
+			cr.L.WithError(err).Warnf("Can't get release %s/%s after a rollback", chart.Status.Namespace, chart.Status.ReleaseName)
+			return nil
+		}
+	}
+
+	if latest.Info == nil || latest.Info.Status != release.StatusDeployed {
+		setChartCondition(chart, v1beta1.ConditionRolledBack, metav1.ConditionFalse, v1beta1.ReasonRollbackFailed,
+			fmt.Sprintf("revision %d of the release is not deployed", latest.Version))
+		return nil
+	}
+	setChartCondition(chart, v1beta1.ConditionRolledBack, metav1.ConditionTrue, v1beta1.ReasonRollbackSucceeded,
+		fmt.Sprintf("rolled back to chart version %s as revision %d", latest.Chart.Metadata.Version, latest.Version))
+	return latest
+}
+
+// values merges the values of the referenced ConfigMaps and Secrets, in order, and the inline values of the chart
+func (cr *ChartReconciler) values(ctx context.Context, chart v1beta1.Chart) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	for _, ref := range chart.Spec.ValuesFrom {
+		refValues, err := cr.referencedValues(ctx, chart.Namespace, ref)
 		if err != nil {
-			return fmt.Errorf("can't reconcile upgrade for `%s`: %v", chart.GetName(), err)
+			return nil, err
 		}
+		values = chartutil.CoalesceTables(refValues, values)
 	}
-	cr.updateStatus(ctx, chart, chartRelease, nil)
-	return nil
+	return chartutil.CoalesceTables(chart.Spec.YamlValues(), values), nil
+}
+
+// referencedValues reads the values of the referenced ConfigMap or Secret, nil if an optional one is missing
+func (cr *ChartReconciler) referencedValues(ctx context.Context, namespace string, ref v1beta1.ValuesReference) (map[string]interface{}, error) {
+	var data []byte
+	found := false
+	key := client.ObjectKey{Namespace: namespace, Name: ref.Name}
+
+	var err error
+	switch ref.Kind {
+	case v1beta1.ValuesKindConfigMap:
+		var configMap corev1.ConfigMap
+		if err = cr.Client.Get(ctx, key, &configMap); err == nil {
+			var value string
+			if value, found = configMap.Data[ref.Key()]; found {
+				data = []byte(value)
+			} else {
+				data, found = configMap.BinaryData[ref.Key()]
+			}
+		}
+	case v1beta1.ValuesKindSecret:
+		var secret corev1.Secret
+		if err = cr.Client.Get(ctx, key, &secret); err == nil {
+			data, found = secret.Data[ref.Key()]
+		}
+	default:
+		return nil, fmt.Errorf("unsupported values reference kind `%s`", ref.Kind)
+	}
+	if err != nil && !(errors.IsNotFound(err) && ref.Optional) {
+		return nil, fmt.Errorf("can't get %s `%s`: %w", ref.Kind, ref.Name, err)
+	}
+	if !found {
+		if ref.Optional {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("%s `%s` has no key `%s`", ref.Kind, ref.Name, ref.Key())
+	}
+
+	values := map[string]interface{}{}
+	if err := yaml.Unmarshal(data, &values); err != nil {
+		return nil, fmt.Errorf("can't parse values of %s `%s`: %w", ref.Kind, ref.Name, err)
+	}
+	return v1beta1.CleanUpGenericMap(values), nil
+}
+
+// chartsReferencing maps a ConfigMap or a Secret to the charts taking values from it
+func (cr *ChartReconciler) chartsReferencing(kind string) handler.MapFunc {
+	return func(object client.Object) []reconcile.Request {
+		var charts v1beta1.ChartList
+		if err := cr.Client.List(context.Background(), &charts, client.InNamespace(object.GetNamespace())); err != nil {
+			cr.L.WithError(err).Warnf("Can't list charts referencing %s %s", kind, object.GetName())
+			return nil
+		}
+		var requests []reconcile.Request
+		for _, chart := range charts.Items {
+			for _, ref := range chart.Spec.ValuesFrom {
+				if ref.Kind == kind && ref.Name == object.GetName() {
+					requests = append(requests, reconcile.Request{NamespacedName: client.ObjectKeyFromObject(&chart)})
+					break
+				}
+			}
+		}
+		return requests
+	}
+}
+
+func releaseOptions(policy *v1beta1.UpgradePolicy, timeout time.Duration) helm.ReleaseOptions {
+	opts := helm.ReleaseOptions{
+		Timeout:     timeout,
+		Atomic:      policy.IsAtomic(),
+		Wait:        policy.ShouldWait(),
+		WaitForJobs: policy.ShouldWaitForJobs(),
+		Force:       policy.ShouldForce(),
+	}
+	if policy != nil {
+		opts.CleanupOnFail = policy.CleanupOnFail
+		opts.MaxHistory = policy.MaxHistory
+	}
+	return opts
+}
+
+func setChartCondition(chart *v1beta1.Chart, conditionType string, status metav1.ConditionStatus, reason string, message string) {
+	meta.SetStatusCondition(&chart.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: chart.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
 }
 
 func (cr *ChartReconciler) updateStatus(ctx context.Context, chart v1beta1.Chart, chartRelease *release.Release, err error) {
@@ -258,6 +419,7 @@ func (cr *ChartReconciler) updateStatus(ctx context.Context, chart v1beta1.Chart
 		chart.Status.Namespace = chartRelease.Namespace
 	}
 	chart.Status.Updated = time.Now().String()
+	chart.Status.Error = ""
 	if err != nil {
 		chart.Status.Error = err.Error()
 	}
@@ -286,6 +448,12 @@ spec:
 {{ .Values | nindent 4 }}
   version: {{ .Version }}
   namespace: {{ .TargetNS }}
+{{- with .ValuesFrom }}
+  valuesFrom: {{ toJson . }}
+{{- end }}
+{{- with .Upgrade }}
+  upgrade: {{ toJson . }}
+{{- end }}
 `
 
 const finalizerName = "helm.k0sproject.io/uninstall-helm-release"
@@ -305,6 +473,8 @@ func (ec *ExtensionsController) Run(ctx context.Context) error {
 	mgr, err := manager.New(config, manager.Options{
 		MetricsBindAddress: "0",
 		Logger:             logrusr.New(ec.L),
+		// the charts and the ConfigMaps and Secrets they take values from are in the same namespace
+		Namespace: namespaceToWatch,
 	})
 	if err != nil {
 		return fmt.Errorf("can't build controller-runtime controller for helm extensions: %w", err)
@@ -329,6 +499,11 @@ func (ec *ExtensionsController) Run(ctx context.Context) error {
 		return fmt.Errorf("can't register Chart crd: %w", err)
 	}
 
+	chartReconciler := &ChartReconciler{
+		leaderElector: ec.leaderElector, // TODO: drop in favor of controller-runtime lease manager?
+		helm:          ec.helm,
+		L:             ec.L.WithField("extensions_type", "helm"),
+	}
 	if err := builder.
 		ControllerManagedBy(mgr).
 		For(&v1beta1.Chart{},
@@ -340,11 +515,9 @@ func (ec *ExtensionsController) Run(ctx context.Context) error {
 			),
 			),
 		).
-		Complete(&ChartReconciler{
-			leaderElector: ec.leaderElector, // TODO: drop in favor of controller-runtime lease manager?
-			helm:          ec.helm,
-			L:             ec.L.WithField("extensions_type", "helm"),
-		}); err != nil {
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(chartReconciler.chartsReferencing(v1beta1.ValuesKindConfigMap))).
+		Watches(&source.Kind{Type: &corev1.Secret{}}, handler.EnqueueRequestsFromMapFunc(chartReconciler.chartsReferencing(v1beta1.ValuesKindSecret))).
+		Complete(chartReconciler); err != nil {
 		return fmt.Errorf("can't build controller-runtime controller for helm extensions: %w", err)
 	}
 
@@ -0,0 +1,146 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package controller
+
+import (
+	"bytes"
+	"context"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/yaml"
+
+	"github.com/k0sproject/k0s/internal/pkg/templatewriter"
+	"github.com/k0sproject/k0s/pkg/apis/helm.k0sproject.io/v1beta1"
+	k0sAPI "github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+)
+
+func TestChartReconcilerValues(t *testing.T) {
+	scheme := runtime.NewScheme()
+	require.NoError(t, clientgoscheme.AddToScheme(scheme))
+	require.NoError(t, v1beta1.AddToScheme(scheme))
+
+	chart := v1beta1.Chart{
+		ObjectMeta: metav1.ObjectMeta{Name: "k0s-addon-chart-db", Namespace: "kube-system"},
+		Spec: v1beta1.ChartSpec{
+			Values: "replicas: 3\nauth:\n  username: app\n",
+			ValuesFrom: []v1beta1.ValuesReference{
+				{Kind: v1beta1.ValuesKindConfigMap, Name: "db-values"},
+				{Kind: v1beta1.ValuesKindSecret, Name: "db-credentials", ValuesKey: "credentials.yaml"},
+				{Kind: v1beta1.ValuesKindSecret, Name: "missing", Optional: true},
+			},
+		},
+	}
+	cr := &ChartReconciler{
+		Client: fake.NewClientBuilder().WithScheme(scheme).WithObjects(
+			&chart,
+			&corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{Name: "db-values", Namespace: "kube-system"},
+				Data:       map[string]string{"values.yaml": "replicas: 1\nauth:\n  username: admin\n  database: app\n"},
+			},
+			&corev1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: "kube-system"},
+				Data:       map[string][]byte{"credentials.yaml": []byte("auth:\n  password: s3cr3t\n")},
+			},
+		).Build(),
+		L: logrus.WithField("component", "test"),
+	}
+	ctx := context.Background()
+
+	values, err := cr.values(ctx, chart)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"replicas": float64(3),
+		"auth": map[string]interface{}{
+			"username": "app",
+			"database": "app",
+			"password": "s3cr3t",
+		},
+	}, values)
+
+	requests := cr.chartsReferencing(v1beta1.ValuesKindSecret)(&corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: "kube-system"},
+	})
+	require.Len(t, requests, 1)
+	assert.Equal(t, "k0s-addon-chart-db", requests[0].Name)
+	assert.Empty(t, cr.chartsReferencing(v1beta1.ValuesKindConfigMap)(&corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "db-credentials", Namespace: "kube-system"},
+	}))
+
+	chart.Spec.ValuesFrom = []v1beta1.ValuesReference{{Kind: v1beta1.ValuesKindSecret, Name: "missing"}}
+	_, err = cr.values(ctx, chart)
+	assert.ErrorContains(t, err, "can't get Secret `missing`")
+
+	chart.Spec.ValuesFrom = []v1beta1.ValuesReference{{Kind: v1beta1.ValuesKindConfigMap, Name: "db-values", ValuesKey: "other.yaml"}}
+	_, err = cr.values(ctx, chart)
+	assert.ErrorContains(t, err, "ConfigMap `db-values` has no key `other.yaml`")
+}
+
+func TestReleaseOptions(t *testing.T) {
+	opts := releaseOptions(nil, defaultTimeout)
+	assert.True(t, opts.Atomic)
+	assert.True(t, opts.Wait)
+	assert.True(t, opts.WaitForJobs)
+	assert.True(t, opts.Force)
+	assert.Zero(t, opts.MaxHistory)
+
+	disabled := false
+	opts = releaseOptions(&v1beta1.UpgradePolicy{Atomic: &disabled, Force: &disabled, MaxHistory: 5, CleanupOnFail: true}, defaultTimeout)
+	assert.False(t, opts.Atomic)
+	assert.True(t, opts.Wait)
+	assert.False(t, opts.Force)
+	assert.True(t, opts.CleanupOnFail)
+	assert.Equal(t, 5, opts.MaxHistory)
+}
+
+func TestChartCrdTemplate(t *testing.T) {
+	atomic := false
+	tw := templatewriter.TemplateWriter{
+		Name:     "addon_crd_manifest",
+		Template: chartCrdTemplate,
+		Data: struct {
+			k0sAPI.Chart
+			Finalizer string
+		}{
+			Chart: k0sAPI.Chart{
+				Name:      "db",
+				ChartName: "bitnami/postgresql",
+				TargetNS:  "db",
+				ValuesFrom: []v1beta1.ValuesReference{
+					{Kind: v1beta1.ValuesKindSecret, Name: "db-credentials"},
+				},
+				Upgrade: &v1beta1.UpgradePolicy{Atomic: &atomic, RollbackOnFailure: true},
+			},
+			Finalizer: finalizerName,
+		},
+	}
+	buf := bytes.Buffer{}
+	require.NoError(t, tw.WriteToBuffer(&buf))
+
+	var chart v1beta1.Chart
+	require.NoError(t, yaml.UnmarshalStrict(buf.Bytes(), &chart))
+	assert.Equal(t, []v1beta1.ValuesReference{{Kind: v1beta1.ValuesKindSecret, Name: "db-credentials"}}, chart.Spec.ValuesFrom)
+	require.NotNil(t, chart.Spec.Upgrade)
+	assert.False(t, chart.Spec.Upgrade.IsAtomic())
+	assert.True(t, chart.Spec.Upgrade.RollbackOnFailure)
+}
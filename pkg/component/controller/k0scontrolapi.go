@@ -27,9 +27,11 @@ import (
 
 // K0SControlAPI implements the k0s control API component
 type K0SControlAPI struct {
-	ConfigPath string
-	K0sVars    constant.CfgVars
-	supervisor supervisor.Supervisor
+	ConfigPath    string
+	K0sVars       constant.CfgVars
+	RestartPolicy *supervisor.RestartPolicy
+	LogConfig     *supervisor.LogConfig
+	supervisor    supervisor.Supervisor
 }
 
 var _ component.Component = (*K0SControlAPI)(nil)
@@ -57,6 +59,8 @@ func (m *K0SControlAPI) Run(_ context.Context) error {
 			"api",
 			fmt.Sprintf("--data-dir=%s", m.K0sVars.DataDir),
 		},
+		RestartPolicy: m.RestartPolicy,
+		LogConfig:     m.LogConfig,
 	}
 
 	return m.supervisor.Supervise()
@@ -67,5 +71,5 @@ func (m *K0SControlAPI) Stop() error {
 	return m.supervisor.Stop()
 }
 
-// Healthy for health-check interface
-func (m *K0SControlAPI) Healthy() error { return nil }
+// Healthy reports whether the control API process is crash-looping
+func (m *K0SControlAPI) Healthy() error { return m.supervisor.Healthy() }
@@ -36,14 +36,16 @@ import (
 
 // Kine implement the component interface to run kine
 type Kine struct {
-	Config     *v1beta1.KineConfig
-	gid        int
-	K0sVars    constant.CfgVars
-	supervisor supervisor.Supervisor
-	uid        int
+	Config        *v1beta1.KineConfig
+	gid           int
+	K0sVars       constant.CfgVars
+	RestartPolicy *supervisor.RestartPolicy
+	LogConfig     *supervisor.LogConfig
+	supervisor    supervisor.Supervisor
+	uid           int
 }
 
-var _ component.Component = (*Kine)(nil)
+var _ component.NodeReconcilerComponent = (*Kine)(nil)
 
 // Init extracts the needed binaries
 func (k *Kine) Init(_ context.Context) error {
@@ -63,7 +65,15 @@ func (k *Kine) Init(_ context.Context) error {
 		logrus.Warningf("failed to chown %s", kineSocketDir)
 	}
 
-	dsURL, err := url.Parse(k.Config.DataSource)
+	if err := k.prepareDataSource(k.Config.DataSource); err != nil {
+		return err
+	}
+	return assets.Stage(k.K0sVars.BinDir, "kine", constant.BinDirMode)
+}
+
+// prepareDataSource makes sure the directory of a sqlite data source exists and is owned by the kine user
+func (k *Kine) prepareDataSource(dataSource string) error {
+	dsURL, err := url.Parse(dataSource)
 	if err != nil {
 		return err
 	}
@@ -81,14 +91,39 @@ func (k *Kine) Init(_ context.Context) error {
 			logrus.Warningf("datasource file %s does not exist", dsURL.Path)
 		}
 	}
-	return assets.Stage(k.K0sVars.BinDir, "kine", constant.BinDirMode)
+	return nil
 }
 
 // Run runs kine
 func (k *Kine) Run(_ context.Context) error {
 	logrus.Info("Starting kine")
 	logrus.Debugf("datasource: %s", k.Config.DataSource)
+	return k.start()
+}
+
+// ReconcileNodeConfig restarts kine if its data source has been changed
+func (k *Kine) ReconcileNodeConfig(_ context.Context, nodeConfig *v1beta1.ClusterConfig) error {
+	storage := nodeConfig.Spec.Storage
+	if storage.Type != v1beta1.KineStorageType || storage.Kine == nil {
+		return fmt.Errorf("changing the storage type from kine to %s requires a restart", storage.Type)
+	}
+	if storage.Kine.DataSource == k.Config.DataSource {
+		logrus.WithField("component", "kine").Info("node config reload has nothing to do")
+		return nil
+	}
+
+	if err := k.prepareDataSource(storage.Kine.DataSource); err != nil {
+		return err
+	}
+	logrus.WithField("component", "kine").Info("restarting kine to apply the reloaded data source")
+	if err := k.supervisor.Stop(); err != nil {
+		return err
+	}
+	k.Config = storage.Kine
+	return k.start()
+}
 
+func (k *Kine) start() error {
 	k.supervisor = supervisor.Supervisor{
 		Name:    "kine",
 		BinPath: assets.BinPath("kine", k.K0sVars.BinDir),
@@ -98,8 +133,10 @@ func (k *Kine) Run(_ context.Context) error {
 			fmt.Sprintf("--endpoint=%s", k.Config.DataSource),
 			fmt.Sprintf("--listen-address=unix://%s", k.K0sVars.KineSocketPath),
 		},
-		UID: k.uid,
-		GID: k.gid,
+		UID:           k.uid,
+		GID:           k.gid,
+		RestartPolicy: k.RestartPolicy,
+		LogConfig:     k.LogConfig,
 	}
 
 	return k.supervisor.Supervise()
@@ -110,5 +147,5 @@ func (k *Kine) Stop() error {
 	return k.supervisor.Stop()
 }
 
-// Health-check interface
-func (k *Kine) Healthy() error { return nil }
+// Healthy reports whether the kine process is crash-looping
+func (k *Kine) Healthy() error { return k.supervisor.Healthy() }
@@ -42,9 +42,11 @@ import (
 
 // Konnectivity implements the component interface of konnectivity server
 type Konnectivity struct {
-	K0sVars    constant.CfgVars
-	LogLevel   string
-	SingleNode bool
+	K0sVars       constant.CfgVars
+	LogLevel      string
+	RestartPolicy *supervisor.RestartPolicy
+	LogConfig     *supervisor.LogConfig
+	SingleNode    bool
 	// used for lease lock
 	KubeClientFactory k8sutil.ClientFactoryInterface
 	NodeConfig        *v1beta1.ClusterConfig
@@ -161,12 +163,14 @@ func (k *Konnectivity) runServer(ctx context.Context) {
 				}
 
 				k.supervisor = &supervisor.Supervisor{
-					Name:    "konnectivity",
-					BinPath: assets.BinPath("konnectivity-server", k.K0sVars.BinDir),
-					DataDir: k.K0sVars.DataDir,
-					RunDir:  k.K0sVars.RunDir,
-					Args:    args.ToArgs(),
-					UID:     k.uid,
+					Name:          "konnectivity",
+					BinPath:       assets.BinPath("konnectivity-server", k.K0sVars.BinDir),
+					DataDir:       k.K0sVars.DataDir,
+					RunDir:        k.K0sVars.RunDir,
+					Args:          args.ToArgs(),
+					UID:           k.uid,
+					RestartPolicy: k.RestartPolicy,
+					LogConfig:     k.LogConfig,
 				}
 				err := k.supervisor.Supervise()
 				if err != nil {
@@ -36,6 +36,8 @@ type Scheduler struct {
 	gid            int
 	K0sVars        constant.CfgVars
 	LogLevel       string
+	RestartPolicy  *supervisor.RestartPolicy
+	LogConfig      *supervisor.LogConfig
 	SingleNode     bool
 	supervisor     *supervisor.Supervisor
 	uid            int
@@ -109,13 +111,15 @@ func (a *Scheduler) Reconcile(_ context.Context, clusterConfig *v1beta1.ClusterC
 	}
 
 	a.supervisor = &supervisor.Supervisor{
-		Name:    "kube-scheduler",
-		BinPath: assets.BinPath("kube-scheduler", a.K0sVars.BinDir),
-		RunDir:  a.K0sVars.RunDir,
-		DataDir: a.K0sVars.DataDir,
-		Args:    args.ToDashedArgs(),
-		UID:     a.uid,
-		GID:     a.gid,
+		Name:          "kube-scheduler",
+		BinPath:       assets.BinPath("kube-scheduler", a.K0sVars.BinDir),
+		RunDir:        a.K0sVars.RunDir,
+		DataDir:       a.K0sVars.DataDir,
+		Args:          args.ToDashedArgs(),
+		UID:           a.uid,
+		GID:           a.gid,
+		RestartPolicy: a.RestartPolicy,
+		LogConfig:     a.LogConfig,
 	}
 	a.previousConfig = args
 	return a.supervisor.Supervise()
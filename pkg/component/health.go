@@ -0,0 +1,196 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package component
+
+import (
+	"context"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// State is the lifecycle state of a managed component
+type State string
+
+const (
+	StateCreated     State = "created"
+	StateInitialized State = "initialized"
+	StateRunning     State = "running"
+	StateUnhealthy   State = "unhealthy"
+	StateStopped     State = "stopped"
+)
+
+// Status is the lifecycle state and the latest health check result of a managed component
+type Status struct {
+	Name  string `json:"name"`
+	State State  `json:"state"`
+	// LastTransition is the time the component entered its current state
+	LastTransition time.Time `json:"lastTransition,omitempty"`
+	// LastHealthCheck is the time of the latest call to Healthy()
+	LastHealthCheck time.Time `json:"lastHealthCheck,omitempty"`
+	// LastError is the error returned by the latest failed call to Init(), Run() or Healthy()
+	LastError string `json:"lastError,omitempty"`
+}
+
+// Ready returns true if the component has been started and passed its latest health check
+func (s *Status) Ready() bool {
+	return s.State == StateRunning
+}
+
+// Live returns false if the component has been started but failed its latest health check
+func (s *Status) Live() bool {
+	return s.State != StateUnhealthy
+}
+
+func componentName(comp Component) string {
+	return reflect.TypeOf(comp).Elem().Name()
+}
+
+// status returns the status of the given component, creating it on first use.
+// The caller has to hold the status mutex.
+func (m *Manager) status(comp Component) *Status {
+	if m.statuses == nil {
+		m.statuses = map[Component]*Status{}
+	}
+	s, ok := m.statuses[comp]
+	if !ok {
+		s = &Status{Name: componentName(comp), State: StateCreated, LastTransition: time.Now()}
+		m.statuses[comp] = s
+	}
+	return s
+}
+
+func (m *Manager) setState(comp Component, state State, err error) {
+	m.statusMutex.Lock()
+	defer m.statusMutex.Unlock()
+	s := m.status(comp)
+	if s.State != state {
+		s.State = state
+		s.LastTransition = time.Now()
+	}
+	if err != nil {
+		s.LastError = err.Error()
+	}
+}
+
+// Statuses returns the status of all managed components, in the order they were added
+func (m *Manager) Statuses() []Status {
+	m.statusMutex.Lock()
+	defer m.statusMutex.Unlock()
+	statuses := make([]Status, 0, len(m.Components))
+	for _, comp := range m.Components {
+		statuses = append(statuses, *m.status(comp))
+	}
+	return statuses
+}
+
+func (m *Manager) isStarted(comp Component) bool {
+	m.statusMutex.Lock()
+	defer m.statusMutex.Unlock()
+	state := m.status(comp).State
+	return state == StateRunning || state == StateUnhealthy
+}
+
+// checkHealth runs the health checks of all started components and updates their state
+func (m *Manager) checkHealth() {
+	m.lifecycleMutex.Lock()
+	defer m.lifecycleMutex.Unlock()
+
+	m.statusMutex.Lock()
+	var started []Component
+	for comp, s := range m.statuses {
+		if s.State == StateRunning || s.State == StateUnhealthy {
+			started = append(started, comp)
+		}
+	}
+	m.statusMutex.Unlock()
+
+	for _, comp := range started {
+		err := comp.Healthy()
+		now := time.Now()
+
+		m.statusMutex.Lock()
+		s := m.status(comp)
+		if s.State == StateRunning || s.State == StateUnhealthy {
+			state := StateRunning
+			if err != nil {
+				state = StateUnhealthy
+				s.LastError = err.Error()
+			}
+			if s.State != state {
+				logrus.Infof("component %s is %s", s.Name, state)
+				s.State = state
+				s.LastTransition = now
+			}
+			s.LastHealthCheck = now
+		}
+		m.statusMutex.Unlock()
+	}
+}
+
+// watchHealth periodically runs the health checks until the context is done
+func (m *Manager) watchHealth(ctx context.Context) {
+	ticker := time.NewTicker(m.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			m.checkHealth()
+		}
+	}
+}
+
+var (
+	registryMutex sync.Mutex
+	registry      []*Manager
+)
+
+func register(m *Manager) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	for _, r := range registry {
+		if r == m {
+			return
+		}
+	}
+	registry = append(registry, m)
+}
+
+func unregister(m *Manager) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	for i, r := range registry {
+		if r == m {
+			registry = append(registry[:i], registry[i+1:]...)
+			return
+		}
+	}
+}
+
+// Statuses returns the status of the components of all initialized managers
+func Statuses() []Status {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	var statuses []Status
+	for _, m := range registry {
+		statuses = append(statuses, m.Statuses()...)
+	}
+	return statuses
+}
@@ -21,6 +21,7 @@ import (
 	"fmt"
 	"reflect"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
@@ -33,17 +34,25 @@ import (
 type Manager struct {
 	Components     []Component
 	HealthyTimeout time.Duration
+	// HealthCheckInterval is the interval between the health checks of the started components
+	HealthCheckInterval time.Duration
 
 	started              *list.List
 	lastReconciledConfig *v1beta1.ClusterConfig
+	statusMutex          sync.Mutex
+	statuses             map[Component]*Status
+	stopHealthChecks     context.CancelFunc
+	// lifecycleMutex serializes the periodic health checks and the node config reconciliation
+	lifecycleMutex sync.Mutex
 }
 
 // NewManager creates a manager
 func NewManager() *Manager {
 	return &Manager{
-		Components:     []Component{},
-		HealthyTimeout: 2 * time.Minute,
-		started:        list.New(),
+		Components:          []Component{},
+		HealthyTimeout:      2 * time.Minute,
+		HealthCheckInterval: 10 * time.Second,
+		started:             list.New(),
 	}
 }
 
@@ -59,6 +68,7 @@ func (m *Manager) Add(ctx context.Context, component Component) {
 
 // Init initializes all managed components
 func (m *Manager) Init(ctx context.Context) error {
+	register(m)
 	g, _ := errgroup.WithContext(ctx)
 
 	for _, comp := range m.Components {
@@ -67,7 +77,12 @@ func (m *Manager) Init(ctx context.Context) error {
 		c := comp
 		// init this async
 		g.Go(func() error {
-			return c.Init(ctx)
+			if err := c.Init(ctx); err != nil {
+				m.setState(c, StateCreated, err)
+				return err
+			}
+			m.setState(c, StateInitialized, nil)
+			return nil
 		})
 	}
 	err := g.Wait()
@@ -82,17 +97,24 @@ func (m *Manager) Start(ctx context.Context) error {
 		perfTimer.Checkpoint(fmt.Sprintf("running-%s", compName))
 		logrus.Infof("starting %v", compName)
 		if err := comp.Run(ctx); err != nil {
+			m.setState(comp, StateStopped, err)
 			_ = m.Stop()
 			return err
 		}
 		m.started.PushFront(comp)
 		perfTimer.Checkpoint(fmt.Sprintf("running-%s-done", compName))
 		if err := waitForHealthy(ctx, comp, compName, m.HealthyTimeout); err != nil {
+			m.setState(comp, StateUnhealthy, err)
 			_ = m.Stop()
 			return err
 		}
+		m.setState(comp, StateRunning, nil)
 	}
 	perfTimer.Output()
+
+	var healthCtx context.Context
+	healthCtx, m.stopHealthChecks = context.WithCancel(context.Background())
+	go m.watchHealth(healthCtx)
 	return nil
 }
 
@@ -101,17 +123,24 @@ func (m *Manager) Stop() error {
 	var ret error
 	var next *list.Element
 
+	if m.stopHealthChecks != nil {
+		m.stopHealthChecks()
+	}
+	defer unregister(m)
+
 	for e := m.started.Front(); e != nil; e = next {
 		component := e.Value.(Component)
 		name := reflect.TypeOf(component).Elem().Name()
 
 		if err := component.Stop(); err != nil {
 			logrus.Errorf("failed to stop component %s: %s", name, err.Error())
+			m.setState(component, StateStopped, err)
 			if ret == nil {
 				ret = fmt.Errorf("failed to stop components")
 			}
 		} else {
 			logrus.Infof("stopped component %s", name)
+			m.setState(component, StateStopped, nil)
 		}
 
 		next = e.Next()
@@ -169,6 +198,82 @@ func (m *Manager) reconcileComponent(ctx context.Context, component Component, c
 	return nil
 }
 
+// ReconcileNodeConfig reconciles all started components with a changed node config, in the order they were added.
+// Each affected component has to become healthy again before the next one is reconciled.
+func (m *Manager) ReconcileNodeConfig(ctx context.Context, nodeConfig *v1beta1.ClusterConfig) error {
+	return m.ReconcileNode(ctx, func(ctx context.Context, comp Component) (bool, error) {
+		nodeComponent, ok := comp.(NodeReconcilerComponent)
+		if !ok {
+			return false, nil
+		}
+		return true, nodeComponent.ReconcileNodeConfig(ctx, nodeConfig)
+	})
+}
+
+// ReconcileNode reconciles all started components with a change of the node settings, in the order they were added.
+// The reconcile function returns false for the components it doesn't reconcile. Each reconciled component has to
+// become healthy again before the next one is reconciled.
+func (m *Manager) ReconcileNode(ctx context.Context, reconcile func(context.Context, Component) (bool, error)) error {
+	m.lifecycleMutex.Lock()
+	defer m.lifecycleMutex.Unlock()
+
+	for _, comp := range m.Components {
+		if !m.isStarted(comp) {
+			continue
+		}
+		compName := reflect.TypeOf(comp).Elem().Name()
+		reconciled, err := reconcile(ctx, comp)
+		if !reconciled {
+			continue
+		}
+		if err != nil {
+			m.setState(comp, StateUnhealthy, err)
+			return fmt.Errorf("failed to reconcile the node settings of %s: %w", compName, err)
+		}
+		logrus.Infof("reconciled the node settings of %s", compName)
+		if err := waitForHealthy(ctx, comp, compName, m.HealthyTimeout); err != nil {
+			m.setState(comp, StateUnhealthy, err)
+			return err
+		}
+		m.setState(comp, StateRunning, nil)
+	}
+	return nil
+}
+
+// Replace stops a started component and puts another one, already initialized and running, in its place.
+// The new component is stopped along with the others, in the order of the replaced one.
+func (m *Manager) Replace(old, replacement Component) error {
+	m.lifecycleMutex.Lock()
+	defer m.lifecycleMutex.Unlock()
+
+	index := -1
+	for i, comp := range m.Components {
+		if comp == old {
+			index = i
+		}
+	}
+	if index < 0 {
+		return fmt.Errorf("component %s is not managed", componentName(old))
+	}
+	m.Components[index] = replacement
+	for e := m.started.Front(); e != nil; e = e.Next() {
+		if e.Value == old {
+			e.Value = replacement
+		}
+	}
+	m.setState(replacement, StateRunning, nil)
+
+	err := old.Stop()
+	m.statusMutex.Lock()
+	delete(m.statuses, old)
+	m.statusMutex.Unlock()
+	if err != nil {
+		return fmt.Errorf("failed to stop component %s: %w", componentName(old), err)
+	}
+	logrus.Infof("replaced component %s with %s", componentName(old), componentName(replacement))
+	return nil
+}
+
 func isReconcileComponent(component Component) bool {
 	_, ok := component.(ReconcilerComponent)
 	return ok
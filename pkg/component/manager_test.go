@@ -22,6 +22,8 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/require"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 )
 
 type Fake struct {
@@ -163,3 +165,106 @@ func TestManagerHealthyFail(t *testing.T) {
 	require.True(t, f2.StopCalled)
 	require.False(t, f3.StopCalled)
 }
+
+func TestManagerStatuses(t *testing.T) {
+	m := NewManager()
+	m.HealthCheckInterval = time.Hour
+
+	ctx := context.Background()
+	f1 := &Fake{}
+	m.Add(ctx, f1)
+
+	require.NoError(t, m.Init(ctx))
+	require.Contains(t, registry, m)
+	require.Equal(t, StateInitialized, m.Statuses()[0].State)
+
+	require.NoError(t, m.Start(ctx))
+	statuses := m.Statuses()
+	require.Len(t, statuses, 1)
+	require.Equal(t, StateRunning, statuses[0].State)
+	require.True(t, statuses[0].Ready())
+
+	f1.HealthyErr = fmt.Errorf("broken")
+	m.checkHealth()
+	statuses = m.Statuses()
+	require.Equal(t, StateUnhealthy, statuses[0].State)
+	require.Equal(t, "broken", statuses[0].LastError)
+	require.False(t, statuses[0].Live())
+	require.False(t, statuses[0].LastHealthCheck.IsZero())
+
+	f1.HealthyErr = nil
+	m.checkHealth()
+	require.Equal(t, StateRunning, m.Statuses()[0].State)
+
+	require.NoError(t, m.Stop())
+	require.Equal(t, StateStopped, m.Statuses()[0].State)
+	require.NotContains(t, registry, m)
+}
+
+type FakeNodeReconciler struct {
+	Fake
+	ReconcileNodeConfigErr    error
+	ReconcileNodeConfigCalled bool
+}
+
+func (f *FakeNodeReconciler) ReconcileNodeConfig(_ context.Context, _ *v1beta1.ClusterConfig) error {
+	f.ReconcileNodeConfigCalled = true
+	return f.ReconcileNodeConfigErr
+}
+
+func TestManagerReconcileNodeConfig(t *testing.T) {
+	m := NewManager()
+	m.HealthCheckInterval = time.Hour
+
+	ctx := context.Background()
+	f1 := &FakeNodeReconciler{}
+	m.Add(ctx, f1)
+	f2 := &FakeNodeReconciler{ReconcileNodeConfigErr: fmt.Errorf("failed")}
+	m.Add(ctx, f2)
+	f3 := &FakeNodeReconciler{}
+	m.Add(ctx, f3)
+
+	cfg := v1beta1.DefaultClusterConfig()
+
+	// components which haven't been started aren't reconciled
+	require.NoError(t, m.Init(ctx))
+	require.NoError(t, m.ReconcileNodeConfig(ctx, cfg))
+	require.False(t, f1.ReconcileNodeConfigCalled)
+
+	require.NoError(t, m.Start(ctx))
+	require.Error(t, m.ReconcileNodeConfig(ctx, cfg))
+	require.True(t, f1.ReconcileNodeConfigCalled)
+	require.True(t, f2.ReconcileNodeConfigCalled)
+	require.False(t, f3.ReconcileNodeConfigCalled)
+
+	statuses := m.Statuses()
+	require.Equal(t, StateRunning, statuses[0].State)
+	require.Equal(t, StateUnhealthy, statuses[1].State)
+	require.Equal(t, "failed", statuses[1].LastError)
+
+	require.NoError(t, m.Stop())
+}
+
+func TestManagerReplace(t *testing.T) {
+	m := NewManager()
+	m.HealthCheckInterval = time.Hour
+
+	ctx := context.Background()
+	f1 := &Fake{}
+	m.Add(ctx, f1)
+	f2 := &FakeNodeReconciler{}
+	m.Add(ctx, f2)
+	require.NoError(t, m.Init(ctx))
+	require.NoError(t, m.Start(ctx))
+
+	replacement := &Fake{}
+	require.NoError(t, m.Replace(f1, replacement))
+	require.True(t, f1.StopCalled)
+	require.Equal(t, []Component{replacement, f2}, m.Components)
+	require.Equal(t, StateRunning, m.Statuses()[0].State)
+
+	require.Error(t, m.Replace(f1, &Fake{}), "a replaced component isn't managed anymore")
+
+	require.NoError(t, m.Stop())
+	require.True(t, replacement.StopCalled)
+}
@@ -0,0 +1,90 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package status
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/k0sproject/k0s/pkg/component"
+	"github.com/k0sproject/k0s/pkg/supervisor"
+)
+
+// healthHandler serves the /healthz and /readyz endpoints in the same plain text format as the kube-apiserver.
+// Liveness fails if a started component fails its health check or a supervised process is crash-looping,
+// readiness additionally requires all components to be started and all supervised processes to be running.
+type healthHandler struct {
+	readiness bool
+}
+
+func (h *healthHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var b strings.Builder
+	passed := true
+	check := func(name string, err error) {
+		if err == nil {
+			fmt.Fprintf(&b, "[+]%s ok\n", name)
+		} else {
+			passed = false
+			fmt.Fprintf(&b, "[-]%s failed: %v\n", name, err)
+		}
+	}
+
+	for _, c := range component.Statuses() {
+		check("component/"+c.Name, h.checkComponent(&c))
+	}
+	for _, p := range supervisor.Statuses() {
+		check("process/"+p.Name, h.checkProcess(&p))
+	}
+
+	endpoint := strings.TrimPrefix(r.URL.Path, "/")
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	if passed {
+		fmt.Fprintf(&b, "%s check passed\n", endpoint)
+		w.WriteHeader(http.StatusOK)
+	} else {
+		fmt.Fprintf(&b, "%s check failed\n", endpoint)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_, _ = w.Write([]byte(b.String()))
+}
+
+func (h *healthHandler) checkComponent(c *component.Status) error {
+	if !c.Live() || (h.readiness && !c.Ready()) {
+		if c.LastError != "" {
+			return fmt.Errorf("%s: %s", c.State, c.LastError)
+		}
+		return fmt.Errorf("%s", c.State)
+	}
+	return nil
+}
+
+func (h *healthHandler) checkProcess(p *supervisor.ProcessStatus) error {
+	switch p.State {
+	case supervisor.StateRunning:
+		return nil
+	case supervisor.StateCrashLoopBackOff, supervisor.StateFailed:
+	default:
+		if !h.readiness {
+			return nil
+		}
+	}
+	if p.LastError != "" {
+		return fmt.Errorf("%s: %s", p.State, p.LastError)
+	}
+	return fmt.Errorf("%s (last exit code %d)", p.State, p.LastExitCode)
+}
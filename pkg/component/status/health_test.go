@@ -0,0 +1,84 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package status
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k0sproject/k0s/pkg/component"
+)
+
+type fakeComponent struct {
+	healthy chan error
+}
+
+func (f *fakeComponent) Init(context.Context) error { return nil }
+func (f *fakeComponent) Run(context.Context) error  { return nil }
+func (f *fakeComponent) Stop() error                { return nil }
+func (f *fakeComponent) Healthy() error {
+	select {
+	case err := <-f.healthy:
+		return err
+	default:
+		return nil
+	}
+}
+
+func get(t *testing.T, handler http.Handler, path string) (int, string) {
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec.Code, rec.Body.String()
+}
+
+func TestHealthHandler(t *testing.T) {
+	ctx := context.Background()
+	comp := &fakeComponent{healthy: make(chan error, 1)}
+	m := component.NewManager()
+	m.HealthCheckInterval = 10 * time.Millisecond
+	m.Add(ctx, comp)
+	require.NoError(t, m.Init(ctx))
+	defer func() { assert.NoError(t, m.Stop()) }()
+
+	healthz, readyz := &healthHandler{}, &healthHandler{readiness: true}
+
+	code, body := get(t, healthz, "/healthz")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Contains(t, body, "[+]component/fakeComponent ok")
+
+	code, body = get(t, readyz, "/readyz")
+	assert.Equal(t, http.StatusServiceUnavailable, code)
+	assert.Contains(t, body, "[-]component/fakeComponent failed: initialized")
+	assert.Contains(t, body, "readyz check failed")
+
+	require.NoError(t, m.Start(ctx))
+	code, _ = get(t, readyz, "/readyz")
+	assert.Equal(t, http.StatusOK, code)
+
+	comp.healthy <- errors.New("broken")
+	assert.Eventually(t, func() bool {
+		code, body = get(t, healthz, "/healthz")
+		return code == http.StatusServiceUnavailable
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Contains(t, body, "[-]component/fakeComponent failed: unhealthy: broken")
+}
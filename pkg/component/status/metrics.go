@@ -0,0 +1,68 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package status
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/k0sproject/k0s/pkg/certificate"
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+var certificateExpiryDesc = prometheus.NewDesc(
+	"k0s_certificate_expiry_days",
+	"Days remaining until the certificate expires, negative if it has expired.",
+	[]string{"name", "ca", "managed"}, nil,
+)
+
+// newMetricsHandler serves the certificate expiry in the Prometheus exposition format
+func newMetricsHandler(s *Status) http.Handler {
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(&certificateCollector{K0sVars: s.StatusInformation.K0sVars, now: time.Now})
+	return promhttp.HandlerFor(registry, promhttp.HandlerOpts{})
+}
+
+// certificateCollector collects the expiry of the certificates on every scrape
+type certificateCollector struct {
+	K0sVars constant.CfgVars
+	now     func() time.Time
+}
+
+var _ prometheus.Collector = (*certificateCollector)(nil)
+
+func (c *certificateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- certificateExpiryDesc
+}
+
+func (c *certificateCollector) Collect(ch chan<- prometheus.Metric) {
+	expiries, err := certificate.ScanExpiry(c.K0sVars.CertRootDir, c.K0sVars.EtcdCertDir)
+	if err != nil {
+		ch <- prometheus.NewInvalidMetric(certificateExpiryDesc, err)
+		return
+	}
+
+	now := c.now()
+	for _, e := range expiries {
+		days := e.Remaining(now).Hours() / 24
+		ch <- prometheus.MustNewConstMetric(certificateExpiryDesc, prometheus.GaugeValue, days,
+			e.Name, strconv.FormatBool(e.IsCA), strconv.FormatBool(e.Managed))
+	}
+}
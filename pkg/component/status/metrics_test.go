@@ -0,0 +1,62 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package status
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k0sproject/k0s/pkg/certificate"
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+func TestCertificateCollector(t *testing.T) {
+	k0sVars := constant.GetConfig(t.TempDir())
+	require.NoError(t, os.MkdirAll(k0sVars.EtcdCertDir, 0755))
+	m := certificate.Manager{K0sVars: k0sVars}
+	require.NoError(t, m.EnsureCA("ca", "kubernetes-ca"))
+	_, err := m.EnsureCertificate(certificate.Request{
+		Name:   "etcd/server",
+		CN:     "etcd-server",
+		O:      "etcd-server",
+		CACert: filepath.Join(k0sVars.CertRootDir, "ca.crt"),
+		CAKey:  filepath.Join(k0sVars.CertRootDir, "ca.key"),
+	}, "root")
+	require.NoError(t, err)
+
+	expiries, err := certificate.ScanExpiry(k0sVars.CertRootDir)
+	require.NoError(t, err)
+	require.Len(t, expiries, 2)
+	ca, server := expiries[0], expiries[1]
+	// half a day after the expiry of the server certificate
+	now := server.NotAfter.Add(12 * time.Hour)
+
+	c := &certificateCollector{K0sVars: k0sVars, now: func() time.Time { return now }}
+	assert.NoError(t, testutil.CollectAndCompare(c, strings.NewReader(fmt.Sprintf(`
+# HELP k0s_certificate_expiry_days Days remaining until the certificate expires, negative if it has expired.
+# TYPE k0s_certificate_expiry_days gauge
+k0s_certificate_expiry_days{ca="true",managed="true",name="ca"} %v
+k0s_certificate_expiry_days{ca="false",managed="true",name="etcd/server"} -0.5
+`, ca.NotAfter.Sub(now).Hours()/24))))
+}
@@ -22,10 +22,14 @@ import (
 	"net"
 	"net/http"
 	"os"
+	"sync"
 
 	"github.com/k0sproject/k0s/internal/pkg/dir"
+	"github.com/k0sproject/k0s/pkg/applier"
+	"github.com/k0sproject/k0s/pkg/certificate"
 	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/install"
+	"github.com/k0sproject/k0s/pkg/supervisor"
 	"github.com/sirupsen/logrus"
 )
 
@@ -36,6 +40,18 @@ type Status struct {
 	httpserver        http.Server
 	listener          net.Listener
 	runCtx            context.Context
+
+	// Reload reloads the node config, the reload endpoint is disabled if nil
+	Reload func(context.Context, install.Reload) error
+	// RotateCertificates rotates the k0s managed certificates, the rotate endpoint is disabled if nil
+	RotateCertificates func(context.Context) error
+	// Stacks returns the statuses of the manifest stacks, none are reported if nil
+	Stacks func() []applier.StackStatus
+	// MigrateStorage migrates the storage of the controller, the storage migration endpoint is disabled if nil
+	MigrateStorage func(context.Context, install.StorageMigration) error
+
+	migrationMutex sync.Mutex
+	migration      *install.StorageMigrationStatus
 }
 
 var _ component.Component = (*Status)(nil)
@@ -48,8 +64,16 @@ func (s *Status) Init(_ context.Context) error {
 	s.L = logrus.WithFields(logrus.Fields{"component": "status"})
 
 	var err error
+	mux := http.NewServeMux()
+	mux.Handle("/", &statusHandler{Status: s})
+	mux.Handle("/healthz", &healthHandler{})
+	mux.Handle("/readyz", &healthHandler{readiness: true})
+	mux.Handle("/reload", &reloadHandler{Status: s})
+	mux.Handle("/metrics", newMetricsHandler(s))
+	mux.Handle("/certificates/rotate", &rotateHandler{Status: s})
+	mux.Handle("/storage/migrate", &storageMigrationHandler{Status: s})
 	s.httpserver = http.Server{
-		Handler: &statusHandler{Status: s},
+		Handler: mux,
 	}
 	err = dir.Init(s.StatusInformation.K0sVars.RunDir, 0755)
 	if err != nil {
@@ -101,7 +125,157 @@ type statusHandler struct {
 // ServerHTTP implementation of handler interface
 func (sh *statusHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	w.Header().Set("Content-Type", "application/json")
-	if json.NewEncoder(w).Encode(sh.Status.StatusInformation) != nil {
+	statusInformation := sh.Status.StatusInformation
+	statusInformation.Processes = supervisor.Statuses()
+	statusInformation.Components = component.Statuses()
+	k0sVars := statusInformation.K0sVars
+	if expiries, err := certificate.ScanExpiry(k0sVars.CertRootDir, k0sVars.EtcdCertDir); err == nil {
+		statusInformation.Certificates = expiries
+	}
+	if sh.Status.Stacks != nil {
+		statusInformation.Stacks = sh.Status.Stacks()
+	}
+	if json.NewEncoder(w).Encode(statusInformation) != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+}
+
+// runDetached runs f on the context of the component, so that it isn't interrupted if the client goes away.
+// The request context is only used to wait for the result.
+func (s *Status) runDetached(r *http.Request, action string, f func(context.Context) error) error {
+	result := make(chan error, 1)
+	go func() {
+		err := f(s.runCtx)
+		if r.Context().Err() != nil && err != nil {
+			s.L.WithError(err).Errorf("Failed to %s", action)
+		}
+		result <- err
+	}()
+
+	select {
+	case err := <-result:
+		return err
+	case <-r.Context().Done():
+		s.L.Infof("Client went away, continuing to %s in the background", action)
+		return r.Context().Err()
+	}
+}
+
+type reloadHandler struct {
+	Status *Status
+}
+
+// ServeHTTP reloads the node config on POST requests
+func (rh *reloadHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if rh.Status.Reload == nil {
+		http.Error(w, fmt.Sprintf("reloading the config is not supported by %s nodes, restart k0s to apply changes", rh.Status.StatusInformation.Role), http.StatusNotImplemented)
+		return
+	}
+	var reload install.Reload
+	if r.Header.Get("Content-Type") == "application/json" {
+		if err := json.NewDecoder(r.Body).Decode(&reload); err != nil {
+			http.Error(w, fmt.Sprintf("invalid reload request: %v", err), http.StatusBadRequest)
+			return
+		}
+	}
+	if err := rh.Status.runDetached(r, "reload the node config", func(ctx context.Context) error { return rh.Status.Reload(ctx, reload) }); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("reloaded\n"))
+}
+
+type rotateHandler struct {
+	Status *Status
+}
+
+// ServeHTTP rotates the certificates on POST requests
+func (rh *rotateHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if rh.Status.RotateCertificates == nil {
+		http.Error(w, fmt.Sprintf("rotating the certificates is not supported by %s nodes", rh.Status.StatusInformation.Role), http.StatusNotImplemented)
+		return
+	}
+	if err := rh.Status.runDetached(r, "rotate the certificates", rh.Status.RotateCertificates); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte("rotated\n"))
+}
+
+type storageMigrationHandler struct {
+	Status *Status
+}
+
+// ServeHTTP starts migrating the storage on POST requests and reports the status of the last migration on GET requests.
+// The migration runs on the context of the component, so that it isn't interrupted if the client goes away.
+func (sh *storageMigrationHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodGet {
+		sh.serveStatus(w)
+		return
+	}
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", fmt.Sprintf("%s, %s", http.MethodGet, http.MethodPost))
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if sh.Status.MigrateStorage == nil {
+		http.Error(w, fmt.Sprintf("migrating the storage is not supported by %s nodes", sh.Status.StatusInformation.Role), http.StatusNotImplemented)
+		return
+	}
+	var migration install.StorageMigration
+	if err := json.NewDecoder(r.Body).Decode(&migration); err != nil {
+		http.Error(w, fmt.Sprintf("invalid storage migration: %v", err), http.StatusBadRequest)
+		return
+	}
+
+	s := sh.Status
+	s.migrationMutex.Lock()
+	defer s.migrationMutex.Unlock()
+	if s.migration != nil && s.migration.State == install.StorageMigrationRunning {
+		http.Error(w, fmt.Sprintf("a migration to %s is already running", s.migration.To), http.StatusConflict)
+		return
+	}
+	status := &install.StorageMigrationStatus{StorageMigration: migration, State: install.StorageMigrationRunning}
+	s.migration = status
+	go func() {
+		err := s.MigrateStorage(s.runCtx, migration)
+		s.migrationMutex.Lock()
+		defer s.migrationMutex.Unlock()
+		if err != nil {
+			s.L.WithError(err).Error("Failed to migrate the storage")
+			status.State, status.Error = install.StorageMigrationFailed, err.Error()
+		} else {
+			status.State = install.StorageMigrationSucceeded
+		}
+	}()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(http.StatusAccepted)
+	_, _ = w.Write([]byte("migrating\n"))
+}
+
+func (sh *storageMigrationHandler) serveStatus(w http.ResponseWriter) {
+	s := sh.Status
+	s.migrationMutex.Lock()
+	defer s.migrationMutex.Unlock()
+	if s.migration == nil {
+		http.Error(w, "no storage migration has been started", http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	if json.NewEncoder(w).Encode(s.migration) != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 	}
 }
@@ -0,0 +1,116 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package status
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k0sproject/k0s/pkg/install"
+)
+
+func TestStorageMigrationHandler(t *testing.T) {
+	release := make(chan error)
+	s := &Status{
+		L:      logrus.WithField("component", "status"),
+		runCtx: context.Background(),
+		MigrateStorage: func(ctx context.Context, _ install.StorageMigration) error {
+			select {
+			case err := <-release:
+				return err
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		},
+	}
+	handler := &storageMigrationHandler{Status: s}
+
+	code, _ := get(t, handler, "/storage/migrate")
+	assert.Equal(t, http.StatusNotFound, code)
+
+	post := func() int {
+		// the migration must outlive the request
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodPost, "/storage/migrate", strings.NewReader(`{"to":"etcd"}`)).WithContext(ctx)
+		handler.ServeHTTP(rec, req)
+		return rec.Code
+	}
+	migrationStatus := func() install.StorageMigrationStatus {
+		code, body := get(t, handler, "/storage/migrate")
+		require.Equal(t, http.StatusOK, code)
+		var status install.StorageMigrationStatus
+		require.NoError(t, json.Unmarshal([]byte(body), &status))
+		return status
+	}
+
+	require.Equal(t, http.StatusAccepted, post())
+	assert.Equal(t, install.StorageMigrationRunning, migrationStatus().State)
+	assert.Equal(t, http.StatusConflict, post())
+
+	release <- errors.New("copy failed")
+	require.Eventually(t, func() bool { return migrationStatus().State == install.StorageMigrationFailed }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "copy failed", migrationStatus().Error)
+
+	require.Equal(t, http.StatusAccepted, post())
+	release <- nil
+	require.Eventually(t, func() bool { return migrationStatus().State == install.StorageMigrationSucceeded }, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, "etcd", migrationStatus().To)
+}
+
+func TestReloadHandlerOutlivesRequest(t *testing.T) {
+	started, release, done := make(chan struct{}), make(chan struct{}), make(chan error, 1)
+	s := &Status{
+		L:      logrus.WithField("component", "status"),
+		runCtx: context.Background(),
+		Reload: func(ctx context.Context, _ install.Reload) error {
+			close(started)
+			<-release
+			done <- ctx.Err()
+			return nil
+		},
+	}
+	handler := &reloadHandler{Status: s}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/reload", nil).WithContext(ctx)
+	served := make(chan struct{})
+	go func() {
+		defer close(served)
+		handler.ServeHTTP(rec, req)
+	}()
+
+	<-started
+	cancel()
+	<-served
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	// the reload keeps running on the context of the component
+	close(release)
+	assert.NoError(t, <-done)
+}
@@ -27,7 +27,6 @@ import (
 	dirutil "github.com/k0sproject/k0s/internal/pkg/dir"
 	fileutil "github.com/k0sproject/k0s/internal/pkg/file"
 	"github.com/k0sproject/k0s/pkg/assets"
-	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/constant"
 	"github.com/k0sproject/k0s/pkg/supervisor"
 )
@@ -42,14 +41,16 @@ const confPath = "/etc/k0s/containerd.toml"
 
 // ContainerD implement the component interface to manage containerd as k0s component
 type ContainerD struct {
-	supervisor supervisor.Supervisor
-	LogLevel   string
-	K0sVars    constant.CfgVars
+	supervisor    supervisor.Supervisor
+	LogLevel      string
+	K0sVars       constant.CfgVars
+	RestartPolicy *supervisor.RestartPolicy
+	LogConfig     *supervisor.LogConfig
 
 	OCIBundlePath string
 }
 
-var _ component.Component = (*ContainerD)(nil)
+var _ FlagsReconcilerComponent = (*ContainerD)(nil)
 
 // Init extracts the needed binaries
 func (c *ContainerD) Init(ctx context.Context) error {
@@ -72,6 +73,25 @@ func (c *ContainerD) Run(_ context.Context) error {
 		return err
 	}
 
+	return c.start()
+}
+
+// ReconcileFlags restarts containerD if its log level has been changed
+func (c *ContainerD) ReconcileFlags(_ context.Context, flags *Flags) error {
+	if flags.LogLevels["containerd"] == c.LogLevel {
+		logrus.WithField("component", "containerd").Info("worker reload has nothing to do")
+		return nil
+	}
+
+	logrus.WithField("component", "containerd").Info("restarting containerd to apply the reloaded log level")
+	if err := c.supervisor.Stop(); err != nil {
+		return err
+	}
+	c.LogLevel = flags.LogLevels["containerd"]
+	return c.start()
+}
+
+func (c *ContainerD) start() error {
 	c.supervisor = supervisor.Supervisor{
 		Name:    "containerd",
 		BinPath: assets.BinPath("containerd", c.K0sVars.BinDir),
@@ -84,6 +104,8 @@ func (c *ContainerD) Run(_ context.Context) error {
 			fmt.Sprintf("--log-level=%s", c.LogLevel),
 			fmt.Sprintf("--config=%s", confPath),
 		},
+		RestartPolicy: c.RestartPolicy,
+		LogConfig:     c.LogConfig,
 	}
 
 	return c.supervisor.Supervise()
@@ -106,5 +128,5 @@ func (c *ContainerD) Stop() error {
 	return c.supervisor.Stop()
 }
 
-// Health-check interface
-func (c *ContainerD) Healthy() error { return nil }
+// Healthy reports whether the containerd process is crash-looping
+func (c *ContainerD) Healthy() error { return c.supervisor.Healthy() }
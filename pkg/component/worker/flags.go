@@ -0,0 +1,43 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package worker
+
+import (
+	"context"
+
+	"github.com/k0sproject/k0s/pkg/component"
+)
+
+// Flags are the settings of the worker components which come from the worker flags and can be changed by a reload
+type Flags struct {
+	Profile          string
+	Labels           []string
+	Taints           []string
+	KubeletExtraArgs string
+	LogLevels        map[string]string
+}
+
+// FlagsReconcilerComponent defines the component interface that is reconciled
+// based on changes of the worker flags, i.e. when a worker is reloaded without
+// restarting k0s.
+type FlagsReconcilerComponent interface {
+	component.Component
+
+	// ReconcileFlags aligns the running component with the changed worker
+	// flags, restarting its processes only if they are affected by the
+	// change. ReconcileFlags may only be called after Run and before Stop.
+	ReconcileFlags(context.Context, *Flags) error
+}
@@ -24,7 +24,9 @@ import (
 	"os"
 	"path"
 	"path/filepath"
+	"reflect"
 	"runtime"
+	"sort"
 	"strings"
 	"time"
 
@@ -41,7 +43,6 @@ import (
 	"github.com/k0sproject/k0s/internal/pkg/flags"
 	"github.com/k0sproject/k0s/internal/pkg/stringmap"
 	"github.com/k0sproject/k0s/pkg/assets"
-	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/constant"
 	"github.com/k0sproject/k0s/pkg/supervisor"
 )
@@ -53,6 +54,8 @@ type Kubelet struct {
 	K0sVars             constant.CfgVars
 	KubeletConfigClient *KubeletConfigClient
 	LogLevel            string
+	RestartPolicy       *supervisor.RestartPolicy
+	LogConfig           *supervisor.LogConfig
 	Profile             string
 	dataDir             string
 	supervisor          supervisor.Supervisor
@@ -62,7 +65,7 @@ type Kubelet struct {
 	ExtraArgs           string
 }
 
-var _ component.Component = (*Kubelet)(nil)
+var _ FlagsReconcilerComponent = (*Kubelet)(nil)
 
 type kubeletConfig struct {
 	ClientCAFile       string
@@ -111,34 +114,103 @@ func (k *Kubelet) Init(_ context.Context) error {
 	return nil
 }
 
+// KubeletCertDir returns the directory the kubelet keeps its certificates in
+func KubeletCertDir(k0sVars constant.CfgVars) string {
+	if runtime.GOOS == "windows" {
+		return "C:\\var\\lib\\k0s\\kubelet_certs"
+	}
+	return filepath.Join(k0sVars.DataDir, "kubelet", "pki")
+}
+
 // Run runs kubelet
 func (k *Kubelet) Run(ctx context.Context) error {
+	logrus.Info("Starting kubelet")
+	args, kubeletconfig, err := k.prepare(ctx)
+	if err != nil {
+		return err
+	}
+	if err := k.writeConfig(kubeletconfig); err != nil {
+		return err
+	}
+	return k.start(args)
+}
+
+// ReconcileFlags restarts kubelet if its arguments or its config, based on the worker profile, are affected by the
+// changed flags. The config of the worker profile is fetched again, so that a reload also picks up its changes.
+func (k *Kubelet) ReconcileFlags(ctx context.Context, flags *Flags) error {
+	profile, labels, taints, extraArgs, logLevel := k.Profile, k.Labels, k.Taints, k.ExtraArgs, k.LogLevel
+	k.Profile, k.Labels, k.Taints, k.ExtraArgs, k.LogLevel = flags.Profile, flags.Labels, flags.Taints, flags.KubeletExtraArgs, flags.LogLevels["kubelet"]
+	args, kubeletconfig, err := k.prepare(ctx)
+	if err != nil {
+		k.Profile, k.Labels, k.Taints, k.ExtraArgs, k.LogLevel = profile, labels, taints, extraArgs, logLevel
+		return err
+	}
+	if current, err := os.ReadFile(k.configPath()); err == nil && string(current) == kubeletconfig && reflect.DeepEqual(args, k.supervisor.Args) {
+		logrus.WithField("component", "kubelet").Info("worker reload has nothing to do")
+		return nil
+	}
+
+	logrus.WithField("component", "kubelet").Info("restarting kubelet to apply the reloaded worker flags")
+	if err := k.supervisor.Stop(); err != nil {
+		return err
+	}
+	if err := k.writeConfig(kubeletconfig); err != nil {
+		return err
+	}
+	return k.start(args)
+}
+
+func (k *Kubelet) configPath() string {
+	return filepath.Join(k.K0sVars.DataDir, "kubelet-config.yaml")
+}
+
+func (k *Kubelet) writeConfig(kubeletconfig string) error {
+	if err := ioutil.WriteFile(k.configPath(), []byte(kubeletconfig), 0644); err != nil {
+		return fmt.Errorf("failed to write kubelet config: %w", err)
+	}
+	return nil
+}
+
+func (k *Kubelet) start(args []string) error {
 	cmd := "kubelet"
+	if runtime.GOOS == "windows" {
+		cmd = "kubelet.exe"
+	}
 
+	logrus.Debugf("starting kubelet with args: %v", args)
+	k.supervisor = supervisor.Supervisor{
+		Name:          cmd,
+		BinPath:       assets.BinPath(cmd, k.K0sVars.BinDir),
+		RunDir:        k.K0sVars.RunDir,
+		DataDir:       k.K0sVars.DataDir,
+		Args:          args,
+		RestartPolicy: k.RestartPolicy,
+		LogConfig:     k.LogConfig,
+	}
+	return k.supervisor.Supervise()
+}
+
+// prepare returns the sorted kubelet arguments and the local kubelet config, based on the config of the worker profile
+func (k *Kubelet) prepare(ctx context.Context) ([]string, string, error) {
 	kubeletConfigData := kubeletConfig{
 		ClientCAFile:       filepath.Join(k.K0sVars.CertRootDir, "ca.crt"),
 		VolumePluginDir:    k.K0sVars.KubeletVolumePluginDir,
 		KubeReservedCgroup: "system.slice",
 		KubeletCgroups:     "/system.slice/containerd.service",
 	}
-	if runtime.GOOS == "windows" {
-		cmd = "kubelet.exe"
-	}
 
-	logrus.Info("Starting kubelet")
-	kubeletConfigPath := filepath.Join(k.K0sVars.DataDir, "kubelet-config.yaml")
 	// get the "real" resolv.conf file (in systemd-resolvd bases system,
 	// this will return /run/systemd/resolve/resolv.conf
 	resolvConfPath := resolvconf.Path()
 
 	args := stringmap.StringMap{
 		"--root-dir":             k.dataDir,
-		"--config":               kubeletConfigPath,
+		"--config":               k.configPath(),
 		"--bootstrap-kubeconfig": k.K0sVars.KubeletBootstrapConfigPath,
 		"--kubeconfig":           k.K0sVars.KubeletAuthConfigPath,
 		"--v":                    k.LogLevel,
 		"--runtime-cgroups":      "/system.slice/containerd.service",
-		"--cert-dir":             filepath.Join(k.dataDir, "pki"),
+		"--cert-dir":             KubeletCertDir(k.K0sVars),
 	}
 
 	if len(k.Labels) > 0 {
@@ -148,7 +220,7 @@ func (k *Kubelet) Run(ctx context.Context) error {
 	if runtime.GOOS == "windows" {
 		node, err := getNodeName(ctx)
 		if err != nil {
-			return fmt.Errorf("can't get hostname: %v", err)
+			return nil, "", fmt.Errorf("can't get hostname: %v", err)
 		}
 		kubeletConfigData.CgroupsPerQOS = false
 		kubeletConfigData.ResolvConf = ""
@@ -159,7 +231,6 @@ func (k *Kubelet) Run(ctx context.Context) error {
 		args["--cni-conf-dir"] = "C:\\k\\cni\\config"
 		args["--hostname-override"] = node
 		args["--hairpin-mode"] = "promiscuous-bridge"
-		args["--cert-dir"] = "C:\\var\\lib\\k0s\\kubelet_certs"
 	} else {
 		kubeletConfigData.CgroupsPerQOS = true
 		kubeletConfigData.ResolvConf = resolvConfPath
@@ -170,7 +241,7 @@ func (k *Kubelet) Run(ctx context.Context) error {
 		// handle any special docker case
 		_, rtSock, err := SplitRuntimeConfig(k.CRISocket)
 		if err != nil {
-			return err
+			return nil, "", err
 		}
 		args["--container-runtime-endpoint"] = rtSock
 
@@ -190,41 +261,30 @@ func (k *Kubelet) Run(ctx context.Context) error {
 		args.Merge(extras)
 	}
 
-	logrus.Debugf("starting kubelet with args: %v", args)
-	k.supervisor = supervisor.Supervisor{
-		Name:    cmd,
-		BinPath: assets.BinPath(cmd, k.K0sVars.BinDir),
-		RunDir:  k.K0sVars.RunDir,
-		DataDir: k.K0sVars.DataDir,
-		Args:    args.ToArgs(),
-	}
-
+	var kubeletconfig string
 	err := retry.Do(func() error {
-		kubeletconfig, err := k.KubeletConfigClient.Get(ctx, k.Profile)
+		profileConfig, err := k.KubeletConfigClient.Get(ctx, k.Profile)
 		if err != nil {
 			logrus.Warnf("failed to get initial kubelet config with join token: %s", err.Error())
 			return err
 		}
-		kubeletconfig, err = k.prepareLocalKubeletConfig(kubeletconfig, kubeletConfigData)
+		kubeletconfig, err = k.prepareLocalKubeletConfig(profileConfig, kubeletConfigData)
 		if err != nil {
 			logrus.Warnf("failed to prepare local kubelet config: %s", err.Error())
 			return err
 		}
-		err = ioutil.WriteFile(kubeletConfigPath, []byte(kubeletconfig), 0644)
-		if err != nil {
-			return fmt.Errorf("failed to write kubelet config: %w", err)
-		}
-
 		return nil
 	},
 		retry.Context(ctx),
 		retry.Delay(time.Millisecond*500),
 		retry.DelayType(retry.BackOffDelay))
 	if err != nil {
-		return err
+		return nil, "", err
 	}
 
-	return k.supervisor.Supervise()
+	sorted := args.ToArgs()
+	sort.Strings(sorted)
+	return sorted, kubeletconfig, nil
 }
 
 // Stop stops kubelet
@@ -232,8 +292,8 @@ func (k *Kubelet) Stop() error {
 	return k.supervisor.Stop()
 }
 
-// Health-check interface
-func (k *Kubelet) Healthy() error { return nil }
+// Healthy reports whether the kubelet process is crash-looping
+func (k *Kubelet) Healthy() error { return k.supervisor.Healthy() }
 
 func (k *Kubelet) prepareLocalKubeletConfig(kubeletconfig string, kubeletConfigData kubeletConfig) (string, error) {
 	var kubeletConfiguration kubeletv1beta1.KubeletConfiguration
@@ -27,20 +27,22 @@ import (
 )
 
 type KubeProxy struct {
-	K0sVars    constant.CfgVars
-	CIDRRange  string
-	LogLevel   string
-	supervisor supervisor.Supervisor
+	K0sVars       constant.CfgVars
+	CIDRRange     string
+	LogLevel      string
+	RestartPolicy *supervisor.RestartPolicy
+	LogConfig     *supervisor.LogConfig
+	supervisor    supervisor.Supervisor
 }
 
 var _ component.Component = (*KubeProxy)(nil)
 
 // Init
-func (k KubeProxy) Init(_ context.Context) error {
+func (k *KubeProxy) Init(_ context.Context) error {
 	return assets.Stage(k.K0sVars.BinDir, "kube-proxy.exe", constant.BinDirMode)
 }
 
-func (k KubeProxy) Run(ctx context.Context) error {
+func (k *KubeProxy) Run(ctx context.Context) error {
 	node, err := getNodeName(ctx)
 	if err != nil {
 		return fmt.Errorf("can't get hostname: %v", err)
@@ -63,20 +65,22 @@ func (k KubeProxy) Run(ctx context.Context) error {
 		"--feature-gates=WinOverlay=true",
 	}
 	k.supervisor = supervisor.Supervisor{
-		Name:    cmd,
-		BinPath: assets.BinPath(cmd, k.K0sVars.BinDir),
-		RunDir:  k.K0sVars.RunDir,
-		DataDir: k.K0sVars.DataDir,
-		Args:    args,
+		Name:          cmd,
+		BinPath:       assets.BinPath(cmd, k.K0sVars.BinDir),
+		RunDir:        k.K0sVars.RunDir,
+		DataDir:       k.K0sVars.DataDir,
+		Args:          args,
+		RestartPolicy: k.RestartPolicy,
+		LogConfig:     k.LogConfig,
 	}
 	k.supervisor.Supervise()
 	return nil
 }
 
-func (k KubeProxy) Stop() error {
+func (k *KubeProxy) Stop() error {
 	return k.supervisor.Stop()
 }
 
-func (k KubeProxy) Healthy() error {
+func (k *KubeProxy) Healthy() error {
 	return nil
 }
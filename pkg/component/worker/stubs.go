@@ -23,6 +23,7 @@ import (
 
 	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/supervisor"
 )
 
 type CalicoInstaller struct {
@@ -51,25 +52,27 @@ func (c CalicoInstaller) Healthy() error {
 }
 
 type KubeProxy struct {
-	K0sVars   constant.CfgVars
-	CIDRRange string
-	LogLevel  string
+	K0sVars       constant.CfgVars
+	CIDRRange     string
+	LogLevel      string
+	RestartPolicy *supervisor.RestartPolicy
+	LogConfig     *supervisor.LogConfig
 }
 
 var _ component.Component = (*KubeProxy)(nil)
 
-func (k KubeProxy) Init(_ context.Context) error {
+func (k *KubeProxy) Init(_ context.Context) error {
 	panic("stub component is used: KubeProxy")
 }
 
-func (k KubeProxy) Run(_ context.Context) error {
+func (k *KubeProxy) Run(_ context.Context) error {
 	panic("stub component is used: KubeProxy")
 }
 
-func (k KubeProxy) Stop() error {
+func (k *KubeProxy) Stop() error {
 	panic("stub component is used: KubeProxy")
 }
 
-func (k KubeProxy) Healthy() error {
+func (k *KubeProxy) Healthy() error {
 	panic("stub component is used: KubeProxy")
 }
@@ -16,14 +16,26 @@ limitations under the License.
 package worker
 
 import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"os"
 	"path"
+	"path/filepath"
+	"strings"
 
 	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+	"k8s.io/client-go/util/certificate"
 
 	"github.com/k0sproject/k0s/internal/pkg/dir"
 	"github.com/k0sproject/k0s/internal/pkg/file"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 	"github.com/k0sproject/k0s/pkg/constant"
 	"github.com/k0sproject/k0s/pkg/token"
 )
@@ -44,15 +56,8 @@ func HandleKubeletBootstrapToken(encodedToken string, k0sVars constant.CfgVars)
 		return fmt.Errorf("wrong token type %s, expected type: kubelet-bootstrap", tokenType)
 	}
 
-	kubeletCAPath := path.Join(k0sVars.CertRootDir, "ca.crt")
-	if !file.Exists(kubeletCAPath) {
-		if err := dir.Init(k0sVars.CertRootDir, constant.CertRootDirMode); err != nil {
-			return fmt.Errorf("failed to initialize directory '%s': %w", k0sVars.CertRootDir, err)
-		}
-		err = os.WriteFile(kubeletCAPath, clientCfg.Clusters["k0s"].CertificateAuthorityData, constant.CertMode)
-		if err != nil {
-			return fmt.Errorf("failed to write ca client cert: %w", err)
-		}
+	if err := writeKubeletCA(clientCfg, k0sVars); err != nil {
+		return err
 	}
 	err = os.WriteFile(k0sVars.KubeletBootstrapConfigPath, kubeconfig, constant.CertSecureMode)
 	if err != nil {
@@ -62,6 +67,142 @@ func HandleKubeletBootstrapToken(encodedToken string, k0sVars constant.CfgVars)
 	return nil
 }
 
+// writeKubeletCA writes the cluster CA of the join token, unless the node already has one. The kubelet trusts it to
+// authenticate clients, so only the CA certificate is written, without the rest of its chain the token may carry.
+func writeKubeletCA(clientCfg *clientcmdapi.Config, k0sVars constant.CfgVars) error {
+	kubeletCAPath := path.Join(k0sVars.CertRootDir, "ca.crt")
+	if file.Exists(kubeletCAPath) {
+		return nil
+	}
+	block, _ := pem.Decode(clientCfg.Clusters["k0s"].CertificateAuthorityData)
+	if block == nil {
+		return fmt.Errorf("no PEM encoded CA certificate found in the token")
+	}
+	if err := dir.Init(k0sVars.CertRootDir, constant.CertRootDirMode); err != nil {
+		return fmt.Errorf("failed to initialize directory '%s': %w", k0sVars.CertRootDir, err)
+	}
+	if err := os.WriteFile(kubeletCAPath, pem.EncodeToMemory(block), constant.CertMode); err != nil {
+		return fmt.Errorf("failed to write ca client cert: %w", err)
+	}
+	return nil
+}
+
+// IsScopedToken checks if the given token is a scoped worker token that has to be exchanged at the k0s API
+func IsScopedToken(encodedToken string) (bool, error) {
+	kubeconfig, err := token.DecodeJoinToken(encodedToken)
+	if err != nil {
+		return false, fmt.Errorf("failed to decode token: %w", err)
+	}
+	clientCfg, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return false, fmt.Errorf("failed to parse join token: %w", err)
+	}
+	return token.GetTokenType(clientCfg) == token.WorkerJoinTokenType, nil
+}
+
+// JoinWithScopedToken exchanges a scoped worker token at the k0s API for a kubelet client certificate of this node,
+// and writes the kubelet kubeconfig using it. The kubelet rotates the certificate on its own afterwards.
+// The worker settings carried by the token are stored for later starts, see LoadJoinSettings.
+func JoinWithScopedToken(encodedToken string, k0sVars constant.CfgVars) error {
+	kubeconfig, err := token.DecodeJoinToken(encodedToken)
+	if err != nil {
+		return fmt.Errorf("failed to decode token: %w", err)
+	}
+	clientCfg, err := clientcmd.Load(kubeconfig)
+	if err != nil {
+		return fmt.Errorf("failed to parse join token: %w", err)
+	}
+	joinClient, err := token.JoinClientFromToken(encodedToken)
+	if err != nil {
+		return fmt.Errorf("failed to create join client: %w", err)
+	}
+	hostname, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	// the kubelet lower cases the host name too
+	nodeName := strings.ToLower(hostname)
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate the kubelet client key: %w", err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	csrDER, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject: pkix.Name{CommonName: "system:node:" + nodeName, Organization: []string{"system:nodes"}},
+	}, key)
+	if err != nil {
+		return fmt.Errorf("failed to create the kubelet client certificate request: %w", err)
+	}
+	joinResp, err := joinClient.JoinWorker(nodeName, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: csrDER}))
+	if err != nil {
+		return err
+	}
+
+	settings, err := json.Marshal(&v1beta1.WorkerJoinResponse{Profile: joinResp.Profile, Labels: joinResp.Labels, Taints: joinResp.Taints})
+	if err != nil {
+		return err
+	}
+	if err := dir.Init(k0sVars.DataDir, constant.DataDirMode); err != nil {
+		return fmt.Errorf("failed to initialize directory '%s': %w", k0sVars.DataDir, err)
+	}
+	if err := os.WriteFile(joinSettingsPath(k0sVars), settings, constant.CertSecureMode); err != nil {
+		return fmt.Errorf("failed to write join settings: %w", err)
+	}
+	if err := writeKubeletCA(clientCfg, k0sVars); err != nil {
+		return err
+	}
+
+	// store the certificate the way the kubelet does, so that it keeps rotating it
+	certDir := KubeletCertDir(k0sVars)
+	store, err := certificate.NewFileStore("kubelet-client", certDir, certDir, "", "")
+	if err != nil {
+		return fmt.Errorf("failed to open the kubelet certificate store: %w", err)
+	}
+	if _, err := store.Update(joinResp.Certificate, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})); err != nil {
+		return fmt.Errorf("failed to store the kubelet client certificate: %w", err)
+	}
+
+	kubeletConfig := clientcmdapi.NewConfig()
+	kubeletConfig.Clusters["k0s"] = &clientcmdapi.Cluster{
+		Server:                   joinResp.APIServer,
+		CertificateAuthorityData: clientCfg.Clusters["k0s"].CertificateAuthorityData,
+	}
+	kubeletConfig.AuthInfos["kubelet"] = &clientcmdapi.AuthInfo{
+		ClientCertificate: store.CurrentPath(),
+		ClientKey:         store.CurrentPath(),
+	}
+	kubeletConfig.Contexts["k0s"] = &clientcmdapi.Context{Cluster: "k0s", AuthInfo: "kubelet"}
+	kubeletConfig.CurrentContext = "k0s"
+	if err := clientcmd.WriteToFile(*kubeletConfig, k0sVars.KubeletAuthConfigPath); err != nil {
+		return fmt.Errorf("failed writing kubelet auth config: %w", err)
+	}
+	return nil
+}
+
+// LoadJoinSettings returns the worker settings stored when joining with a scoped token, nil if the worker joined otherwise
+func LoadJoinSettings(k0sVars constant.CfgVars) (*v1beta1.WorkerJoinResponse, error) {
+	data, err := os.ReadFile(joinSettingsPath(k0sVars))
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var settings v1beta1.WorkerJoinResponse
+	if err := json.Unmarshal(data, &settings); err != nil {
+		return nil, fmt.Errorf("failed to parse join settings: %w", err)
+	}
+	return &settings, nil
+}
+
+func joinSettingsPath(k0sVars constant.CfgVars) string {
+	return filepath.Join(k0sVars.DataDir, "worker-join.json")
+}
+
 func LoadKubeletConfigClient(k0svars constant.CfgVars) (*KubeletConfigClient, error) {
 	var kubeletConfigClient *KubeletConfigClient
 	// Prefer to load client config from kubelet auth, fallback to bootstrap token auth
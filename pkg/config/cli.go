@@ -32,6 +32,7 @@ import (
 	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/supervisor"
 )
 
 var (
@@ -81,18 +82,22 @@ type ControllerOptions struct {
 
 // Shared worker cli flags
 type WorkerOptions struct {
-	APIServer        string
-	CIDRRange        string
-	CloudProvider    bool
-	ClusterDNS       string
-	CmdLogLevels     map[string]string
-	CriSocket        string
-	KubeletExtraArgs string
-	Labels           []string
-	Taints           []string
-	TokenFile        string
-	TokenArg         string
-	WorkerProfile    string
+	APIServer           string
+	CIDRRange           string
+	CloudProvider       bool
+	ClusterDNS          string
+	CmdLogLevels        map[string]string
+	ContainerdResources map[string]string
+	CriSocket           string
+	KubeletExtraArgs    string
+	KubeletResources    map[string]string
+	Labels              []string
+	Taints              []string
+	TokenFile           string
+	TokenArg            string
+	WorkerProfile       string
+	RestartPolicy       supervisor.RestartPolicy
+	ComponentLogs       supervisor.LogConfig
 }
 
 func DefaultLogLevels() map[string]string {
@@ -138,6 +143,24 @@ func GetCriSocketFlag() *pflag.FlagSet {
 	return flagset
 }
 
+// GetSupervisorFlags returns the flags configuring how the supervised processes are respawned and where their output goes
+func GetSupervisorFlags() *pflag.FlagSet {
+	flagset := &pflag.FlagSet{}
+	policy, defaultPolicy := &workerOpts.RestartPolicy, supervisor.DefaultRestartPolicy
+	flagset.IntVar(&policy.MaxRestarts, "supervisor-max-restarts", defaultPolicy.MaxRestarts, "number of consecutive restarts after which a crashing component process isn't respawned anymore, 0 means unlimited")
+	flagset.DurationVar(&policy.MaxBackoff, "supervisor-max-backoff", defaultPolicy.MaxBackoff, "maximum delay between the respawns of a crashing component process")
+	flagset.IntVar(&policy.CrashLoopThreshold, "supervisor-crash-loop-threshold", defaultPolicy.CrashLoopThreshold, "number of consecutive restarts after which a component process is reported as crash-looping")
+
+	logConfig, defaultLogConfig := &workerOpts.ComponentLogs, supervisor.DefaultLogConfig
+	flagset.StringVar(&logConfig.Dir, "component-log-dir", defaultLogConfig.Dir, "directory to write a rotating log file per component process to, e.g. /var/log/k0s (default: disabled)")
+	flagset.IntVar(&logConfig.MaxSize, "component-log-max-size", defaultLogConfig.MaxSize, "size in megabytes after which a component log file is rotated")
+	flagset.IntVar(&logConfig.MaxAge, "component-log-max-age", defaultLogConfig.MaxAge, "number of days to keep rotated component log files for, 0 means forever")
+	flagset.IntVar(&logConfig.MaxBackups, "component-log-max-backups", defaultLogConfig.MaxBackups, "number of rotated component log files to keep, 0 means all of them")
+	flagset.BoolVar(&logConfig.Compress, "component-log-compress", defaultLogConfig.Compress, "gzip rotated component log files")
+	flagset.BoolVar(&logConfig.Forward, "component-log-forward", defaultLogConfig.Forward, "also forward the component output to the k0s log when component log files are enabled")
+	return flagset
+}
+
 func GetWorkerFlags() *pflag.FlagSet {
 	flagset := &pflag.FlagSet{}
 
@@ -151,7 +174,10 @@ func GetWorkerFlags() *pflag.FlagSet {
 	flagset.StringSliceVarP(&workerOpts.Labels, "labels", "", []string{}, "Node labels, list of key=value pairs")
 	flagset.StringSliceVarP(&workerOpts.Taints, "taints", "", []string{}, "Node taints, list of key=value:effect strings")
 	flagset.StringVar(&workerOpts.KubeletExtraArgs, "kubelet-extra-args", "", "extra args for kubelet")
+	flagset.StringToStringVar(&workerOpts.KubeletResources, "kubelet-resources", nil, "cgroup limits for kubelet, e.g. memoryMax=1Gi,cpuWeight=200,pidsMax=4096,oomScoreAdj=-999")
+	flagset.StringToStringVar(&workerOpts.ContainerdResources, "containerd-resources", nil, "cgroup limits for containerd, e.g. memoryMax=1Gi,cpuWeight=200,pidsMax=4096,oomScoreAdj=-999")
 	flagset.AddFlagSet(GetCriSocketFlag())
+	flagset.AddFlagSet(GetSupervisorFlags())
 
 	return flagset
 }
@@ -191,6 +217,7 @@ func GetControllerFlags() *pflag.FlagSet {
 	flagset.BoolVar(&controllerOpts.EnableDynamicConfig, "enable-dynamic-config", false, "enable cluster-wide dynamic config based on custom resource")
 	flagset.BoolVar(&controllerOpts.EnableMetricsScraper, "enable-metrics-scraper", false, "enable scraping metrics from the controller components (kube-scheduler, kube-controller-manager)")
 	flagset.AddFlagSet(FileInputFlag())
+	flagset.AddFlagSet(GetSupervisorFlags())
 	return flagset
 }
 
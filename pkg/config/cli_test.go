@@ -0,0 +1,40 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/k0sproject/k0s/pkg/supervisor"
+)
+
+func TestGetSupervisorFlags(t *testing.T) {
+	flags := GetSupervisorFlags()
+	assert.Equal(t, supervisor.DefaultRestartPolicy, workerOpts.RestartPolicy)
+	assert.Equal(t, supervisor.DefaultLogConfig, workerOpts.ComponentLogs)
+
+	require.NoError(t, flags.Parse([]string{"--supervisor-max-backoff=1m", "--component-log-dir=/var/log/k0s"}))
+	assert.Equal(t, time.Minute, workerOpts.RestartPolicy.MaxBackoff)
+	assert.Equal(t, "/var/log/k0s", workerOpts.ComponentLogs.Dir)
+
+	// the defaults used by the supervisors without explicit settings stay untouched
+	assert.Equal(t, 5*time.Minute, supervisor.DefaultRestartPolicy.MaxBackoff)
+	assert.Empty(t, supervisor.DefaultLogConfig.Dir)
+}
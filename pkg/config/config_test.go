@@ -97,6 +97,78 @@ func TestGetConfigFromFile(t *testing.T) {
 	}
 }
 
+// Test reloading the runtime config after the config file has been changed
+func TestReloadRuntimeConfig(t *testing.T) {
+	CfgFile = writeConfigFile(t, fileYaml)
+
+	loadingRules := ClientConfigLoadingRules{
+		RuntimeConfigPath: nonExistentPath(t),
+		K0sVars:           constant.GetConfig(""),
+	}
+	require.NoError(t, loadingRules.InitRuntimeConfig(constant.GetConfig("")))
+
+	require.NoError(t, os.WriteFile(CfgFile, []byte(strings.Replace(fileYaml, "file_external_address", "reloaded_external_address", 1)+`
+  componentResources:
+    kube-apiserver:
+      pidsMax: 4096
+`), 0644))
+
+	reloaded, err := loadingRules.LoadConfigFile()
+	require.NoError(t, err)
+	require.Equal(t, "reloaded_external_address", reloaded.Spec.API.ExternalAddress)
+	require.Equal(t, int64(4096), reloaded.Spec.ComponentResources["kube-apiserver"].PidsMax)
+
+	// the runtime config is only replaced once the reloaded config has been applied
+	cfg, err := loadingRules.Load()
+	require.NoError(t, err)
+	require.Equal(t, "file_external_address", cfg.Spec.API.ExternalAddress)
+
+	require.NoError(t, loadingRules.WriteRuntimeConfig(reloaded))
+	cfg, err = loadingRules.Load()
+	require.NoError(t, err)
+	require.Equal(t, "reloaded_external_address", cfg.Spec.API.ExternalAddress)
+
+	require.NoError(t, os.WriteFile(CfgFile, []byte(fileYaml+`
+  componentResources:
+    kube-apiserver:
+      pidsMax: -1
+`), 0644))
+	_, err = loadingRules.LoadConfigFile()
+	require.Error(t, err)
+
+	CfgFile = "-"
+	_, err = loadingRules.LoadConfigFile()
+	require.Error(t, err)
+}
+
+func TestRecordedStorage(t *testing.T) {
+	CfgFile = writeConfigFile(t, fileYaml)
+
+	k0sVars := constant.GetConfig(t.TempDir())
+	k0sVars.DefaultStorageType = "kine"
+	loadingRules := ClientConfigLoadingRules{
+		RuntimeConfigPath: nonExistentPath(t),
+		K0sVars:           k0sVars,
+	}
+	require.NoError(t, loadingRules.InitRuntimeConfig(k0sVars))
+	cfg, err := loadingRules.Load()
+	require.NoError(t, err)
+	require.Equal(t, v1beta1.KineStorageType, cfg.Spec.Storage.Type)
+
+	cfg.Spec.Storage = v1beta1.DefaultStorageSpec()
+	require.NoError(t, loadingRules.RecordStorage(cfg))
+
+	cfg, err = loadingRules.Load()
+	require.NoError(t, err)
+	require.Equal(t, v1beta1.EtcdStorageType, cfg.Spec.Storage.Type)
+
+	// the recorded storage takes precedence over the default one, and over the config file
+	reloaded, err := loadingRules.LoadConfigFile()
+	require.NoError(t, err)
+	require.Equal(t, v1beta1.EtcdStorageType, reloaded.Spec.Storage.Type)
+	require.Equal(t, "file_external_address", reloaded.Spec.API.ExternalAddress)
+}
+
 func TestExternalEtcdConfig(t *testing.T) {
 	yamlData := `
 spec:
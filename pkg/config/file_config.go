@@ -61,13 +61,7 @@ func (rules *ClientConfigLoadingRules) readRuntimeConfig() (clusterConfig *v1bet
 func (rules *ClientConfigLoadingRules) ParseRuntimeConfig() (*v1beta1.ClusterConfig, error) {
 	var cfg *v1beta1.ClusterConfig
 
-	var storage *v1beta1.StorageSpec
-	if rules.K0sVars.DefaultStorageType == "kine" {
-		storage = &v1beta1.StorageSpec{
-			Type: v1beta1.KineStorageType,
-			Kine: v1beta1.DefaultKineConfig(rules.K0sVars.DataDir),
-		}
-	}
+	storage := rules.defaultStorageSpec()
 	if rules.RuntimeConfigPath == "" {
 		rules.RuntimeConfigPath = runtimeConfigPathDefault
 	}
@@ -90,17 +84,57 @@ func (rules *ClientConfigLoadingRules) ParseRuntimeConfig() (*v1beta1.ClusterCon
 		return cfg, nil
 	}
 
+	return rules.parseConfigFile(storage)
+}
+
+// LoadConfigFile re-reads and validates the `--config` file. The runtime config is left untouched, so that it keeps
+// matching the running components until the reloaded config has been applied and WriteRuntimeConfig is called.
+func (rules *ClientConfigLoadingRules) LoadConfigFile() (*v1beta1.ClusterConfig, error) {
+	if CfgFile == "-" {
+		return nil, fmt.Errorf("the config has been read from stdin and can't be reloaded")
+	}
+	return rules.parseConfigFile(rules.defaultStorageSpec())
+}
+
+// WriteRuntimeConfig replaces the runtime config with the given config
+func (rules *ClientConfigLoadingRules) WriteRuntimeConfig(cfg *v1beta1.ClusterConfig) error {
+	if rules.RuntimeConfigPath == "" {
+		rules.RuntimeConfigPath = runtimeConfigPathDefault
+	}
+
+	yamlData, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return rules.writeConfig(yamlData, cfg.Spec.Storage)
+}
+
+// defaultStorageSpec returns the storage used if the config doesn't define one, nil meaning etcd
+func (rules *ClientConfigLoadingRules) defaultStorageSpec() *v1beta1.StorageSpec {
+	if rules.K0sVars.DefaultStorageType == "kine" {
+		return &v1beta1.StorageSpec{
+			Type: v1beta1.KineStorageType,
+			Kine: v1beta1.DefaultKineConfig(rules.K0sVars.DataDir),
+		}
+	}
+	return nil
+}
+
+// parseConfigFile parses the `--config` file, or generates the default config if there is none
+func (rules *ClientConfigLoadingRules) parseConfigFile(storage *v1beta1.StorageSpec) (*v1beta1.ClusterConfig, error) {
+	var cfg *v1beta1.ClusterConfig
+
 	switch CfgFile {
 	// stdin input
 	case "-":
-		return v1beta1.ConfigFromReader(os.Stdin, storage)
+		return rules.withRecordedStorage(v1beta1.ConfigFromReader(os.Stdin, storage))
 	case "":
 		// if no config is set, look for config in the default location
 		// if it does not exist there either, generate default config
 		f, err := os.Open(constant.K0sConfigPathDefault)
 		if err != nil {
 			if os.IsNotExist(err) {
-				return rules.generateDefaults(storage), nil
+				return rules.withRecordedStorage(rules.generateDefaults(storage), nil)
 			}
 		}
 		defer f.Close()
@@ -121,6 +155,10 @@ func (rules *ClientConfigLoadingRules) ParseRuntimeConfig() (*v1beta1.ClusterCon
 			return nil, err
 		}
 	}
+	cfg, err := rules.withRecordedStorage(cfg, nil)
+	if err != nil {
+		return nil, err
+	}
 	if cfg.Spec.Storage.Type == v1beta1.KineStorageType && cfg.Spec.Storage.Kine == nil {
 		logrus.Warn("storage type is kine but no config given, setting up defaults")
 		cfg.Spec.Storage.Kine = v1beta1.DefaultKineConfig(rules.K0sVars.DataDir)
@@ -141,6 +179,48 @@ func (rules *ClientConfigLoadingRules) ParseRuntimeConfig() (*v1beta1.ClusterCon
 	return cfg, nil
 }
 
+// withRecordedStorage replaces the storage spec of the config with the one recorded by a storage migration, if any
+func (rules *ClientConfigLoadingRules) withRecordedStorage(cfg *v1beta1.ClusterConfig, err error) (*v1beta1.ClusterConfig, error) {
+	if err != nil || rules.K0sVars.StorageConfigPath == "" || !file.Exists(rules.K0sVars.StorageConfigPath) {
+		return cfg, err
+	}
+
+	data, err := os.ReadFile(rules.K0sVars.StorageConfigPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the recorded storage: %w", err)
+	}
+	storage := &v1beta1.StorageSpec{}
+	if err := yaml.Unmarshal(data, storage); err != nil {
+		return nil, fmt.Errorf("failed to parse the recorded storage %s: %w", rules.K0sVars.StorageConfigPath, err)
+	}
+	logrus.Infof("using the %s storage recorded in %s instead of the one of the config", storage.Type, rules.K0sVars.StorageConfigPath)
+	cfg.Spec.Storage = storage
+	return cfg, nil
+}
+
+// RecordStorage records the storage spec of a migrated controller, it overrides the one of the config file from
+// now on, and updates the runtime config accordingly
+func (rules *ClientConfigLoadingRules) RecordStorage(cfg *v1beta1.ClusterConfig) error {
+	if rules.RuntimeConfigPath == "" {
+		rules.RuntimeConfigPath = runtimeConfigPathDefault
+	}
+
+	data, err := yaml.Marshal(cfg.Spec.Storage)
+	if err != nil {
+		return err
+	}
+	// the kine data source may contain credentials
+	if err := os.WriteFile(rules.K0sVars.StorageConfigPath, data, 0600); err != nil {
+		return fmt.Errorf("failed to record the storage: %w", err)
+	}
+
+	yamlData, err := yaml.Marshal(cfg)
+	if err != nil {
+		return err
+	}
+	return rules.writeConfig(yamlData, cfg.Spec.Storage)
+}
+
 // generate default config and return the config object
 func (rules *ClientConfigLoadingRules) generateDefaults(defaultStorage *v1beta1.StorageSpec) (config *v1beta1.ClusterConfig) {
 	logrus.Debugf("no config file given, using defaults")
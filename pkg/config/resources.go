@@ -0,0 +1,74 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+)
+
+// ParseComponentResources parses the key=value pairs given to the --<component>-resources flags,
+// the keys are the same as the fields of v1beta1.ComponentResources
+func ParseComponentResources(values map[string]string) (*v1beta1.ComponentResources, error) {
+	r := &v1beta1.ComponentResources{}
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		value := values[key]
+		switch key {
+		case "memoryMax":
+			q, err := resource.ParseQuantity(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid memoryMax: %v", err)
+			}
+			r.MemoryMax = &q
+		case "cpuWeight":
+			i, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid cpuWeight: %v", err)
+			}
+			r.CPUWeight = i
+		case "pidsMax":
+			i, err := strconv.ParseInt(value, 10, 64)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pidsMax: %v", err)
+			}
+			r.PidsMax = i
+		case "oomScoreAdj":
+			i, err := strconv.Atoi(value)
+			if err != nil {
+				return nil, fmt.Errorf("invalid oomScoreAdj: %v", err)
+			}
+			r.OOMScoreAdj = &i
+		default:
+			return nil, fmt.Errorf("unknown key `%s`, must be one of memoryMax, cpuWeight, pidsMax, oomScoreAdj", key)
+		}
+	}
+
+	if errs := r.Validate(); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return r, nil
+}
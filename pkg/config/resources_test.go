@@ -0,0 +1,51 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseComponentResources(t *testing.T) {
+	r, err := ParseComponentResources(map[string]string{
+		"memoryMax":   "512Mi",
+		"cpuWeight":   "200",
+		"pidsMax":     "4096",
+		"oomScoreAdj": "-999",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, int64(512<<20), r.MemoryMax.Value())
+	assert.Equal(t, int64(200), r.CPUWeight)
+	assert.Equal(t, int64(4096), r.PidsMax)
+	assert.Equal(t, -999, *r.OOMScoreAdj)
+
+	r, err = ParseComponentResources(nil)
+	require.NoError(t, err)
+	assert.True(t, r.IsEmpty())
+
+	for _, values := range []map[string]string{
+		{"memory": "1Gi"},
+		{"memoryMax": "lots"},
+		{"cpuWeight": "0.5"},
+		{"oomScoreAdj": "-1001"},
+	} {
+		_, err := ParseComponentResources(values)
+		assert.Error(t, err, "%v", values)
+	}
+}
@@ -58,6 +58,11 @@ const (
 	// KineDBDirMode is the expected directory permissions for the Kine DB
 	KineDBDirMode = 0750
 
+	// BackupDirMode is the expected directory permissions for the scheduled backups save path
+	BackupDirMode = 0700
+	// ComponentLogDirMode is the expected directory permissions for the component log files directory
+	ComponentLogDirMode = 0750
+
 	// User accounts for services
 
 	// EtcdUser defines the user to use for running etcd process
@@ -140,11 +145,14 @@ type CfgVars struct {
 	KonnectivityKubeConfigPath string // location for konnectivity kubeconfig
 	OCIBundleDir               string // location for OCI bundles
 	DefaultStorageType         string // Default backend storage
+	StorageConfigPath          string // storage spec recorded by k0s storage migrate, overriding the one of the config file
 
 	// Helm config
 	HelmHome             string
 	HelmRepositoryCache  string
 	HelmRepositoryConfig string
+	HelmRegistryConfig   string // credentials of the OCI registries, in docker config format
+	HelmChartsDir        string // location of the local chart archives, relative chart paths are resolved against it
 }
 
 // GetConfig returns the pointer to a Config struct
@@ -191,10 +199,13 @@ func GetConfig(dataDir string) CfgVars {
 		ManifestsDir:               formatPath(dataDir, "manifests"),
 		RunDir:                     runDir,
 		KonnectivityKubeConfigPath: formatPath(certDir, "konnectivity.conf"),
+		StorageConfigPath:          formatPath(dataDir, "storage.yaml"),
 
 		// Helm Config
 		HelmHome:             helmHome,
 		HelmRepositoryCache:  formatPath(helmHome, "cache"),
 		HelmRepositoryConfig: formatPath(helmHome, "repositories.yaml"),
+		HelmRegistryConfig:   formatPath(helmHome, "registry.json"),
+		HelmChartsDir:        formatPath(dataDir, "charts"),
 	}
 }
@@ -19,10 +19,15 @@ import (
 	"context"
 	"crypto/tls"
 	"fmt"
+
 	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
 	"go.etcd.io/etcd/api/v3/v3rpc/rpctypes"
 	"go.etcd.io/etcd/client/pkg/v3/transport"
 	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/client/v3/concurrency"
+	"go.etcd.io/etcd/client/v3/snapshot"
+	"go.uber.org/zap"
 )
 
 // Client is our internal helper to access some of the etcd APIs
@@ -76,6 +81,21 @@ func (c *Client) ListMembers(ctx context.Context) (map[string]string, error) {
 	return memberList, nil
 }
 
+// MemberClientURLs returns the first client URL of each member, by member ID
+func (c *Client) MemberClientURLs(ctx context.Context) (map[uint64]string, error) {
+	members, err := c.client.MemberList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	urls := make(map[uint64]string, len(members.Members))
+	for _, m := range members.Members {
+		if len(m.ClientURLs) > 0 {
+			urls[m.ID] = m.ClientURLs[0]
+		}
+	}
+	return urls, nil
+}
+
 // AddMember add new member to etcd cluster
 func (c *Client) AddMember(ctx context.Context, name, peerAddress string) ([]string, error) {
 
@@ -123,6 +143,66 @@ func (c *Client) DeleteMember(ctx context.Context, peerID uint64) error {
 	return err
 }
 
+// Status returns the status of the member serving the given endpoint
+func (c *Client) Status(ctx context.Context, endpoint string) (*clientv3.StatusResponse, error) {
+	return c.client.Status(ctx, endpoint)
+}
+
+// Defragment defragments the database of the member serving the given endpoint
+func (c *Client) Defragment(ctx context.Context, endpoint string) error {
+	_, err := c.client.Defragment(ctx, endpoint)
+	return err
+}
+
+// Compact compacts the key-value store history up to the given revision
+func (c *Client) Compact(ctx context.Context, revision int64) error {
+	_, err := c.client.Compact(ctx, revision, clientv3.WithCompactPhysical())
+	return err
+}
+
+// Alarms returns the alarms raised in the cluster
+func (c *Client) Alarms(ctx context.Context) ([]*etcdserverpb.AlarmMember, error) {
+	resp, err := c.client.AlarmList(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Alarms, nil
+}
+
+// DisarmAlarm disarms the given alarm
+func (c *Client) DisarmAlarm(ctx context.Context, alarm *etcdserverpb.AlarmMember) error {
+	_, err := c.client.AlarmDisarm(ctx, (*clientv3.AlarmMember)(alarm))
+	return err
+}
+
+// Snapshot saves a snapshot of the database of the first endpoint to the given path
+func (c *Client) Snapshot(ctx context.Context, path string) error {
+	cfg := *c.Config
+	cfg.Endpoints = cfg.Endpoints[:1]
+	// disable etcd's logging
+	return snapshot.Save(ctx, zap.NewNop(), cfg, path)
+}
+
+// WithLock runs fn while holding the cluster wide lock of the given name
+func (c *Client) WithLock(ctx context.Context, name string, fn func() error) error {
+	session, err := concurrency.NewSession(c.client, concurrency.WithContext(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to create etcd session: %w", err)
+	}
+	defer session.Close()
+
+	mutex := concurrency.NewMutex(session, name)
+	if err := mutex.Lock(ctx); err != nil {
+		return fmt.Errorf("failed to acquire lock %s: %w", name, err)
+	}
+	defer func() {
+		// the lock is released anyways when the session lease expires
+		_ = mutex.Unlock(context.Background())
+	}()
+
+	return fn()
+}
+
 // Close closes the etcd client
 func (c *Client) Close() {
 	c.client.Close()
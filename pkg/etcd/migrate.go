@@ -0,0 +1,104 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	clientv3 "go.etcd.io/etcd/client/v3"
+)
+
+const (
+	// copyPrefix is the prefix of the keys copied between storages, the kubernetes resources live below it
+	copyPrefix = "/registry/"
+	// copyPageSize is the number of keys read at once from the source storage
+	copyPageSize = 500
+)
+
+// NewKineClient creates a Client talking to the kine instance listening on the given unix socket
+func NewKineClient(socketPath string) (*Client, error) {
+	cfg := clientv3.Config{
+		Endpoints:   []string{"unix://" + socketPath},
+		DialTimeout: 5 * time.Second,
+	}
+	cli, err := clientv3.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{Config: &cfg, client: cli}, nil
+}
+
+// CopyResult is the outcome of a copy between storages
+type CopyResult struct {
+	// Copied is the number of copied keys
+	Copied int
+	// Skipped is the number of keys attached to a lease, e.g. events, which are not copied
+	Skipped int
+}
+
+// CountKeys returns the number of kubernetes resources stored
+func (c *Client) CountKeys(ctx context.Context) (int64, error) {
+	resp, err := c.client.Get(ctx, copyPrefix, clientv3.WithPrefix(), clientv3.WithCountOnly())
+	if err != nil {
+		return 0, err
+	}
+	return resp.Count, nil
+}
+
+// CopyTo copies all the kubernetes resources to another, empty, storage. The keys attached to a lease are skipped as
+// leases can't be carried over. The copy uses create-only transactions, the only writes kine supports.
+func (c *Client) CopyTo(ctx context.Context, dst *Client) (CopyResult, error) {
+	var result CopyResult
+	key, end := copyPrefix, clientv3.GetPrefixRangeEnd(copyPrefix)
+	var revision int64
+	for {
+		opts := []clientv3.OpOption{clientv3.WithRange(end), clientv3.WithLimit(copyPageSize)}
+		// read all the pages at the revision of the first one, like kube-apiserver paginates
+		if revision != 0 {
+			opts = append(opts, clientv3.WithRev(revision))
+		}
+		resp, err := c.client.Get(ctx, key, opts...)
+		if err != nil {
+			return result, fmt.Errorf("failed to read the keys from %s: %w", key, err)
+		}
+		revision = resp.Header.Revision
+
+		for _, kv := range resp.Kvs {
+			if kv.Lease != 0 {
+				result.Skipped++
+				continue
+			}
+			txn, err := dst.client.Txn(ctx).
+				If(clientv3.Compare(clientv3.ModRevision(string(kv.Key)), "=", 0)).
+				Then(clientv3.OpPut(string(kv.Key), string(kv.Value))).
+				Commit()
+			if err != nil {
+				return result, fmt.Errorf("failed to write %s: %w", kv.Key, err)
+			}
+			if !txn.Succeeded {
+				return result, fmt.Errorf("failed to write %s: it already exists", kv.Key)
+			}
+			result.Copied++
+		}
+
+		if !resp.More || len(resp.Kvs) == 0 {
+			return result, nil
+		}
+		key = string(resp.Kvs[len(resp.Kvs)-1].Key) + "\x00"
+	}
+}
@@ -0,0 +1,94 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package etcd
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	clientv3 "go.etcd.io/etcd/client/v3"
+	"go.etcd.io/etcd/server/v3/embed"
+)
+
+func startTestEtcd(t *testing.T) *Client {
+	cfg := embed.NewConfig()
+	cfg.Dir = t.TempDir()
+	cfg.LogLevel = "error"
+	clientURL, peerURL := freeLocalURL(t), freeLocalURL(t)
+	cfg.LCUrls, cfg.ACUrls = []url.URL{*clientURL}, []url.URL{*clientURL}
+	cfg.LPUrls, cfg.APUrls = []url.URL{*peerURL}, []url.URL{*peerURL}
+	cfg.InitialCluster = cfg.InitialClusterFromName(cfg.Name)
+
+	server, err := embed.StartEtcd(cfg)
+	require.NoError(t, err)
+	t.Cleanup(server.Close)
+	select {
+	case <-server.Server.ReadyNotify():
+	case <-time.After(10 * time.Second):
+		require.Fail(t, "etcd didn't start in time")
+	}
+
+	clientCfg := clientv3.Config{Endpoints: []string{clientURL.String()}}
+	cli, err := clientv3.New(clientCfg)
+	require.NoError(t, err)
+	t.Cleanup(func() { cli.Close() })
+	return &Client{Config: &clientCfg, client: cli}
+}
+
+func freeLocalURL(t *testing.T) *url.URL {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	return &url.URL{Scheme: "http", Host: l.Addr().String()}
+}
+
+func TestCopyTo(t *testing.T) {
+	ctx := context.Background()
+	src, dst := startTestEtcd(t), startTestEtcd(t)
+
+	for i := 0; i < 2*copyPageSize+10; i++ {
+		_, err := src.client.Put(ctx, fmt.Sprintf("/registry/configmaps/default/cm-%04d", i), fmt.Sprint(i))
+		require.NoError(t, err)
+	}
+	_, err := src.client.Put(ctx, "/unrelated", "not copied")
+	require.NoError(t, err)
+	lease, err := src.client.Grant(ctx, 3600)
+	require.NoError(t, err)
+	_, err = src.client.Put(ctx, "/registry/events/default/event", "not copied", clientv3.WithLease(lease.ID))
+	require.NoError(t, err)
+
+	result, err := src.CopyTo(ctx, dst)
+	require.NoError(t, err)
+	assert.Equal(t, CopyResult{Copied: 2*copyPageSize + 10, Skipped: 1}, result)
+
+	count, err := dst.CountKeys(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2*copyPageSize+10), count)
+	resp, err := dst.client.Get(ctx, "/registry/configmaps/default/cm-0042")
+	require.NoError(t, err)
+	require.Len(t, resp.Kvs, 1)
+	assert.Equal(t, "42", string(resp.Kvs[0].Value))
+
+	// the target must be empty
+	_, err = src.CopyTo(ctx, dst)
+	assert.ErrorContains(t, err, "already exists")
+}
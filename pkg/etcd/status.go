@@ -0,0 +1,257 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package etcd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+	"go.etcd.io/etcd/api/v3/version"
+)
+
+// peerProbeTimeout is the time given to the members to answer on their peer URL
+const peerProbeTimeout = 5 * time.Second
+
+// ClusterStatus is the status of the etcd cluster, as seen from the local member
+type ClusterStatus struct {
+	ClusterID string   `json:"clusterID"`
+	Leader    string   `json:"leader"`
+	RaftTerm  uint64   `json:"raftTerm"`
+	RaftIndex uint64   `json:"raftIndex"`
+	Revision  int64    `json:"revision"`
+	Members   []Member `json:"members"`
+	Alarms    []Alarm  `json:"alarms"`
+}
+
+// Member is the status of an etcd cluster member. The database size is only known for the local member,
+// as the members only serve clients on their loopback address. The others are probed on their peer URL.
+type Member struct {
+	ID          string   `json:"id"`
+	Name        string   `json:"name"`
+	PeerURLs    []string `json:"peerURLs"`
+	Local       bool     `json:"local"`
+	Leader      bool     `json:"leader"`
+	Learner     bool     `json:"learner"`
+	Healthy     bool     `json:"healthy"`
+	Error       string   `json:"error,omitempty"`
+	Version     string   `json:"version,omitempty"`
+	DBSize      int64    `json:"dbSize,omitempty"`
+	DBSizeInUse int64    `json:"dbSizeInUse,omitempty"`
+}
+
+// Alarm is an alarm raised on a member
+type Alarm struct {
+	MemberID string `json:"memberID"`
+	Member   string `json:"member"`
+	Alarm    string `json:"alarm"`
+}
+
+// FormatID formats a member or cluster ID the way etcd does
+func FormatID(id uint64) string {
+	return fmt.Sprintf("%x", id)
+}
+
+// ClusterStatus returns the status of the cluster and of all of its members
+func (c *Client) ClusterStatus(ctx context.Context) (*ClusterStatus, error) {
+	local, err := c.client.Status(ctx, c.Config.Endpoints[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to get the status of the local member: %w", err)
+	}
+	members, err := c.client.MemberList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the members: %w", err)
+	}
+	alarms, err := c.ListAlarms(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	status := &ClusterStatus{
+		ClusterID: FormatID(local.Header.ClusterId),
+		RaftTerm:  local.RaftTerm,
+		RaftIndex: local.RaftIndex,
+		Revision:  local.Header.Revision,
+		Alarms:    alarms,
+	}
+	for _, m := range members.Members {
+		member := Member{
+			ID:       FormatID(m.ID),
+			Name:     m.Name,
+			PeerURLs: m.PeerURLs,
+			Local:    m.ID == local.Header.MemberId,
+			Leader:   m.ID == local.Leader,
+			Learner:  m.IsLearner,
+		}
+		if member.Leader {
+			status.Leader = m.Name
+		}
+		if member.Local {
+			member.Version = local.Version
+			member.DBSize = local.DbSize
+			member.DBSizeInUse = local.DbSizeInUse
+			err = c.Health(ctx)
+		} else {
+			member.Version, err = c.probePeer(ctx, m.PeerURLs)
+		}
+		member.Healthy = err == nil
+		if err != nil {
+			member.Error = err.Error()
+		}
+		status.Members = append(status.Members, member)
+	}
+	return status, nil
+}
+
+// ListAlarms returns the alarms raised in the cluster
+func (c *Client) ListAlarms(ctx context.Context) ([]Alarm, error) {
+	alarms, err := c.Alarms(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the alarms: %w", err)
+	}
+	return c.toAlarms(ctx, alarms)
+}
+
+// DisarmAlarms disarms all the alarms raised in the cluster and returns them
+func (c *Client) DisarmAlarms(ctx context.Context) ([]Alarm, error) {
+	alarms, err := c.Alarms(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the alarms: %w", err)
+	}
+	for _, a := range alarms {
+		if err := c.DisarmAlarm(ctx, a); err != nil {
+			return nil, fmt.Errorf("failed to disarm the %s alarm of member %s: %w", a.Alarm, FormatID(a.MemberID), err)
+		}
+	}
+	return c.toAlarms(ctx, alarms)
+}
+
+func (c *Client) toAlarms(ctx context.Context, alarms []*etcdserverpb.AlarmMember) ([]Alarm, error) {
+	result := []Alarm{}
+	if len(alarms) == 0 {
+		return result, nil
+	}
+	members, err := c.client.MemberList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the members: %w", err)
+	}
+	names := make(map[uint64]string, len(members.Members))
+	for _, m := range members.Members {
+		names[m.ID] = m.Name
+	}
+	for _, a := range alarms {
+		result = append(result, Alarm{
+			MemberID: FormatID(a.MemberID),
+			Member:   names[a.MemberID],
+			Alarm:    a.Alarm.String(),
+		})
+	}
+	return result, nil
+}
+
+// probePeer returns the etcd version served on the first reachable peer URL of a member
+func (c *Client) probePeer(ctx context.Context, peerURLs []string) (string, error) {
+	if len(peerURLs) == 0 {
+		return "", fmt.Errorf("member has no peer URL, it didn't start yet")
+	}
+
+	httpClient := &http.Client{
+		Transport: &http.Transport{TLSClientConfig: c.Config.TLS},
+		Timeout:   peerProbeTimeout,
+	}
+	var lastErr error
+	for _, peerURL := range peerURLs {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, strings.TrimSuffix(peerURL, "/")+"/version", nil)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		resp, err := httpClient.Do(req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		var versions version.Versions
+		err = json.NewDecoder(resp.Body).Decode(&versions)
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			lastErr = fmt.Errorf("%s answered %s", peerURL, resp.Status)
+			continue
+		}
+		if err != nil {
+			lastErr = fmt.Errorf("invalid version answered by %s: %w", peerURL, err)
+			continue
+		}
+		return versions.Server, nil
+	}
+	return "", lastErr
+}
+
+// FindMember looks up a member by name, hexadecimal ID or peer address
+func (c *Client) FindMember(ctx context.Context, member string) (*etcdserverpb.Member, error) {
+	resp, err := c.client.MemberList(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list the members: %w", err)
+	}
+	return findMember(resp.Members, member)
+}
+
+func findMember(members []*etcdserverpb.Member, member string) (*etcdserverpb.Member, error) {
+	id, idErr := strconv.ParseUint(member, 16, 64)
+	var found []*etcdserverpb.Member
+	for _, m := range members {
+		if m.Name == member || (idErr == nil && m.ID == id) || matchesPeerAddress(m.PeerURLs, member) {
+			found = append(found, m)
+		}
+	}
+	switch len(found) {
+	case 0:
+		return nil, fmt.Errorf("member `%s` not found", member)
+	case 1:
+		return found[0], nil
+	default:
+		return nil, fmt.Errorf("`%s` matches %d members, use the member ID instead", member, len(found))
+	}
+}
+
+func matchesPeerAddress(peerURLs []string, address string) bool {
+	for _, peerURL := range peerURLs {
+		if peerURL == address || peerURL == fmt.Sprintf("https://%s:2380", address) {
+			return true
+		}
+	}
+	return false
+}
+
+// LocalMember returns the ID of the local member and the one of the leader
+func (c *Client) LocalMember(ctx context.Context) (uint64, uint64, error) {
+	status, err := c.client.Status(ctx, c.Config.Endpoints[0])
+	if err != nil {
+		return 0, 0, err
+	}
+	return status.Header.MemberId, status.Leader, nil
+}
+
+// MoveLeader transfers the leadership to the given member. It must be called on the leader.
+func (c *Client) MoveLeader(ctx context.Context, memberID uint64) error {
+	_, err := c.client.MoveLeader(ctx, memberID)
+	return err
+}
@@ -0,0 +1,42 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package etcd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.etcd.io/etcd/api/v3/etcdserverpb"
+)
+
+func TestFindMember(t *testing.T) {
+	members := []*etcdserverpb.Member{
+		{ID: 0x8e9e05c52164694d, Name: "controller-1", PeerURLs: []string{"https://10.0.0.1:2380"}},
+		{ID: 0x91bc3c398fb3c146, Name: "controller-2", PeerURLs: []string{"https://10.0.0.2:2380"}},
+		{ID: 0xfd422379fda50e48, Name: "", PeerURLs: []string{"https://10.0.0.3:2380"}},
+	}
+
+	for _, query := range []string{"controller-2", "91bc3c398fb3c146", "10.0.0.2", "https://10.0.0.2:2380"} {
+		m, err := findMember(members, query)
+		if assert.NoError(t, err, query) {
+			assert.Equal(t, "controller-2", m.Name, query)
+		}
+	}
+
+	_, err := findMember(members, "controller-4")
+	require.EqualError(t, err, "member `controller-4` not found")
+}
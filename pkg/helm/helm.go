@@ -23,6 +23,7 @@ import (
 	"time"
 
 	"github.com/k0sproject/k0s/internal/pkg/dir"
+	"github.com/k0sproject/k0s/internal/pkg/file"
 	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 	"github.com/k0sproject/k0s/pkg/constant"
 	"helm.sh/helm/v3/pkg/action"
@@ -30,6 +31,7 @@ import (
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/downloader"
 	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/registry"
 	"helm.sh/helm/v3/pkg/release"
 	"helm.sh/helm/v3/pkg/repo"
 	"k8s.io/cli-runtime/pkg/genericclioptions"
@@ -38,9 +40,11 @@ import (
 
 // Commands run different helm command in the same way as CLI tool
 type Commands struct {
-	repoFile     string
-	helmCacheDir string
-	kubeConfig   string
+	repoFile       string
+	registryConfig string
+	helmCacheDir   string
+	chartsDir      string
+	kubeConfig     string
 }
 
 var getters = getter.Providers{
@@ -48,15 +52,30 @@ var getters = getter.Providers{
 		Schemes: []string{"http", "https"},
 		New:     getter.NewHTTPGetter,
 	},
+	getter.Provider{
+		Schemes: []string{registry.OCIScheme},
+		New:     getter.NewOCIGetter,
+	},
 }
 
 // NewCommands builds new Commands instance with default values
 func NewCommands(k0sVars constant.CfgVars) *Commands {
 	return &Commands{
-		repoFile:     k0sVars.HelmRepositoryConfig,
-		helmCacheDir: k0sVars.HelmRepositoryCache,
-		kubeConfig:   k0sVars.AdminKubeConfigPath,
+		repoFile:       k0sVars.HelmRepositoryConfig,
+		registryConfig: k0sVars.HelmRegistryConfig,
+		helmCacheDir:   k0sVars.HelmRepositoryCache,
+		chartsDir:      k0sVars.HelmChartsDir,
+		kubeConfig:     k0sVars.AdminKubeConfigPath,
+	}
+}
+
+// registryClient creates a client for the OCI registries, using the credentials stored when adding the repositories
+func (hc *Commands) registryClient() (*registry.Client, error) {
+	client, err := registry.NewClient(registry.ClientOptCredentialsFile(hc.registryConfig))
+	if err != nil {
+		return nil, fmt.Errorf("can't create registry client: %v", err)
 	}
+	return client, nil
 }
 
 func (hc *Commands) getActionCfg(namespace string) (*action.Configuration, error) {
@@ -78,6 +97,10 @@ func (hc *Commands) getActionCfg(namespace string) (*action.Configuration, error
 }
 
 func (hc *Commands) AddRepository(repoCfg v1beta1.Repository) error {
+	if registry.IsOCI(repoCfg.URL) {
+		return hc.loginRegistry(repoCfg)
+	}
+
 	err := dir.Init(filepath.Dir(hc.repoFile), constant.DataDirMode)
 	if err != nil && !os.IsExist(err) {
 		return fmt.Errorf("can't add repository to %s: %v", hc.repoFile, err)
@@ -121,16 +144,45 @@ func (hc *Commands) AddRepository(repoCfg v1beta1.Repository) error {
 	return nil
 }
 
+// loginRegistry stores the credentials of the OCI registry hosting the repository.
+// OCI registries have no index, there's nothing else to add, the charts being referenced by their oci:// URL.
+func (hc *Commands) loginRegistry(repoCfg v1beta1.Repository) error {
+	if repoCfg.Username == "" && repoCfg.Password == "" {
+		return nil
+	}
+	host := strings.SplitN(strings.TrimPrefix(repoCfg.URL, fmt.Sprintf("%s://", registry.OCIScheme)), "/", 2)[0]
+
+	if err := dir.Init(filepath.Dir(hc.registryConfig), constant.DataDirMode); err != nil {
+		return fmt.Errorf("can't log in to registry %s: %v", host, err)
+	}
+	client, err := hc.registryClient()
+	if err != nil {
+		return err
+	}
+	if err := client.Login(host,
+		registry.LoginOptBasicAuth(repoCfg.Username, repoCfg.Password),
+		registry.LoginOptInsecure(repoCfg.Insecure),
+	); err != nil {
+		return fmt.Errorf("can't log in to registry %s: %v", host, err)
+	}
+	return nil
+}
+
 func (hc *Commands) downloadDependencies(chart *chart.Chart, chartPath string) error {
 	if chart.Metadata.Dependencies == nil {
 		return nil
 	}
 	if err := action.CheckDependencies(chart, chart.Metadata.Dependencies); err != nil {
+		registryClient, err := hc.registryClient()
+		if err != nil {
+			return err
+		}
 		man := &downloader.Manager{
 			Out:              os.Stdout,
 			ChartPath:        chartPath,
 			SkipUpdate:       false,
 			Getters:          getters,
+			RegistryClient:   registryClient,
 			RepositoryConfig: hc.repoFile,
 			RepositoryCache:  hc.helmCacheDir,
 			Debug:            false,
@@ -142,9 +194,20 @@ func (hc *Commands) downloadDependencies(chart *chart.Chart, chartPath string) e
 	return nil
 }
 
+// locateChart returns the path of the chart archive or directory, downloading it if needed.
+// The chart is either an oci:// URL, a path, relative ones being looked up in the charts directory first, or a repo/chart reference.
 func (hc *Commands) locateChart(name string, version string) (string, error) {
 	name = strings.TrimSpace(name)
 
+	if !registry.IsOCI(name) && !filepath.IsAbs(name) && hc.chartsDir != "" {
+		local, err := hc.localChartPath(name)
+		if err != nil {
+			return "", err
+		}
+		if file.Exists(local) {
+			return local, nil
+		}
+	}
 	if _, err := os.Stat(name); err == nil {
 		abs, err := filepath.Abs(name)
 		if err != nil {
@@ -156,10 +219,15 @@ func (hc *Commands) locateChart(name string, version string) (string, error) {
 		return name, fmt.Errorf("can't locate chart: path not found: %s", name)
 	}
 
+	registryClient, err := hc.registryClient()
+	if err != nil {
+		return "", fmt.Errorf("can't locate chart `%s-%s`: %v", name, version, err)
+	}
 	dl := downloader.ChartDownloader{
 		Out:              os.Stdout,
 		Getters:          getters,
-		Options:          []getter.Option{},
+		Options:          []getter.Option{getter.WithRegistryClient(registryClient)},
+		RegistryClient:   registryClient,
 		RepositoryConfig: hc.repoFile,
 		RepositoryCache:  hc.helmCacheDir,
 	}
@@ -187,6 +255,51 @@ func (hc *Commands) locateChart(name string, version string) (string, error) {
 	return filename, fmt.Errorf("failed to download %q%s (hint: running `helm repo update` may help)", name, atVersion)
 }
 
+// localChartPath resolves the relative chart path against the charts directory,
+// rejecting the paths which lead outside of it, either by themselves or through symlinks.
+func (hc *Commands) localChartPath(name string) (string, error) {
+	local := filepath.Join(hc.chartsDir, name)
+	if !isWithinDir(hc.chartsDir, local) {
+		return "", fmt.Errorf("can't locate chart: `%s` is outside of the charts directory %s", name, hc.chartsDir)
+	}
+	if !file.Exists(local) {
+		return local, nil
+	}
+
+	chartsDir, err := filepath.EvalSymlinks(hc.chartsDir)
+	if err != nil {
+		return "", fmt.Errorf("can't locate chart `%s`: %v", name, err)
+	}
+	resolved, err := filepath.EvalSymlinks(local)
+	if err != nil {
+		return "", fmt.Errorf("can't locate chart `%s`: %v", name, err)
+	}
+	if !isWithinDir(chartsDir, resolved) {
+		return "", fmt.Errorf("can't locate chart: `%s` links outside of the charts directory %s", name, hc.chartsDir)
+	}
+	return local, nil
+}
+
+// isWithinDir returns true if the path is the directory itself or one of its descendants
+func isWithinDir(dir, path string) bool {
+	rel, err := filepath.Rel(dir, path)
+	if err != nil {
+		return false
+	}
+	return rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+// ReleaseOptions configures how a release gets installed, upgraded or rolled back
+type ReleaseOptions struct {
+	Timeout       time.Duration
+	Atomic        bool
+	Wait          bool
+	WaitForJobs   bool
+	Force         bool
+	CleanupOnFail bool
+	MaxHistory    int
+}
+
 func (hc *Commands) isInstallable(chart *chart.Chart) bool {
 	if chart.Metadata.Type != "" && chart.Metadata.Type != "application" {
 		return false
@@ -194,22 +307,22 @@ func (hc *Commands) isInstallable(chart *chart.Chart) bool {
 	return true
 }
 
-func (hc *Commands) InstallChart(chartName string, version string, releaseName string, namespace string, values map[string]interface{}, timeout time.Duration) (*release.Release, error) {
+func (hc *Commands) InstallChart(chartName string, version string, releaseName string, namespace string, values map[string]interface{}, opts ReleaseOptions) (*release.Release, error) {
 	cfg, err := hc.getActionCfg(namespace)
 	if err != nil {
 		return nil, fmt.Errorf("can't create action configuration: %v", err)
 	}
 	install := action.NewInstall(cfg)
 	install.CreateNamespace = true
-	install.WaitForJobs = true
-	install.Wait = true
-	install.Timeout = timeout
+	install.WaitForJobs = opts.WaitForJobs
+	install.Wait = opts.Wait
+	install.Timeout = opts.Timeout
 	chartDir, err := hc.locateChart(chartName, version)
 	if err != nil {
 		return nil, err
 	}
 	install.Namespace = namespace
-	install.Atomic = true
+	install.Atomic = opts.Atomic
 	install.ReleaseName = releaseName
 	name, _, err := install.NameAndChart([]string{chartName})
 	install.ReleaseName = name
@@ -241,19 +354,21 @@ func (hc *Commands) InstallChart(chartName string, version string, releaseName s
 	return chartRelease, nil
 }
 
-func (hc *Commands) UpgradeChart(chartName string, version string, releaseName string, namespace string, values map[string]interface{}, timeout time.Duration) (*release.Release, error) {
+func (hc *Commands) UpgradeChart(chartName string, version string, releaseName string, namespace string, values map[string]interface{}, opts ReleaseOptions) (*release.Release, error) {
 	cfg, err := hc.getActionCfg(namespace)
 	if err != nil {
 		return nil, fmt.Errorf("can't create action configuration: %v", err)
 	}
 	upgrade := action.NewUpgrade(cfg)
 	upgrade.Namespace = namespace
-	upgrade.Wait = true
-	upgrade.WaitForJobs = true
+	upgrade.Wait = opts.Wait
+	upgrade.WaitForJobs = opts.WaitForJobs
 	upgrade.Install = true
-	upgrade.Force = true
-	upgrade.Atomic = true
-	upgrade.Timeout = timeout
+	upgrade.Force = opts.Force
+	upgrade.Atomic = opts.Atomic
+	upgrade.CleanupOnFail = opts.CleanupOnFail
+	upgrade.MaxHistory = opts.MaxHistory
+	upgrade.Timeout = opts.Timeout
 	chartDir, err := hc.locateChart(chartName, version)
 	if err != nil {
 		return nil, err
@@ -283,6 +398,34 @@ func (hc *Commands) UpgradeChart(chartName string, version string, releaseName s
 	return chartRelease, nil
 }
 
+// RollbackRelease rolls the release back to its previous revision
+func (hc *Commands) RollbackRelease(releaseName string, namespace string, opts ReleaseOptions) error {
+	cfg, err := hc.getActionCfg(namespace)
+	if err != nil {
+		return fmt.Errorf("can't create helmAction configuration: %v", err)
+	}
+	rollback := action.NewRollback(cfg)
+	rollback.Wait = opts.Wait
+	rollback.WaitForJobs = opts.WaitForJobs
+	rollback.Force = opts.Force
+	rollback.CleanupOnFail = opts.CleanupOnFail
+	rollback.MaxHistory = opts.MaxHistory
+	rollback.Timeout = opts.Timeout
+	if err := rollback.Run(releaseName); err != nil {
+		return fmt.Errorf("can't roll back release `%s`: %v", releaseName, err)
+	}
+	return nil
+}
+
+// GetRelease returns the latest revision of the release
+func (hc *Commands) GetRelease(releaseName string, namespace string) (*release.Release, error) {
+	cfg, err := hc.getActionCfg(namespace)
+	if err != nil {
+		return nil, fmt.Errorf("can't create helmAction configuration: %v", err)
+	}
+	return action.NewGet(cfg).Run(releaseName)
+}
+
 func stringptr(s string) *string {
 	return &s
 }
@@ -0,0 +1,93 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package helm
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+)
+
+func newTestCommands(t *testing.T) *Commands {
+	tmp := t.TempDir()
+	return &Commands{
+		repoFile:       filepath.Join(tmp, "helmhome", "repositories.yaml"),
+		registryConfig: filepath.Join(tmp, "helmhome", "registry.json"),
+		helmCacheDir:   filepath.Join(tmp, "helmhome", "cache"),
+		chartsDir:      filepath.Join(tmp, "charts"),
+	}
+}
+
+func TestLocateLocalChart(t *testing.T) {
+	hc := newTestCommands(t)
+	require.NoError(t, os.MkdirAll(hc.chartsDir, 0755))
+	archive, err := chartutil.Save(&chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "nginx", Version: "1.2.3"},
+	}, hc.chartsDir)
+	require.NoError(t, err)
+
+	t.Run("relative_to_charts_dir", func(t *testing.T) {
+		path, err := hc.locateChart("nginx-1.2.3.tgz", "")
+		require.NoError(t, err)
+		assert.Equal(t, archive, path)
+
+		loaded, err := loader.Load(path)
+		require.NoError(t, err)
+		assert.Equal(t, "1.2.3", loaded.Metadata.Version)
+	})
+
+	t.Run("absolute", func(t *testing.T) {
+		path, err := hc.locateChart(archive, "")
+		require.NoError(t, err)
+		assert.Equal(t, archive, path)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := hc.locateChart(filepath.Join(hc.chartsDir, "missing-0.1.0.tgz"), "")
+		assert.ErrorContains(t, err, "path not found")
+	})
+
+	t.Run("outside_charts_dir", func(t *testing.T) {
+		outside := filepath.Join(filepath.Dir(hc.chartsDir), "outside-0.1.0.tgz")
+		require.NoError(t, os.WriteFile(outside, []byte("chart"), 0600))
+
+		_, err := hc.locateChart("../outside-0.1.0.tgz", "")
+		assert.ErrorContains(t, err, "is outside of the charts directory")
+		_, err = hc.locateChart("nginx/../../outside-0.1.0.tgz", "")
+		assert.ErrorContains(t, err, "is outside of the charts directory")
+
+		require.NoError(t, os.Symlink(outside, filepath.Join(hc.chartsDir, "link-0.1.0.tgz")))
+		_, err = hc.locateChart("link-0.1.0.tgz", "")
+		assert.ErrorContains(t, err, "links outside of the charts directory")
+	})
+}
+
+func TestAddOCIRepository(t *testing.T) {
+	hc := newTestCommands(t)
+
+	// registries without credentials don't need a login
+	require.NoError(t, hc.AddRepository(v1beta1.Repository{Name: "registry", URL: "oci://registry.example.com/charts"}))
+	assert.NoFileExists(t, hc.repoFile, "OCI registries must not be added to the repositories file")
+	assert.NoFileExists(t, hc.registryConfig)
+}
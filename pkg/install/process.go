@@ -16,14 +16,21 @@ limitations under the License.
 package install
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
+	"fmt"
 	"io"
 	"net"
 	"net/http"
+	"strings"
 
 	config "github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/applier"
+	"github.com/k0sproject/k0s/pkg/certificate"
+	"github.com/k0sproject/k0s/pkg/component"
 	"github.com/k0sproject/k0s/pkg/constant"
+	"github.com/k0sproject/k0s/pkg/supervisor"
 )
 
 type K0sStatus struct {
@@ -39,19 +46,20 @@ type K0sStatus struct {
 	Args          []string
 	ClusterConfig *config.ClusterConfig
 	K0sVars       constant.CfgVars
+	// Processes are the statuses of the processes supervised by k0s
+	Processes []supervisor.ProcessStatus `json:",omitempty"`
+	// Components are the statuses of the components managed by k0s
+	Components []component.Status `json:",omitempty"`
+	// Certificates are the expiry of the certificates in the cert dirs
+	Certificates []certificate.Expiry `json:",omitempty"`
+	// Stacks are the statuses of the manifest stacks applied by the controller leader
+	Stacks []applier.StackStatus `json:",omitempty"`
 }
 
 func GetStatusInfo(socketPath string) (status *K0sStatus, err error) {
 	status = &K0sStatus{}
 
-	httpc := http.Client{
-		Transport: &http.Transport{
-			DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
-				return net.Dial("unix", socketPath)
-			},
-		},
-	}
-
+	httpc := statusSocketClient(socketPath)
 	response, err := httpc.Get("http://localhost")
 	if err != nil {
 		return nil, err
@@ -69,3 +77,106 @@ func GetStatusInfo(socketPath string) (status *K0sStatus, err error) {
 	}
 	return status, nil
 }
+
+// Reload is a request to reload the node config of the running k0s
+type Reload struct {
+	// WorkerFlags are the changed worker flags, e.g. --labels or --kubelet-extra-args, only workers accept them
+	WorkerFlags []string `json:"workerFlags,omitempty"`
+}
+
+// ReloadConfig asks the k0s process listening on the status socket to reload its node config
+func ReloadConfig(socketPath string, reload Reload) error {
+	body, err := json.Marshal(reload)
+	if err != nil {
+		return err
+	}
+	return postStatusSocketBody(socketPath, "/reload", "reload the config", "application/json", body)
+}
+
+// RotateCertificates asks the running controller to rotate its k0s managed certificates
+func RotateCertificates(socketPath string) error {
+	return postStatusSocket(socketPath, "/certificates/rotate", "rotate the certificates")
+}
+
+// StorageMigration is a request to migrate the storage of the running controller
+type StorageMigration struct {
+	// To is the storage type to migrate to, etcd or kine
+	To string `json:"to"`
+	// KineDataSource is the data source of kine when migrating to kine, the default sqlite database if empty
+	KineDataSource string `json:"kineDataSource,omitempty"`
+}
+
+const (
+	// StorageMigrationRunning is the state of a storage migration in progress
+	StorageMigrationRunning = "running"
+	// StorageMigrationSucceeded is the state of a completed storage migration
+	StorageMigrationSucceeded = "succeeded"
+	// StorageMigrationFailed is the state of a storage migration which failed and was rolled back
+	StorageMigrationFailed = "failed"
+)
+
+// StorageMigrationStatus is the status of the last storage migration of the running controller
+type StorageMigrationStatus struct {
+	StorageMigration
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+}
+
+// MigrateStorage asks the running controller to start migrating its storage, the migration carries on in the
+// background even if the caller goes away. Its progress is reported by GetStorageMigrationStatus.
+func MigrateStorage(socketPath string, migration StorageMigration) error {
+	body, err := json.Marshal(migration)
+	if err != nil {
+		return err
+	}
+	return postStatusSocketBody(socketPath, "/storage/migrate", "migrate the storage", "application/json", body)
+}
+
+// GetStorageMigrationStatus returns the status of the last storage migration of the running controller
+func GetStorageMigrationStatus(socketPath string) (*StorageMigrationStatus, error) {
+	httpc := statusSocketClient(socketPath)
+	response, err := httpc.Get("http://localhost/storage/migrate")
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(response.Body)
+		return nil, fmt.Errorf("failed to get the storage migration status: %s", strings.TrimSpace(string(body)))
+	}
+	status := &StorageMigrationStatus{}
+	if err := json.NewDecoder(response.Body).Decode(status); err != nil {
+		return nil, err
+	}
+	return status, nil
+}
+
+func postStatusSocket(socketPath, path, action string) error {
+	return postStatusSocketBody(socketPath, path, action, "text/plain", nil)
+}
+
+func postStatusSocketBody(socketPath, path, action, contentType string, body []byte) error {
+	httpc := statusSocketClient(socketPath)
+	response, err := httpc.Post("http://localhost"+path, contentType, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK && response.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(response.Body)
+		return fmt.Errorf("failed to %s: %s", action, strings.TrimSpace(string(body)))
+	}
+	return nil
+}
+
+func statusSocketClient(socketPath string) *http.Client {
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(_ context.Context, _, _ string) (net.Conn, error) {
+				return net.Dial("unix", socketPath)
+			},
+		},
+	}
+}
@@ -17,6 +17,7 @@ package kubernetes
 
 import (
 	"fmt"
+	"path/filepath"
 	"sync"
 
 	cfgClient "github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/clientset/typed/k0s.k0sproject.io/v1beta1"
@@ -44,6 +45,8 @@ type ClientFactoryInterface interface {
 func NewAdminClientFactory(k0sVars constant.CfgVars) ClientFactoryInterface {
 	return &ClientFactory{
 		configPath: k0sVars.AdminKubeConfigPath,
+		certFile:   filepath.Join(k0sVars.CertRootDir, "admin.crt"),
+		keyFile:    filepath.Join(k0sVars.CertRootDir, "admin.key"),
 	}
 }
 
@@ -52,6 +55,10 @@ func NewAdminClientFactory(k0sVars constant.CfgVars) ClientFactoryInterface {
 // the factory itself to components needing kube clients and creation time.
 type ClientFactory struct {
 	configPath string
+	// certFile and keyFile, if set, replace the client certificate embedded in the kubeconfig,
+	// so that the clients pick up rotated certificates
+	certFile string
+	keyFile  string
 
 	client          kubernetes.Interface
 	dynamicClient   dynamic.Interface
@@ -77,6 +84,7 @@ func (c *ClientFactory) GetClient() (kubernetes.Interface, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
 		}
+		c.useCertFiles()
 	}
 
 	if c.client != nil {
@@ -107,6 +115,7 @@ func (c *ClientFactory) GetDynamicClient() (dynamic.Interface, error) {
 		if err != nil {
 			return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
 		}
+		c.useCertFiles()
 	}
 
 	if c.dynamicClient != nil {
@@ -132,6 +141,7 @@ func (c *ClientFactory) GetDiscoveryClient() (discovery.CachedDiscoveryInterface
 		if err != nil {
 			return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
 		}
+		c.useCertFiles()
 	}
 
 	if c.discoveryClient != nil {
@@ -157,6 +167,7 @@ func (c *ClientFactory) GetConfigClient() (cfgClient.ClusterConfigInterface, err
 		if err != nil {
 			return nil, fmt.Errorf("failed to load kubeconfig: %w", err)
 		}
+		c.useCertFiles()
 	}
 	if c.configClient != nil {
 		return c.configClient, nil
@@ -170,6 +181,15 @@ func (c *ClientFactory) GetConfigClient() (cfgClient.ClusterConfigInterface, err
 	return c.configClient, nil
 }
 
+// useCertFiles makes the rest config load the client certificate from the cert files, client-go reloads them when they change
+func (c *ClientFactory) useCertFiles() {
+	if c.certFile == "" || c.keyFile == "" {
+		return
+	}
+	c.restConfig.CertData, c.restConfig.KeyData = nil, nil
+	c.restConfig.CertFile, c.restConfig.KeyFile = c.certFile, c.keyFile
+}
+
 func (c *ClientFactory) GetRESTClient() (rest.Interface, error) {
 	cs, ok := c.client.(*kubernetes.Clientset)
 	if !ok {
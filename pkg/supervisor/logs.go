@@ -0,0 +1,78 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package supervisor
+
+import (
+	"io"
+	"path/filepath"
+
+	"gopkg.in/natefinch/lumberjack.v2"
+
+	"github.com/k0sproject/k0s/internal/pkg/dir"
+	"github.com/k0sproject/k0s/pkg/constant"
+)
+
+// LogConfig defines where the output of the supervised processes is written to
+type LogConfig struct {
+	// Dir is the directory the per-process log files are written to, log files are disabled if empty
+	Dir string
+	// MaxSize is the size in megabytes after which a log file is rotated
+	MaxSize int
+	// MaxAge is the number of days to keep rotated log files for, 0 means forever
+	MaxAge int
+	// MaxBackups is the number of rotated log files to keep, 0 means all of them
+	MaxBackups int
+	// Compress gzips the rotated log files
+	Compress bool
+	// Forward keeps forwarding the output to the k0s log when log files are enabled
+	Forward bool
+}
+
+// DefaultLogConfig is used by supervisors without an explicit LogConfig
+var DefaultLogConfig = LogConfig{
+	MaxSize:    100,
+	MaxBackups: 5,
+	Forward:    true,
+}
+
+// openLogFile returns the rotating log file for the named process, or nil if log files are disabled
+func (c *LogConfig) openLogFile(name string) (*lumberjack.Logger, error) {
+	if c.Dir == "" {
+		return nil, nil
+	}
+	if err := dir.Init(c.Dir, constant.ComponentLogDirMode); err != nil {
+		return nil, err
+	}
+	return &lumberjack.Logger{
+		Filename:   filepath.Join(c.Dir, name+".log"),
+		MaxSize:    c.MaxSize,
+		MaxAge:     c.MaxAge,
+		MaxBackups: c.MaxBackups,
+		Compress:   c.Compress,
+	}, nil
+}
+
+// outputWriter returns the writer the process output is sent to
+func (s *Supervisor) outputWriter() io.Writer {
+	switch {
+	case s.logFile == nil:
+		return s.log.Writer()
+	case s.LogConfig.Forward:
+		return io.MultiWriter(s.logFile, s.log.Writer())
+	default:
+		return s.logFile
+	}
+}
@@ -0,0 +1,45 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package supervisor
+
+import (
+	"sync"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+)
+
+var (
+	resourcesMutex sync.Mutex
+	resources      = map[string]*v1beta1.ComponentResources{}
+)
+
+// SetResources sets the resource limits of the processes supervised under the given name,
+// it is used by supervisors without explicit Resources
+func SetResources(name string, r *v1beta1.ComponentResources) {
+	resourcesMutex.Lock()
+	defer resourcesMutex.Unlock()
+	if r == nil || r.IsEmpty() {
+		delete(resources, name)
+		return
+	}
+	resources[name] = r
+}
+
+func resourcesFor(name string) *v1beta1.ComponentResources {
+	resourcesMutex.Lock()
+	defer resourcesMutex.Unlock()
+	return resources[name]
+}
@@ -0,0 +1,257 @@
+//go:build linux
+// +build linux
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package supervisor
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+)
+
+var (
+	// cgroupRoot is the mount point of the cgroup v2 hierarchy
+	cgroupRoot = "/sys/fs/cgroup"
+	// procRoot is the mount point of procfs
+	procRoot = "/proc"
+
+	// ownCgroup is the cgroup k0s has been started in, below which the supervised processes get their cgroups
+	ownCgroup     string
+	ownCgroupErr  error
+	ownCgroupOnce sync.Once
+)
+
+// selfCgroup is the cgroup below the own cgroup of k0s which k0s moves itself to, as only leaf cgroups may hold processes
+const selfCgroup = "k0s"
+
+// CgroupExecCommand is the hidden k0s sub-command placing itself in the cgroup of a supervised process before executing it
+const CgroupExecCommand = "cgroup-exec"
+
+// newCommand returns the command running the supervised process. Processes with cgroup limits
+// are started through CgroupExecCommand, so that they never run outside of their cgroup.
+func (s *Supervisor) newCommand() *exec.Cmd {
+	cmd := exec.Command(s.BinPath, s.Args...)
+	// detach from the process group so children don't
+	// get signals sent directly to parent.
+	cmd.SysProcAttr = DetachAttr(s.UID, s.GID)
+
+	r := s.Resources
+	if r == nil || (r.MemoryMax == nil && r.CPUWeight == 0 && r.PidsMax == 0) {
+		return cmd
+	}
+	if os.Geteuid() != 0 {
+		s.log.Warn("Cgroup limits require k0s to run as root, ignoring them")
+		return cmd
+	}
+	self, err := os.Executable()
+	if err != nil {
+		s.log.Warnf("Failed to apply cgroup limits: %v", err)
+		return cmd
+	}
+	cgroup, err := prepareCgroup(s.Name, r)
+	if err != nil {
+		s.log.Warnf("Failed to apply cgroup limits: %v", err)
+		return cmd
+	}
+	s.cgroup = cgroup
+
+	args := append([]string{CgroupExecCommand, cgroup, strconv.Itoa(s.UID), strconv.Itoa(s.GID), s.BinPath}, s.Args...)
+	wrapped := exec.Command(self, args...)
+	// the wrapper drops the credentials itself, once placed in the cgroup
+	wrapped.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+	return wrapped
+}
+
+// ExecInCgroup is run as CgroupExecCommand with the arguments <cgroup> <uid> <gid> <binary> [args...].
+// It moves the current process into the cgroup, drops the root credentials and executes the binary,
+// which keeps the pid of the process.
+func ExecInCgroup(args []string) error {
+	if len(args) < 4 {
+		return fmt.Errorf("usage: %s <cgroup> <uid> <gid> <binary> [args...]", CgroupExecCommand)
+	}
+	uid, err := strconv.Atoi(args[1])
+	if err != nil {
+		return fmt.Errorf("invalid uid: %w", err)
+	}
+	gid, err := strconv.Atoi(args[2])
+	if err != nil {
+		return fmt.Errorf("invalid gid: %w", err)
+	}
+
+	// writing 0 moves the writing process itself
+	if err := writeCgroupFile(args[0], "cgroup.procs", "0"); err != nil {
+		return err
+	}
+	if err := syscall.Setgroups([]int{}); err != nil {
+		return fmt.Errorf("failed to drop the supplementary groups: %w", err)
+	}
+	if err := syscall.Setgid(gid); err != nil {
+		return fmt.Errorf("failed to set gid %d: %w", gid, err)
+	}
+	if err := syscall.Setuid(uid); err != nil {
+		return fmt.Errorf("failed to set uid %d: %w", uid, err)
+	}
+	return syscall.Exec(args[3], args[3:], os.Environ())
+}
+
+// applyResources applies the limits which aren't covered by the cgroup of the process
+func (s *Supervisor) applyResources(pid int) {
+	r := s.Resources
+	if r == nil || r.OOMScoreAdj == nil {
+		return
+	}
+	file := filepath.Join(procRoot, strconv.Itoa(pid), "oom_score_adj")
+	if err := os.WriteFile(file, []byte(strconv.Itoa(*r.OOMScoreAdj)), 0644); err != nil {
+		s.log.Warnf("Failed to set oom_score_adj: %v", err)
+	}
+}
+
+// removeCgroup removes the process' cgroup once the process is gone
+func (s *Supervisor) removeCgroup() {
+	if s.cgroup == "" {
+		return
+	}
+	if err := os.Remove(s.cgroup); err != nil && !errors.Is(err, os.ErrNotExist) {
+		s.log.Debugf("Failed to remove cgroup %s: %v", s.cgroup, err)
+	}
+}
+
+// getOwnCgroup returns the path of the cgroup k0s has been started in, as found in /proc/self/cgroup
+func getOwnCgroup() (string, error) {
+	ownCgroupOnce.Do(func() {
+		content, err := os.ReadFile(filepath.Join(procRoot, "self", "cgroup"))
+		if err != nil {
+			ownCgroupErr = err
+			return
+		}
+		for _, line := range strings.Split(string(content), "\n") {
+			if path := strings.TrimPrefix(line, "0::"); path != line {
+				if path == "/" {
+					ownCgroupErr = errors.New("k0s runs in the root cgroup, start it as a service with its own cgroup")
+					return
+				}
+				ownCgroup = filepath.Join(cgroupRoot, path)
+				return
+			}
+		}
+		ownCgroupErr = fmt.Errorf("cgroup v2 is not mounted at %s", cgroupRoot)
+	})
+	return ownCgroup, ownCgroupErr
+}
+
+// prepareCgroup creates the cgroup of the named process below the own cgroup of k0s, with the given limits.
+// The processes of the own cgroup are moved to a leaf cgroup first, as the controllers can't be enabled for
+// the child cgroups otherwise.
+func prepareCgroup(name string, r *v1beta1.ComponentResources) (string, error) {
+	parent, err := getOwnCgroup()
+	if err != nil {
+		return "", err
+	}
+	available, err := os.ReadFile(filepath.Join(parent, "cgroup.controllers"))
+	if errors.Is(err, os.ErrNotExist) {
+		return "", fmt.Errorf("cgroup v2 is not mounted at %s", cgroupRoot)
+	} else if err != nil {
+		return "", err
+	}
+
+	limits := map[string]string{}
+	var controllers []string
+	if r.MemoryMax != nil {
+		limits["memory.max"] = strconv.FormatInt(r.MemoryMax.Value(), 10)
+		controllers = append(controllers, "memory")
+	}
+	if r.CPUWeight != 0 {
+		limits["cpu.weight"] = strconv.FormatInt(r.CPUWeight, 10)
+		controllers = append(controllers, "cpu")
+	}
+	if r.PidsMax != 0 {
+		limits["pids.max"] = strconv.FormatInt(r.PidsMax, 10)
+		controllers = append(controllers, "pids")
+	}
+
+	enable := make([]string, len(controllers))
+	for i, c := range controllers {
+		if !containsField(string(available), c) {
+			return "", fmt.Errorf("cgroup controller `%s` is not available in %s, is the k0s service delegated its cgroup?", c, parent)
+		}
+		enable[i] = "+" + c
+	}
+
+	if err := vacateCgroup(parent); err != nil {
+		return "", err
+	}
+	if err := writeCgroupFile(parent, "cgroup.subtree_control", strings.Join(enable, " ")); err != nil {
+		return "", err
+	}
+	leaf := filepath.Join(parent, name)
+	if err := os.MkdirAll(leaf, 0755); err != nil {
+		return "", err
+	}
+	for file, value := range limits {
+		if err := writeCgroupFile(leaf, file, value); err != nil {
+			return "", err
+		}
+	}
+	return leaf, nil
+}
+
+// vacateCgroup moves the processes of the cgroup, k0s itself and the processes started without limits, to selfCgroup
+func vacateCgroup(cgroup string) error {
+	procs, err := os.ReadFile(filepath.Join(cgroup, "cgroup.procs"))
+	if err != nil {
+		return err
+	}
+	pids := strings.Fields(string(procs))
+	if len(pids) == 0 {
+		return nil
+	}
+	self := filepath.Join(cgroup, selfCgroup)
+	if err := os.MkdirAll(self, 0755); err != nil {
+		return err
+	}
+	for _, pid := range pids {
+		if err := writeCgroupFile(self, "cgroup.procs", pid); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeCgroupFile(dir, file, value string) error {
+	if err := os.WriteFile(filepath.Join(dir, file), []byte(value), 0644); err != nil {
+		return fmt.Errorf("failed to write %q to %s: %v", value, filepath.Join(dir, file), err)
+	}
+	return nil
+}
+
+func containsField(s, field string) bool {
+	for _, f := range strings.Fields(s) {
+		if f == field {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+//go:build linux
+// +build linux
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package supervisor
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/resource"
+
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+)
+
+// fakeCgroupRoot fakes k0s running in the cgroup /system.slice/k0scontroller.service, along with another process
+func fakeCgroupRoot(t *testing.T, controllers string) string {
+	oldCgroupRoot, oldProcRoot := cgroupRoot, procRoot
+	t.Cleanup(func() {
+		cgroupRoot, procRoot = oldCgroupRoot, oldProcRoot
+		ownCgroup, ownCgroupErr, ownCgroupOnce = "", nil, sync.Once{}
+	})
+	ownCgroup, ownCgroupErr, ownCgroupOnce = "", nil, sync.Once{}
+	cgroupRoot, procRoot = t.TempDir(), t.TempDir()
+
+	own := filepath.Join(cgroupRoot, "system.slice", "k0scontroller.service")
+	if err := os.MkdirAll(own, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(own, "cgroup.procs"), []byte("1234\n1240\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if controllers != "" {
+		if err := os.WriteFile(filepath.Join(own, "cgroup.controllers"), []byte(controllers), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	writeProcSelfCgroup(t, "0::/system.slice/k0scontroller.service\n")
+	return own
+}
+
+func writeProcSelfCgroup(t *testing.T, content string) {
+	if err := os.MkdirAll(filepath.Join(procRoot, "self"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(procRoot, "self", "cgroup"), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func assertFileContent(t *testing.T, file, expected string) {
+	t.Helper()
+	content, err := os.ReadFile(file)
+	if err != nil {
+		t.Errorf("Failed to read %s: %v", file, err)
+	} else if string(content) != expected {
+		t.Errorf("Unexpected content of %s: expected %q, got %q", file, expected, content)
+	}
+}
+
+func TestPrepareCgroup(t *testing.T) {
+	own := fakeCgroupRoot(t, "cpuset cpu io memory pids\n")
+
+	memoryMax := resource.MustParse("1Gi")
+	leaf, err := prepareCgroup("etcd", &v1beta1.ComponentResources{
+		MemoryMax: &memoryMax,
+		CPUWeight: 200,
+		PidsMax:   1024,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if expected := filepath.Join(own, "etcd"); leaf != expected {
+		t.Errorf("Expected the cgroup %s, got %s", expected, leaf)
+	}
+	// the processes of the k0s cgroup are moved one by one to a leaf cgroup
+	assertFileContent(t, filepath.Join(own, "k0s", "cgroup.procs"), "1240")
+	assertFileContent(t, filepath.Join(own, "cgroup.subtree_control"), "+memory +cpu +pids")
+	assertFileContent(t, filepath.Join(leaf, "memory.max"), "1073741824")
+	assertFileContent(t, filepath.Join(leaf, "cpu.weight"), "200")
+	assertFileContent(t, filepath.Join(leaf, "pids.max"), "1024")
+	if _, err := os.Stat(filepath.Join(cgroupRoot, "cgroup.subtree_control")); !os.IsNotExist(err) {
+		t.Errorf("Expected the root cgroup to be left alone, got %v", err)
+	}
+}
+
+func TestApplyResources(t *testing.T) {
+	fakeCgroupRoot(t, "")
+	if err := os.Mkdir(filepath.Join(procRoot, "42"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	oomScoreAdj := -500
+	s := Supervisor{
+		Name:      "etcd",
+		Resources: &v1beta1.ComponentResources{OOMScoreAdj: &oomScoreAdj},
+		log:       logrus.WithField("component", "etcd"),
+	}
+	s.applyResources(42)
+	assertFileContent(t, filepath.Join(procRoot, "42", "oom_score_adj"), "-500")
+}
+
+func TestPrepareCgroupErrors(t *testing.T) {
+	r := &v1beta1.ComponentResources{PidsMax: 100}
+
+	own := fakeCgroupRoot(t, "")
+	if _, err := prepareCgroup("kubelet", r); err == nil {
+		t.Error("Expected an error without cgroup v2")
+	}
+
+	own = fakeCgroupRoot(t, "cpu memory\n")
+	if _, err := prepareCgroup("kubelet", r); err == nil {
+		t.Error("Expected an error for the missing pids controller")
+	}
+	if _, err := os.Stat(filepath.Join(own, "kubelet")); !os.IsNotExist(err) {
+		t.Errorf("Expected no cgroup to be created, got %v", err)
+	}
+
+	fakeCgroupRoot(t, "cpu memory pids\n")
+	writeProcSelfCgroup(t, "0::/\n")
+	if _, err := prepareCgroup("kubelet", r); err == nil || !strings.Contains(err.Error(), "root cgroup") {
+		t.Errorf("Expected an error in the root cgroup, got %v", err)
+	}
+}
@@ -0,0 +1,50 @@
+//go:build !linux
+// +build !linux
+
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package supervisor
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// CgroupExecCommand is the hidden k0s sub-command placing itself in the cgroup of a supervised process before executing it
+const CgroupExecCommand = "cgroup-exec"
+
+// newCommand returns the command running the supervised process
+func (s *Supervisor) newCommand() *exec.Cmd {
+	cmd := exec.Command(s.BinPath, s.Args...)
+	// detach from the process group so children don't
+	// get signals sent directly to parent.
+	cmd.SysProcAttr = DetachAttr(s.UID, s.GID)
+	return cmd
+}
+
+// ExecInCgroup is not supported, cgroups are only supported on Linux
+func ExecInCgroup([]string) error {
+	return fmt.Errorf("cgroups are only supported on Linux")
+}
+
+// applyResources is a no-op, resource limits are only supported on Linux
+func (s *Supervisor) applyResources(pid int) {
+	if s.Resources != nil && !s.Resources.IsEmpty() {
+		s.log.Warn("Resource limits are only supported on Linux, ignoring them")
+	}
+}
+
+func (s *Supervisor) removeCgroup() {}
@@ -0,0 +1,161 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package supervisor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProcessState is the state of a supervised process
+type ProcessState string
+
+const (
+	// StateStarting means the process hasn't been started yet
+	StateStarting ProcessState = "Starting"
+	// StateRunning means the process is running
+	StateRunning ProcessState = "Running"
+	// StateBackOff means the process exited and is waiting to be respawned
+	StateBackOff ProcessState = "BackOff"
+	// StateCrashLoopBackOff means the process keeps exiting shortly after being respawned
+	StateCrashLoopBackOff ProcessState = "CrashLoopBackOff"
+	// StateFailed means the process exceeded the maximum number of restarts and won't be respawned
+	StateFailed ProcessState = "Failed"
+	// StateStopped means the supervisor has been stopped
+	StateStopped ProcessState = "Stopped"
+)
+
+// ProcessStatus describes the state and the restart history of a supervised process
+type ProcessStatus struct {
+	Name  string
+	State ProcessState
+	// Pid is the pid of the running process, 0 if not running
+	Pid       int
+	StartedAt time.Time `json:",omitempty"`
+	// Restarts is the total number of restarts
+	Restarts int
+	// ConsecutiveRestarts is the number of restarts since the process last ran stable
+	ConsecutiveRestarts int
+	// LastExitCode is the exit code of the last process, -1 if it was killed by a signal or failed to start
+	LastExitCode int
+	LastExitTime time.Time `json:",omitempty"`
+	// LastError is the error the last process failed to start with, if any
+	LastError   string    `json:",omitempty"`
+	NextRestart time.Time `json:",omitempty"`
+}
+
+// RestartPolicy defines how dead processes are respawned
+type RestartPolicy struct {
+	// MaxRestarts is the number of consecutive restarts after which the process isn't respawned anymore, 0 means unlimited
+	MaxRestarts int
+	// MaxBackoff caps the respawn delay, which doubles with every consecutive restart
+	MaxBackoff time.Duration
+	// CrashLoopThreshold is the number of consecutive restarts after which the process is considered crash-looping
+	CrashLoopThreshold int
+}
+
+// DefaultRestartPolicy is used by supervisors without an explicit RestartPolicy
+var DefaultRestartPolicy = RestartPolicy{
+	MaxRestarts:        0,
+	MaxBackoff:         5 * time.Minute,
+	CrashLoopThreshold: 5,
+}
+
+// maxBackoff returns the upper bound of the respawn delay, which is never below the initial one
+func (p *RestartPolicy) maxBackoff(initial time.Duration) time.Duration {
+	if p.MaxBackoff < initial {
+		return initial
+	}
+	return p.MaxBackoff
+}
+
+// backoff returns the delay before the next respawn after the given number of consecutive restarts
+func (p *RestartPolicy) backoff(initial time.Duration, consecutiveRestarts int) time.Duration {
+	max := p.maxBackoff(initial)
+	delay := initial
+	for i := 0; i < consecutiveRestarts && delay < max; i++ {
+		delay *= 2
+	}
+	if delay > max {
+		delay = max
+	}
+	return delay
+}
+
+// stableRunTime returns how long a process has to run to reset the backoff,
+// which is twice the maximum backoff, same as the kubelet does for containers
+func (p *RestartPolicy) stableRunTime(initial time.Duration) time.Duration {
+	return 2 * p.maxBackoff(initial)
+}
+
+var (
+	registryMutex sync.Mutex
+	registry      = map[*Supervisor]struct{}{}
+)
+
+func register(s *Supervisor) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	registry[s] = struct{}{}
+}
+
+func unregister(s *Supervisor) {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	delete(registry, s)
+}
+
+// Statuses returns the status of all the running supervisors, sorted by name
+func Statuses() []ProcessStatus {
+	registryMutex.Lock()
+	defer registryMutex.Unlock()
+	statuses := make([]ProcessStatus, 0, len(registry))
+	for s := range registry {
+		statuses = append(statuses, s.Status())
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Name < statuses[j].Name })
+	return statuses
+}
+
+// Restart restarts the running supervised processes with the given names, see Supervisor.Restart
+func Restart(names ...string) error {
+	registryMutex.Lock()
+	var supervisors []*Supervisor
+	for s := range registry {
+		for _, name := range names {
+			if s.Name == name {
+				supervisors = append(supervisors, s)
+				break
+			}
+		}
+	}
+	registryMutex.Unlock()
+	sort.Slice(supervisors, func(i, j int) bool { return supervisors[i].Name < supervisors[j].Name })
+
+	var failed []string
+	for _, s := range supervisors {
+		if err := s.Restart(); err != nil {
+			failed = append(failed, err.Error())
+		}
+	}
+	if len(failed) > 0 {
+		return fmt.Errorf("failed to restart processes: %s", strings.Join(failed, "; "))
+	}
+	return nil
+}
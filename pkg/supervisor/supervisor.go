@@ -29,23 +29,33 @@ import (
 	"time"
 
 	"github.com/sirupsen/logrus"
+	"gopkg.in/natefinch/lumberjack.v2"
 
 	"github.com/k0sproject/k0s/internal/pkg/dir"
+	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
 	"github.com/k0sproject/k0s/pkg/constant"
 )
 
 // Supervisor is dead simple and stupid process supervisor, just tries to keep the process running in a while-true loop
+// with an exponential backoff between the respawns
 type Supervisor struct {
-	Name           string
-	BinPath        string
-	RunDir         string
-	DataDir        string
-	Args           []string
-	PidFile        string
-	UID            int
-	GID            int
-	TimeoutStop    time.Duration
+	Name        string
+	BinPath     string
+	RunDir      string
+	DataDir     string
+	Args        []string
+	PidFile     string
+	UID         int
+	GID         int
+	TimeoutStop time.Duration
+	// TimeoutRespawn is the initial delay before respawning a dead process, it doubles with every consecutive crash
 	TimeoutRespawn time.Duration
+	// RestartPolicy overrides DefaultRestartPolicy if set
+	RestartPolicy *RestartPolicy
+	// LogConfig overrides DefaultLogConfig if set
+	LogConfig *LogConfig
+	// Resources overrides the limits set with SetResources if set
+	Resources *v1beta1.ComponentResources
 	// For those components having env prefix convention such as ETCD_xxx, we should keep the prefix.
 	KeepEnvPrefix bool
 
@@ -54,6 +64,13 @@ type Supervisor struct {
 	log    *logrus.Entry
 	mutex  sync.Mutex
 	cancel context.CancelFunc
+	status ProcessStatus
+	// logFile is the process' own log file, if enabled
+	logFile *lumberjack.Logger
+	// restart is set when the running process has been asked to terminate in order to be restarted
+	restart bool
+	// cgroup is the cgroup of the process, if it has cgroup limits
+	cgroup string
 }
 
 // processWaitQuit waits for a process to exit or a shut down signal
@@ -111,6 +128,24 @@ func (s *Supervisor) Supervise() error {
 	if s.TimeoutRespawn == 0 {
 		s.TimeoutRespawn = 5 * time.Second
 	}
+	if s.RestartPolicy == nil {
+		policy := DefaultRestartPolicy
+		s.RestartPolicy = &policy
+	}
+	if s.LogConfig == nil {
+		logConfig := DefaultLogConfig
+		s.LogConfig = &logConfig
+	}
+	if s.Resources == nil {
+		s.Resources = resourcesFor(s.Name)
+	}
+	logFile, err := s.LogConfig.openLogFile(s.Name)
+	if err != nil {
+		s.log.Warnf("failed to initialize log file dir: %v", err)
+		return err
+	}
+	s.logFile = logFile
+	s.status = ProcessStatus{Name: s.Name, State: StateStarting}
 
 	var ctx context.Context
 	ctx, s.cancel = context.WithCancel(context.Background())
@@ -120,22 +155,29 @@ func (s *Supervisor) Supervise() error {
 		restarts := 0
 		for {
 			s.mutex.Lock()
-			s.cmd = exec.Command(s.BinPath, s.Args...)
+			s.cmd = s.newCommand()
 			s.cmd.Dir = s.DataDir
 			s.cmd.Env = getEnv(s.DataDir, s.Name, s.KeepEnvPrefix)
 
-			// detach from the process group so children don't
-			// get signals sent directly to parent.
-			s.cmd.SysProcAttr = DetachAttr(s.UID, s.GID)
-
-			s.cmd.Stdout = s.log.Writer()
-			s.cmd.Stderr = s.log.Writer()
+			output := s.outputWriter()
+			s.cmd.Stdout = output
+			s.cmd.Stderr = output
 
 			err := s.cmd.Start()
+			startedAt := time.Now()
+			if err == nil {
+				s.status.State = StateRunning
+				s.status.Pid = s.cmd.Process.Pid
+				s.status.StartedAt = startedAt
+				s.applyResources(s.cmd.Process.Pid)
+			}
 			s.mutex.Unlock()
 			if err != nil {
 				s.log.Warnf("Failed to start: %s", err)
 				if restarts == 0 {
+					if s.logFile != nil {
+						_ = s.logFile.Close()
+					}
 					started <- err
 					return
 				}
@@ -146,24 +188,36 @@ func (s *Supervisor) Supervise() error {
 					defer func() {
 						s.done <- true
 					}()
+					register(s)
 					started <- nil
 				} else {
 					s.log.Infof("Restarted (%d)", restarts)
 				}
 				restarts++
 				if s.processWaitQuit(ctx) {
+					s.setState(StateStopped)
 					return
 				}
+				if s.takeRestart() {
+					s.log.Info("Restarting on request")
+					continue
+				}
 			}
 
-			// TODO Maybe some backoff thingy would be nice
-			s.log.Infof("respawning in %s", s.TimeoutRespawn.String())
+			delay, giveUp := s.recordExit(startedAt, err)
+			if giveUp {
+				s.log.Errorf("giving up after %d consecutive restarts", s.RestartPolicy.MaxRestarts)
+				<-ctx.Done()
+				return
+			}
+			s.log.Infof("respawning in %s", delay.String())
 
 			select {
 			case <-ctx.Done():
 				s.log.Debug("respawn cancelled")
+				s.setState(StateStopped)
 				return
-			case <-time.After(s.TimeoutRespawn):
+			case <-time.After(delay):
 				s.log.Debug("respawning")
 			}
 		}
@@ -171,6 +225,111 @@ func (s *Supervisor) Supervise() error {
 	return <-started
 }
 
+// recordExit updates the process status after the process exited or failed
+// to start, and returns the delay before respawning it, or whether to give up
+func (s *Supervisor) recordExit(startedAt time.Time, startErr error) (time.Duration, bool) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	now := time.Now()
+	policy := s.RestartPolicy
+	if startErr != nil {
+		s.status.LastExitCode = -1
+		s.status.LastError = startErr.Error()
+	} else {
+		s.status.LastExitCode = s.cmd.ProcessState.ExitCode()
+		s.status.LastError = ""
+		// a process that stayed up long enough isn't crash-looping anymore
+		if now.Sub(startedAt) >= policy.stableRunTime(s.TimeoutRespawn) {
+			s.status.ConsecutiveRestarts = 0
+		}
+	}
+	s.status.Pid = 0
+	s.status.LastExitTime = now
+
+	if policy.MaxRestarts > 0 && s.status.ConsecutiveRestarts >= policy.MaxRestarts {
+		s.status.State = StateFailed
+		s.status.NextRestart = time.Time{}
+		return 0, true
+	}
+
+	delay := policy.backoff(s.TimeoutRespawn, s.status.ConsecutiveRestarts)
+	s.status.Restarts++
+	s.status.ConsecutiveRestarts++
+	s.status.NextRestart = now.Add(delay)
+	if policy.CrashLoopThreshold > 0 && s.status.ConsecutiveRestarts >= policy.CrashLoopThreshold {
+		if s.status.State != StateCrashLoopBackOff {
+			s.log.Errorf("crash-looping, restarted %d times in a row", s.status.ConsecutiveRestarts)
+		}
+		s.status.State = StateCrashLoopBackOff
+	} else {
+		s.status.State = StateBackOff
+	}
+	return delay, false
+}
+
+// takeRestart clears a pending restart request after the process exited, and returns whether there was one
+func (s *Supervisor) takeRestart() bool {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if !s.restart {
+		return false
+	}
+	s.restart = false
+	s.status.Restarts++
+	s.status.LastExitCode = s.cmd.ProcessState.ExitCode()
+	s.status.LastExitTime = time.Now()
+	s.status.Pid = 0
+	return true
+}
+
+// Restart terminates the running process so that it gets respawned right away, without
+// counting as a crash. It doesn't wait for the process to exit.
+func (s *Supervisor) Restart() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.status.State != StateRunning || s.cmd == nil || s.cmd.Process == nil {
+		return fmt.Errorf("%s is not running", s.Name)
+	}
+	s.log.Infof("Restarting pid %d", s.cmd.Process.Pid)
+	s.restart = true
+	if err := s.cmd.Process.Signal(syscall.SIGTERM); err != nil {
+		s.restart = false
+		return fmt.Errorf("failed to send SIGTERM to %s: %w", s.Name, err)
+	}
+	return nil
+}
+
+func (s *Supervisor) setState(state ProcessState) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	s.status.State = state
+	s.status.Pid = 0
+	s.status.NextRestart = time.Time{}
+}
+
+// Status returns the current status of the supervised process
+func (s *Supervisor) Status() ProcessStatus {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	return s.status
+}
+
+// Healthy returns an error if the supervised process is crash-looping or has been given up on
+func (s *Supervisor) Healthy() error {
+	if s == nil {
+		return nil
+	}
+	status := s.Status()
+	switch status.State {
+	case StateCrashLoopBackOff:
+		return fmt.Errorf("%s is crash-looping, restarted %d times in a row, last exit code %d", s.Name, status.ConsecutiveRestarts, status.LastExitCode)
+	case StateFailed:
+		return fmt.Errorf("%s failed, gave up after %d consecutive restarts, last exit code %d", s.Name, status.ConsecutiveRestarts, status.LastExitCode)
+	}
+	return nil
+}
+
 // Stop stops the supervised
 func (s *Supervisor) Stop() error {
 	if s.log != nil {
@@ -185,6 +344,11 @@ func (s *Supervisor) Stop() error {
 	if s.done != nil {
 		<-s.done
 	}
+	unregister(s)
+	s.removeCgroup()
+	if s.logFile != nil {
+		return s.logFile.Close()
+	}
 	return nil
 }
 
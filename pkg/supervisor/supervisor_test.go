@@ -19,6 +19,7 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"path"
 	"sort"
 	"strings"
 	"syscall"
@@ -191,3 +192,183 @@ func TestStopWhileRespawn(t *testing.T) {
 		t.Errorf("Failed to stop %s: %v", s.Name, err)
 	}
 }
+
+func TestRestartPolicyBackoff(t *testing.T) {
+	policy := RestartPolicy{MaxBackoff: time.Minute}
+	for restarts, expected := range []time.Duration{
+		5 * time.Second,
+		10 * time.Second,
+		20 * time.Second,
+		40 * time.Second,
+		time.Minute,
+		time.Minute,
+	} {
+		if actual := policy.backoff(5*time.Second, restarts); actual != expected {
+			t.Errorf("Unexpected backoff after %d restarts, expected: %s, actual: %s", restarts, expected, actual)
+		}
+	}
+
+	if actual := policy.stableRunTime(5 * time.Second); actual != 2*time.Minute {
+		t.Errorf("Unexpected stable run time, expected: %s, actual: %s", 2*time.Minute, actual)
+	}
+
+	// the initial delay is never capped
+	if actual := policy.backoff(2*time.Minute, 3); actual != 2*time.Minute {
+		t.Errorf("Unexpected backoff for a large initial delay: %s", actual)
+	}
+}
+
+func waitForState(t *testing.T, s *Supervisor, state ProcessState) ProcessStatus {
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		status := s.Status()
+		if status.State == state {
+			return status
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%s didn't reach state %s, last status: %+v", s.Name, state, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+func TestCrashLoop(t *testing.T) {
+	s := Supervisor{
+		Name:           "supervisor-test-crash-loop",
+		BinPath:        "/bin/sh",
+		RunDir:         ".",
+		Args:           []string{"-c", "exit 3"},
+		TimeoutRespawn: time.Millisecond,
+		RestartPolicy:  &RestartPolicy{MaxBackoff: time.Second, CrashLoopThreshold: 3},
+	}
+	if err := s.Supervise(); err != nil {
+		t.Fatalf("Failed to start %s: %v", s.Name, err)
+	}
+
+	status := waitForState(t, &s, StateCrashLoopBackOff)
+	if status.LastExitCode != 3 {
+		t.Errorf("Unexpected last exit code, expected: 3, actual: %d", status.LastExitCode)
+	}
+	if status.ConsecutiveRestarts < 3 || status.Restarts < status.ConsecutiveRestarts {
+		t.Errorf("Unexpected restart counters: %+v", status)
+	}
+	if err := s.Healthy(); err == nil {
+		t.Errorf("%s should be unhealthy while crash-looping", s.Name)
+	}
+
+	found := false
+	for _, status := range Statuses() {
+		found = found || status.Name == s.Name
+	}
+	if !found {
+		t.Errorf("%s not found in the supervisor statuses", s.Name)
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Errorf("Failed to stop %s: %v", s.Name, err)
+	}
+	if s.Status().State != StateStopped {
+		t.Errorf("Unexpected state after stop: %s", s.Status().State)
+	}
+	for _, status := range Statuses() {
+		if status.Name == s.Name {
+			t.Errorf("%s should have been removed from the supervisor statuses", s.Name)
+		}
+	}
+}
+
+func TestMaxRestarts(t *testing.T) {
+	s := Supervisor{
+		Name:           "supervisor-test-max-restarts",
+		BinPath:        "/bin/sh",
+		RunDir:         ".",
+		Args:           []string{"-c", "exit 1"},
+		TimeoutRespawn: time.Millisecond,
+		RestartPolicy:  &RestartPolicy{MaxRestarts: 2, MaxBackoff: time.Second},
+	}
+	if err := s.Supervise(); err != nil {
+		t.Fatalf("Failed to start %s: %v", s.Name, err)
+	}
+
+	status := waitForState(t, &s, StateFailed)
+	if status.Restarts != 2 {
+		t.Errorf("Unexpected number of restarts, expected: 2, actual: %d", status.Restarts)
+	}
+	if err := s.Healthy(); err == nil {
+		t.Errorf("%s should be unhealthy after giving up", s.Name)
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Errorf("Failed to stop %s: %v", s.Name, err)
+	}
+}
+
+func TestRestart(t *testing.T) {
+	s := Supervisor{
+		Name:           "supervisor-test-restart",
+		BinPath:        "/bin/sh",
+		RunDir:         ".",
+		Args:           []string{"-c", "exec sleep 60"},
+		TimeoutRespawn: time.Minute,
+	}
+	if err := s.Supervise(); err != nil {
+		t.Fatalf("Failed to start %s: %v", s.Name, err)
+	}
+	pid := s.Status().Pid
+
+	if err := Restart(s.Name); err != nil {
+		t.Fatalf("Failed to restart %s: %v", s.Name, err)
+	}
+
+	// a requested restart doesn't wait for the respawn delay
+	deadline := time.Now().Add(5 * time.Second)
+	status := s.Status()
+	for status.State != StateRunning || status.Pid == pid {
+		if time.Now().After(deadline) {
+			t.Fatalf("%s wasn't restarted, last status: %+v", s.Name, status)
+		}
+		time.Sleep(5 * time.Millisecond)
+		status = s.Status()
+	}
+	if status.Restarts != 1 || status.ConsecutiveRestarts != 0 {
+		t.Errorf("Unexpected restart counters: %+v", status)
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Errorf("Failed to stop %s: %v", s.Name, err)
+	}
+	if err := s.Restart(); err == nil {
+		t.Errorf("Restarting a stopped process should fail")
+	}
+}
+
+func TestLogFile(t *testing.T) {
+	logDir := t.TempDir()
+	s := Supervisor{
+		Name:      "supervisor-test-log-file",
+		BinPath:   "/bin/sh",
+		RunDir:    ".",
+		Args:      []string{"-c", "echo out; echo err >&2; exec sleep 10"},
+		LogConfig: &LogConfig{Dir: logDir, MaxSize: 1},
+	}
+	if err := s.Supervise(); err != nil {
+		t.Fatalf("Failed to start %s: %v", s.Name, err)
+	}
+
+	logFile := path.Join(logDir, s.Name+".log")
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		content, _ := os.ReadFile(logFile)
+		if string(content) == "out\nerr\n" {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("Unexpected content of %s: %q", logFile, content)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := s.Stop(); err != nil {
+		t.Errorf("Failed to stop %s: %v", s.Name, err)
+	}
+}
@@ -84,7 +84,9 @@ func (j *JoinClient) GetCA() (v1beta1.CaResponse, error) {
 	if err != nil {
 		return caData, err
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", j.bearerToken))
+	if err := j.addHeaders(req); err != nil {
+		return caData, err
+	}
 
 	resp, err := j.httpClient.Do(req)
 	if err != nil {
@@ -131,7 +133,9 @@ func (j *JoinClient) JoinEtcd(peerAddress string) (v1beta1.EtcdResponse, error)
 	if err != nil {
 		return etcdResponse, err
 	}
-	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", j.bearerToken))
+	if err := j.addHeaders(req); err != nil {
+		return etcdResponse, err
+	}
 	resp, err := j.httpClient.Do(req)
 	if err != nil {
 		return etcdResponse, err
@@ -153,6 +157,49 @@ func (j *JoinClient) JoinEtcd(peerAddress string) (v1beta1.EtcdResponse, error)
 	return etcdResponse, nil
 }
 
+// JoinWorker exchanges a scoped worker token for the kubelet client certificate requested by the PEM encoded CSR
+func (j *JoinClient) JoinWorker(nodeName string, csr []byte) (v1beta1.WorkerJoinResponse, error) {
+	var joinResponse v1beta1.WorkerJoinResponse
+	buf := new(bytes.Buffer)
+	if err := json.NewEncoder(buf).Encode(v1beta1.WorkerJoinRequest{Node: nodeName, CSR: csr}); err != nil {
+		return joinResponse, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, j.joinAddress+"/v1beta1/join/worker", buf)
+	if err != nil {
+		return joinResponse, err
+	}
+	if err := j.addHeaders(req); err != nil {
+		return joinResponse, err
+	}
+	req.Header.Set(NodeNameHeader, nodeName)
+	resp, err := j.httpClient.Do(req)
+	if err != nil {
+		return joinResponse, err
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return joinResponse, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return joinResponse, fmt.Errorf("unexpected response status when trying to join as a worker: %s: %s", resp.Status, bytes.TrimSpace(b))
+	}
+	err = json.Unmarshal(b, &joinResponse)
+	return joinResponse, err
+}
+
+// addHeaders authenticates the request with the token and names the calling node
+func (j *JoinClient) addHeaders(req *http.Request) error {
+	name, err := os.Hostname()
+	if err != nil {
+		return err
+	}
+	req.Header.Add("Authorization", fmt.Sprintf("Bearer %s", j.bearerToken))
+	req.Header.Set(NodeNameHeader, name)
+	return nil
+}
+
 func (j *JoinClient) JoinTokenType() string {
 	return j.joinTokenType
 }
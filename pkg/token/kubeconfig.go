@@ -26,6 +26,7 @@ import (
 	"time"
 
 	"github.com/k0sproject/k0s/pkg/apis/k0s.k0sproject.io/v1beta1"
+	"github.com/k0sproject/k0s/pkg/certificate"
 	"github.com/k0sproject/k0s/pkg/constant"
 )
 
@@ -56,6 +57,20 @@ users:
 `))
 
 func CreateKubeletBootstrapConfig(ctx context.Context, api *v1beta1.APISpec, k0sVars constant.CfgVars, role string, expiry time.Duration) (string, error) {
+	return CreateScopedBootstrapConfig(ctx, api, k0sVars, role, expiry, Scope{})
+}
+
+// CreateScopedBootstrapConfig creates a join token restricted by the given scope
+func CreateScopedBootstrapConfig(ctx context.Context, api *v1beta1.APISpec, k0sVars constant.CfgVars, role string, expiry time.Duration, scope Scope) (string, error) {
+	manager, err := NewManager(filepath.Join(k0sVars.AdminKubeConfigPath))
+	if err != nil {
+		return "", err
+	}
+	return manager.BootstrapConfig(ctx, api, k0sVars, role, expiry, scope)
+}
+
+// BootstrapConfig creates a new token restricted by the given scope and returns the encoded join token embedding it
+func (m *Manager) BootstrapConfig(ctx context.Context, api *v1beta1.APISpec, k0sVars constant.CfgVars, role string, expiry time.Duration, scope Scope) (string, error) {
 	data := struct {
 		CACert  string
 		Token   string
@@ -69,11 +84,15 @@ func CreateKubeletBootstrapConfig(ctx context.Context, api *v1beta1.APISpec, k0s
 	if err != nil {
 		return "", err
 	}
+	if role == RoleWorker && !scope.IsZero() {
+		// scoped worker tokens are exchanged at the k0s API
+		data.User, data.JoinURL = WorkerJoinTokenType, api.K0sControlPlaneAPIAddress()
+	}
 	data.CACert, err = loadCACert(k0sVars)
 	if err != nil {
 		return "", err
 	}
-	data.Token, err = loadToken(ctx, k0sVars, role, expiry)
+	data.Token, err = m.CreateScoped(ctx, expiry, role, scope)
 	if err != nil {
 		return "", err
 	}
@@ -98,7 +117,7 @@ func loadUserAndJoinURL(api *v1beta1.APISpec, role string) (string, string, erro
 }
 
 func loadCACert(k0sVars constant.CfgVars) (string, error) {
-	crtFile := filepath.Join(k0sVars.CertRootDir, "ca.crt")
+	crtFile := certificate.CABundleFile(filepath.Join(k0sVars.CertRootDir, "ca.crt"))
 	caCert, err := os.ReadFile(crtFile)
 	if err != nil {
 		return "", fmt.Errorf("failed to read cluster CA from %q: %w; check if the control plane is initialized on this node", crtFile, err)
@@ -106,11 +125,3 @@ func loadCACert(k0sVars constant.CfgVars) (string, error) {
 
 	return base64.StdEncoding.EncodeToString(caCert), nil
 }
-
-func loadToken(ctx context.Context, k0sVars constant.CfgVars, role string, expiry time.Duration) (string, error) {
-	manager, err := NewManager(filepath.Join(k0sVars.AdminKubeConfigPath))
-	if err != nil {
-		return "", err
-	}
-	return manager.Create(ctx, expiry, role)
-}
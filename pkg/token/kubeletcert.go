@@ -0,0 +1,137 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package token
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"time"
+
+	certificatesv1 "k8s.io/api/certificates/v1"
+	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/util/retry"
+
+	"github.com/k0sproject/k0s/internal/pkg/random"
+)
+
+// kubeletCertificatePollInterval is the interval of checking if the kubelet client certificate got signed
+const kubeletCertificatePollInterval = 500 * time.Millisecond
+
+// IssueKubeletCertificate has the kube API sign the kubelet client certificate of the given node, requested by the PEM encoded CSR.
+// The CSR must only ask for the identity of the node, so that the certificate can't be used by any other node.
+// It returns the PEM encoded certificate, once signed by the kube-controller-manager.
+func (m *Manager) IssueKubeletCertificate(ctx context.Context, nodeName string, csrPEM []byte, timeout time.Duration) ([]byte, error) {
+	if err := verifyKubeletCSR(nodeName, csrPEM); err != nil {
+		return nil, err
+	}
+
+	csr, err := m.client.CertificatesV1().CertificateSigningRequests().Create(ctx, &certificatesv1.CertificateSigningRequest{
+		ObjectMeta: metav1.ObjectMeta{Name: fmt.Sprintf("k0s-worker-join-%s", random.String(8))},
+		Spec: certificatesv1.CertificateSigningRequestSpec{
+			Request:    csrPEM,
+			SignerName: certificatesv1.KubeAPIServerClientKubeletSignerName,
+			Usages: []certificatesv1.KeyUsage{
+				certificatesv1.UsageDigitalSignature,
+				certificatesv1.UsageKeyEncipherment,
+				certificatesv1.UsageClientAuth,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the kubelet certificate signing request of node %s: %w", nodeName, err)
+	}
+
+	err = retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		csr, err = m.client.CertificatesV1().CertificateSigningRequests().Get(ctx, csr.Name, metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		if hasCondition(csr, certificatesv1.CertificateApproved) {
+			return nil
+		}
+		csr.Status.Conditions = append(csr.Status.Conditions, certificatesv1.CertificateSigningRequestCondition{
+			Type:           certificatesv1.CertificateApproved,
+			Status:         v1.ConditionTrue,
+			Reason:         "K0sWorkerJoin",
+			Message:        fmt.Sprintf("node %s joined with a k0s worker join token", nodeName),
+			LastUpdateTime: metav1.Now(),
+		})
+		_, err = m.client.CertificatesV1().CertificateSigningRequests().UpdateApproval(ctx, csr.Name, csr, metav1.UpdateOptions{})
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve the kubelet certificate signing request %s: %w", csr.Name, err)
+	}
+
+	var cert []byte
+	err = wait.PollImmediateWithContext(ctx, kubeletCertificatePollInterval, timeout, func(ctx context.Context) (bool, error) {
+		csr, err := m.client.CertificatesV1().CertificateSigningRequests().Get(ctx, csr.Name, metav1.GetOptions{})
+		if errors.IsNotFound(err) {
+			return false, err
+		}
+		if err != nil {
+			return false, nil
+		}
+		if hasCondition(csr, certificatesv1.CertificateDenied) || hasCondition(csr, certificatesv1.CertificateFailed) {
+			return false, fmt.Errorf("the certificate signing request has been denied or has failed")
+		}
+		cert = csr.Status.Certificate
+		return len(cert) > 0, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("kubelet certificate signing request %s not signed: %w", csr.Name, err)
+	}
+	return cert, nil
+}
+
+// verifyKubeletCSR checks that the CSR requests the identity of the given node, and nothing more
+func verifyKubeletCSR(nodeName string, csrPEM []byte) error {
+	block, _ := pem.Decode(csrPEM)
+	if block == nil || block.Type != "CERTIFICATE REQUEST" {
+		return fmt.Errorf("the kubelet CSR is not a PEM encoded certificate request")
+	}
+	req, err := x509.ParseCertificateRequest(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("failed to parse the kubelet CSR: %w", err)
+	}
+	if err := req.CheckSignature(); err != nil {
+		return fmt.Errorf("invalid kubelet CSR signature: %w", err)
+	}
+	if cn := req.Subject.CommonName; cn != "system:node:"+nodeName {
+		return fmt.Errorf("the kubelet CSR is for `%s`, not for node `%s`", cn, nodeName)
+	}
+	if o := req.Subject.Organization; len(o) != 1 || o[0] != "system:nodes" {
+		return fmt.Errorf("the kubelet CSR must have the single organization system:nodes, got %v", o)
+	}
+	if len(req.DNSNames) > 0 || len(req.IPAddresses) > 0 || len(req.EmailAddresses) > 0 || len(req.URIs) > 0 {
+		return fmt.Errorf("the kubelet CSR must not have subject alternative names")
+	}
+	return nil
+}
+
+func hasCondition(csr *certificatesv1.CertificateSigningRequest, conditionType certificatesv1.RequestConditionType) bool {
+	for _, c := range csr.Status.Conditions {
+		if c.Type == conditionType && c.Status == v1.ConditionTrue {
+			return true
+		}
+	}
+	return false
+}
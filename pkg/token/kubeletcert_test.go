@@ -0,0 +1,104 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package token
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	certificatesv1 "k8s.io/api/certificates/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestVerifyKubeletCSR(t *testing.T) {
+	assert.NoError(t, verifyKubeletCSR("worker-1", kubeletCSR(t, "system:node:worker-1", []string{"system:nodes"}, nil)))
+
+	assert.EqualError(t, verifyKubeletCSR("worker-1", kubeletCSR(t, "system:node:worker-2", []string{"system:nodes"}, nil)),
+		"the kubelet CSR is for `system:node:worker-2`, not for node `worker-1`")
+	assert.EqualError(t, verifyKubeletCSR("worker-1", kubeletCSR(t, "system:node:worker-1", []string{"system:nodes", "system:masters"}, nil)),
+		"the kubelet CSR must have the single organization system:nodes, got [system:nodes system:masters]")
+	assert.EqualError(t, verifyKubeletCSR("worker-1", kubeletCSR(t, "system:node:worker-1", []string{"system:nodes"}, []net.IP{net.ParseIP("10.0.0.1")})),
+		"the kubelet CSR must not have subject alternative names")
+	assert.EqualError(t, verifyKubeletCSR("worker-1", []byte("garbage")), "the kubelet CSR is not a PEM encoded certificate request")
+}
+
+func TestIssueKubeletCertificate(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	m := NewManagerForClient(client)
+	csrPEM := kubeletCSR(t, "system:node:worker-1", []string{"system:nodes"}, nil)
+
+	_, err := m.IssueKubeletCertificate(ctx, "worker-2", csrPEM, time.Second)
+	assert.ErrorContains(t, err, "not for node `worker-2`")
+
+	// nothing signs the approved request
+	_, err = m.IssueKubeletCertificate(ctx, "worker-1", csrPEM, time.Second)
+	assert.ErrorContains(t, err, "not signed")
+
+	// act as the kube-controller-manager signing the approved requests
+	signCtx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	go func() {
+		for signCtx.Err() == nil {
+			csrs, err := client.CertificatesV1().CertificateSigningRequests().List(signCtx, metav1.ListOptions{})
+			if err == nil {
+				for i := range csrs.Items {
+					csr := &csrs.Items[i]
+					if hasCondition(csr, certificatesv1.CertificateApproved) && len(csr.Status.Certificate) == 0 {
+						csr.Status.Certificate = []byte("signed")
+						_, _ = client.CertificatesV1().CertificateSigningRequests().UpdateStatus(signCtx, csr, metav1.UpdateOptions{})
+					}
+				}
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+	}()
+
+	cert, err := m.IssueKubeletCertificate(ctx, "worker-1", csrPEM, 5*time.Second)
+	require.NoError(t, err)
+	assert.Equal(t, []byte("signed"), cert)
+
+	csrs, err := client.CertificatesV1().CertificateSigningRequests().List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, csrs.Items, 2)
+	for _, csr := range csrs.Items {
+		assert.Equal(t, certificatesv1.KubeAPIServerClientKubeletSignerName, csr.Spec.SignerName)
+		assert.Equal(t, csrPEM, csr.Spec.Request)
+		assert.True(t, hasCondition(&csr, certificatesv1.CertificateApproved))
+	}
+}
+
+func kubeletCSR(t *testing.T, commonName string, organization []string, ips []net.IP) []byte {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	der, err := x509.CreateCertificateRequest(rand.Reader, &x509.CertificateRequest{
+		Subject:     pkix.Name{CommonName: commonName, Organization: organization},
+		IPAddresses: ips,
+	}, key)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
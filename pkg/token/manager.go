@@ -17,7 +17,12 @@ package token
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/sirupsen/logrus"
@@ -25,19 +30,66 @@ import (
 	"k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/util/retry"
 
 	"github.com/k0sproject/k0s/internal/pkg/random"
 	k8sutil "github.com/k0sproject/k0s/pkg/kubernetes"
 )
 
+// Token describes a join token
 type Token struct {
-	ID     string
-	Role   string
-	Expiry string
+	ID        string     `json:"id"`
+	Role      string     `json:"role"`
+	CreatedAt time.Time  `json:"createdAt"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	Scope     *Scope     `json:"scope,omitempty"`
+	// Joins are the nodes that joined through the k0s API with the token, see Manager.RecordJoin
+	Joins []Join `json:"joins,omitempty"`
 }
 
-func (t Token) ToArray() []string {
-	return []string{t.ID, t.Role, t.Expiry}
+// Uses returns the number of joins done with the token
+func (t Token) Uses() int {
+	return len(t.Joins)
+}
+
+// Expired checks if the token has expired at the given time
+func (t Token) Expired(now time.Time) bool {
+	return t.ExpiresAt != nil && !now.Before(*t.ExpiresAt)
+}
+
+// UsedUp checks if the token has been used as many times as its scope allows
+func (t Token) UsedUp() bool {
+	return t.Scope != nil && t.Scope.MaxUses > 0 && t.Uses() >= t.Scope.MaxUses
+}
+
+// Remaining returns the remaining validity of the token at the given time, "expired" once it expired and empty if it
+// never expires
+func (t Token) Remaining(now time.Time) string {
+	switch {
+	case t.ExpiresAt == nil:
+		return ""
+	case t.Expired(now):
+		return "expired"
+	default:
+		return t.ExpiresAt.Sub(now).Round(time.Second).String()
+	}
+}
+
+// ToArray returns the token as a table row: ID, role, creation and expiry time, remaining validity, uses and joined nodes
+func (t Token) ToArray(now time.Time) []string {
+	expiresAt := "never"
+	if t.ExpiresAt != nil {
+		expiresAt = t.ExpiresAt.Format(time.RFC3339)
+	}
+	uses := strconv.Itoa(t.Uses())
+	if t.Scope != nil && t.Scope.MaxUses > 0 {
+		uses = fmt.Sprintf("%d/%d", t.Uses(), t.Scope.MaxUses)
+	}
+	nodes := make([]string, 0, len(t.Joins))
+	for _, j := range t.Joins {
+		nodes = append(nodes, j.Node)
+	}
+	return []string{t.ID, t.Role, t.CreatedAt.UTC().Format(time.RFC3339), expiresAt, t.Remaining(now), uses, strings.Join(nodes, ",")}
 }
 
 // NewManager creates a new token manager using given kubeconfig
@@ -57,8 +109,23 @@ type Manager struct {
 	client kubernetes.Interface
 }
 
+// NewManagerForClient creates a new token manager using the given client
+func NewManagerForClient(client kubernetes.Interface) *Manager {
+	return &Manager{client: client}
+}
+
 // Create creates a new bootstrap token
 func (m *Manager) Create(ctx context.Context, valid time.Duration, role string) (string, error) {
+	return m.CreateScoped(ctx, valid, role, Scope{})
+}
+
+// CreateScoped creates a new bootstrap token restricted by the given scope. Scoped worker tokens can't authenticate
+// to the kube API, they have to be exchanged at the k0s API for a kubelet client certificate, see Use and IssueKubeletCertificate.
+func (m *Manager) CreateScoped(ctx context.Context, valid time.Duration, role string, scope Scope) (string, error) {
+	if err := scope.Validate(role); err != nil {
+		return "", err
+	}
+
 	tokenID := random.String(6)
 	tokenSecret := random.String(16)
 
@@ -78,7 +145,12 @@ func (m *Manager) Create(ctx context.Context, valid time.Duration, role string)
 	// windows workers during the join step
 	data["usage-bootstrap-api-auth"] = "true"
 
-	if role == "worker" {
+	if role == RoleWorker && !scope.IsZero() {
+		data["description"] = "Scoped worker join token generated by k0s"
+		data["usage-bootstrap-authentication"] = "false"
+		data["usage-bootstrap-signing"] = "false"
+		data["usage-worker-join"] = "true"
+	} else if role == RoleWorker {
 		data["description"] = "Worker bootstrap token generated by k0s"
 		data["usage-bootstrap-authentication"] = "true"
 		data["usage-bootstrap-api-worker-calls"] = "true"
@@ -97,6 +169,13 @@ func (m *Manager) Create(ctx context.Context, valid time.Duration, role string)
 		Type:       v1.SecretTypeBootstrapToken,
 		StringData: data,
 	}
+	if !scope.IsZero() {
+		scopeJSON, err := json.Marshal(scope)
+		if err != nil {
+			return "", err
+		}
+		secret.Annotations = map[string]string{ScopeAnnotation: string(scopeJSON)}
+	}
 
 	_, err := m.client.CoreV1().Secrets("kube-system").Create(ctx, secret, metav1.CreateOptions{})
 	if err != nil {
@@ -121,17 +200,98 @@ func (m *Manager) List(ctx context.Context, role string) ([]Token, error) {
 		if string(t.Data["usage-controller-join"]) == "true" {
 			r = "controller"
 		}
-		if r == role || role == "" {
-			tokens = append(tokens, Token{
-				ID:     string(t.Data["token-id"]),
-				Role:   r,
-				Expiry: string(t.Data["expiration"]),
-			})
+		if r != role && role != "" {
+			continue
+		}
+
+		token := Token{
+			ID:        string(t.Data["token-id"]),
+			Role:      r,
+			CreatedAt: t.CreationTimestamp.Time,
+		}
+		if expiration, ok := t.Data["expiration"]; ok {
+			expiresAt, err := time.Parse(time.RFC3339, string(expiration))
+			if err != nil {
+				return nil, fmt.Errorf("invalid expiration of token %s: %w", token.ID, err)
+			}
+			token.ExpiresAt = &expiresAt
 		}
+		scope, err := scopeOf(&t)
+		if err != nil {
+			return nil, err
+		}
+		if !scope.IsZero() {
+			token.Scope = scope
+		}
+		if token.Joins, err = joinsOf(&t); err != nil {
+			return nil, err
+		}
+		tokens = append(tokens, token)
 	}
+	sort.Slice(tokens, func(i, j int) bool { return tokens[i].CreatedAt.Before(tokens[j].CreatedAt) })
 	return tokens, nil
 }
 
+// Allows checks that the node with the given name and address may join with the token, without using it up.
+// It returns the scope of the token.
+func (m *Manager) Allows(ctx context.Context, tokenID, nodeName string, addr net.IP) (*Scope, error) {
+	secret, err := m.client.CoreV1().Secrets("kube-system").Get(ctx, fmt.Sprintf("bootstrap-token-%s", tokenID), metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	scope, _, err := checkJoin(secret, tokenID, nodeName, addr)
+	return scope, err
+}
+
+// RecordJoin records the join of the node with the given name and address on the token secret, counting as one of its uses.
+// It is meant to be called once the node got what it joins with, the join is checked again in case the token got used up meanwhile.
+func (m *Manager) RecordJoin(ctx context.Context, tokenID, nodeName string, addr net.IP) error {
+	return retry.RetryOnConflict(retry.DefaultRetry, func() error {
+		secret, err := m.client.CoreV1().Secrets("kube-system").Get(ctx, fmt.Sprintf("bootstrap-token-%s", tokenID), metav1.GetOptions{})
+		if err != nil {
+			return err
+		}
+		_, joins, err := checkJoin(secret, tokenID, nodeName, addr)
+		if err != nil {
+			return err
+		}
+
+		joins = append(joins, Join{Node: nodeName, Address: addr.String(), Time: time.Now().UTC()})
+		joinsJSON, err := json.Marshal(joins)
+		if err != nil {
+			return err
+		}
+		if secret.Annotations == nil {
+			secret.Annotations = map[string]string{}
+		}
+		secret.Annotations[JoinsAnnotation] = string(joinsJSON)
+		_, err = m.client.CoreV1().Secrets("kube-system").Update(ctx, secret, metav1.UpdateOptions{})
+		return err
+	})
+}
+
+// checkJoin checks the expiration and the scope of the token secret for the joining node, returning the scope and the recorded joins
+func checkJoin(secret *v1.Secret, tokenID, nodeName string, addr net.IP) (*Scope, []Join, error) {
+	if expiration, ok := secret.Data["expiration"]; ok {
+		expiresAt, err := time.Parse(time.RFC3339, string(expiration))
+		if err != nil || time.Now().After(expiresAt) {
+			return nil, nil, fmt.Errorf("token %s has expired", tokenID)
+		}
+	}
+	scope, err := scopeOf(secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	joins, err := joinsOf(secret)
+	if err != nil {
+		return nil, nil, err
+	}
+	if err := scope.allows(nodeName, addr, len(joins)); err != nil {
+		return nil, nil, fmt.Errorf("token %s can't be used by node %s: %w", tokenID, nodeName, err)
+	}
+	return scope, joins, nil
+}
+
 func (m *Manager) Remove(ctx context.Context, tokenID string) error {
 	err := m.client.CoreV1().Secrets("kube-system").Delete(ctx, fmt.Sprintf("bootstrap-token-%s", tokenID), metav1.DeleteOptions{})
 	if errors.IsNotFound(err) {
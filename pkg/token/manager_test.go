@@ -0,0 +1,171 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package token
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestManagerCreateScoped(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	m := NewManagerForClient(client)
+
+	tok, err := m.CreateScoped(ctx, time.Hour, RoleWorker, Scope{MaxUses: 1, Labels: []string{"gpu=true"}})
+	require.NoError(t, err)
+	tokenID := strings.Split(tok, ".")[0]
+
+	secret, err := client.CoreV1().Secrets("kube-system").Get(ctx, "bootstrap-token-"+tokenID, metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "false", secret.StringData["usage-bootstrap-authentication"], "scoped worker tokens must not authenticate to the kube API")
+	assert.Equal(t, "true", secret.StringData["usage-worker-join"])
+	assert.JSONEq(t, `{"maxUses":1,"labels":["gpu=true"]}`, secret.Annotations[ScopeAnnotation])
+
+	tok, err = m.Create(ctx, 0, RoleWorker)
+	require.NoError(t, err)
+	secret, err = client.CoreV1().Secrets("kube-system").Get(ctx, "bootstrap-token-"+strings.Split(tok, ".")[0], metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, "true", secret.StringData["usage-bootstrap-authentication"])
+	assert.Empty(t, secret.Annotations)
+
+	_, err = m.CreateScoped(ctx, 0, RoleController, Scope{Profile: "gpu"})
+	assert.ErrorContains(t, err, "only supported for worker tokens")
+	_, err = m.CreateScoped(ctx, 0, RoleWorker, Scope{Addresses: []string{"10.0.0.0/33"}})
+	assert.ErrorContains(t, err, "invalid address `10.0.0.0/33`")
+}
+
+func TestManagerAllowsAndRecordJoin(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+	m := NewManagerForClient(client)
+
+	tok, err := m.CreateScoped(ctx, time.Hour, RoleWorker, Scope{
+		MaxUses:   2,
+		NodeName:  "worker-1",
+		Addresses: []string{"10.0.0.0/24", "192.168.1.10"},
+		Profile:   "gpu",
+	})
+	require.NoError(t, err)
+	tokenID := strings.Split(tok, ".")[0]
+	// the fake client doesn't convert StringData to Data
+	secret, err := client.CoreV1().Secrets("kube-system").Get(ctx, "bootstrap-token-"+tokenID, metav1.GetOptions{})
+	require.NoError(t, err)
+	secret.Data = map[string][]byte{"expiration": []byte(secret.StringData["expiration"])}
+	_, err = client.CoreV1().Secrets("kube-system").Update(ctx, secret, metav1.UpdateOptions{})
+	require.NoError(t, err)
+
+	_, err = m.Allows(ctx, tokenID, "worker-2", net.ParseIP("10.0.0.5"))
+	assert.ErrorContains(t, err, "bound to node `worker-1`")
+	_, err = m.Allows(ctx, tokenID, "worker-1", net.ParseIP("10.0.1.5"))
+	assert.ErrorContains(t, err, "not allowed from 10.0.1.5")
+	assert.ErrorContains(t, m.RecordJoin(ctx, tokenID, "worker-2", net.ParseIP("10.0.0.5")), "bound to node `worker-1`")
+
+	// checking doesn't use up the token
+	for i := 0; i < 3; i++ {
+		scope, err := m.Allows(ctx, tokenID, "worker-1", net.ParseIP("10.0.0.5"))
+		require.NoError(t, err)
+		assert.Equal(t, "gpu", scope.Profile)
+	}
+	require.NoError(t, m.RecordJoin(ctx, tokenID, "worker-1", net.ParseIP("10.0.0.5")))
+	require.NoError(t, m.RecordJoin(ctx, tokenID, "worker-1", net.ParseIP("192.168.1.10")))
+	_, err = m.Allows(ctx, tokenID, "worker-1", net.ParseIP("10.0.0.5"))
+	assert.ErrorContains(t, err, "already used 2 times")
+	assert.ErrorContains(t, m.RecordJoin(ctx, tokenID, "worker-1", net.ParseIP("10.0.0.5")), "already used 2 times")
+
+	secret, err = client.CoreV1().Secrets("kube-system").Get(ctx, "bootstrap-token-"+tokenID, metav1.GetOptions{})
+	require.NoError(t, err)
+	joins, err := joinsOf(secret)
+	require.NoError(t, err)
+	require.Len(t, joins, 2)
+	assert.Equal(t, "worker-1", joins[0].Node)
+	assert.Equal(t, "10.0.0.5", joins[0].Address)
+	assert.Equal(t, "192.168.1.10", joins[1].Address)
+
+	// expired tokens
+	secret.Data["expiration"] = []byte(time.Now().Add(-time.Minute).UTC().Format(time.RFC3339))
+	_, err = client.CoreV1().Secrets("kube-system").Update(ctx, secret, metav1.UpdateOptions{})
+	require.NoError(t, err)
+	_, err = m.Allows(ctx, tokenID, "worker-1", net.ParseIP("10.0.0.5"))
+	assert.ErrorContains(t, err, "has expired")
+}
+
+func TestManagerList(t *testing.T) {
+	ctx := context.Background()
+	now := time.Now().UTC().Truncate(time.Second)
+	newSecret := func(id string, created time.Time, data map[string]string, annotations map[string]string) *v1.Secret {
+		secret := &v1.Secret{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:              "bootstrap-token-" + id,
+				Namespace:         "kube-system",
+				CreationTimestamp: metav1.NewTime(created),
+				Annotations:       annotations,
+			},
+			Type: v1.SecretTypeBootstrapToken,
+			Data: map[string][]byte{"token-id": []byte(id)},
+		}
+		for k, v := range data {
+			secret.Data[k] = []byte(v)
+		}
+		return secret
+	}
+	client := fake.NewSimpleClientset(
+		newSecret("ctrl01", now.Add(-2*time.Hour), map[string]string{
+			"usage-controller-join": "true",
+			"expiration":            now.Add(-time.Hour).Format(time.RFC3339),
+		}, nil),
+		newSecret("wrkr01", now.Add(-time.Hour), map[string]string{
+			"usage-worker-join": "true",
+			"expiration":        now.Add(90 * time.Minute).Format(time.RFC3339),
+		}, map[string]string{
+			ScopeAnnotation: `{"maxUses":1}`,
+			JoinsAnnotation: `[{"node":"worker-1","address":"10.0.0.5","time":"2022-06-01T10:00:00Z"}]`,
+		}),
+	)
+	m := NewManagerForClient(client)
+
+	tokens, err := m.List(ctx, "")
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+
+	ctrl, wrkr := tokens[0], tokens[1]
+	assert.Equal(t, "controller", ctrl.Role)
+	assert.True(t, ctrl.Expired(now))
+	assert.False(t, ctrl.UsedUp())
+	assert.Nil(t, ctrl.Scope)
+	assert.Equal(t, []string{"ctrl01", "controller", now.Add(-2 * time.Hour).Format(time.RFC3339), now.Add(-time.Hour).Format(time.RFC3339), "expired", "0", ""}, ctrl.ToArray(now))
+
+	assert.Equal(t, "worker", wrkr.Role)
+	assert.False(t, wrkr.Expired(now))
+	assert.True(t, wrkr.UsedUp())
+	assert.Equal(t, 1, wrkr.Uses())
+	row := wrkr.ToArray(now)
+	assert.Equal(t, []string{"1h30m0s", "1/1", "worker-1"}, row[4:])
+
+	tokens, err = m.List(ctx, RoleController)
+	require.NoError(t, err)
+	require.Len(t, tokens, 1)
+	assert.Equal(t, "ctrl01", tokens[0].ID)
+}
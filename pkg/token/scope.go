@@ -0,0 +1,150 @@
+/*
+Copyright 2022 k0s authors
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+package token
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	v1 "k8s.io/api/core/v1"
+)
+
+const (
+	// ScopeAnnotation holds the JSON encoded Scope of a join token on its secret
+	ScopeAnnotation = "k0s.k0sproject.io/token-scope"
+	// JoinsAnnotation holds the JSON encoded list of the nodes that joined with a token on its secret
+	JoinsAnnotation = "k0s.k0sproject.io/token-joins"
+
+	// NodeNameHeader carries the name of the node calling the join API
+	NodeNameHeader = "K0s-Node-Name"
+
+	// WorkerJoinTokenType is the type of the scoped worker tokens, exchanged at the k0s API for a kubelet client certificate
+	WorkerJoinTokenType = "worker-join"
+)
+
+// Scope restricts the use of a join token and carries the worker settings applied by the join
+type Scope struct {
+	// MaxUses is the number of joins allowed with the token, 0 for unlimited
+	MaxUses int `json:"maxUses,omitempty"`
+	// NodeName is the only node name allowed to join with the token
+	NodeName string `json:"nodeName,omitempty"`
+	// Addresses are the IPs and CIDRs the joining node may connect from
+	Addresses []string `json:"addresses,omitempty"`
+	// Profile is the worker profile of the joining node
+	Profile string `json:"profile,omitempty"`
+	// Labels are added to the joining node, as key=value pairs
+	Labels []string `json:"labels,omitempty"`
+	// Taints are added to the joining node, as key=value:effect strings
+	Taints []string `json:"taints,omitempty"`
+}
+
+// Join records a node joining with a token
+type Join struct {
+	Node    string    `json:"node"`
+	Address string    `json:"address"`
+	Time    time.Time `json:"time"`
+}
+
+// IsZero returns true if the scope neither restricts the token nor carries worker settings
+func (s *Scope) IsZero() bool {
+	return s.MaxUses == 0 && s.NodeName == "" && len(s.Addresses) == 0 && s.Profile == "" && len(s.Labels) == 0 && len(s.Taints) == 0
+}
+
+// Validate validates the scope of a token for the given role
+func (s *Scope) Validate(role string) error {
+	if s.MaxUses < 0 {
+		return fmt.Errorf("max uses must not be negative, got %d", s.MaxUses)
+	}
+	for _, addr := range s.Addresses {
+		if _, err := parseAddress(addr); err != nil {
+			return err
+		}
+	}
+	if role != RoleWorker && (s.Profile != "" || len(s.Labels) > 0 || len(s.Taints) > 0) {
+		return fmt.Errorf("profile, labels and taints are only supported for worker tokens")
+	}
+	for _, label := range s.Labels {
+		if k, _, ok := strings.Cut(label, "="); !ok || k == "" {
+			return fmt.Errorf("invalid label `%s`, must be key=value", label)
+		}
+	}
+	for _, taint := range s.Taints {
+		if k, effect, ok := strings.Cut(taint, ":"); !ok || k == "" || effect == "" {
+			return fmt.Errorf("invalid taint `%s`, must be key=value:effect", taint)
+		}
+	}
+	return nil
+}
+
+// allows checks if a node with the given name and address may join with a token already used the given number of times
+func (s *Scope) allows(nodeName string, addr net.IP, uses int) error {
+	if s.MaxUses > 0 && uses >= s.MaxUses {
+		return fmt.Errorf("token already used %d times", uses)
+	}
+	if s.NodeName != "" && s.NodeName != nodeName {
+		return fmt.Errorf("token is bound to node `%s`, not `%s`", s.NodeName, nodeName)
+	}
+	if len(s.Addresses) == 0 {
+		return nil
+	}
+	for _, a := range s.Addresses {
+		if ipNet, err := parseAddress(a); err == nil && addr != nil && ipNet.Contains(addr) {
+			return nil
+		}
+	}
+	return fmt.Errorf("token is not allowed from %s", addr)
+}
+
+// parseAddress parses an IP or CIDR, an IP being a network of its own
+func parseAddress(addr string) (*net.IPNet, error) {
+	if ip := net.ParseIP(addr); ip != nil {
+		bits := 8 * len(ip.To16())
+		if ip.To4() != nil {
+			ip, bits = ip.To4(), 32
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)}, nil
+	}
+	_, ipNet, err := net.ParseCIDR(addr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid address `%s`, must be an IP or a CIDR", addr)
+	}
+	return ipNet, nil
+}
+
+// scopeOf returns the scope of the token secret, a zero one if it has none
+func scopeOf(secret *v1.Secret) (*Scope, error) {
+	scope := &Scope{}
+	if data, ok := secret.Annotations[ScopeAnnotation]; ok {
+		if err := json.Unmarshal([]byte(data), scope); err != nil {
+			return nil, fmt.Errorf("failed to parse the scope of token secret %s: %w", secret.Name, err)
+		}
+	}
+	return scope, nil
+}
+
+// joinsOf returns the joins recorded on the token secret
+func joinsOf(secret *v1.Secret) ([]Join, error) {
+	var joins []Join
+	if data, ok := secret.Annotations[JoinsAnnotation]; ok {
+		if err := json.Unmarshal([]byte(data), &joins); err != nil {
+			return nil, fmt.Errorf("failed to parse the joins of token secret %s: %w", secret.Name, err)
+		}
+	}
+	return joins, nil
+}